@@ -0,0 +1,40 @@
+package mocks
+
+import gateways "github.com/jakelong95/TownCenter/gateways"
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/jakelong95/TownCenter/models"
+
+// Publisher is an autogenerated mock type for the Publisher type
+type Publisher struct {
+	mock.Mock
+}
+
+// Close provides a mock function with given fields:
+func (_m *Publisher) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Publish provides a mock function with given fields: _a0
+func (_m *Publisher) Publish(_a0 *models.Event) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Event) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ gateways.Publisher = (*Publisher)(nil)
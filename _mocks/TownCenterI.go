@@ -1,6 +1,8 @@
 package mocks
 
+import context "golang.org/x/net/context"
 import gateways "github.com/jakelong95/TownCenter/gateways"
+import io "io"
 import mock "github.com/stretchr/testify/mock"
 import models "github.com/jakelong95/TownCenter/models"
 import uuid "github.com/pborman/uuid"
@@ -10,21 +12,44 @@ type TownCenterI struct {
 	mock.Mock
 }
 
-// GetAllRoasters provides a mock function with given fields: _a0, _a1
-func (_m *TownCenterI) GetAllRoasters(_a0 int, _a1 int) ([]*models.Roaster, error) {
+// CreateRoaster provides a mock function with given fields: _a0, _a1, _a2
+func (_m *TownCenterI) CreateRoaster(_a0 context.Context, _a1 uuid.UUID, _a2 *models.Roaster) (*models.Roaster, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *models.Roaster
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *models.Roaster) *models.Roaster); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Roaster)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, *models.Roaster) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateUser provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) CreateUser(_a0 context.Context, _a1 *models.User) (*models.User, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 []*models.Roaster
-	if rf, ok := ret.Get(0).(func(int, int) []*models.Roaster); ok {
+	var r0 *models.User
+	if rf, ok := ret.Get(0).(func(context.Context, *models.User) *models.User); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Roaster)
+			r0 = ret.Get(0).(*models.User)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *models.User) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -33,22 +58,78 @@ func (_m *TownCenterI) GetAllRoasters(_a0 int, _a1 int) ([]*models.Roaster, erro
 	return r0, r1
 }
 
-// GetAllUsers provides a mock function with given fields: _a0, _a1
-func (_m *TownCenterI) GetAllUsers(_a0 int, _a1 int) ([]*models.User, error) {
+// DeleteRoaster provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) DeleteRoaster(_a0 context.Context, _a1 uuid.UUID) error {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 []*models.User
-	if rf, ok := ret.Get(0).(func(int, int) []*models.User); ok {
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteRoasterPhoto provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) DeleteRoasterPhoto(_a0 context.Context, _a1 uuid.UUID) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUser provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) DeleteUser(_a0 context.Context, _a1 uuid.UUID) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUserPhoto provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) DeleteUserPhoto(_a0 context.Context, _a1 uuid.UUID) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
 		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAllRoasters provides a mock function with given fields: _a0, _a1, _a2
+func (_m *TownCenterI) GetAllRoasters(_a0 context.Context, _a1 int, _a2 int) ([]*models.Roaster, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []*models.Roaster
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []*models.Roaster); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.User)
+			r0 = ret.Get(0).([]*models.Roaster)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -56,13 +137,13 @@ func (_m *TownCenterI) GetAllUsers(_a0 int, _a1 int) ([]*models.User, error) {
 	return r0, r1
 }
 
-// GetRoaster provides a mock function with given fields: _a0
-func (_m *TownCenterI) GetRoaster(_a0 uuid.UUID) (*models.Roaster, error) {
-	ret := _m.Called(_a0)
+// GetRoaster provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) GetRoaster(_a0 context.Context, _a1 uuid.UUID) (*models.Roaster, error) {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 *models.Roaster
-	if rf, ok := ret.Get(0).(func(uuid.UUID) *models.Roaster); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *models.Roaster); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Roaster)
@@ -70,8 +151,31 @@ func (_m *TownCenterI) GetRoaster(_a0 uuid.UUID) (*models.Roaster, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRoasters provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) GetRoasters(_a0 context.Context, _a1 []uuid.UUID) (map[string]*models.Roaster, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 map[string]*models.Roaster
+	if rf, ok := ret.Get(0).(func(context.Context, []uuid.UUID) map[string]*models.Roaster); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]*models.Roaster)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uuid.UUID) error); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -79,13 +183,13 @@ func (_m *TownCenterI) GetRoaster(_a0 uuid.UUID) (*models.Roaster, error) {
 	return r0, r1
 }
 
-// GetUser provides a mock function with given fields: _a0
-func (_m *TownCenterI) GetUser(_a0 uuid.UUID) (*models.User, error) {
-	ret := _m.Called(_a0)
+// GetUser provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) GetUser(_a0 context.Context, _a1 uuid.UUID) (*models.User, error) {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 *models.User
-	if rf, ok := ret.Get(0).(func(uuid.UUID) *models.User); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *models.User); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.User)
@@ -93,8 +197,8 @@ func (_m *TownCenterI) GetUser(_a0 uuid.UUID) (*models.User, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -102,13 +206,13 @@ func (_m *TownCenterI) GetUser(_a0 uuid.UUID) (*models.User, error) {
 	return r0, r1
 }
 
-// GetUserByRoaster provides a mock function with given fields: _a0
-func (_m *TownCenterI) GetUserByRoaster(_a0 uuid.UUID) (*models.User, error) {
-	ret := _m.Called(_a0)
+// GetUserByEmail provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) GetUserByEmail(_a0 context.Context, _a1 string) (*models.User, error) {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 *models.User
-	if rf, ok := ret.Get(0).(func(uuid.UUID) *models.User); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.User)
@@ -116,8 +220,8 @@ func (_m *TownCenterI) GetUserByRoaster(_a0 uuid.UUID) (*models.User, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -125,12 +229,88 @@ func (_m *TownCenterI) GetUserByRoaster(_a0 uuid.UUID) (*models.User, error) {
 	return r0, r1
 }
 
-// UpdateRoaster provides a mock function with given fields: _a0, _a1
-func (_m *TownCenterI) UpdateRoaster(_a0 uuid.UUID, _a1 *models.Roaster) error {
+// GetUserByRoaster provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) GetUserByRoaster(_a0 context.Context, _a1 uuid.UUID) (*models.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *models.User
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *models.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUsers provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) GetUsers(_a0 context.Context, _a1 []uuid.UUID) (map[string]*models.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 map[string]*models.User
+	if rf, ok := ret.Get(0).(func(context.Context, []uuid.UUID) map[string]*models.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]*models.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uuid.UUID) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Login provides a mock function with given fields: _a0, _a1, _a2
+func (_m *TownCenterI) Login(_a0 context.Context, _a1 string, _a2 string) (*models.User, string, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *models.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.User); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) string); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(_a0, _a1, _a2)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RequestReset provides a mock function with given fields: _a0, _a1
+func (_m *TownCenterI) RequestReset(_a0 context.Context, _a1 string) error {
 	ret := _m.Called(_a0, _a1)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, *models.Roaster) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
@@ -139,13 +319,115 @@ func (_m *TownCenterI) UpdateRoaster(_a0 uuid.UUID, _a1 *models.Roaster) error {
 	return r0
 }
 
-// UpdateUser provides a mock function with given fields: _a0, _a1
-func (_m *TownCenterI) UpdateUser(_a0 uuid.UUID, _a1 *models.User) error {
-	ret := _m.Called(_a0, _a1)
+// ResetPassword provides a mock function with given fields: _a0, _a1, _a2
+func (_m *TownCenterI) ResetPassword(_a0 context.Context, _a1 string, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, *models.User) error); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchRoasters provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *TownCenterI) SearchRoasters(_a0 context.Context, _a1 string, _a2 int, _a3 int) ([]*models.Roaster, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []*models.Roaster
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Roaster); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Roaster)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchUsers provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *TownCenterI) SearchUsers(_a0 context.Context, _a1 string, _a2 int, _a3 int) ([]*models.User, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []*models.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.User); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateRoaster provides a mock function with given fields: _a0, _a1, _a2
+func (_m *TownCenterI) UpdateRoaster(_a0 context.Context, _a1 uuid.UUID, _a2 *models.Roaster) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *models.Roaster) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateUser provides a mock function with given fields: _a0, _a1, _a2
+func (_m *TownCenterI) UpdateUser(_a0 context.Context, _a1 uuid.UUID, _a2 *models.User) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *models.User) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UploadRoasterPhoto provides a mock function with given fields: _a0, _a1, _a2
+func (_m *TownCenterI) UploadRoasterPhoto(_a0 context.Context, _a1 uuid.UUID, _a2 io.Reader) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, io.Reader) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UploadUserPhoto provides a mock function with given fields: _a0, _a1, _a2
+func (_m *TownCenterI) UploadUserPhoto(_a0 context.Context, _a1 uuid.UUID, _a2 io.Reader) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, io.Reader) error); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -0,0 +1,69 @@
+package mocks
+
+import gin "gopkg.in/gin-gonic/gin.v1"
+import handlers "github.com/jakelong95/TownCenter/handlers"
+import mock "github.com/stretchr/testify/mock"
+
+// AddressI is an autogenerated mock type for the AddressI type
+type AddressI struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: ctx
+func (_m *AddressI) Delete(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// GetJWT provides a mock function with given fields:
+func (_m *AddressI) GetJWT() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// New provides a mock function with given fields: ctx
+func (_m *AddressI) New(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Time provides a mock function with given fields:
+func (_m *AddressI) Time() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx
+func (_m *AddressI) Update(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// View provides a mock function with given fields: ctx
+func (_m *AddressI) View(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ViewAll provides a mock function with given fields: ctx
+func (_m *AddressI) ViewAll(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+var _ handlers.AddressI = (*AddressI)(nil)
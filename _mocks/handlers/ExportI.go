@@ -0,0 +1,54 @@
+package mocks
+
+import gin "gopkg.in/gin-gonic/gin.v1"
+import handlers "github.com/jakelong95/TownCenter/handlers"
+import mock "github.com/stretchr/testify/mock"
+
+// ExportI is an autogenerated mock type for the ExportI type
+type ExportI struct {
+	mock.Mock
+}
+
+// GetJWT provides a mock function with given fields:
+func (_m *ExportI) GetJWT() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Roasters provides a mock function with given fields: ctx
+func (_m *ExportI) Roasters(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Time provides a mock function with given fields:
+func (_m *ExportI) Time() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Users provides a mock function with given fields: ctx
+func (_m *ExportI) Users(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+var _ handlers.ExportI = (*ExportI)(nil)
@@ -0,0 +1,28 @@
+package mocks
+
+import gin "gopkg.in/gin-gonic/gin.v1"
+import handlers "github.com/jakelong95/TownCenter/handlers"
+import mock "github.com/stretchr/testify/mock"
+
+// IdempotencyI is an autogenerated mock type for the IdempotencyI type
+type IdempotencyI struct {
+	mock.Mock
+}
+
+// Idempotent provides a mock function with given fields:
+func (_m *IdempotencyI) Idempotent() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+var _ handlers.IdempotencyI = (*IdempotencyI)(nil)
@@ -0,0 +1,59 @@
+package mocks
+
+import gin "gopkg.in/gin-gonic/gin.v1"
+import handlers "github.com/jakelong95/TownCenter/handlers"
+import mock "github.com/stretchr/testify/mock"
+
+// ImportI is an autogenerated mock type for the ImportI type
+type ImportI struct {
+	mock.Mock
+}
+
+// GetJWT provides a mock function with given fields:
+func (_m *ImportI) GetJWT() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// New provides a mock function with given fields: ctx
+func (_m *ImportI) New(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Resume provides a mock function with given fields: ctx
+func (_m *ImportI) Resume(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Time provides a mock function with given fields:
+func (_m *ImportI) Time() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// View provides a mock function with given fields: ctx
+func (_m *ImportI) View(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+var _ handlers.ImportI = (*ImportI)(nil)
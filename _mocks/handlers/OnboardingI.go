@@ -0,0 +1,89 @@
+package mocks
+
+import gin "gopkg.in/gin-gonic/gin.v1"
+import handlers "github.com/jakelong95/TownCenter/handlers"
+import mock "github.com/stretchr/testify/mock"
+
+// OnboardingI is an autogenerated mock type for the OnboardingI type
+type OnboardingI struct {
+	mock.Mock
+}
+
+// Approve provides a mock function with given fields: ctx
+func (_m *OnboardingI) Approve(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Close provides a mock function with given fields: ctx
+func (_m *OnboardingI) Close(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// GetJWT provides a mock function with given fields:
+func (_m *OnboardingI) GetJWT() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// History provides a mock function with given fields: ctx
+func (_m *OnboardingI) History(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Reinstate provides a mock function with given fields: ctx
+func (_m *OnboardingI) Reinstate(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Reject provides a mock function with given fields: ctx
+func (_m *OnboardingI) Reject(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Submit provides a mock function with given fields: ctx
+func (_m *OnboardingI) Submit(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Suspend provides a mock function with given fields: ctx
+func (_m *OnboardingI) Suspend(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Time provides a mock function with given fields:
+func (_m *OnboardingI) Time() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// UploadDocument provides a mock function with given fields: ctx
+func (_m *OnboardingI) UploadDocument(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ViewDocuments provides a mock function with given fields: ctx
+func (_m *OnboardingI) ViewDocuments(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+var _ handlers.OnboardingI = (*OnboardingI)(nil)
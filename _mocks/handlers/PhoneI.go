@@ -0,0 +1,64 @@
+package mocks
+
+import gin "gopkg.in/gin-gonic/gin.v1"
+import handlers "github.com/jakelong95/TownCenter/handlers"
+import mock "github.com/stretchr/testify/mock"
+
+// PhoneI is an autogenerated mock type for the PhoneI type
+type PhoneI struct {
+	mock.Mock
+}
+
+// GetJWT provides a mock function with given fields:
+func (_m *PhoneI) GetJWT() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// RequestRoaster provides a mock function with given fields: ctx
+func (_m *PhoneI) RequestRoaster(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// RequestUser provides a mock function with given fields: ctx
+func (_m *PhoneI) RequestUser(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Time provides a mock function with given fields:
+func (_m *PhoneI) Time() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// VerifyRoaster provides a mock function with given fields: ctx
+func (_m *PhoneI) VerifyRoaster(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// VerifyUser provides a mock function with given fields: ctx
+func (_m *PhoneI) VerifyUser(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+var _ handlers.PhoneI = (*PhoneI)(nil)
@@ -0,0 +1,43 @@
+package mocks
+
+import gin "gopkg.in/gin-gonic/gin.v1"
+import handlers "github.com/jakelong95/TownCenter/handlers"
+import mock "github.com/stretchr/testify/mock"
+
+// PublicI is an autogenerated mock type for the PublicI type
+type PublicI struct {
+	mock.Mock
+}
+
+// RoasterAvatar provides a mock function with given fields: ctx
+func (_m *PublicI) RoasterAvatar(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Time provides a mock function with given fields:
+func (_m *PublicI) Time() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// UserAvatar provides a mock function with given fields: ctx
+func (_m *PublicI) UserAvatar(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ViewRoaster provides a mock function with given fields: ctx
+func (_m *PublicI) ViewRoaster(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+var _ handlers.PublicI = (*PublicI)(nil)
@@ -9,11 +9,26 @@ type RoasterI struct {
 	mock.Mock
 }
 
+// Batch provides a mock function with given fields: ctx
+func (_m *RoasterI) Batch(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// CompletePhoto provides a mock function with given fields: ctx
+func (_m *RoasterI) CompletePhoto(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // Delete provides a mock function with given fields: ctx
 func (_m *RoasterI) Delete(ctx *gin.Context) {
 	_m.Called(ctx)
 }
 
+// DeletePhoto provides a mock function with given fields: ctx
+func (_m *RoasterI) DeletePhoto(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // GetJWT provides a mock function with given fields:
 func (_m *RoasterI) GetJWT() gin.HandlerFunc {
 	ret := _m.Called()
@@ -30,11 +45,26 @@ func (_m *RoasterI) GetJWT() gin.HandlerFunc {
 	return r0
 }
 
+// Nearby provides a mock function with given fields: ctx
+func (_m *RoasterI) Nearby(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // New provides a mock function with given fields: ctx
 func (_m *RoasterI) New(ctx *gin.Context) {
 	_m.Called(ctx)
 }
 
+// PresignPhoto provides a mock function with given fields: ctx
+func (_m *RoasterI) PresignPhoto(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Search provides a mock function with given fields: ctx
+func (_m *RoasterI) Search(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // Time provides a mock function with given fields:
 func (_m *RoasterI) Time() gin.HandlerFunc {
 	ret := _m.Called()
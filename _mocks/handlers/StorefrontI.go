@@ -0,0 +1,79 @@
+package mocks
+
+import gin "gopkg.in/gin-gonic/gin.v1"
+import handlers "github.com/jakelong95/TownCenter/handlers"
+import mock "github.com/stretchr/testify/mock"
+
+// StorefrontI is an autogenerated mock type for the StorefrontI type
+type StorefrontI struct {
+	mock.Mock
+}
+
+// AddImage provides a mock function with given fields: ctx
+func (_m *StorefrontI) AddImage(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// DeleteImage provides a mock function with given fields: ctx
+func (_m *StorefrontI) DeleteImage(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// GetJWT provides a mock function with given fields:
+func (_m *StorefrontI) GetJWT() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// OrderGallery provides a mock function with given fields: ctx
+func (_m *StorefrontI) OrderGallery(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Time provides a mock function with given fields:
+func (_m *StorefrontI) Time() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// UpdateImage provides a mock function with given fields: ctx
+func (_m *StorefrontI) UpdateImage(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// UpdateProfile provides a mock function with given fields: ctx
+func (_m *StorefrontI) UpdateProfile(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ViewGallery provides a mock function with given fields: ctx
+func (_m *StorefrontI) ViewGallery(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ViewProfile provides a mock function with given fields: ctx
+func (_m *StorefrontI) ViewProfile(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+var _ handlers.StorefrontI = (*StorefrontI)(nil)
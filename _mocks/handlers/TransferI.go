@@ -0,0 +1,74 @@
+package mocks
+
+import gin "gopkg.in/gin-gonic/gin.v1"
+import handlers "github.com/jakelong95/TownCenter/handlers"
+import mock "github.com/stretchr/testify/mock"
+
+// TransferI is an autogenerated mock type for the TransferI type
+type TransferI struct {
+	mock.Mock
+}
+
+// Accept provides a mock function with given fields: ctx
+func (_m *TransferI) Accept(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Cancel provides a mock function with given fields: ctx
+func (_m *TransferI) Cancel(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Decline provides a mock function with given fields: ctx
+func (_m *TransferI) Decline(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// GetJWT provides a mock function with given fields:
+func (_m *TransferI) GetJWT() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Initiate provides a mock function with given fields: ctx
+func (_m *TransferI) Initiate(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Time provides a mock function with given fields:
+func (_m *TransferI) Time() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// View provides a mock function with given fields: ctx
+func (_m *TransferI) View(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ViewByToken provides a mock function with given fields: ctx
+func (_m *TransferI) ViewByToken(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+var _ handlers.TransferI = (*TransferI)(nil)
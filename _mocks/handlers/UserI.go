@@ -9,11 +9,26 @@ type UserI struct {
 	mock.Mock
 }
 
+// Batch provides a mock function with given fields: ctx
+func (_m *UserI) Batch(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// CompletePhoto provides a mock function with given fields: ctx
+func (_m *UserI) CompletePhoto(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // Delete provides a mock function with given fields: ctx
 func (_m *UserI) Delete(ctx *gin.Context) {
 	_m.Called(ctx)
 }
 
+// DeletePhoto provides a mock function with given fields: ctx
+func (_m *UserI) DeletePhoto(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // GetJWT provides a mock function with given fields:
 func (_m *UserI) GetJWT() gin.HandlerFunc {
 	ret := _m.Called()
@@ -40,6 +55,16 @@ func (_m *UserI) New(ctx *gin.Context) {
 	_m.Called(ctx)
 }
 
+// PresignPhoto provides a mock function with given fields: ctx
+func (_m *UserI) PresignPhoto(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Search provides a mock function with given fields: ctx
+func (_m *UserI) Search(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // Time provides a mock function with given fields:
 func (_m *UserI) Time() gin.HandlerFunc {
 	ret := _m.Called()
@@ -76,6 +101,16 @@ func (_m *UserI) ViewAll(ctx *gin.Context) {
 	_m.Called(ctx)
 }
 
+// ViewByEmail provides a mock function with given fields: ctx
+func (_m *UserI) ViewByEmail(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ViewByRoaster provides a mock function with given fields: ctx
+func (_m *UserI) ViewByRoaster(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // ViewByToken provides a mock function with given fields: ctx
 func (_m *UserI) ViewByToken(ctx *gin.Context) {
 	_m.Called(ctx)
@@ -0,0 +1,79 @@
+package mocks
+
+import gin "gopkg.in/gin-gonic/gin.v1"
+import handlers "github.com/jakelong95/TownCenter/handlers"
+import mock "github.com/stretchr/testify/mock"
+
+// WebhookI is an autogenerated mock type for the WebhookI type
+type WebhookI struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: ctx
+func (_m *WebhookI) Delete(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Deliveries provides a mock function with given fields: ctx
+func (_m *WebhookI) Deliveries(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// GetJWT provides a mock function with given fields:
+func (_m *WebhookI) GetJWT() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// New provides a mock function with given fields: ctx
+func (_m *WebhookI) New(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Replay provides a mock function with given fields: ctx
+func (_m *WebhookI) Replay(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// Time provides a mock function with given fields:
+func (_m *WebhookI) Time() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx
+func (_m *WebhookI) Update(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// View provides a mock function with given fields: ctx
+func (_m *WebhookI) View(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ViewAll provides a mock function with given fields: ctx
+func (_m *WebhookI) ViewAll(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+var _ handlers.WebhookI = (*WebhookI)(nil)
@@ -0,0 +1,100 @@
+package mocks
+
+import helpers "github.com/jakelong95/TownCenter/helpers"
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/jakelong95/TownCenter/models"
+
+// AddressI is an autogenerated mock type for the AddressI type
+type AddressI struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: _a0
+func (_m *AddressI) Delete(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByID provides a mock function with given fields: _a0
+func (_m *AddressI) GetByID(_a0 string) (*models.Address, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *models.Address
+	if rf, ok := ret.Get(0).(func(string) *models.Address); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Address)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByUser provides a mock function with given fields: _a0
+func (_m *AddressI) GetByUser(_a0 string) ([]*models.Address, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []*models.Address
+	if rf, ok := ret.Get(0).(func(string) []*models.Address); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Address)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: _a0
+func (_m *AddressI) Insert(_a0 *models.Address) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Address) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: _a0, _a1
+func (_m *AddressI) Update(_a0 *models.Address, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Address, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ helpers.AddressI = (*AddressI)(nil)
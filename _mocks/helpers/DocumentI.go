@@ -0,0 +1,50 @@
+package mocks
+
+import helpers "github.com/jakelong95/TownCenter/helpers"
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/jakelong95/TownCenter/models"
+import multipart "mime/multipart"
+
+// DocumentI is an autogenerated mock type for the DocumentI type
+type DocumentI struct {
+	mock.Mock
+}
+
+// GetByRoaster provides a mock function with given fields: _a0
+func (_m *DocumentI) GetByRoaster(_a0 string) ([]*models.RoasterDocument, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []*models.RoasterDocument
+	if rf, ok := ret.Get(0).(func(string) []*models.RoasterDocument); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.RoasterDocument)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: _a0, _a1
+func (_m *DocumentI) Insert(_a0 *models.RoasterDocument, _a1 multipart.File) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.RoasterDocument, multipart.File) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ helpers.DocumentI = (*DocumentI)(nil)
@@ -0,0 +1,40 @@
+package mocks
+
+import helpers "github.com/jakelong95/TownCenter/helpers"
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/jakelong95/TownCenter/models"
+
+// ExportI is an autogenerated mock type for the ExportI type
+type ExportI struct {
+	mock.Mock
+}
+
+// Roasters provides a mock function with given fields: _a0, _a1
+func (_m *ExportI) Roasters(_a0 *models.ListFilter, _a1 func(*models.Roaster) error) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.ListFilter, func(*models.Roaster) error) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Users provides a mock function with given fields: _a0, _a1
+func (_m *ExportI) Users(_a0 *models.ListFilter, _a1 func(*models.User) error) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.ListFilter, func(*models.User) error) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ helpers.ExportI = (*ExportI)(nil)
@@ -0,0 +1,115 @@
+package mocks
+
+import helpers "github.com/jakelong95/TownCenter/helpers"
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/jakelong95/TownCenter/models"
+import multipart "mime/multipart"
+
+// GalleryI is an autogenerated mock type for the GalleryI type
+type GalleryI struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: _a0
+func (_m *GalleryI) Delete(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByID provides a mock function with given fields: _a0
+func (_m *GalleryI) GetByID(_a0 string) (*models.GalleryImage, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *models.GalleryImage
+	if rf, ok := ret.Get(0).(func(string) *models.GalleryImage); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.GalleryImage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByRoaster provides a mock function with given fields: _a0
+func (_m *GalleryI) GetByRoaster(_a0 string) ([]*models.GalleryImage, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []*models.GalleryImage
+	if rf, ok := ret.Get(0).(func(string) []*models.GalleryImage); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.GalleryImage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: _a0, _a1, _a2
+func (_m *GalleryI) Insert(_a0 *models.GalleryImage, _a1 string, _a2 multipart.File) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.GalleryImage, string, multipart.File) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Reorder provides a mock function with given fields: _a0, _a1
+func (_m *GalleryI) Reorder(_a0 string, _a1 []string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: _a0, _a1
+func (_m *GalleryI) Update(_a0 *models.GalleryImage, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.GalleryImage, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ helpers.GalleryI = (*GalleryI)(nil)
@@ -0,0 +1,70 @@
+package mocks
+
+import helpers "github.com/jakelong95/TownCenter/helpers"
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/jakelong95/TownCenter/models"
+
+// IdempotencyI is an autogenerated mock type for the IdempotencyI type
+type IdempotencyI struct {
+	mock.Mock
+}
+
+// Claim provides a mock function with given fields: _a0, _a1, _a2
+func (_m *IdempotencyI) Claim(_a0 string, _a1 string, _a2 string) (*models.IdempotencyRecord, bool, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *models.IdempotencyRecord
+	if rf, ok := ret.Get(0).(func(string, string, string) *models.IdempotencyRecord); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.IdempotencyRecord)
+		}
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(string, string, string) bool); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string, string) error); ok {
+		r2 = rf(_a0, _a1, _a2)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Release provides a mock function with given fields: _a0
+func (_m *IdempotencyI) Release(_a0 *models.IdempotencyRecord) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.IdempotencyRecord) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Save provides a mock function with given fields: _a0
+func (_m *IdempotencyI) Save(_a0 *models.IdempotencyRecord) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.IdempotencyRecord) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ helpers.IdempotencyI = (*IdempotencyI)(nil)
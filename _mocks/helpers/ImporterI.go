@@ -0,0 +1,35 @@
+package mocks
+
+import helpers "github.com/jakelong95/TownCenter/helpers"
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/jakelong95/TownCenter/models"
+
+// ImporterI is an autogenerated mock type for the ImporterI type
+type ImporterI struct {
+	mock.Mock
+}
+
+// Run provides a mock function with given fields: _a0
+func (_m *ImporterI) Run(_a0 string) (*models.Import, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *models.Import
+	if rf, ok := ret.Get(0).(func(string) *models.Import); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Import)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+var _ helpers.ImporterI = (*ImporterI)(nil)
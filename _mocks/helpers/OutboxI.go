@@ -0,0 +1,78 @@
+package mocks
+
+import helpers "github.com/jakelong95/TownCenter/helpers"
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/jakelong95/TownCenter/models"
+import time "time"
+
+// OutboxI is an autogenerated mock type for the OutboxI type
+type OutboxI struct {
+	mock.Mock
+}
+
+// Claim provides a mock function with given fields: _a0, _a1
+func (_m *OutboxI) Claim(_a0 string, _a1 int) ([]*models.Event, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []*models.Event
+	if rf, ok := ret.Get(0).(func(string, int) []*models.Event); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Event)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkPublished provides a mock function with given fields: _a0
+func (_m *OutboxI) MarkPublished(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Prune provides a mock function with given fields: _a0
+func (_m *OutboxI) Prune(_a0 time.Time) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(time.Time) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Release provides a mock function with given fields: _a0
+func (_m *OutboxI) Release(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ helpers.OutboxI = (*OutboxI)(nil)
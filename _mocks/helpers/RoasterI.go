@@ -3,7 +3,6 @@ package mocks
 import helpers "github.com/jakelong95/TownCenter/helpers"
 import mock "github.com/stretchr/testify/mock"
 import models "github.com/jakelong95/TownCenter/models"
-import multipart "mime/multipart"
 import uuid "github.com/pborman/uuid"
 
 // RoasterI is an autogenerated mock type for the RoasterI type
@@ -11,6 +10,20 @@ type RoasterI struct {
 	mock.Mock
 }
 
+// CompleteProfile provides a mock function with given fields: _a0, _a1
+func (_m *RoasterI) CompleteProfile(_a0 string, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateAccount provides a mock function with given fields: id
 func (_m *RoasterI) CreateAccount(id uuid.UUID) error {
 	ret := _m.Called(id)
@@ -39,13 +52,13 @@ func (_m *RoasterI) Delete(_a0 string) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: _a0, _a1
-func (_m *RoasterI) GetAll(_a0 int, _a1 int) ([]*models.Roaster, error) {
-	ret := _m.Called(_a0, _a1)
+// GetAll provides a mock function with given fields: _a0, _a1, _a2
+func (_m *RoasterI) GetAll(_a0 int, _a1 int, _a2 *models.ListFilter) ([]*models.Roaster, error) {
+	ret := _m.Called(_a0, _a1, _a2)
 
 	var r0 []*models.Roaster
-	if rf, ok := ret.Get(0).(func(int, int) []*models.Roaster); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(int, int, *models.ListFilter) []*models.Roaster); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Roaster)
@@ -53,8 +66,31 @@ func (_m *RoasterI) GetAll(_a0 int, _a1 int) ([]*models.Roaster, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(int, int, *models.ListFilter) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByEmail provides a mock function with given fields: _a0
+func (_m *RoasterI) GetByEmail(_a0 string) (*models.Roaster, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *models.Roaster
+	if rf, ok := ret.Get(0).(func(string) *models.Roaster); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Roaster)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -85,6 +121,96 @@ func (_m *RoasterI) GetByID(_a0 string) (*models.Roaster, error) {
 	return r0, r1
 }
 
+// GetByIDs provides a mock function with given fields: _a0
+func (_m *RoasterI) GetByIDs(_a0 []string) ([]*models.Roaster, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []*models.Roaster
+	if rf, ok := ret.Get(0).(func([]string) []*models.Roaster); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Roaster)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBySlug provides a mock function with given fields: _a0
+func (_m *RoasterI) GetBySlug(_a0 string) (*models.Roaster, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *models.Roaster
+	if rf, ok := ret.Get(0).(func(string) *models.Roaster); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Roaster)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNearby provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
+func (_m *RoasterI) GetNearby(_a0 float64, _a1 float64, _a2 float64, _a3 int, _a4 int) ([]*models.NearbyRoaster, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
+
+	var r0 []*models.NearbyRoaster
+	if rf, ok := ret.Get(0).(func(float64, float64, float64, int, int) []*models.NearbyRoaster); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.NearbyRoaster)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(float64, float64, float64, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRedirect provides a mock function with given fields: _a0
+func (_m *RoasterI) GetRedirect(_a0 string) (string, error) {
+	ret := _m.Called(_a0)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: _a0
 func (_m *RoasterI) Insert(_a0 *models.Roaster) error {
 	ret := _m.Called(_a0)
@@ -99,13 +225,36 @@ func (_m *RoasterI) Insert(_a0 *models.Roaster) error {
 	return r0
 }
 
-// Profile provides a mock function with given fields: _a0, _a1, _a2
-func (_m *RoasterI) Profile(_a0 string, _a1 string, _a2 multipart.File) error {
-	ret := _m.Called(_a0, _a1, _a2)
+// PresignProfile provides a mock function with given fields: _a0, _a1
+func (_m *RoasterI) PresignProfile(_a0 string, _a1 string) (*models.PresignedUpload, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *models.PresignedUpload
+	if rf, ok := ret.Get(0).(func(string, string) *models.PresignedUpload); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PresignedUpload)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Profile provides a mock function with given fields: _a0, _a1
+func (_m *RoasterI) Profile(_a0 string, _a1 *helpers.Photo) error {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, multipart.File) error); ok {
-		r0 = rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(0).(func(string, *helpers.Photo) error); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -113,6 +262,64 @@ func (_m *RoasterI) Profile(_a0 string, _a1 string, _a2 multipart.File) error {
 	return r0
 }
 
+// RemoveProfile provides a mock function with given fields: _a0, _a1
+func (_m *RoasterI) RemoveProfile(_a0 string, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Search provides a mock function with given fields: _a0, _a1, _a2
+func (_m *RoasterI) Search(_a0 string, _a1 int, _a2 int) ([]*models.Roaster, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []*models.Roaster
+	if rf, ok := ret.Get(0).(func(string, int, int) []*models.Roaster); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Roaster)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SlugTaken provides a mock function with given fields: _a0, _a1
+func (_m *RoasterI) SlugTaken(_a0 string, _a1 string) (bool, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: _a0, _a1
 func (_m *RoasterI) Update(_a0 *models.Roaster, _a1 string) error {
 	ret := _m.Called(_a0, _a1)
@@ -127,4 +334,18 @@ func (_m *RoasterI) Update(_a0 *models.Roaster, _a1 string) error {
 	return r0
 }
 
+// VerifyPhone provides a mock function with given fields: _a0, _a1
+func (_m *RoasterI) VerifyPhone(_a0 string, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 var _ helpers.RoasterI = (*RoasterI)(nil)
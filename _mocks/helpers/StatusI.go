@@ -0,0 +1,49 @@
+package mocks
+
+import helpers "github.com/jakelong95/TownCenter/helpers"
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/jakelong95/TownCenter/models"
+
+// StatusI is an autogenerated mock type for the StatusI type
+type StatusI struct {
+	mock.Mock
+}
+
+// GetHistory provides a mock function with given fields: _a0
+func (_m *StatusI) GetHistory(_a0 string) ([]*models.StatusChange, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []*models.StatusChange
+	if rf, ok := ret.Get(0).(func(string) []*models.StatusChange); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.StatusChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Set provides a mock function with given fields: _a0
+func (_m *StatusI) Set(_a0 *models.StatusChange) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.StatusChange) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ helpers.StatusI = (*StatusI)(nil)
@@ -0,0 +1,100 @@
+package mocks
+
+import helpers "github.com/jakelong95/TownCenter/helpers"
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/jakelong95/TownCenter/models"
+
+// TransferI is an autogenerated mock type for the TransferI type
+type TransferI struct {
+	mock.Mock
+}
+
+// Accept provides a mock function with given fields: _a0
+func (_m *TransferI) Accept(_a0 *models.Transfer) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Transfer) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByToken provides a mock function with given fields: _a0
+func (_m *TransferI) GetByToken(_a0 string) (*models.Transfer, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *models.Transfer
+	if rf, ok := ret.Get(0).(func(string) *models.Transfer); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Transfer)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPending provides a mock function with given fields: _a0
+func (_m *TransferI) GetPending(_a0 string) (*models.Transfer, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *models.Transfer
+	if rf, ok := ret.Get(0).(func(string) *models.Transfer); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Transfer)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: _a0
+func (_m *TransferI) Insert(_a0 *models.Transfer) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Transfer) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetStatus provides a mock function with given fields: _a0, _a1
+func (_m *TransferI) SetStatus(_a0 *models.Transfer, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Transfer, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ helpers.TransferI = (*TransferI)(nil)
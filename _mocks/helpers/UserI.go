@@ -3,13 +3,26 @@ package mocks
 import helpers "github.com/jakelong95/TownCenter/helpers"
 import mock "github.com/stretchr/testify/mock"
 import models "github.com/jakelong95/TownCenter/models"
-import multipart "mime/multipart"
 
 // UserI is an autogenerated mock type for the UserI type
 type UserI struct {
 	mock.Mock
 }
 
+// CompleteProfile provides a mock function with given fields: _a0, _a1
+func (_m *UserI) CompleteProfile(_a0 string, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Delete provides a mock function with given fields: _a0
 func (_m *UserI) Delete(_a0 string) error {
 	ret := _m.Called(_a0)
@@ -24,13 +37,13 @@ func (_m *UserI) Delete(_a0 string) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: _a0, _a1
-func (_m *UserI) GetAll(_a0 int, _a1 int) ([]*models.User, error) {
-	ret := _m.Called(_a0, _a1)
+// GetAll provides a mock function with given fields: _a0, _a1, _a2
+func (_m *UserI) GetAll(_a0 int, _a1 int, _a2 *models.ListFilter) ([]*models.User, error) {
+	ret := _m.Called(_a0, _a1, _a2)
 
 	var r0 []*models.User
-	if rf, ok := ret.Get(0).(func(int, int) []*models.User); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(int, int, *models.ListFilter) []*models.User); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.User)
@@ -38,8 +51,8 @@ func (_m *UserI) GetAll(_a0 int, _a1 int) ([]*models.User, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(int, int, *models.ListFilter) error); ok {
+		r1 = rf(_a0, _a1, _a2)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -93,6 +106,29 @@ func (_m *UserI) GetByID(_a0 string) (*models.User, error) {
 	return r0, r1
 }
 
+// GetByIDs provides a mock function with given fields: _a0
+func (_m *UserI) GetByIDs(_a0 []string) ([]*models.User, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []*models.User
+	if rf, ok := ret.Get(0).(func([]string) []*models.User); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByRoaster provides a mock function with given fields: _a0
 func (_m *UserI) GetByRoaster(_a0 string) (*models.User, error) {
 	ret := _m.Called(_a0)
@@ -130,13 +166,87 @@ func (_m *UserI) Insert(_a0 *models.User) error {
 	return r0
 }
 
-// Profile provides a mock function with given fields: _a0, _a1, _a2
-func (_m *UserI) Profile(_a0 string, _a1 string, _a2 multipart.File) error {
-	ret := _m.Called(_a0, _a1, _a2)
+// PresignProfile provides a mock function with given fields: _a0, _a1
+func (_m *UserI) PresignProfile(_a0 string, _a1 string) (*models.PresignedUpload, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *models.PresignedUpload
+	if rf, ok := ret.Get(0).(func(string, string) *models.PresignedUpload); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PresignedUpload)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Profile provides a mock function with given fields: _a0, _a1
+func (_m *UserI) Profile(_a0 string, _a1 *helpers.Photo) error {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, multipart.File) error); ok {
+	if rf, ok := ret.Get(0).(func(string, *helpers.Photo) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveProfile provides a mock function with given fields: _a0, _a1
+func (_m *UserI) RemoveProfile(_a0 string, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Search provides a mock function with given fields: _a0, _a1, _a2
+func (_m *UserI) Search(_a0 string, _a1 int, _a2 int) ([]*models.User, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []*models.User
+	if rf, ok := ret.Get(0).(func(string, int, int) []*models.User); ok {
 		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetAddress provides a mock function with given fields: _a0, _a1
+func (_m *UserI) SetAddress(_a0 string, _a1 *models.Address) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, *models.Address) error); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -158,4 +268,18 @@ func (_m *UserI) Update(_a0 *models.User, _a1 string) error {
 	return r0
 }
 
+// VerifyPhone provides a mock function with given fields: _a0, _a1
+func (_m *UserI) VerifyPhone(_a0 string, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 var _ helpers.UserI = (*UserI)(nil)
@@ -0,0 +1,198 @@
+package mocks
+
+import helpers "github.com/jakelong95/TownCenter/helpers"
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/jakelong95/TownCenter/models"
+import time "time"
+
+// WebhookI is an autogenerated mock type for the WebhookI type
+type WebhookI struct {
+	mock.Mock
+}
+
+// ClaimDue provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WebhookI) ClaimDue(_a0 string, _a1 time.Time, _a2 int) ([]*models.WebhookDelivery, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []*models.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(string, time.Time, int) []*models.WebhookDelivery); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.WebhookDelivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: _a0
+func (_m *WebhookI) Delete(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByID provides a mock function with given fields: _a0
+func (_m *WebhookI) GetByID(_a0 string) (*models.Webhook, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *models.Webhook
+	if rf, ok := ret.Get(0).(func(string) *models.Webhook); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Webhook)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByRoaster provides a mock function with given fields: _a0
+func (_m *WebhookI) GetByRoaster(_a0 string) ([]*models.Webhook, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []*models.Webhook
+	if rf, ok := ret.Get(0).(func(string) []*models.Webhook); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Webhook)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeliveries provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WebhookI) GetDeliveries(_a0 string, _a1 int, _a2 int) ([]*models.WebhookDelivery, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []*models.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(string, int, int) []*models.WebhookDelivery); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.WebhookDelivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDelivery provides a mock function with given fields: _a0
+func (_m *WebhookI) GetDelivery(_a0 string) (*models.WebhookDelivery, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *models.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(string) *models.WebhookDelivery); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.WebhookDelivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: _a0
+func (_m *WebhookI) Insert(_a0 *models.Webhook) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Webhook) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Replay provides a mock function with given fields: _a0
+func (_m *WebhookI) Replay(_a0 *models.WebhookDelivery) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.WebhookDelivery) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveDelivery provides a mock function with given fields: _a0
+func (_m *WebhookI) SaveDelivery(_a0 *models.WebhookDelivery) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.WebhookDelivery) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: _a0
+func (_m *WebhookI) Update(_a0 *models.Webhook) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Webhook) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ helpers.WebhookI = (*WebhookI)(nil)
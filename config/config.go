@@ -0,0 +1,44 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/ghmeier/bloodlines/config"
+)
+
+// Root is the bloodlines config along with the settings only TownCenter
+// reads, which bloodlines doesn't know about. Both come from the same file.
+type Root struct {
+	*config.Root
+	Rabbit Rabbit
+}
+
+/*Rabbit is where the event relay publishes to, it only runs when Host is set*/
+type Rabbit struct {
+	Host string
+	Port string
+	User string
+	Pass string
+}
+
+/*Init loads the config at path*/
+func Init(path string) (*Root, error) {
+	root, err := config.Init(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Root{Root: root}
+	err = json.Unmarshal(file, &struct{ Rabbit *Rabbit }{&c.Rabbit})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInit(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeConfig(t, `{"Port": "8080", "Rabbit": {"Host": "rabbit", "Port": "5672", "User": "expresso", "Pass": "secret"}}`)
+	defer os.Remove(path)
+
+	c, err := Init(path)
+
+	assert.NoError(err)
+	assert.NotNil(c.Root)
+	assert.Equal(Rabbit{Host: "rabbit", Port: "5672", User: "expresso", Pass: "secret"}, c.Rabbit)
+}
+
+func TestInitNoRabbit(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeConfig(t, `{"Port": "8080"}`)
+	defer os.Remove(path)
+
+	c, err := Init(path)
+
+	assert.NoError(err)
+	assert.Equal("", c.Rabbit.Host)
+}
+
+func TestInitInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeConfig(t, `{"Rabbit": "rabbit"}`)
+	defer os.Remove(path)
+
+	_, err := Init(path)
+
+	assert.Error(err)
+}
+
+func writeConfig(t *testing.T, contents string) string {
+	file, err := ioutil.TempFile("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	file.WriteString(contents)
+	return file.Name()
+}
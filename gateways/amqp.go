@@ -0,0 +1,134 @@
+package gateways
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jakelong95/TownCenter/config"
+	"github.com/jakelong95/TownCenter/models"
+	"github.com/streadway/amqp"
+)
+
+/*EventExchange is the topic exchange events are published to, routed by their type*/
+const EventExchange = "towncenter.events"
+
+/*ConfirmTimeout is how long Publish waits for the broker to confirm a message*/
+const ConfirmTimeout = 10 * time.Second
+
+var ErrNotConfirmed = errors.New("Error: broker didn't confirm the event")
+
+type Publisher interface {
+	Publish(*models.Event) error
+	Close() error
+}
+
+// AMQP publishes events as persistent messages, waiting for the broker to
+// confirm each one. It connects on the first Publish and again after any
+// failure, so a broker that's down only delays events.
+type AMQP struct {
+	url      string
+	mutex    sync.Mutex
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	confirms chan amqp.Confirmation
+}
+
+func NewAMQP(config config.Rabbit) *AMQP {
+	return &AMQP{
+		url: fmt.Sprintf("amqp://%s:%s@%s:%s/", config.User, config.Pass, config.Host, config.Port),
+	}
+}
+
+/*Publish sends event to EventExchange with its type as the routing key and its ID as the message ID*/
+func (a *AMQP) Publish(event *models.Event) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	err := a.connect()
+	if err != nil {
+		return err
+	}
+
+	err = a.channel.Publish(EventExchange, event.Type, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    event.ID.String(),
+		Type:         event.Type,
+		Timestamp:    event.CreatedAt,
+		AppId:        "towncenter",
+		Body:         event.Data,
+	})
+	if err != nil {
+		a.reset()
+		return err
+	}
+
+	select {
+	case confirm, ok := <-a.confirms:
+		if !ok || !confirm.Ack {
+			a.reset()
+			return ErrNotConfirmed
+		}
+	case <-time.After(ConfirmTimeout):
+		a.reset()
+		return ErrNotConfirmed
+	}
+
+	return nil
+}
+
+/*Close closes the connection to the broker, Publish will open a new one*/
+func (a *AMQP) Close() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.reset()
+	return nil
+}
+
+func (a *AMQP) connect() error {
+	if a.channel != nil {
+		return nil
+	}
+
+	conn, err := amqp.Dial(a.url)
+	if err != nil {
+		return err
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	err = channel.ExchangeDeclare(EventExchange, "topic", true, false, false, false, nil)
+	if err == nil {
+		err = channel.Confirm(false)
+	}
+	if err != nil {
+		channel.Close()
+		conn.Close()
+		return err
+	}
+
+	a.conn = conn
+	a.channel = channel
+	a.confirms = channel.NotifyPublish(make(chan amqp.Confirmation, 1))
+	return nil
+}
+
+func (a *AMQP) reset() {
+	if a.channel != nil {
+		a.channel.Close()
+	}
+	if a.conn != nil {
+		a.conn.Close()
+	}
+
+	a.conn = nil
+	a.channel = nil
+	a.confirms = nil
+}
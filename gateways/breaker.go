@@ -0,0 +1,76 @@
+package gateways
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+/*ErrCircuitOpen is returned without sending the request while the breaker is open*/
+var ErrCircuitOpen = errors.New("Error: TownCenter is unavailable, the circuit breaker is open")
+
+// breaker stops requests to a service that keeps failing. After threshold
+// failures in a row it opens and turns requests away for cooldown, then lets
+// a single request through. That request closing or reopening the breaker
+// decides what happens next. A threshold of 0 never opens.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+/*allow reports whether a request can be sent, a request it allows must be followed by success or failure*/
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold == 0 || b.failures < b.threshold {
+		return true
+	}
+	if b.trial || time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+
+	b.trial = true
+	return true
+}
+
+/*success closes the breaker*/
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+}
+
+/*failure counts a failed request, returning true if it opened the breaker*/
+func (b *breaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.threshold == 0 || b.failures < b.threshold {
+		return false
+	}
+
+	b.trial = false
+	b.openedAt = time.Now()
+	return true
+}
+
+/*release gives up a request that was cancelled before it succeeded or failed*/
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
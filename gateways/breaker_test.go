@@ -0,0 +1,80 @@
+package gateways
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakerOpens(t *testing.T) {
+	assert := assert.New(t)
+
+	b := newBreaker(2, time.Hour)
+
+	assert.True(b.allow())
+	assert.False(b.failure())
+	assert.True(b.allow())
+	assert.True(b.failure())
+	assert.False(b.allow())
+}
+
+func TestBreakerSuccessResets(t *testing.T) {
+	assert := assert.New(t)
+
+	b := newBreaker(2, time.Hour)
+
+	b.failure()
+	b.success()
+	assert.False(b.failure())
+	assert.True(b.allow())
+}
+
+func TestBreakerTrial(t *testing.T) {
+	assert := assert.New(t)
+
+	b := newBreaker(1, time.Millisecond)
+	b.failure()
+	time.Sleep(2 * time.Millisecond)
+
+	assert.True(b.allow())
+	assert.False(b.allow())
+
+	b.success()
+	assert.True(b.allow())
+	assert.True(b.allow())
+}
+
+func TestBreakerTrialFails(t *testing.T) {
+	assert := assert.New(t)
+
+	b := newBreaker(1, time.Hour)
+	b.failure()
+	b.openedAt = time.Now().Add(-2 * time.Hour)
+
+	assert.True(b.allow())
+	assert.True(b.failure())
+	assert.False(b.allow())
+}
+
+func TestBreakerRelease(t *testing.T) {
+	assert := assert.New(t)
+
+	b := newBreaker(1, time.Hour)
+	b.failure()
+	b.openedAt = time.Now().Add(-2 * time.Hour)
+
+	assert.True(b.allow())
+	b.release()
+	assert.True(b.allow())
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	b := newBreaker(0, time.Hour)
+	for i := 0; i < 10; i++ {
+		assert.False(b.failure())
+	}
+	assert.True(b.allow())
+}
@@ -0,0 +1,82 @@
+package gateways
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+/*cacheEntry is a cached response, entries are replaced rather than changed so they can be shared*/
+type cacheEntry struct {
+	key     string
+	etag    string
+	data    []byte
+	expires time.Time
+}
+
+// cache is a least recently used cache of up to size responses. Entries are
+// fresh for ttl, after which they're kept so they can be revalidated with
+// their ETag.
+type cache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newCache(size int, ttl time.Duration) *cache {
+	return &cache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+/*get returns the entry for key, if there is one, and whether it's still fresh*/
+func (c *cache) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(e)
+	entry := e.Value.(*cacheEntry)
+	return entry, time.Now().Before(entry.expires)
+}
+
+/*add caches data for key, fresh for the cache's ttl, evicting the least recently used entry when it's full*/
+func (c *cache) add(key, etag string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, etag: etag, data: data, expires: time.Now().Add(c.ttl)}
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+/*remove drops the entry for key*/
+func (c *cache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+		delete(c.entries, key)
+	}
+}
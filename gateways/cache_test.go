@@ -0,0 +1,91 @@
+package gateways
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheGet(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(2, time.Hour)
+	c.add("a", `"etag"`, []byte("data"))
+
+	entry, fresh := c.get("a")
+
+	assert.True(fresh)
+	assert.Equal(`"etag"`, entry.etag)
+	assert.Equal("data", string(entry.data))
+}
+
+func TestCacheMiss(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(2, time.Hour)
+
+	entry, fresh := c.get("a")
+
+	assert.Nil(entry)
+	assert.False(fresh)
+}
+
+func TestCacheStale(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(2, time.Nanosecond)
+	c.add("a", `"etag"`, []byte("data"))
+	time.Sleep(time.Millisecond)
+
+	entry, fresh := c.get("a")
+
+	assert.False(fresh)
+	assert.Equal(`"etag"`, entry.etag)
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(2, time.Hour)
+	c.add("a", "", []byte("a"))
+	c.add("b", "", []byte("b"))
+	c.get("a")
+	c.add("c", "", []byte("c"))
+
+	a, _ := c.get("a")
+	b, _ := c.get("b")
+	cc, _ := c.get("c")
+
+	assert.NotNil(a)
+	assert.Nil(b)
+	assert.NotNil(cc)
+}
+
+func TestCacheReplace(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(2, time.Hour)
+	c.add("a", `"1"`, []byte("old"))
+	c.add("a", `"2"`, []byte("new"))
+
+	entry, _ := c.get("a")
+
+	assert.Equal(`"2"`, entry.etag)
+	assert.Equal("new", string(entry.data))
+	assert.Equal(1, c.order.Len())
+}
+
+func TestCacheRemove(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(2, time.Hour)
+	c.add("a", "", []byte("a"))
+	c.remove("a")
+	c.remove("b")
+
+	entry, _ := c.get("a")
+
+	assert.Nil(entry)
+	assert.Equal(0, c.order.Len())
+}
@@ -0,0 +1,169 @@
+package gateways
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/ghmeier/bloodlines/config"
+	g "github.com/ghmeier/bloodlines/gateways"
+)
+
+/*ErrNoObject is returned when opening a key that nothing has been uploaded to*/
+var ErrNoObject = errors.New("Error: no such object")
+
+// S3 adds what the bloodlines S3 gateway is missing, which can only upload
+// through us. PresignUpload lets clients PUT straight to a key, and Open
+// reads back what they put there along with its size. UploadPrivate stores
+// files only PresignDownload's short-lived URLs can read.
+type S3 interface {
+	g.S3
+	Delete(string) error
+	PresignUpload(string, string, time.Duration) (string, error)
+	Open(string) (io.ReadCloser, int64, error)
+	UploadPrivate(string, io.ReadSeeker) error
+	PresignDownload(string, string, time.Duration) (string, error)
+}
+
+/*S3Store uploads through bloodlines and deletes with its own client for the same bucket*/
+type S3Store struct {
+	g.S3
+	bucket string
+	client *s3.S3
+}
+
+/*NewS3 creates an S3 gateway for the bucket in config*/
+func NewS3(config config.S3) S3 {
+	store := &S3Store{
+		S3:     g.NewS3(config),
+		bucket: config.Bucket,
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(config.Region),
+		Credentials: credentials.NewStaticCredentials(config.AccessKey, config.AccessSecret, ""),
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		return store
+	}
+
+	store.client = s3.New(sess)
+	return store
+}
+
+// PresignUpload returns a URL that accepts a PUT of contentType to key until
+// expires has passed, without any other credentials.
+func (s *S3Store) PresignUpload(key string, contentType string, expires time.Duration) (string, error) {
+	if s.client == nil {
+		return "", fmt.Errorf("Error: no S3 session")
+	}
+
+	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	return req.Presign(expires)
+}
+
+/*UploadPrivate stores body at key readable only by the bucket's owner, unlike Upload's public files*/
+func (s *S3Store) UploadPrivate(key string, body io.ReadSeeker) error {
+	if s.client == nil {
+		return fmt.Errorf("Error: no S3 session")
+	}
+
+	_, err := s.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		ACL:    aws.String(s3.ObjectCannedACLPrivate),
+		Body:   body,
+	})
+	return err
+}
+
+// PresignDownload returns a URL that GETs the object at key, or at the URL
+// Upload returned for it, until expires has passed. It's downloaded as
+// filename.
+func (s *S3Store) PresignDownload(location string, filename string, expires time.Duration) (string, error) {
+	if s.client == nil {
+		return "", fmt.Errorf("Error: no S3 session")
+	}
+
+	key, err := s.key(location)
+	if err != nil {
+		return "", err
+	}
+
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket:                     aws.String(s.bucket),
+		Key:                        aws.String(key),
+		ResponseContentDisposition: aws.String(fmt.Sprintf("attachment; filename=%q", filename)),
+	})
+	return req.Presign(expires)
+}
+
+/*Open returns the body and size in bytes of the object at key*/
+func (s *S3Store) Open(key string) (io.ReadCloser, int64, error) {
+	if s.client == nil {
+		return nil, 0, fmt.Errorf("Error: no S3 session")
+	}
+
+	out, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NoSuchKey" {
+		return nil, 0, ErrNoObject
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return out.Body, aws.Int64Value(out.ContentLength), nil
+}
+
+/*Delete removes the object at key, or at the URL Upload returned for it*/
+func (s *S3Store) Delete(location string) error {
+	if s.client == nil {
+		return fmt.Errorf("Error: no S3 session")
+	}
+
+	key, err := s.key(location)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// key finds the object key in an S3 URL, which has the bucket in either the
+// host (bucket.s3.amazonaws.com/key) or the path (s3.amazonaws.com/bucket/key).
+func (s *S3Store) key(location string) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+
+	key := strings.TrimPrefix(u.Path, "/")
+	if !strings.HasPrefix(u.Host, s.bucket+".") {
+		key = strings.TrimPrefix(key, s.bucket+"/")
+	}
+	if key == "" {
+		return "", fmt.Errorf("Error: %s is not an S3 object", location)
+	}
+
+	return key, nil
+}
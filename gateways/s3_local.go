@@ -0,0 +1,189 @@
+package gateways
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LocalS3 is an in-memory stand-in for S3 in tests. It serves the objects it
+// holds over HTTP, and accepts PUTs to the URLs PresignUpload returns, so
+// start it with httptest.NewServer and set Endpoint to the server's URL.
+// Private objects are only served at the URLs PresignDownload returns.
+type LocalS3 struct {
+	Endpoint string
+	bucket   string
+	mutex    sync.Mutex
+	objects  map[string][]byte
+	private  map[string]bool
+}
+
+/*NewLocalS3 creates an empty bucket*/
+func NewLocalS3(bucket string) *LocalS3 {
+	return &LocalS3{
+		bucket:  bucket,
+		objects: make(map[string][]byte),
+		private: make(map[string]bool),
+	}
+}
+
+/*Upload stores the file at folder/name*/
+func (l *LocalS3) Upload(folder string, name string, file multipart.File) (string, error) {
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+
+	key := folder + "/" + name
+	l.Put(key, data)
+	return l.url(key), nil
+}
+
+/*UploadPrivate stores body at key, served only at the URLs PresignDownload returns*/
+func (l *LocalS3) UploadPrivate(key string, body io.ReadSeeker) error {
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	l.Put(key, data)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.private[key] = true
+	return nil
+}
+
+// PresignDownload returns a URL on Endpoint that GETs the object at key, or
+// at the URL Upload returned for it, until expires has passed. Like
+// PresignUpload the expiry is carried in the query string rather than signed.
+func (l *LocalS3) PresignDownload(location string, filename string, expires time.Duration) (string, error) {
+	key := strings.TrimPrefix(location, l.url(""))
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(time.Now().Add(expires).Unix(), 10))
+	query.Set("filename", filename)
+
+	return l.url(key) + "?" + query.Encode(), nil
+}
+
+/*Put stores data at key as though it had been uploaded*/
+func (l *LocalS3) Put(key string, data []byte) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.objects[key] = data
+}
+
+/*Get returns the object at key and whether there is one*/
+func (l *LocalS3) Get(key string) ([]byte, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	data, ok := l.objects[key]
+	return data, ok
+}
+
+/*Delete removes the object at key, or at the URL Upload returned for it*/
+func (l *LocalS3) Delete(location string) error {
+	key := strings.TrimPrefix(location, l.url(""))
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.objects, key)
+	delete(l.private, key)
+	return nil
+}
+
+// PresignUpload returns a URL on Endpoint that accepts one PUT of
+// contentType to key. Unlike S3 nothing is signed, the expiry and content
+// type are carried in the query string and checked by ServeHTTP.
+func (l *LocalS3) PresignUpload(key string, contentType string, expires time.Duration) (string, error) {
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(time.Now().Add(expires).Unix(), 10))
+	query.Set("contentType", contentType)
+
+	return l.url(key) + "?" + query.Encode(), nil
+}
+
+/*Open returns the body and size in bytes of the object at key*/
+func (l *LocalS3) Open(key string) (io.ReadCloser, int64, error) {
+	data, ok := l.Get(key)
+	if !ok {
+		return nil, 0, ErrNoObject
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+}
+
+/*ServeHTTP handles GETs of stored objects and PUTs to presigned URLs*/
+func (l *LocalS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := "/" + l.bucket + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	switch r.Method {
+	case "GET":
+		data, ok := l.Get(key)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if l.isPrivate(key) && !unexpired(r) {
+			http.Error(w, "Access Denied", http.StatusForbidden)
+			return
+		}
+		w.Write(data)
+	case "PUT":
+		if !unexpired(r) {
+			http.Error(w, "Request has expired", http.StatusForbidden)
+			return
+		}
+
+		contentType := r.URL.Query().Get("contentType")
+		if r.Header.Get("Content-Type") != contentType {
+			http.Error(w, "Content-Type doesn't match the signature", http.StatusForbidden)
+			return
+		}
+
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		l.Put(key, data)
+	default:
+		http.Error(w, fmt.Sprintf("%s isn't supported", r.Method), http.StatusMethodNotAllowed)
+	}
+}
+
+func (l *LocalS3) isPrivate(key string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.private[key]
+}
+
+/*unexpired checks the expiry in a presigned URL's query string*/
+func unexpired(r *http.Request) bool {
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	return err == nil && time.Now().Unix() <= expires
+}
+
+func (l *LocalS3) url(key string) string {
+	return l.Endpoint + "/" + l.bucket + "/" + key
+}
@@ -0,0 +1,127 @@
+package gateways
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalS3PresignUpload(t *testing.T) {
+	assert := assert.New(t)
+
+	s3, server := getLocalS3()
+	defer server.Close()
+
+	url, err := s3.PresignUpload("upload/profile/a.png", "image/png", time.Minute)
+	assert.NoError(err)
+
+	res, err := put(url, "image/png", []byte("photo"))
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	body, size, err := s3.Open("upload/profile/a.png")
+	assert.NoError(err)
+	data, _ := ioutil.ReadAll(body)
+	assert.Equal("photo", string(data))
+	assert.Equal(int64(5), size)
+}
+
+func TestLocalS3PresignExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	s3, server := getLocalS3()
+	defer server.Close()
+
+	url, _ := s3.PresignUpload("upload/profile/a.png", "image/png", -time.Minute)
+	res, err := put(url, "image/png", []byte("photo"))
+
+	assert.NoError(err)
+	assert.Equal(403, res.StatusCode)
+	_, ok := s3.Get("upload/profile/a.png")
+	assert.False(ok)
+}
+
+func TestLocalS3PresignContentType(t *testing.T) {
+	assert := assert.New(t)
+
+	s3, server := getLocalS3()
+	defer server.Close()
+
+	url, _ := s3.PresignUpload("upload/profile/a.png", "image/png", time.Minute)
+	res, err := put(url, "text/html", []byte("photo"))
+
+	assert.NoError(err)
+	assert.Equal(403, res.StatusCode)
+}
+
+func TestLocalS3UploadDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	s3, server := getLocalS3()
+	defer server.Close()
+
+	url, err := s3.Upload("profile", "a.png", &file{bytes.NewReader([]byte("photo"))})
+	assert.NoError(err)
+	assert.Equal(server.URL+"/bucket/profile/a.png", url)
+
+	res, err := http.Get(url)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	assert.NoError(s3.Delete(url))
+	_, _, err = s3.Open("profile/a.png")
+	assert.Equal(ErrNoObject, err)
+}
+
+func getLocalS3() (*LocalS3, *httptest.Server) {
+	s3 := NewLocalS3("bucket")
+	server := httptest.NewServer(s3)
+	s3.Endpoint = server.URL
+
+	return s3, server
+}
+
+func put(url string, contentType string, body []byte) (*http.Response, error) {
+	req, _ := http.NewRequest("PUT", url, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	return http.DefaultClient.Do(req)
+}
+
+type file struct {
+	*bytes.Reader
+}
+
+func (f *file) Close() error {
+	return nil
+}
+
+func TestLocalS3PresignDownload(t *testing.T) {
+	assert := assert.New(t)
+
+	s3, server := getLocalS3()
+	defer server.Close()
+
+	assert.NoError(s3.UploadPrivate("documents/a.pdf", bytes.NewReader([]byte("license"))))
+
+	res, err := http.Get(server.URL + "/bucket/documents/a.pdf")
+	assert.NoError(err)
+	assert.Equal(403, res.StatusCode)
+
+	url, err := s3.PresignDownload("documents/a.pdf", "a.pdf", time.Minute)
+	assert.NoError(err)
+	res, err = http.Get(url)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	data, _ := ioutil.ReadAll(res.Body)
+	assert.Equal("license", string(data))
+
+	url, _ = s3.PresignDownload("documents/a.pdf", "a.pdf", -time.Minute)
+	res, err = http.Get(url)
+	assert.NoError(err)
+	assert.Equal(403, res.StatusCode)
+}
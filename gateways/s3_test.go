@@ -0,0 +1,57 @@
+package gateways
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestS3KeyVirtualHosted(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &S3Store{bucket: "expresso"}
+
+	key, err := s.key("https://expresso.s3.amazonaws.com/profile/id-1-original.jpg")
+
+	assert.NoError(err)
+	assert.Equal("profile/id-1-original.jpg", key)
+}
+
+func TestS3KeyPathStyle(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &S3Store{bucket: "expresso"}
+
+	key, err := s.key("https://s3-us-west-2.amazonaws.com/expresso/profile/id-1-original.jpg")
+
+	assert.NoError(err)
+	assert.Equal("profile/id-1-original.jpg", key)
+}
+
+func TestS3KeyNoObject(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &S3Store{bucket: "expresso"}
+
+	_, err := s.key("https://expresso.s3.amazonaws.com/")
+
+	assert.Error(err)
+}
+
+func TestS3DeleteNoSession(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &S3Store{bucket: "expresso"}
+
+	assert.Error(s.Delete("https://expresso.s3.amazonaws.com/profile/id-1-original.jpg"))
+}
+
+func TestS3PresignDownloadNoSession(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &S3Store{bucket: "expresso"}
+
+	_, err := s.PresignDownload("documents/id-license.pdf", "license.pdf", time.Minute)
+	assert.Error(err)
+}
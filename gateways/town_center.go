@@ -1,38 +1,162 @@
 package gateways
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/ghmeier/bloodlines/config"
-	g "github.com/ghmeier/bloodlines/gateways"
 	"github.com/jakelong95/TownCenter/models"
 
 	"github.com/pborman/uuid"
+	"golang.org/x/net/context"
+	"gopkg.in/alexcesaro/statsd.v2"
 )
 
-/*TownCenterI describes the functions for interacting with town center*/
+// TownCenterI describes the functions for interacting with town center. Every
+// call takes a context that cancels the request, and failed responses are
+// returned as a NotFoundError, ConflictError, UnauthorizedError,
+// ValidationError or ResponseError.
 type TownCenterI interface {
-	GetUser(uuid.UUID) (*models.User, error)
-	GetUserByRoaster(uuid.UUID) (*models.User, error)
-	GetAllUsers(int, int) ([]*models.User, error)
-	UpdateUser(uuid.UUID, *models.User) error
-	GetRoaster(uuid.UUID) (*models.Roaster, error)
-	GetAllRoasters(int, int) ([]*models.Roaster, error)
-	UpdateRoaster(uuid.UUID, *models.Roaster) error
+	GetUser(context.Context, uuid.UUID) (*models.User, error)
+	GetUsers(context.Context, []uuid.UUID) (map[string]*models.User, error)
+	GetUserByEmail(context.Context, string) (*models.User, error)
+	GetUserByRoaster(context.Context, uuid.UUID) (*models.User, error)
+	SearchUsers(context.Context, string, int, int) ([]*models.User, error)
+	CreateUser(context.Context, *models.User) (*models.User, error)
+	UpdateUser(context.Context, uuid.UUID, *models.User) error
+	DeleteUser(context.Context, uuid.UUID) error
+	UploadUserPhoto(context.Context, uuid.UUID, io.Reader) error
+	DeleteUserPhoto(context.Context, uuid.UUID) error
+	Login(context.Context, string, string) (*models.User, string, error)
+	RequestReset(context.Context, string) error
+	ResetPassword(context.Context, string, string) error
+	GetRoaster(context.Context, uuid.UUID) (*models.Roaster, error)
+	GetRoasters(context.Context, []uuid.UUID) (map[string]*models.Roaster, error)
+	GetAllRoasters(context.Context, int, int) ([]*models.Roaster, error)
+	SearchRoasters(context.Context, string, int, int) ([]*models.Roaster, error)
+	CreateRoaster(context.Context, uuid.UUID, *models.Roaster) (*models.Roaster, error)
+	UpdateRoaster(context.Context, uuid.UUID, *models.Roaster) error
+	DeleteRoaster(context.Context, uuid.UUID) error
+	UploadRoasterPhoto(context.Context, uuid.UUID, io.Reader) error
+	DeleteRoasterPhoto(context.Context, uuid.UUID) error
+}
+
+// Defaults for the TownCenterOptions left at zero. Setting Retries or
+// BreakerThreshold below zero turns retries or the circuit breaker off, and
+// responses are only cached when CacheSize is set.
+const (
+	DefaultTimeout          = 10 * time.Second
+	DefaultDialTimeout      = 2 * time.Second
+	DefaultRetries          = 2
+	DefaultRetryBackoff     = 100 * time.Millisecond
+	MaxRetryBackoff         = 2 * time.Second
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+	DefaultMaxIdleConns     = 16
+	DefaultCacheTTL         = time.Minute
+)
+
+/*TownCenterOptions tunes the client's connections, retries, circuit breaker and cache, zero values use the defaults*/
+type TownCenterOptions struct {
+	Timeout          time.Duration
+	DialTimeout      time.Duration
+	Retries          int
+	RetryBackoff     time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	MaxIdleConns     int
+	CacheSize        int
+	CacheTTL         time.Duration
+}
+
+// ReadTownCenterOptions reads the options from the TownCenter block of the
+// config at path, the same block bloodlines reads Host and Port from. A
+// config without the block gets the defaults.
+func ReadTownCenterOptions(path string) (TownCenterOptions, error) {
+	var options TownCenterOptions
+
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return options, err
+	}
+
+	err = json.Unmarshal(file, &struct{ TownCenter *TownCenterOptions }{&options})
+	return options, err
+}
+
+/*UnmarshalJSON reads the options from config, where durations are strings like "5s"*/
+func (o *TownCenterOptions) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Timeout          string
+		DialTimeout      string
+		Retries          int
+		RetryBackoff     string
+		BreakerThreshold int
+		BreakerCooldown  string
+		MaxIdleConns     int
+		CacheSize        int
+		CacheTTL         string
+	}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	durations := []struct {
+		name  string
+		value string
+		to    *time.Duration
+	}{
+		{"Timeout", raw.Timeout, &o.Timeout},
+		{"DialTimeout", raw.DialTimeout, &o.DialTimeout},
+		{"RetryBackoff", raw.RetryBackoff, &o.RetryBackoff},
+		{"BreakerCooldown", raw.BreakerCooldown, &o.BreakerCooldown},
+		{"CacheTTL", raw.CacheTTL, &o.CacheTTL},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+
+		*d.to, err = time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("Error: TownCenter.%s must be a duration like \"5s\": %s", d.name, err.Error())
+		}
+	}
+
+	o.Retries = raw.Retries
+	o.BreakerThreshold = raw.BreakerThreshold
+	o.MaxIdleConns = raw.MaxIdleConns
+	o.CacheSize = raw.CacheSize
+	return nil
 }
 
 /*TownCenter contains instrumentation for accessing TownCenter service*/
 type TownCenter struct {
-	*g.BaseService
-	host   string
-	port   string
-	url    string
-	client *http.Client
+	host    string
+	port    string
+	url     string
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	breaker *breaker
+	cache   *cache
+	stats   *statsd.Client
 }
 
-/*NewTownCenter creates and returns a TownCenter gateway*/
-func NewTownCenter(config config.TownCenter) TownCenterI {
+// NewTownCenter creates and returns a TownCenter gateway for the host in
+// config. Metrics are sent to stats under the town_center prefix, and aren't
+// sent at all when it's nil.
+func NewTownCenter(config config.TownCenter, options TownCenterOptions, stats *statsd.Client) TownCenterI {
 	var url string
 	if config.Port != "" {
 		url = fmt.Sprintf("http://%s:%s/api/", config.Host, config.Port)
@@ -40,21 +164,114 @@ func NewTownCenter(config config.TownCenter) TownCenterI {
 		url = fmt.Sprintf("https://%s/api/", config.Host)
 	}
 
+	if stats == nil {
+		stats, _ = statsd.New(statsd.Mute(true))
+	}
+
+	dialTimeout := duration(options.DialTimeout, DefaultDialTimeout)
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		Dial:                (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).Dial,
+		TLSHandshakeTimeout: dialTimeout,
+		MaxIdleConnsPerHost: setting(options.MaxIdleConns, DefaultMaxIdleConns),
+	}
+
+	var responses *cache
+	if options.CacheSize > 0 {
+		responses = newCache(options.CacheSize, duration(options.CacheTTL, DefaultCacheTTL))
+	}
+
 	return &TownCenter{
-		BaseService: g.NewBaseService(),
-		host:        config.Host,
-		port:        config.Port,
-		url:         url,
-		client:      &http.Client{},
+		host: config.Host,
+		port: config.Port,
+		url:  url,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   duration(options.Timeout, DefaultTimeout),
+		},
+		retries: setting(options.Retries, DefaultRetries),
+		backoff: duration(options.RetryBackoff, DefaultRetryBackoff),
+		breaker: newBreaker(
+			setting(options.BreakerThreshold, DefaultBreakerThreshold),
+			duration(options.BreakerCooldown, DefaultBreakerCooldown),
+		),
+		cache: responses,
+		stats: stats.Clone(statsd.Prefix("town_center")),
 	}
 }
 
+/*setting returns value, def when it's unset, or 0 when it's negative*/
+func setting(value, def int) int {
+	if value == 0 {
+		return def
+	}
+	if value < 0 {
+		return 0
+	}
+
+	return value
+}
+
+/*duration returns value, or def when it isn't positive*/
+func duration(value, def time.Duration) time.Duration {
+	if value <= 0 {
+		return def
+	}
+
+	return value
+}
+
+type tokenKey struct{}
+
+/*WithToken returns a copy of ctx whose requests are sent with the X-Auth token*/
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
 /*GetUser  gets information about a user based on the user ID*/
-func (t *TownCenter) GetUser(id uuid.UUID) (*models.User, error) {
+func (t *TownCenter) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	url := fmt.Sprintf("%suser/%s", t.url, id.String())
 
 	var user models.User
-	err := t.ServiceSend(http.MethodGet, url, nil, &user)
+	err := t.cached(ctx, url, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetUsers gets the users with the given IDs, keyed by ID, in as few requests
+// as it takes to send models.MaxBatch IDs at a time. IDs that weren't found
+// are left out.
+func (t *TownCenter) GetUsers(ctx context.Context, ids []uuid.UUID) (map[string]*models.User, error) {
+	url := fmt.Sprintf("%suser/batch", t.url)
+
+	users := make(map[string]*models.User)
+	for _, batch := range batches(ids) {
+		results := make(map[string]*models.UserResult)
+		err := t.lookup(ctx, url, batch, &results)
+		if err != nil {
+			return nil, err
+		}
+
+		for id, result := range results {
+			if result.Found {
+				users[id] = result.User
+			}
+		}
+	}
+
+	return users, nil
+}
+
+/*GetUserByEmail gets the user with exactly the given email, it needs an admin's token*/
+func (t *TownCenter) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	email = url.QueryEscape(email)
+	url := fmt.Sprintf("%suser/email?email=%s", t.url, email)
+
+	var user models.User
+	err := t.send(ctx, http.MethodGet, url, nil, &user)
 	if err != nil {
 		return nil, err
 	}
@@ -63,11 +280,11 @@ func (t *TownCenter) GetUser(id uuid.UUID) (*models.User, error) {
 }
 
 /*GetUserByRoaster returns the user associated with the given roaster ID*/
-func (t *TownCenter) GetUserByRoaster(id uuid.UUID) (*models.User, error) {
+func (t *TownCenter) GetUserByRoaster(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	url := fmt.Sprintf("%sroaster/%s/user", t.url, id.String())
 
 	var user models.User
-	err := t.ServiceSend(http.MethodGet, url, nil, &user)
+	err := t.send(ctx, http.MethodGet, url, nil, &user)
 	if err != nil {
 		return nil, err
 	}
@@ -75,12 +292,13 @@ func (t *TownCenter) GetUserByRoaster(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
-/*GetAllUsers gets information about all the users, paginated with an offset and limit per page*/
-func (t *TownCenter) GetAllUsers(offset, limit int) ([]*models.User, error) {
-	url := fmt.Sprintf("%suser?offset=%d&limit=%d", t.url, offset, limit)
+/*SearchUsers gets the users whose name, email or phone match query, it needs an admin's token*/
+func (t *TownCenter) SearchUsers(ctx context.Context, query string, offset, limit int) ([]*models.User, error) {
+	query = url.QueryEscape(query)
+	url := fmt.Sprintf("%suser/search?q=%s&offset=%d&limit=%d", t.url, query, offset, limit)
 
 	users := make([]*models.User, 0)
-	err := t.ServiceSend(http.MethodGet, url, nil, &users)
+	err := t.send(ctx, http.MethodGet, url, nil, &users)
 	if err != nil {
 		return nil, err
 	}
@@ -88,18 +306,93 @@ func (t *TownCenter) GetAllUsers(offset, limit int) ([]*models.User, error) {
 	return users, nil
 }
 
+/*CreateUser signs up a new user, the returned user has its ID*/
+func (t *TownCenter) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	url := fmt.Sprintf("%suser", t.url)
+
+	var created models.User
+	err := t.sendIdempotent(ctx, http.MethodPost, url, user, &created)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
 /*UpdateUser updates the information about a user based on user id*/
-func (t *TownCenter) UpdateUser(id uuid.UUID, user *models.User) error {
+func (t *TownCenter) UpdateUser(ctx context.Context, id uuid.UUID, user *models.User) error {
+	defer t.invalidate(fmt.Sprintf("%suser/%s", t.url, id.String()))
+
+	url := fmt.Sprintf("%suser/%s", t.url, id.String())
+	return t.send(ctx, http.MethodPut, url, user, nil)
+}
+
+/*DeleteUser deletes the user with the given ID*/
+func (t *TownCenter) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	defer t.invalidate(fmt.Sprintf("%suser/%s", t.url, id.String()))
+
 	url := fmt.Sprintf("%suser/%s", t.url, id.String())
-	return t.ServiceSend(http.MethodPut, url, user, nil)
+	return t.send(ctx, http.MethodDelete, url, nil, nil)
+}
+
+/*UploadUserPhoto sets the user's profile photo to the image read from photo*/
+func (t *TownCenter) UploadUserPhoto(ctx context.Context, id uuid.UUID, photo io.Reader) error {
+	defer t.invalidate(fmt.Sprintf("%suser/%s", t.url, id.String()))
+
+	url := fmt.Sprintf("%suser/%s/photo", t.url, id.String())
+	return t.upload(ctx, url, photo)
+}
+
+/*DeleteUserPhoto removes the user's profile photo*/
+func (t *TownCenter) DeleteUserPhoto(ctx context.Context, id uuid.UUID) error {
+	defer t.invalidate(fmt.Sprintf("%suser/%s", t.url, id.String()))
+
+	url := fmt.Sprintf("%suser/%s/photo", t.url, id.String())
+	return t.send(ctx, http.MethodDelete, url, nil, nil)
+}
+
+// Login checks the user's email and password, returning the user along with
+// the token to send as X-Auth. An unknown email or a wrong password are both
+// returned as an UnauthorizedError.
+func (t *TownCenter) Login(ctx context.Context, email, password string) (*models.User, string, error) {
+	url := fmt.Sprintf("%sauth/login", t.url)
+	body, err := json.Marshal(&models.User{Email: email, PassHash: password})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var user models.User
+	header, err := t.do(ctx, &request{method: http.MethodPost, url: url, contentType: "application/json", body: body}, &user)
+	switch err.(type) {
+	case nil:
+	case *NotFoundError, *ValidationError:
+		return nil, "", &UnauthorizedError{Msg: err.Error()}
+	default:
+		return nil, "", err
+	}
+
+	return &user, header.Get("X-Auth"), nil
+}
+
+/*RequestReset emails a password reset link to the user with the given email*/
+func (t *TownCenter) RequestReset(ctx context.Context, email string) error {
+	email = url.QueryEscape(email)
+	url := fmt.Sprintf("%sreset?email=%s", t.url, email)
+	return t.send(ctx, http.MethodPost, url, nil, nil)
+}
+
+/*ResetPassword sets a new password for the user the reset token was sent to*/
+func (t *TownCenter) ResetPassword(ctx context.Context, token, password string) error {
+	url := fmt.Sprintf("%sreset/%s", t.url, token)
+	return t.send(ctx, http.MethodPost, url, &models.ResetRequest{PassHash: password}, nil)
 }
 
 /*GetRoaster gets information about a roaster based on the roaster ID*/
-func (t *TownCenter) GetRoaster(id uuid.UUID) (*models.Roaster, error) {
+func (t *TownCenter) GetRoaster(ctx context.Context, id uuid.UUID) (*models.Roaster, error) {
 	url := fmt.Sprintf("%sroaster/%s", t.url, id.String())
 
 	var roaster models.Roaster
-	err := t.ServiceSend(http.MethodGet, url, nil, &roaster)
+	err := t.cached(ctx, url, &roaster)
 	if err != nil {
 		return nil, err
 	}
@@ -107,12 +400,50 @@ func (t *TownCenter) GetRoaster(id uuid.UUID) (*models.Roaster, error) {
 	return &roaster, nil
 }
 
+// GetRoasters gets the roasters with the given IDs, keyed by ID, in as few
+// requests as it takes to send models.MaxBatch IDs at a time. IDs that weren't
+// found are left out.
+func (t *TownCenter) GetRoasters(ctx context.Context, ids []uuid.UUID) (map[string]*models.Roaster, error) {
+	url := fmt.Sprintf("%sroaster/batch", t.url)
+
+	roasters := make(map[string]*models.Roaster)
+	for _, batch := range batches(ids) {
+		results := make(map[string]*models.RoasterResult)
+		err := t.lookup(ctx, url, batch, &results)
+		if err != nil {
+			return nil, err
+		}
+
+		for id, result := range results {
+			if result.Found {
+				roasters[id] = result.Roaster
+			}
+		}
+	}
+
+	return roasters, nil
+}
+
 /*GetAllRoasters gets information about all the roasters, paginated with an offset and limit per page*/
-func (t *TownCenter) GetAllRoasters(offset, limit int) ([]*models.Roaster, error) {
+func (t *TownCenter) GetAllRoasters(ctx context.Context, offset, limit int) ([]*models.Roaster, error) {
 	url := fmt.Sprintf("%sroaster?offset=%d&limit=%d", t.url, offset, limit)
 
 	roasters := make([]*models.Roaster, 0)
-	err := t.ServiceSend(http.MethodGet, url, nil, &roasters)
+	err := t.send(ctx, http.MethodGet, url, nil, &roasters)
+	if err != nil {
+		return nil, err
+	}
+
+	return roasters, nil
+}
+
+/*SearchRoasters gets the roasters whose name or city match query, best match first*/
+func (t *TownCenter) SearchRoasters(ctx context.Context, query string, offset, limit int) ([]*models.Roaster, error) {
+	query = url.QueryEscape(query)
+	url := fmt.Sprintf("%sroaster/search?q=%s&offset=%d&limit=%d", t.url, query, offset, limit)
+
+	roasters := make([]*models.Roaster, 0)
+	err := t.send(ctx, http.MethodGet, url, nil, &roasters)
 	if err != nil {
 		return nil, err
 	}
@@ -120,8 +451,320 @@ func (t *TownCenter) GetAllRoasters(offset, limit int) ([]*models.Roaster, error
 	return roasters, nil
 }
 
+/*CreateRoaster creates a roaster owned by the user with the given ID*/
+func (t *TownCenter) CreateRoaster(ctx context.Context, userID uuid.UUID, roaster *models.Roaster) (*models.Roaster, error) {
+	url := fmt.Sprintf("%sroaster", t.url)
+	data := struct {
+		Roaster *models.Roaster `json:"roaster"`
+		UserID  uuid.UUID       `json:"userId"`
+	}{roaster, userID}
+
+	// the owner's roasterId changes along with the new roaster
+	defer t.invalidate(fmt.Sprintf("%suser/%s", t.url, userID.String()))
+
+	var created models.Roaster
+	err := t.sendIdempotent(ctx, http.MethodPost, url, data, &created)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
 /*UpdateRoaster updates the information about a roaster based on roaster id*/
-func (t *TownCenter) UpdateRoaster(id uuid.UUID, roaster *models.Roaster) error {
+func (t *TownCenter) UpdateRoaster(ctx context.Context, id uuid.UUID, roaster *models.Roaster) error {
+	defer t.invalidate(fmt.Sprintf("%sroaster/%s", t.url, id.String()))
+
 	url := fmt.Sprintf("%sroaster/%s", t.url, id.String())
-	return t.ServiceSend(http.MethodPut, url, roaster, nil)
+	return t.send(ctx, http.MethodPut, url, roaster, nil)
+}
+
+/*DeleteRoaster deletes the roaster with the given ID*/
+func (t *TownCenter) DeleteRoaster(ctx context.Context, id uuid.UUID) error {
+	defer t.invalidate(fmt.Sprintf("%sroaster/%s", t.url, id.String()))
+
+	url := fmt.Sprintf("%sroaster/%s", t.url, id.String())
+	return t.send(ctx, http.MethodDelete, url, nil, nil)
+}
+
+/*UploadRoasterPhoto sets the roaster's profile photo to the image read from photo*/
+func (t *TownCenter) UploadRoasterPhoto(ctx context.Context, id uuid.UUID, photo io.Reader) error {
+	defer t.invalidate(fmt.Sprintf("%sroaster/%s", t.url, id.String()))
+
+	url := fmt.Sprintf("%sroaster/%s/photo", t.url, id.String())
+	return t.upload(ctx, url, photo)
+}
+
+/*DeleteRoasterPhoto removes the roaster's profile photo*/
+func (t *TownCenter) DeleteRoasterPhoto(ctx context.Context, id uuid.UUID) error {
+	defer t.invalidate(fmt.Sprintf("%sroaster/%s", t.url, id.String()))
+
+	url := fmt.Sprintf("%sroaster/%s/photo", t.url, id.String())
+	return t.send(ctx, http.MethodDelete, url, nil, nil)
+}
+
+// cached gets the data at url into i through the cache, when there is one.
+// Stale entries are revalidated with their ETag rather than fetched again.
+func (t *TownCenter) cached(ctx context.Context, url string, i interface{}) error {
+	if t.cache == nil {
+		return t.send(ctx, http.MethodGet, url, nil, i)
+	}
+
+	entry, fresh := t.cache.get(url)
+	if fresh {
+		t.stats.Increment("cache.hit")
+		return json.Unmarshal(entry.data, i)
+	}
+
+	r := &request{method: http.MethodGet, url: url}
+	if entry != nil {
+		r.etag = entry.etag
+	}
+
+	var data json.RawMessage
+	header, err := t.do(ctx, r, &data)
+	if err == errNotModified && entry != nil {
+		t.stats.Increment("cache.revalidated")
+		t.cache.add(url, entry.etag, entry.data)
+		return json.Unmarshal(entry.data, i)
+	}
+	if err != nil {
+		if _, ok := err.(*NotFoundError); ok {
+			t.cache.remove(url)
+		}
+		return err
+	}
+
+	t.stats.Increment("cache.miss")
+	t.cache.add(url, header.Get("ETag"), data)
+	return json.Unmarshal(data, i)
+}
+
+/*invalidate drops the cached response for url after the client changed it*/
+func (t *TownCenter) invalidate(url string) {
+	if t.cache != nil {
+		t.cache.remove(url)
+	}
+}
+
+/*lookup posts a batch of IDs, it only reads so it's retried like a get*/
+func (t *TownCenter) lookup(ctx context.Context, url string, ids []string, i interface{}) error {
+	body, err := json.Marshal(&models.BatchRequest{IDs: ids})
+	if err != nil {
+		return err
+	}
+
+	_, err = t.do(ctx, &request{method: http.MethodPost, url: url, contentType: "application/json", body: body, safe: true}, i)
+	return err
+}
+
+/*batches splits ids into lists of up to models.MaxBatch*/
+func batches(ids []uuid.UUID) [][]string {
+	batches := make([][]string, 0, len(ids)/models.MaxBatch+1)
+	for start := 0; start < len(ids); start += models.MaxBatch {
+		end := start + models.MaxBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		batch := make([]string, end-start)
+		for i, id := range ids[start:end] {
+			batch[i] = id.String()
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+/*send sends data as JSON, decoding the data of the response into i*/
+func (t *TownCenter) send(ctx context.Context, method, url string, data interface{}, i interface{}) error {
+	var body []byte
+	if data != nil {
+		var err error
+		body, err = json.Marshal(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := t.do(ctx, &request{method: method, url: url, contentType: "application/json", body: body}, i)
+	return err
+}
+
+// sendIdempotent sends data with a new Idempotency-Key, sending it again with
+// the same key if there's no response. TownCenter replays the first response
+// to the retry, so a request that was handled before the connection dropped
+// isn't handled twice.
+func (t *TownCenter) sendIdempotent(ctx context.Context, method, url string, data interface{}, i interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.do(ctx, &request{method: method, url: url, contentType: "application/json", body: body, key: uuid.New()}, i)
+	return err
+}
+
+/*upload sends photo as the profile file of a multipart form*/
+func (t *TownCenter) upload(ctx context.Context, url string, photo io.Reader) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("profile", "profile")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, photo)
+	if err != nil {
+		return err
+	}
+	err = form.Close()
+	if err != nil {
+		return err
+	}
+
+	_, err = t.do(ctx, &request{method: http.MethodPost, url: url, contentType: form.FormDataContentType(), body: body.Bytes()}, nil)
+	return err
+}
+
+/*request is a single call to TownCenter*/
+type request struct {
+	method      string
+	url         string
+	contentType string
+	body        []byte
+	// key is sent as the Idempotency-Key, etag as If-None-Match
+	key  string
+	etag string
+	// safe is set on requests that only read, so they can be retried
+	safe bool
+}
+
+// do makes the request, decoding the data of the response into i and
+// returning the response headers. Idempotent requests, including those with an
+// idempotency key, are retried with jittered backoff when they get no response
+// or a server error. A 304 is returned as errNotModified.
+func (t *TownCenter) do(ctx context.Context, r *request, i interface{}) (http.Header, error) {
+	attempts := 1
+	if r.key != "" || r.safe || r.method == http.MethodGet || r.method == http.MethodPut || r.method == http.MethodDelete {
+		attempts += t.retries
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			t.stats.Increment("retry")
+			err = sleep(ctx, t.retryBackoff(attempt))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err = t.roundTrip(ctx, r)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			break
+		}
+		if err == ErrCircuitOpen || ctx.Err() != nil || attempt == attempts-1 {
+			break
+		}
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return resp.Header, errNotModified
+	}
+
+	var body struct {
+		Success bool            `json:"success"`
+		Msg     string          `json:"msg"`
+		Data    json.RawMessage `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return nil, responseError(resp.StatusCode, fmt.Sprintf("Error: unexpected %d response from TownCenter", resp.StatusCode), nil)
+	}
+	if !body.Success {
+		return nil, responseError(resp.StatusCode, body.Msg, body.Data)
+	}
+	if i == nil || len(body.Data) == 0 {
+		return resp.Header, nil
+	}
+
+	return resp.Header, json.Unmarshal(body.Data, i)
+}
+
+// roundTrip sends the request once, if the circuit breaker allows it, and
+// counts server errors and requests without a response against the breaker.
+func (t *TownCenter) roundTrip(ctx context.Context, r *request) (*http.Response, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if !t.breaker.allow() {
+		t.stats.Increment("breaker.rejected")
+		return nil, ErrCircuitOpen
+	}
+
+	req, err := http.NewRequest(r.method, r.url, bytes.NewReader(r.body))
+	if err != nil {
+		t.breaker.release()
+		return nil, err
+	}
+	req.Cancel = ctx.Done()
+	if r.body != nil {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	if r.key != "" {
+		req.Header.Set("Idempotency-Key", r.key)
+	}
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	if token, ok := ctx.Value(tokenKey{}).(string); ok {
+		req.Header.Set("X-Auth", token)
+	}
+
+	timing := t.stats.NewTiming()
+	resp, err := t.client.Do(req)
+	timing.Send("request")
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		t.breaker.release()
+		return nil, ctx.Err()
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		t.stats.Increment("error")
+		if t.breaker.failure() {
+			t.stats.Increment("breaker.open")
+		}
+	default:
+		t.breaker.success()
+	}
+
+	return resp, err
+}
+
+/*retryBackoff picks a random wait before the retry, up to twice as long as the last one's longest*/
+func (t *TownCenter) retryBackoff(attempt int) time.Duration {
+	max := t.backoff << uint(attempt-1)
+	if max <= 0 || max > MaxRetryBackoff {
+		max = MaxRetryBackoff
+	}
+
+	return time.Duration(rand.Int63n(int64(max)) + 1)
+}
+
+/*sleep waits for d, or until ctx is done*/
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
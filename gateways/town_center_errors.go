@@ -0,0 +1,70 @@
+package gateways
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jakelong95/TownCenter/models"
+)
+
+/*NotFoundError is returned when the user, roaster or token asked for doesn't exist*/
+type NotFoundError struct {
+	Msg string
+}
+
+func (e *NotFoundError) Error() string { return e.Msg }
+
+/*ConflictError is returned when the request clashes with another, like a create that is still running with the same Idempotency-Key*/
+type ConflictError struct {
+	Msg string
+}
+
+func (e *ConflictError) Error() string { return e.Msg }
+
+/*UnauthorizedError is returned when the credentials or X-Auth token were rejected, or don't allow the request*/
+type UnauthorizedError struct {
+	Msg string
+}
+
+func (e *UnauthorizedError) Error() string { return e.Msg }
+
+// ValidationError is returned when TownCenter rejected the request as invalid.
+// Fields lists every field that failed, when TownCenter said which ones.
+type ValidationError struct {
+	Msg    string
+	Fields models.ValidationErrors
+}
+
+func (e *ValidationError) Error() string { return e.Msg }
+
+/*ResponseError is returned for any other failed response, Status is its HTTP status code*/
+type ResponseError struct {
+	Status int
+	Msg    string
+}
+
+func (e *ResponseError) Error() string { return e.Msg }
+
+/*errNotModified is returned for a 304, when the ETag sent as If-None-Match is still current*/
+var errNotModified = errors.New("Error: not modified")
+
+/*responseError turns a failed response into the error for its status code*/
+func responseError(status int, msg string, data json.RawMessage) error {
+	switch status {
+	case http.StatusNotFound:
+		return &NotFoundError{Msg: msg}
+	case http.StatusConflict:
+		return &ConflictError{Msg: msg}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &UnauthorizedError{Msg: msg}
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		var fields models.ValidationErrors
+		if json.Unmarshal(data, &fields) != nil {
+			fields = nil
+		}
+		return &ValidationError{Msg: msg, Fields: fields}
+	}
+
+	return &ResponseError{Status: status, Msg: msg}
+}
@@ -1,5 +1,566 @@
 package gateways
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
 
-)
\ No newline at end of file
+	"github.com/ghmeier/bloodlines/config"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestCreateUser(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	var got *http.Request
+	sent := &models.User{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		json.NewDecoder(r.Body).Decode(sent)
+		w.Write([]byte(`{"success":true,"data":{"id":"` + id.String() + `","email":"new@expresso.store"}}`))
+	}))
+	defer server.Close()
+
+	user, err := getTestTownCenter(server).CreateUser(context.Background(), &models.User{Email: "new@expresso.store"})
+
+	assert.NoError(err)
+	assert.Equal(id, user.ID)
+	assert.Equal("POST", got.Method)
+	assert.Equal("/api/user", got.URL.Path)
+	assert.NotEqual("", got.Header.Get("Idempotency-Key"))
+	assert.Equal("new@expresso.store", sent.Email)
+}
+
+func TestCreateUserRetriesWithSameKey(t *testing.T) {
+	assert := assert.New(t)
+
+	keys := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"success":true,"data":{"email":"new@expresso.store"}}`))
+	}))
+	defer server.Close()
+
+	_, err := getTestTownCenter(server).CreateUser(context.Background(), &models.User{Email: "new@expresso.store"})
+
+	assert.NoError(err)
+	assert.Equal(2, len(keys))
+	assert.Equal(keys[0], keys[1])
+}
+
+func TestCreateRoasterError(t *testing.T) {
+	assert := assert.New(t)
+
+	userID := uuid.NewUUID()
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"success":false,"msg":"Error: invalid roaster"}`))
+	}))
+	defer server.Close()
+
+	roaster, err := getTestTownCenter(server).CreateRoaster(context.Background(), userID, &models.Roaster{Name: "Kaldi's"})
+
+	assert.EqualError(err, "Error: invalid roaster")
+	assert.IsType(&ValidationError{}, err)
+	assert.Nil(roaster)
+	assert.Equal(userID.String(), sent["userId"])
+	assert.Equal("Kaldi's", sent["roaster"].(map[string]interface{})["name"])
+}
+
+func TestGetUserNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"success":false,"msg":"Error: User with ID 1 does not exist"}`))
+	}))
+	defer server.Close()
+
+	user, err := getTestTownCenter(server).GetUser(context.Background(), uuid.NewUUID())
+
+	assert.Nil(user)
+	assert.IsType(&NotFoundError{}, err)
+	assert.EqualError(err, "Error: User with ID 1 does not exist")
+}
+
+func TestUpdateUserValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"success":false,"msg":"Error: invalid user","data":[{"field":"email","code":"invalid_email","message":"email is not a valid email address"}]}`))
+	}))
+	defer server.Close()
+
+	err := getTestTownCenter(server).UpdateUser(context.Background(), uuid.NewUUID(), &models.User{Email: "jake"})
+
+	verr, ok := err.(*ValidationError)
+	assert.True(ok)
+	assert.Equal(1, len(verr.Fields))
+	assert.Equal("email", verr.Fields[0].Field)
+	assert.Equal(models.INVALID_EMAIL, verr.Fields[0].Code)
+}
+
+func TestCreateUserConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"success":false,"msg":"Error: a request with this Idempotency-Key is still in progress"}`))
+	}))
+	defer server.Close()
+
+	_, err := getTestTownCenter(server).CreateUser(context.Background(), &models.User{Email: "new@expresso.store"})
+
+	assert.IsType(&ConflictError{}, err)
+}
+
+func TestDeleteUserServerError(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		got = r
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"success":false,"msg":"This is an error"}`))
+	}))
+	defer server.Close()
+
+	id := uuid.NewUUID()
+	err := getTestTownCenter(server).DeleteUser(context.Background(), id)
+
+	rerr, ok := err.(*ResponseError)
+	assert.True(ok)
+	assert.Equal(http.StatusInternalServerError, rerr.Status)
+	assert.Equal(1+DefaultRetries, calls)
+	assert.Equal("DELETE", got.Method)
+	assert.Equal("/api/user/"+id.String(), got.URL.Path)
+}
+
+func TestLogin(t *testing.T) {
+	assert := assert.New(t)
+
+	sent := &models.User{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(sent)
+		w.Header().Set("X-Auth", "token")
+		w.Write([]byte(`{"success":true,"data":{"email":"jake@expresso.store"}}`))
+	}))
+	defer server.Close()
+
+	user, token, err := getTestTownCenter(server).Login(context.Background(), "jake@expresso.store", "secret")
+
+	assert.NoError(err)
+	assert.Equal("token", token)
+	assert.Equal("jake@expresso.store", user.Email)
+	assert.Equal("secret", sent.PassHash)
+}
+
+func TestLoginIncorrect(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"success":false,"msg":"Incorrect login credentials"}`))
+	}))
+	defer server.Close()
+
+	user, token, err := getTestTownCenter(server).Login(context.Background(), "jake@expresso.store", "wrong")
+
+	assert.Nil(user)
+	assert.Equal("", token)
+	assert.IsType(&UnauthorizedError{}, err)
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`{"success":true,"data":{"email":"jake+1@expresso.store"}}`))
+	}))
+	defer server.Close()
+
+	ctx := WithToken(context.Background(), "token")
+	user, err := getTestTownCenter(server).GetUserByEmail(ctx, "jake+1@expresso.store")
+
+	assert.NoError(err)
+	assert.Equal("jake+1@expresso.store", user.Email)
+	assert.Equal("/api/user/email", got.URL.Path)
+	assert.Equal("jake+1@expresso.store", got.URL.Query().Get("email"))
+	assert.Equal("token", got.Header.Get("X-Auth"))
+}
+
+func TestUploadRoasterPhoto(t *testing.T) {
+	assert := assert.New(t)
+
+	var photo string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("profile")
+		if err == nil {
+			b, _ := ioutil.ReadAll(file)
+			photo = string(b)
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	err := getTestTownCenter(server).UploadRoasterPhoto(context.Background(), uuid.NewUUID(), strings.NewReader("image"))
+
+	assert.NoError(err)
+	assert.Equal("image", photo)
+}
+
+func TestRequestReset(t *testing.T) {
+	assert := assert.New(t)
+
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`{"success":true,"data":{}}`))
+	}))
+	defer server.Close()
+
+	err := getTestTownCenter(server).RequestReset(context.Background(), "jake@expresso.store")
+
+	assert.NoError(err)
+	assert.Equal("POST", got.Method)
+	assert.Equal("/api/reset", got.URL.Path)
+	assert.Equal("jake@expresso.store", got.URL.Query().Get("email"))
+}
+
+func TestCanceledContext(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := getTestTownCenter(server).GetRoaster(ctx, uuid.NewUUID())
+
+	assert.Equal(context.Canceled, err)
+	assert.Equal(0, calls)
+}
+
+func TestGetUserRetriesServerError(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"success":true,"data":{"email":"jake@expresso.store"}}`))
+	}))
+	defer server.Close()
+
+	user, err := getTestTownCenter(server).GetUser(context.Background(), uuid.NewUUID())
+
+	assert.NoError(err)
+	assert.Equal("jake@expresso.store", user.Email)
+	assert.Equal(3, calls)
+}
+
+func TestLoginNotRetried(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"success":false,"msg":"This is an error"}`))
+	}))
+	defer server.Close()
+
+	_, _, err := getTestTownCenter(server).Login(context.Background(), "jake@expresso.store", "secret")
+
+	assert.IsType(&ResponseError{}, err)
+	assert.Equal(1, calls)
+}
+
+func TestTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	start := time.Now()
+	_, err := getTestTownCenterOptions(server, TownCenterOptions{Timeout: 20 * time.Millisecond, Retries: -1}).GetUser(context.Background(), uuid.NewUUID())
+
+	assert.Error(err)
+	assert.True(time.Since(start) < time.Second)
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	tc := getTestTownCenterOptions(server, TownCenterOptions{Retries: -1, BreakerThreshold: 2, BreakerCooldown: time.Hour})
+	for i := 0; i < 2; i++ {
+		_, err := tc.GetRoaster(context.Background(), uuid.NewUUID())
+		assert.IsType(&ResponseError{}, err)
+	}
+	_, err := tc.GetRoaster(context.Background(), uuid.NewUUID())
+
+	assert.Equal(ErrCircuitOpen, err)
+	assert.Equal(2, calls)
+}
+
+func TestGetUserCached(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"success":true,"data":{"email":"jake@expresso.store"}}`))
+	}))
+	defer server.Close()
+
+	tc := getTestTownCenterOptions(server, TownCenterOptions{CacheSize: 10})
+	id := uuid.NewUUID()
+	first, _ := tc.GetUser(context.Background(), id)
+	first.Email = "changed@expresso.store"
+	second, err := tc.GetUser(context.Background(), id)
+
+	assert.NoError(err)
+	assert.Equal("jake@expresso.store", second.Email)
+	assert.Equal(1, calls)
+}
+
+func TestGetRoasterRevalidates(t *testing.T) {
+	assert := assert.New(t)
+
+	matches := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		matches = append(matches, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{"success":true,"data":{"name":"Kaldi's"}}`))
+	}))
+	defer server.Close()
+
+	tc := getTestTownCenterOptions(server, TownCenterOptions{CacheSize: 10, CacheTTL: time.Nanosecond})
+	id := uuid.NewUUID()
+	tc.GetRoaster(context.Background(), id)
+	time.Sleep(time.Millisecond)
+	roaster, err := tc.GetRoaster(context.Background(), id)
+
+	assert.NoError(err)
+	assert.Equal("Kaldi's", roaster.Name)
+	assert.Equal([]string{"", `"v1"`}, matches)
+}
+
+func TestUpdateUserInvalidatesCache(t *testing.T) {
+	assert := assert.New(t)
+
+	gets := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			gets++
+		}
+		w.Write([]byte(`{"success":true,"data":{"email":"jake@expresso.store"}}`))
+	}))
+	defer server.Close()
+
+	tc := getTestTownCenterOptions(server, TownCenterOptions{CacheSize: 10})
+	id := uuid.NewUUID()
+	tc.GetUser(context.Background(), id)
+	tc.UpdateUser(context.Background(), id, &models.User{Email: "jake@expresso.store"})
+	tc.GetUser(context.Background(), id)
+
+	assert.Equal(2, gets)
+}
+
+func TestCreateRoasterInvalidatesOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gets := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			gets++
+		}
+		w.Write([]byte(`{"success":true,"data":{}}`))
+	}))
+	defer server.Close()
+
+	tc := getTestTownCenterOptions(server, TownCenterOptions{CacheSize: 10})
+	id := uuid.NewUUID()
+	tc.GetUser(context.Background(), id)
+	tc.CreateRoaster(context.Background(), id, &models.Roaster{Name: "Kaldi's"})
+	tc.GetUser(context.Background(), id)
+
+	assert.Equal(2, gets)
+}
+
+func TestGetUsers(t *testing.T) {
+	assert := assert.New(t)
+
+	sizes := make([]int, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch models.BatchRequest
+		json.NewDecoder(r.Body).Decode(&batch)
+		sizes = append(sizes, len(batch.IDs))
+
+		results := make(map[string]*models.UserResult)
+		for i, id := range batch.IDs {
+			results[id] = &models.UserResult{Found: i%2 == 0}
+			if i%2 == 0 {
+				results[id].User = &models.User{ID: uuid.Parse(id)}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": results})
+	}))
+	defer server.Close()
+
+	ids := make([]uuid.UUID, models.MaxBatch+2)
+	for i := range ids {
+		ids[i] = uuid.NewUUID()
+	}
+	users, err := getTestTownCenter(server).GetUsers(context.Background(), ids)
+
+	assert.NoError(err)
+	assert.Equal([]int{models.MaxBatch, 2}, sizes)
+	assert.Equal(models.MaxBatch/2+1, len(users))
+	assert.Equal(ids[0], users[ids[0].String()].ID)
+	assert.Nil(users[ids[1].String()])
+}
+
+func TestGetRoastersRetried(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"success":true,"data":{}}`))
+	}))
+	defer server.Close()
+
+	roasters, err := getTestTownCenter(server).GetRoasters(context.Background(), []uuid.UUID{uuid.NewUUID()})
+
+	assert.NoError(err)
+	assert.Equal(0, len(roasters))
+	assert.Equal(2, calls)
+}
+
+func TestReadTownCenterOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeTestConfig(t, `{"TownCenter": {"Host": "towncenter", "Port": "8084", "Timeout": "5s", "Retries": 3, "BreakerThreshold": -1, "BreakerCooldown": "1m30s", "CacheSize": 100, "CacheTTL": "500ms"}}`)
+	defer os.Remove(path)
+
+	options, err := ReadTownCenterOptions(path)
+
+	assert.NoError(err)
+	assert.Equal(TownCenterOptions{
+		Timeout:          5 * time.Second,
+		Retries:          3,
+		BreakerThreshold: -1,
+		BreakerCooldown:  90 * time.Second,
+		CacheSize:        100,
+		CacheTTL:         500 * time.Millisecond,
+	}, options)
+}
+
+func TestReadTownCenterOptionsNoBlock(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeTestConfig(t, `{"Port": "8080"}`)
+	defer os.Remove(path)
+
+	options, err := ReadTownCenterOptions(path)
+
+	assert.NoError(err)
+	assert.Equal(TownCenterOptions{}, options)
+}
+
+func TestReadTownCenterOptionsInvalidDuration(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeTestConfig(t, `{"TownCenter": {"Host": "towncenter", "Timeout": "soon"}}`)
+	defer os.Remove(path)
+
+	_, err := ReadTownCenterOptions(path)
+
+	assert.Error(err)
+	assert.Contains(err.Error(), "TownCenter.Timeout")
+}
+
+func TestReadTownCenterOptionsMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := ReadTownCenterOptions("/does/not/exist.json")
+
+	assert.Error(err)
+}
+
+func getTestTownCenter(server *httptest.Server) TownCenterI {
+	return getTestTownCenterOptions(server, TownCenterOptions{RetryBackoff: time.Millisecond})
+}
+
+func getTestTownCenterOptions(server *httptest.Server, options TownCenterOptions) TownCenterI {
+	u, _ := url.Parse(server.URL)
+	host, port := u.Host, ""
+	for i := len(host) - 1; i >= 0; i-- {
+		if host[i] == ':' {
+			host, port = u.Host[:i], u.Host[i+1:]
+			break
+		}
+	}
+
+	return NewTownCenter(config.TownCenter{Host: host, Port: port}, options, nil)
+}
+
+func writeTestConfig(t *testing.T, contents string) string {
+	file, err := ioutil.TempFile("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	file.WriteString(contents)
+	return file.Name()
+}
@@ -0,0 +1,602 @@
+package towncentertest
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"sort"
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+
+	"github.com/jakelong95/TownCenter/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+// Target is a gateways.TownCenterI for Contract to check. AddRoaster stores
+// a roaster directly, like TownCenter.AddRoaster, since the client can only
+// create pending roasters and Contract needs active ones to list. ResetToken
+// returns the last password reset token made for an email, which TownCenter
+// would have emailed.
+type Target struct {
+	Client     gateways.TownCenterI
+	AddRoaster func(*models.Roaster)
+	ResetToken func(string) string
+}
+
+/*check is a single part of the contract, run against a target of its own*/
+type check struct {
+	name string
+	run  func(*assert.Assertions, *Target)
+}
+
+// Contract checks that the TownCenterI made by newTarget behaves the way
+// TownCenter does. It's run against TownCenter, through the client and the
+// real router, and against the fake in this package, so the two agree.
+// newTarget should return an empty target each time it's called.
+func Contract(t *testing.T, newTarget func() *Target) {
+	for _, c := range checks {
+		failed := t.Failed()
+		c.run(assert.New(t), newTarget())
+		if t.Failed() && !failed {
+			t.Logf("TownCenter contract: %s failed", c.name)
+		}
+	}
+}
+
+var checks = []*check{
+	{"create and get a user", createUser},
+	{"create a user normalizes the phone", createUserPhone},
+	{"create an invalid user", createUserInvalid},
+	{"create a user with a taken email", createUserDuplicate},
+	{"update a user keeps empty fields", updateUserMerges},
+	{"update a user's password", updateUserPassword},
+	{"update an invalid or missing user", updateUserInvalid},
+	{"delete a user", deleteUser},
+	{"log in", login},
+	{"get users by ID", getUsers},
+	{"get a user by email", getUserByEmail},
+	{"search users", searchUsers},
+	{"change a user's photo", userPhoto},
+	{"reset a password", resetPassword},
+	{"create a roaster", createRoaster},
+	{"create a roaster with a taken slug", createRoasterSlugTaken},
+	{"create a roaster for a missing user", createRoasterNoUser},
+	{"update a roaster replaces it", updateRoasterReplaces},
+	{"update a roaster's slug", updateRoasterSlug},
+	{"update an invalid or missing roaster", updateRoasterInvalid},
+	{"delete a roaster", deleteRoaster},
+	{"page through roasters", getAllRoasters},
+	{"get roasters by ID", getRoasters},
+	{"search roasters", searchRoasters},
+	{"change a roaster's photo", roasterPhoto},
+}
+
+/*password is the password of every user the contract creates*/
+const password = "correct horse"
+
+/*newUser creates a valid user with the given email*/
+func newUser(assert *assert.Assertions, target *Target, email string) *models.User {
+	user, err := target.Client.CreateUser(context.Background(), &models.User{
+		PassHash:       password,
+		FirstName:      "Ada",
+		LastName:       "Lovelace",
+		Email:          email,
+		AddressCountry: "US",
+	})
+	assert.NoError(err)
+	if user == nil {
+		return &models.User{ID: uuid.NewUUID()}
+	}
+
+	return user
+}
+
+/*newRoaster creates a valid roaster named name owned by a new user*/
+func newRoaster(assert *assert.Assertions, target *Target, name string) *models.Roaster {
+	owner := newUser(assert, target, uuid.New()+"@example.com")
+	roaster, err := target.Client.CreateRoaster(context.Background(), owner.ID, &models.Roaster{
+		Name:        name,
+		Email:       "roaster@example.com",
+		AddressCity: "Des Moines",
+	})
+	assert.NoError(err)
+	if roaster == nil {
+		return &models.Roaster{ID: uuid.NewUUID()}
+	}
+
+	return roaster
+}
+
+func createUser(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	user := newUser(assert, target, "ada@example.com")
+
+	assert.NotNil(user.ID)
+	assert.Empty(user.PassHash)
+	assert.Equal("Ada", user.FirstName)
+	assert.False(user.CreatedAt.IsZero())
+
+	got, err := target.Client.GetUser(ctx, user.ID)
+	assert.NoError(err)
+	if assert.NotNil(got) {
+		assert.True(uuid.Equal(user.ID, got.ID))
+		assert.Equal("ada@example.com", got.Email)
+		assert.Equal("Lovelace", got.LastName)
+		assert.Empty(got.PassHash)
+		assert.NotEmpty(got.Photos)
+	}
+
+	_, err = target.Client.GetUser(ctx, uuid.NewUUID())
+	assert.IsType(&gateways.NotFoundError{}, err)
+}
+
+func createUserPhone(assert *assert.Assertions, target *Target) {
+	user, err := target.Client.CreateUser(context.Background(), &models.User{
+		PassHash:       password,
+		Email:          "phone@example.com",
+		Phone:          "(515) 555-0123",
+		AddressCountry: "US",
+	})
+
+	assert.NoError(err)
+	if assert.NotNil(user) {
+		assert.Equal("+15155550123", user.Phone)
+		assert.False(user.PhoneVerified)
+	}
+}
+
+func createUserInvalid(assert *assert.Assertions, target *Target) {
+	_, err := target.Client.CreateUser(context.Background(), &models.User{
+		PassHash:       password,
+		Email:          "not an email",
+		AddressCountry: "USA",
+	})
+
+	verr, ok := err.(*gateways.ValidationError)
+	if assert.True(ok, "expected a ValidationError, got %v", err) {
+		assert.Equal("Error: invalid user", verr.Msg)
+		assert.Equal([]string{models.INVALID_EMAIL, models.INVALID_COUNTRY}, codes(verr.Fields))
+	}
+}
+
+func createUserDuplicate(assert *assert.Assertions, target *Target) {
+	newUser(assert, target, "ada@example.com")
+	_, err := target.Client.CreateUser(context.Background(), &models.User{PassHash: password, Email: "ada@example.com"})
+
+	verr, ok := err.(*gateways.ValidationError)
+	if assert.True(ok, "expected a ValidationError, got %v", err) {
+		assert.Equal("Error: user with that email already exists", verr.Msg)
+	}
+}
+
+func updateUserMerges(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	user := newUser(assert, target, "ada@example.com")
+
+	err := target.Client.UpdateUser(ctx, user.ID, &models.User{LastName: "Byron", AddressCity: "London"})
+	assert.NoError(err)
+
+	got, err := target.Client.GetUser(ctx, user.ID)
+	assert.NoError(err)
+	if assert.NotNil(got) {
+		assert.Equal("Ada", got.FirstName)
+		assert.Equal("Byron", got.LastName)
+		assert.Equal("London", got.AddressCity)
+		assert.Equal("ada@example.com", got.Email)
+		assert.Equal(user.CreatedAt.Unix(), got.CreatedAt.Unix())
+	}
+
+	//Leaving out the password keeps it
+	_, _, err = target.Client.Login(ctx, "ada@example.com", password)
+	assert.NoError(err)
+}
+
+func updateUserPassword(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	user := newUser(assert, target, "ada@example.com")
+
+	err := target.Client.UpdateUser(ctx, user.ID, &models.User{PassHash: "new password"})
+	assert.NoError(err)
+
+	_, _, err = target.Client.Login(ctx, "ada@example.com", "new password")
+	assert.NoError(err)
+	_, _, err = target.Client.Login(ctx, "ada@example.com", password)
+	assert.IsType(&gateways.UnauthorizedError{}, err)
+}
+
+func updateUserInvalid(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	user := newUser(assert, target, "ada@example.com")
+
+	err := target.Client.UpdateUser(ctx, user.ID, &models.User{Email: "not an email"})
+	verr, ok := err.(*gateways.ValidationError)
+	if assert.True(ok, "expected a ValidationError, got %v", err) {
+		assert.Equal([]string{models.INVALID_EMAIL}, codes(verr.Fields))
+	}
+
+	err = target.Client.UpdateUser(ctx, uuid.NewUUID(), &models.User{LastName: "Byron"})
+	assert.IsType(&gateways.NotFoundError{}, err)
+}
+
+func deleteUser(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	user := newUser(assert, target, "ada@example.com")
+
+	assert.NoError(target.Client.DeleteUser(ctx, user.ID))
+
+	_, err := target.Client.GetUser(ctx, user.ID)
+	assert.IsType(&gateways.NotFoundError{}, err)
+
+	//Deleting a user that doesn't exist isn't an error
+	assert.NoError(target.Client.DeleteUser(ctx, user.ID))
+}
+
+func login(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	user := newUser(assert, target, "ada@example.com")
+
+	got, token, err := target.Client.Login(ctx, "ada@example.com", password)
+	assert.NoError(err)
+	assert.NotEmpty(token)
+	if assert.NotNil(got) {
+		assert.True(uuid.Equal(user.ID, got.ID))
+		assert.Empty(got.PassHash)
+	}
+
+	_, _, err = target.Client.Login(ctx, "ada@example.com", "wrong")
+	assert.IsType(&gateways.UnauthorizedError{}, err)
+
+	_, _, err = target.Client.Login(ctx, "nobody@example.com", password)
+	assert.IsType(&gateways.UnauthorizedError{}, err)
+}
+
+func getUsers(assert *assert.Assertions, target *Target) {
+	first := newUser(assert, target, "ada@example.com")
+	second := newUser(assert, target, "grace@example.com")
+	missing := uuid.NewUUID()
+
+	users, err := target.Client.GetUsers(context.Background(), []uuid.UUID{first.ID, second.ID, missing})
+
+	assert.NoError(err)
+	assert.Len(users, 2)
+	if assert.NotNil(users[second.ID.String()]) {
+		assert.Equal("grace@example.com", users[second.ID.String()].Email)
+		assert.Empty(users[second.ID.String()].PassHash)
+	}
+	assert.Nil(users[missing.String()])
+}
+
+func getUserByEmail(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	user := newUser(assert, target, "ada@example.com")
+
+	got, err := target.Client.GetUserByEmail(ctx, "ada@example.com")
+	assert.NoError(err)
+	if assert.NotNil(got) {
+		assert.True(uuid.Equal(user.ID, got.ID))
+		assert.Empty(got.PassHash)
+	}
+
+	_, err = target.Client.GetUserByEmail(ctx, "nobody@example.com")
+	assert.IsType(&gateways.NotFoundError{}, err)
+}
+
+func searchUsers(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	byEmail := newUser(assert, target, "grace@example.com")
+	byName, err := target.Client.CreateUser(ctx, &models.User{PassHash: password, FirstName: "Grace", Email: "hopper@example.com"})
+	assert.NoError(err)
+	newUser(assert, target, "alan@example.com")
+
+	//A match on the name counts for more than one on the email
+	users, err := target.Client.SearchUsers(ctx, "grace", 0, 20)
+	assert.NoError(err)
+	if byName != nil {
+		assert.Equal([]string{byName.ID.String(), byEmail.ID.String()}, userIDs(users))
+	}
+
+	users, err = target.Client.SearchUsers(ctx, "grace", 1, 20)
+	assert.NoError(err)
+	assert.Equal([]string{byEmail.ID.String()}, userIDs(users))
+
+	_, err = target.Client.SearchUsers(ctx, "", 0, 20)
+	assert.IsType(&gateways.ValidationError{}, err)
+}
+
+func userPhoto(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	user := newUser(assert, target, "ada@example.com")
+
+	assert.NoError(target.Client.UploadUserPhoto(ctx, user.ID, bytes.NewReader(photo())))
+	got, err := target.Client.GetUser(ctx, user.ID)
+	assert.NoError(err)
+	if assert.NotNil(got) {
+		assert.NotEmpty(got.ProfileURL)
+		assert.Equal(models.PhotoURLs(got.ProfileURL), got.Photos)
+	}
+
+	assert.NoError(target.Client.DeleteUserPhoto(ctx, user.ID))
+	got, err = target.Client.GetUser(ctx, user.ID)
+	assert.NoError(err)
+	if assert.NotNil(got) {
+		assert.Empty(got.ProfileURL)
+	}
+
+	err = target.Client.DeleteUserPhoto(ctx, user.ID)
+	assert.IsType(&gateways.NotFoundError{}, err)
+
+	err = target.Client.UploadUserPhoto(ctx, user.ID, bytes.NewReader([]byte("not a photo")))
+	assert.IsType(&gateways.ValidationError{}, err)
+}
+
+func resetPassword(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	newUser(assert, target, "ada@example.com")
+
+	assert.NoError(target.Client.RequestReset(ctx, "ada@example.com"))
+	token := target.ResetToken("ada@example.com")
+	assert.NotEmpty(token)
+
+	assert.NoError(target.Client.ResetPassword(ctx, token, "new password"))
+	_, _, err := target.Client.Login(ctx, "ada@example.com", "new password")
+	assert.NoError(err)
+
+	//Tokens only work once
+	err = target.Client.ResetPassword(ctx, token, "another password")
+	assert.IsType(&gateways.ValidationError{}, err)
+
+	err = target.Client.ResetPassword(ctx, uuid.New(), "another password")
+	assert.IsType(&gateways.NotFoundError{}, err)
+
+	err = target.Client.RequestReset(ctx, "nobody@example.com")
+	assert.IsType(&gateways.ValidationError{}, err)
+}
+
+func createRoaster(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	owner := newUser(assert, target, "ada@example.com")
+
+	roaster, err := target.Client.CreateRoaster(ctx, owner.ID, &models.Roaster{Name: "Kaldi's Coffee", Email: "kaldi@example.com"})
+	assert.NoError(err)
+	if !assert.NotNil(roaster) {
+		return
+	}
+	assert.Equal(models.STATUS_PENDING, roaster.Status)
+	assert.Equal("kaldi-s-coffee", roaster.Slug)
+
+	user, err := target.Client.GetUserByRoaster(ctx, roaster.ID)
+	assert.NoError(err)
+	if assert.NotNil(user) {
+		assert.True(uuid.Equal(owner.ID, user.ID))
+		assert.True(uuid.Equal(roaster.ID, user.RoasterId))
+	}
+
+	got, err := target.Client.GetRoaster(ctx, roaster.ID)
+	assert.NoError(err)
+	if assert.NotNil(got) {
+		assert.Equal("Kaldi's Coffee", got.Name)
+		assert.Equal(models.STATUS_PENDING, got.Status)
+	}
+
+	//A second roaster with the same name gets the next free slug
+	second := newRoaster(assert, target, "Kaldi's Coffee")
+	assert.Equal("kaldi-s-coffee-2", second.Slug)
+
+	_, err = target.Client.GetUserByRoaster(ctx, uuid.NewUUID())
+	assert.IsType(&gateways.NotFoundError{}, err)
+}
+
+func createRoasterSlugTaken(assert *assert.Assertions, target *Target) {
+	newRoaster(assert, target, "Kaldi")
+	owner := newUser(assert, target, "ada@example.com")
+
+	_, err := target.Client.CreateRoaster(context.Background(), owner.ID, &models.Roaster{Name: "Other", Slug: "kaldi"})
+
+	verr, ok := err.(*gateways.ValidationError)
+	if assert.True(ok, "expected a ValidationError, got %v", err) {
+		assert.Equal("Error: slug kaldi is already taken", verr.Msg)
+	}
+}
+
+func createRoasterNoUser(assert *assert.Assertions, target *Target) {
+	_, err := target.Client.CreateRoaster(context.Background(), uuid.NewUUID(), &models.Roaster{Name: "Kaldi"})
+	assert.IsType(&gateways.NotFoundError{}, err)
+
+	_, err = target.Client.CreateRoaster(context.Background(), uuid.NewUUID(), &models.Roaster{Name: "Kaldi", Email: "kaldi"})
+	verr, ok := err.(*gateways.ValidationError)
+	if assert.True(ok, "expected a ValidationError, got %v", err) {
+		assert.Equal("Error: invalid roaster", verr.Msg)
+	}
+}
+
+func updateRoasterReplaces(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	roaster := newRoaster(assert, target, "Kaldi")
+
+	err := target.Client.UpdateRoaster(ctx, roaster.ID, &models.Roaster{Name: "Kaldi Roasting", Email: "kaldi@example.com"})
+	assert.NoError(err)
+
+	got, err := target.Client.GetRoaster(ctx, roaster.ID)
+	assert.NoError(err)
+	if assert.NotNil(got) {
+		assert.Equal("Kaldi Roasting", got.Name)
+		assert.Equal("kaldi@example.com", got.Email)
+		assert.Empty(got.AddressCity)
+		assert.Equal("kaldi", got.Slug)
+		assert.Equal(models.STATUS_PENDING, got.Status)
+		assert.Equal(roaster.CreatedAt.Unix(), got.CreatedAt.Unix())
+	}
+}
+
+func updateRoasterSlug(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	roaster := newRoaster(assert, target, "Kaldi")
+
+	err := target.Client.UpdateRoaster(ctx, roaster.ID, &models.Roaster{Name: "Kaldi", Slug: "kaldi-roasting"})
+	assert.NoError(err)
+
+	got, err := target.Client.GetRoaster(ctx, roaster.ID)
+	assert.NoError(err)
+	if assert.NotNil(got) {
+		assert.Equal("kaldi-roasting", got.Slug)
+	}
+
+	//The old slug stays reserved for links to the roaster
+	owner := newUser(assert, target, "ada@example.com")
+	_, err = target.Client.CreateRoaster(ctx, owner.ID, &models.Roaster{Name: "Other", Slug: "kaldi"})
+	assert.IsType(&gateways.ValidationError{}, err)
+
+	//But the roaster can take it back
+	err = target.Client.UpdateRoaster(ctx, roaster.ID, &models.Roaster{Name: "Kaldi", Slug: "kaldi"})
+	assert.NoError(err)
+
+	other := newRoaster(assert, target, "Other")
+	err = target.Client.UpdateRoaster(ctx, other.ID, &models.Roaster{Name: "Other", Slug: "kaldi-roasting"})
+	assert.IsType(&gateways.ValidationError{}, err)
+}
+
+func updateRoasterInvalid(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+
+	roaster := newRoaster(assert, target, "Kaldi")
+	err := target.Client.UpdateRoaster(ctx, roaster.ID, &models.Roaster{Name: "Kaldi", Email: "not an email"})
+	verr, ok := err.(*gateways.ValidationError)
+	if assert.True(ok, "expected a ValidationError, got %v", err) {
+		assert.Equal([]string{models.INVALID_EMAIL}, codes(verr.Fields))
+	}
+
+	err = target.Client.UpdateRoaster(ctx, uuid.NewUUID(), &models.Roaster{Name: "Kaldi"})
+	assert.IsType(&gateways.NotFoundError{}, err)
+}
+
+func deleteRoaster(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	roaster := newRoaster(assert, target, "Kaldi")
+
+	assert.NoError(target.Client.DeleteRoaster(ctx, roaster.ID))
+
+	_, err := target.Client.GetRoaster(ctx, roaster.ID)
+	assert.IsType(&gateways.NotFoundError{}, err)
+
+	assert.NoError(target.Client.DeleteRoaster(ctx, roaster.ID))
+}
+
+func getAllRoasters(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+
+	ids := make([]string, 0)
+	for _, name := range []string{"Kaldi", "Blue Bottle", "Verve"} {
+		roaster := &models.Roaster{Name: name, Status: models.STATUS_ACTIVE}
+		target.AddRoaster(roaster)
+		ids = append(ids, roaster.ID.String())
+	}
+	sort.Strings(ids)
+
+	//Pending roasters aren't listed
+	newRoaster(assert, target, "Pending")
+
+	first, err := target.Client.GetAllRoasters(ctx, 0, 2)
+	assert.NoError(err)
+	assert.Equal(ids[:2], roasterIDs(first))
+
+	second, err := target.Client.GetAllRoasters(ctx, 2, 2)
+	assert.NoError(err)
+	assert.Equal(ids[2:], roasterIDs(second))
+
+	past, err := target.Client.GetAllRoasters(ctx, 4, 2)
+	assert.NoError(err)
+	assert.Empty(past)
+}
+
+func getRoasters(assert *assert.Assertions, target *Target) {
+	active := &models.Roaster{Name: "Kaldi", Status: models.STATUS_ACTIVE}
+	target.AddRoaster(active)
+	pending := newRoaster(assert, target, "Pending")
+	missing := uuid.NewUUID()
+
+	roasters, err := target.Client.GetRoasters(context.Background(), []uuid.UUID{active.ID, pending.ID, missing})
+
+	assert.NoError(err)
+	assert.Len(roasters, 2)
+	if assert.NotNil(roasters[pending.ID.String()]) {
+		assert.Equal(models.STATUS_PENDING, roasters[pending.ID.String()].Status)
+	}
+	assert.Nil(roasters[missing.String()])
+}
+
+func searchRoasters(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	active := &models.Roaster{Name: "Kaldi Coffee", AddressCity: "Des Moines", Status: models.STATUS_ACTIVE}
+	target.AddRoaster(active)
+	target.AddRoaster(&models.Roaster{Name: "Verve", Status: models.STATUS_ACTIVE})
+
+	//Pending roasters aren't found
+	newRoaster(assert, target, "Kaldi Roasting")
+
+	roasters, err := target.Client.SearchRoasters(ctx, "kaldi", 0, 20)
+	assert.NoError(err)
+	assert.Equal([]string{active.ID.String()}, roasterIDs(roasters))
+
+	_, err = target.Client.SearchRoasters(ctx, "", 0, 20)
+	assert.IsType(&gateways.ValidationError{}, err)
+}
+
+func roasterPhoto(assert *assert.Assertions, target *Target) {
+	ctx := context.Background()
+	roaster := newRoaster(assert, target, "Kaldi")
+
+	assert.NoError(target.Client.UploadRoasterPhoto(ctx, roaster.ID, bytes.NewReader(photo())))
+	got, err := target.Client.GetRoaster(ctx, roaster.ID)
+	assert.NoError(err)
+	if assert.NotNil(got) {
+		assert.NotEmpty(got.ProfileUrl)
+		assert.Equal(models.PhotoURLs(got.ProfileUrl), got.Photos)
+	}
+
+	assert.NoError(target.Client.DeleteRoasterPhoto(ctx, roaster.ID))
+	got, err = target.Client.GetRoaster(ctx, roaster.ID)
+	assert.NoError(err)
+	if assert.NotNil(got) {
+		assert.Empty(got.ProfileUrl)
+	}
+
+	err = target.Client.DeleteRoasterPhoto(ctx, roaster.ID)
+	assert.IsType(&gateways.NotFoundError{}, err)
+}
+
+func codes(fields models.ValidationErrors) []string {
+	codes := make([]string, len(fields))
+	for i, field := range fields {
+		codes[i] = field.Code
+	}
+
+	return codes
+}
+
+func userIDs(users []*models.User) []string {
+	ids := make([]string, len(users))
+	for i, user := range users {
+		ids[i] = user.ID.String()
+	}
+
+	return ids
+}
+
+func roasterIDs(roasters []*models.Roaster) []string {
+	ids := make([]string, len(roasters))
+	for i, roaster := range roasters {
+		ids[i] = roaster.ID.String()
+	}
+
+	return ids
+}
+
+/*photo returns a small PNG for the photo uploads*/
+func photo() []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 64)))
+	return buf.Bytes()
+}
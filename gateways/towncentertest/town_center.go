@@ -0,0 +1,729 @@
+// Package towncentertest has an in-memory TownCenter for the tests of services
+// that use gateways.TownCenterI, and the contract it's checked against.
+package towncentertest
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/imdario/mergo"
+	"github.com/pborman/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/context"
+
+	"github.com/jakelong95/TownCenter/gateways"
+	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+/*ResetExpiration is how long a password reset token works for, as it is in TownCenter*/
+const ResetExpiration = 2 * time.Hour
+
+// TownCenter is a gateways.TownCenterI that keeps users and roasters in
+// memory. It validates, pages and updates them the way TownCenter does, and
+// fails with the same errors, so a test written against it holds against
+// TownCenter too. Roasters are created pending, as TownCenter creates them,
+// so use AddRoaster for ones that should be listed. Photos are processed and
+// stored in S3, and reset tokens are kept for ResetToken rather than emailed.
+type TownCenter struct {
+	S3       *gateways.LocalS3
+	mutex    sync.Mutex
+	users    map[string]*models.User
+	roasters map[string]*models.Roaster
+	slugs    map[string]string
+	tokens   map[string]*models.Token
+	resets   map[string]string
+}
+
+/*NewTownCenter creates a TownCenter without any users or roasters*/
+func NewTownCenter() *TownCenter {
+	return &TownCenter{
+		S3:       gateways.NewLocalS3("towncenter"),
+		users:    make(map[string]*models.User),
+		roasters: make(map[string]*models.Roaster),
+		slugs:    make(map[string]string),
+		tokens:   make(map[string]*models.Token),
+		resets:   make(map[string]string),
+	}
+}
+
+// AddUser stores user as it is, without validating it, giving it an ID if it
+// doesn't have one. Its PassHash is taken to be the password and hashed.
+func (t *TownCenter) AddUser(user *models.User) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	stored := *user
+	if stored.ID == nil {
+		stored.ID = uuid.NewUUID()
+	}
+	if stored.PassHash != "" {
+		stored.PassHash = hash(stored.PassHash)
+	}
+	stored.SetPhotos()
+	user.ID = stored.ID
+
+	t.users[stored.ID.String()] = &stored
+}
+
+// AddRoaster stores roaster as it is, without validating it, giving it an ID
+// and slug if it doesn't have them. Set its Status to models.STATUS_ACTIVE for
+// it to be listed and found by search.
+func (t *TownCenter) AddRoaster(roaster *models.Roaster) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	stored := *roaster
+	if stored.ID == nil {
+		stored.ID = uuid.NewUUID()
+	}
+	if stored.Slug == "" {
+		stored.Slug = t.uniqueSlug(&stored)
+	}
+	stored.SetPhotos()
+	roaster.ID, roaster.Slug = stored.ID, stored.Slug
+
+	t.roasters[stored.ID.String()] = &stored
+}
+
+/*ResetToken returns the last password reset token sent to email, or "" when none was*/
+func (t *TownCenter) ResetToken(email string) string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.resets[email]
+}
+
+/*GetUser gets the user with the given ID*/
+func (t *TownCenter) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	user, ok := t.users[id.String()]
+	if !ok {
+		return nil, &gateways.NotFoundError{Msg: "Error: User with ID " + id.String() + " does not exist"}
+	}
+
+	return userCopy(user), nil
+}
+
+/*GetUsers gets the users with the given IDs, keyed by ID, leaving out the ones that don't exist*/
+func (t *TownCenter) GetUsers(ctx context.Context, ids []uuid.UUID) (map[string]*models.User, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	users := make(map[string]*models.User)
+	for _, id := range ids {
+		if user, ok := t.users[id.String()]; ok {
+			users[id.String()] = userCopy(user)
+		}
+	}
+
+	return users, nil
+}
+
+/*GetUserByEmail gets the user with exactly the given email, without checking for an admin's token*/
+func (t *TownCenter) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if email == "" {
+		return nil, &gateways.ValidationError{Msg: "Error: email is required"}
+	}
+
+	user := t.byEmail(email)
+	if user == nil {
+		return nil, &gateways.NotFoundError{Msg: "Error: User with email " + email + " does not exist"}
+	}
+
+	return userCopy(user), nil
+}
+
+/*GetUserByRoaster gets the user who owns the roaster with the given ID*/
+func (t *TownCenter) GetUserByRoaster(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, userID := range t.userIDs() {
+		if uuid.Equal(t.users[userID].RoasterId, id) {
+			return userCopy(t.users[userID]), nil
+		}
+	}
+
+	return nil, &gateways.NotFoundError{Msg: "Error: no user for that roaster"}
+}
+
+/*SearchUsers gets the users whose name, email or phone match query, best match first, without checking for an admin's token*/
+func (t *TownCenter) SearchUsers(ctx context.Context, query string, offset, limit int) ([]*models.User, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if query == "" {
+		return nil, &gateways.ValidationError{Msg: "Error: q is required"}
+	}
+
+	terms := make(map[string][]*models.SearchTerm)
+	for id, user := range t.users {
+		terms[id] = user.SearchTerms()
+	}
+
+	users := make([]*models.User, 0)
+	for _, id := range page(search(terms, query), offset, limit) {
+		users = append(users, userCopy(t.users[id]))
+	}
+
+	return users, nil
+}
+
+/*CreateUser validates and stores a new user, returning it with its ID*/
+func (t *TownCenter) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	json := *user
+	json.Phone = normalizePhone(json.Phone, json.AddressCountry)
+	errs := models.Validate(&json)
+	if errs != nil {
+		return nil, &gateways.ValidationError{Msg: "Error: invalid user", Fields: errs}
+	}
+	if t.byEmail(json.Email) != nil {
+		return nil, &gateways.ValidationError{Msg: "Error: user with that email already exists"}
+	}
+
+	created := models.NewUser(json.PassHash, json.FirstName, json.LastName, json.Email, json.Phone,
+		json.AddressLine1, json.AddressLine2, json.AddressCity, json.AddressState, json.AddressZip,
+		json.AddressCountry)
+	created.PassHash = hash(created.PassHash)
+	created.CreatedAt = now()
+	created.UpdatedAt = created.CreatedAt
+	t.users[created.ID.String()] = created
+
+	return userCopy(created), nil
+}
+
+// UpdateUser updates the user with the given ID. Fields left empty keep their
+// current values, the password only changes when PassHash is set, and the
+// phone stays verified only if it's unchanged.
+func (t *TownCenter) UpdateUser(ctx context.Context, id uuid.UUID, user *models.User) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	existing, ok := t.users[id.String()]
+	if !ok {
+		return &gateways.NotFoundError{Msg: "Error: No user found."}
+	}
+
+	json := *user
+	err := mergo.Merge(&json, userCopy(existing))
+	if err != nil {
+		return err
+	}
+
+	json.Phone = normalizePhone(json.Phone, json.AddressCountry)
+	json.PhoneVerified = existing.PhoneVerified && json.Phone == existing.Phone
+	errs := models.ValidateChanges(&json, existing)
+	if errs != nil {
+		return &gateways.ValidationError{Msg: "Error: invalid user", Fields: errs}
+	}
+
+	updated := json
+	updated.ID = existing.ID
+	updated.CreatedAt = existing.CreatedAt
+	updated.UpdatedAt = now()
+	updated.PassHash = existing.PassHash
+	if json.PassHash != "" {
+		updated.PassHash = hash(json.PassHash)
+	}
+	updated.SetPhotos()
+	t.users[id.String()] = &updated
+
+	return nil
+}
+
+/*DeleteUser deletes the user with the given ID, which succeeds when there isn't one*/
+func (t *TownCenter) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.users, id.String())
+	return nil
+}
+
+/*UploadUserPhoto processes the image read from photo, stores it in S3 and makes it the user's photo*/
+func (t *TownCenter) UploadUserPhoto(ctx context.Context, id uuid.UUID, photo io.Reader) error {
+	url, err := t.upload(id, photo)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if user, ok := t.users[id.String()]; ok {
+		updated := *user
+		updated.ProfileURL = url
+		updated.UpdatedAt = now()
+		updated.SetPhotos()
+		t.users[id.String()] = &updated
+	}
+
+	return nil
+}
+
+/*DeleteUserPhoto removes the user's photo and every size of it from S3*/
+func (t *TownCenter) DeleteUserPhoto(ctx context.Context, id uuid.UUID) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	user, ok := t.users[id.String()]
+	if !ok {
+		return &gateways.NotFoundError{Msg: "Error: User with ID " + id.String() + " does not exist"}
+	}
+	if user.ProfileURL == "" {
+		return &gateways.NotFoundError{Msg: "Error: user doesn't have a photo"}
+	}
+
+	t.removePhoto(user.ProfileURL)
+
+	updated := *user
+	updated.ProfileURL = ""
+	updated.UpdatedAt = now()
+	updated.SetPhotos()
+	t.users[id.String()] = &updated
+
+	return nil
+}
+
+/*Login checks the user's email and password, returning the user and a token signed the way TownCenter signs them*/
+func (t *TownCenter) Login(ctx context.Context, email, password string) (*models.User, string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	user := t.byEmail(email)
+	if user == nil {
+		return nil, "", &gateways.UnauthorizedError{Msg: "Error: User with email " + email + " not found"}
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(user.PassHash), []byte(password))
+	if err != nil {
+		return nil, "", &gateways.UnauthorizedError{Msg: "Incorrect login credentials"}
+	}
+
+	token, err := handlers.CreateJWT(user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return userCopy(user), token, nil
+}
+
+/*RequestReset creates a password reset token for the user with the given email, see ResetToken*/
+func (t *TownCenter) RequestReset(ctx context.Context, email string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if email == "" {
+		return &gateways.ValidationError{Msg: "Error: must provied email parameter"}
+	}
+	if t.byEmail(email) == nil {
+		return &gateways.ValidationError{Msg: "ERROR: no user found for email"}
+	}
+
+	token := models.NewToken(email)
+	t.tokens[token.Value] = token
+	t.resets[email] = token.Value
+
+	return nil
+}
+
+/*ResetPassword sets a new password for the user the reset token was made for, a token can only be used once*/
+func (t *TownCenter) ResetPassword(ctx context.Context, value, password string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if password == "" {
+		return &gateways.ValidationError{Msg: "Error: unable to parse request"}
+	}
+
+	token, ok := t.tokens[value]
+	if !ok {
+		return &gateways.NotFoundError{Msg: "Error: no token for that value"}
+	}
+	if time.Since(token.CreatedAt) >= ResetExpiration {
+		token.Status = models.EXPIRED
+	}
+	if token.Status == models.INVALID || token.Status == models.EXPIRED {
+		return &gateways.ValidationError{Msg: "Error: token has expired, request a new one"}
+	}
+
+	user := t.byEmail(token.Email)
+	if user == nil {
+		return &gateways.NotFoundError{Msg: "Error: invlaid email"}
+	}
+
+	updated := *user
+	updated.PassHash = hash(password)
+	updated.UpdatedAt = now()
+	t.users[updated.ID.String()] = &updated
+	token.Status = models.INVALID
+
+	return nil
+}
+
+/*GetRoaster gets the roaster with the given ID, in any status*/
+func (t *TownCenter) GetRoaster(ctx context.Context, id uuid.UUID) (*models.Roaster, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	roaster, ok := t.roasters[id.String()]
+	if !ok {
+		return nil, &gateways.NotFoundError{Msg: "Error: Roaster with ID " + id.String() + " does not exist"}
+	}
+
+	return roasterCopy(roaster), nil
+}
+
+/*GetRoasters gets the roasters with the given IDs in any status, keyed by ID, leaving out the ones that don't exist*/
+func (t *TownCenter) GetRoasters(ctx context.Context, ids []uuid.UUID) (map[string]*models.Roaster, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	roasters := make(map[string]*models.Roaster)
+	for _, id := range ids {
+		if roaster, ok := t.roasters[id.String()]; ok {
+			roasters[id.String()] = roasterCopy(roaster)
+		}
+	}
+
+	return roasters, nil
+}
+
+/*GetAllRoasters pages through the active roasters ordered by ID*/
+func (t *TownCenter) GetAllRoasters(ctx context.Context, offset, limit int) ([]*models.Roaster, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	ids := make([]string, 0)
+	for id, roaster := range t.roasters {
+		if roaster.Status == models.STATUS_ACTIVE {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	roasters := make([]*models.Roaster, 0)
+	for _, id := range page(ids, offset, limit) {
+		roasters = append(roasters, roasterCopy(t.roasters[id]))
+	}
+
+	return roasters, nil
+}
+
+/*SearchRoasters gets the active roasters whose name or city match query, best match first*/
+func (t *TownCenter) SearchRoasters(ctx context.Context, query string, offset, limit int) ([]*models.Roaster, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if query == "" {
+		return nil, &gateways.ValidationError{Msg: "Error: q is required"}
+	}
+
+	terms := make(map[string][]*models.SearchTerm)
+	for id, roaster := range t.roasters {
+		if roaster.Status == models.STATUS_ACTIVE {
+			terms[id] = roaster.SearchTerms()
+		}
+	}
+
+	roasters := make([]*models.Roaster, 0)
+	for _, id := range page(search(terms, query), offset, limit) {
+		roasters = append(roasters, roasterCopy(t.roasters[id]))
+	}
+
+	return roasters, nil
+}
+
+// CreateRoaster validates and stores a new pending roaster, making it the
+// roaster of the user with the given ID. Like TownCenter, the roaster is
+// stored before the user is looked up, so it's kept even when there's no
+// such user.
+func (t *TownCenter) CreateRoaster(ctx context.Context, userID uuid.UUID, roaster *models.Roaster) (*models.Roaster, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	json := *roaster
+	json.Phone = normalizePhone(json.Phone, json.AddressCountry)
+	errs := models.Validate(&json)
+	if errs != nil {
+		return nil, &gateways.ValidationError{Msg: "Error: invalid roaster", Fields: errs}
+	}
+
+	created := models.NewRoaster(json.Name, json.Email, json.Phone, json.AddressLine1, json.AddressLine2, json.AddressCity, json.AddressState, json.AddressZip, json.AddressCountry, json.Birthday)
+	created.Slug = json.Slug
+	if created.Slug != "" && t.slugTaken(created.Slug, created.ID.String()) {
+		return nil, &gateways.ValidationError{Msg: "Error: slug " + created.Slug + " is already taken"}
+	}
+	if created.Slug == "" {
+		created.Slug = t.uniqueSlug(created)
+	}
+	created.CreatedAt = now()
+	created.UpdatedAt = created.CreatedAt
+	created.Geocode()
+	t.roasters[created.ID.String()] = created
+
+	user, ok := t.users[userID.String()]
+	if !ok {
+		return nil, &gateways.NotFoundError{Msg: "Error: User with ID " + userID.String() + " does not exist"}
+	}
+
+	updated := *user
+	updated.RoasterId = created.ID
+	updated.UpdatedAt = now()
+	t.users[userID.String()] = &updated
+
+	return roasterCopy(created), nil
+}
+
+// UpdateRoaster replaces the roaster with the given ID with roaster, so
+// fields left empty are cleared. The exceptions are the slug, which is kept
+// when left out, and the status, which doesn't change. The roaster is
+// validated before it's looked up.
+func (t *TownCenter) UpdateRoaster(ctx context.Context, id uuid.UUID, roaster *models.Roaster) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	existing, ok := t.roasters[id.String()]
+	if !ok {
+		return &gateways.NotFoundError{Msg: "Error: Roaster with ID " + id.String() + " does not exist"}
+	}
+
+	json := *roaster
+	json.Phone = normalizePhone(json.Phone, json.AddressCountry)
+	errs := models.ValidateChanges(&json, existing)
+	if errs != nil {
+		return &gateways.ValidationError{Msg: "Error: invalid roaster", Fields: errs}
+	}
+
+	if json.Slug == "" {
+		json.Slug = existing.Slug
+	} else if json.Slug != existing.Slug && t.slugTaken(json.Slug, id.String()) {
+		return &gateways.ValidationError{Msg: "Error: slug " + json.Slug + " is already taken"}
+	}
+
+	updated := json
+	updated.ID = existing.ID
+	updated.Status = existing.Status
+	updated.PhoneVerified = existing.PhoneVerified && json.Phone == existing.Phone
+	updated.CreatedAt = existing.CreatedAt
+	updated.UpdatedAt = now()
+	updated.Geocode()
+	if updated.Slug == "" {
+		updated.Slug = t.uniqueSlug(&updated)
+	}
+	updated.SetPhotos()
+
+	//The old slug is kept so it can't be taken by another roaster
+	if existing.Slug != "" && existing.Slug != updated.Slug {
+		t.slugs[existing.Slug] = id.String()
+	}
+	delete(t.slugs, updated.Slug)
+	t.roasters[id.String()] = &updated
+
+	return nil
+}
+
+/*DeleteRoaster deletes the roaster with the given ID, which succeeds when there isn't one*/
+func (t *TownCenter) DeleteRoaster(ctx context.Context, id uuid.UUID) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.roasters, id.String())
+	return nil
+}
+
+/*UploadRoasterPhoto processes the image read from photo, stores it in S3 and makes it the roaster's photo*/
+func (t *TownCenter) UploadRoasterPhoto(ctx context.Context, id uuid.UUID, photo io.Reader) error {
+	url, err := t.upload(id, photo)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if roaster, ok := t.roasters[id.String()]; ok {
+		updated := *roaster
+		updated.ProfileUrl = url
+		updated.UpdatedAt = now()
+		updated.SetPhotos()
+		t.roasters[id.String()] = &updated
+	}
+
+	return nil
+}
+
+/*DeleteRoasterPhoto removes the roaster's photo and every size of it from S3*/
+func (t *TownCenter) DeleteRoasterPhoto(ctx context.Context, id uuid.UUID) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	roaster, ok := t.roasters[id.String()]
+	if !ok {
+		return &gateways.NotFoundError{Msg: "Error: Roaster with ID " + id.String() + " does not exist"}
+	}
+	if roaster.ProfileUrl == "" {
+		return &gateways.NotFoundError{Msg: "Error: roaster doesn't have a photo"}
+	}
+
+	t.removePhoto(roaster.ProfileUrl)
+
+	updated := *roaster
+	updated.ProfileUrl = ""
+	updated.UpdatedAt = now()
+	updated.SetPhotos()
+	t.roasters[id.String()] = &updated
+
+	return nil
+}
+
+/*byEmail returns the user with email, or nil when there isn't one*/
+func (t *TownCenter) byEmail(email string) *models.User {
+	for _, id := range t.userIDs() {
+		if t.users[id].Email == email {
+			return t.users[id]
+		}
+	}
+
+	return nil
+}
+
+/*userIDs returns the ID of every user in order*/
+func (t *TownCenter) userIDs() []string {
+	ids := make([]string, 0, len(t.users))
+	for id := range t.users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+/*slugTaken reports whether a roaster other than id has, or used to have, slug*/
+func (t *TownCenter) slugTaken(slug string, id string) bool {
+	for _, roaster := range t.roasters {
+		if roaster.Slug == slug && roaster.ID.String() != id {
+			return true
+		}
+	}
+
+	owner, ok := t.slugs[slug]
+	return ok && owner != id
+}
+
+/*uniqueSlug returns the first free slug built from the roaster's name*/
+func (t *TownCenter) uniqueSlug(roaster *models.Roaster) string {
+	base := models.Slugify(roaster.Name)
+	for n := 1; ; n++ {
+		slug := models.SlugCandidate(base, n)
+		if !t.slugTaken(slug, roaster.ID.String()) {
+			return slug
+		}
+	}
+}
+
+/*upload processes photo the way TownCenter does and stores every size of it, returning the original's URL*/
+func (t *TownCenter) upload(id uuid.UUID, photo io.Reader) (string, error) {
+	processed, err := helpers.ProcessPhoto(photo)
+	if err != nil {
+		return "", &gateways.ValidationError{Msg: err.Error()}
+	}
+
+	return processed.Upload(t.S3, "profile", id.String())
+}
+
+/*removePhoto deletes every size of the photo at url from S3*/
+func (t *TownCenter) removePhoto(url string) {
+	for _, u := range models.PhotoURLs(url) {
+		t.S3.Delete(u)
+	}
+}
+
+// search returns the IDs of the records whose terms match query, best match
+// first. Like TownCenter's index, only terms sharing a first letter with a
+// query token are candidates.
+func search(terms map[string][]*models.SearchTerm, query string) []string {
+	tokens := models.Tokenize(query)
+	if len(tokens) == 0 {
+		return make([]string, 0)
+	}
+
+	prefixes := make(map[string]bool)
+	for _, token := range tokens {
+		r, _ := utf8.DecodeRuneInString(token)
+		prefixes[string(r)] = true
+	}
+
+	candidates := make([]*models.IndexedTerm, 0)
+	for id, list := range terms {
+		for _, term := range list {
+			r, _ := utf8.DecodeRuneInString(term.Term)
+			if prefixes[string(r)] {
+				candidates = append(candidates, &models.IndexedTerm{ID: uuid.Parse(id), SearchTerm: *term})
+			}
+		}
+	}
+
+	return models.Rank(tokens, candidates)
+}
+
+/*page returns the slice of ids for the given offset and limit*/
+func page(ids []string, offset int, limit int) []string {
+	if offset >= len(ids) {
+		return make([]string, 0)
+	}
+	if offset+limit < len(ids) {
+		ids = ids[:offset+limit]
+	}
+
+	return ids[offset:]
+}
+
+/*userCopy returns a copy of user without its password hash*/
+func userCopy(user *models.User) *models.User {
+	c := *user
+	c.PassHash = ""
+	return &c
+}
+
+func roasterCopy(roaster *models.Roaster) *models.Roaster {
+	c := *roaster
+	return &c
+}
+
+/*normalizePhone puts phone in E.164 form, keeping it as it is when it can't be*/
+func normalizePhone(phone, country string) string {
+	if phone == "" {
+		return phone
+	}
+
+	normalized, ok := models.NormalizePhone(phone, country)
+	if !ok {
+		return phone
+	}
+
+	return normalized
+}
+
+/*now returns the current time truncated to the second precision stored by mysql*/
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func hash(s string) string {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte(s), bcrypt.DefaultCost)
+	return string(hashed)
+}
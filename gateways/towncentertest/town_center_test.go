@@ -0,0 +1,58 @@
+package towncentertest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+
+	"github.com/jakelong95/TownCenter/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+func TestContract(t *testing.T) {
+	Contract(t, func() *Target {
+		tc := NewTownCenter()
+		return &Target{Client: tc, AddRoaster: tc.AddRoaster, ResetToken: tc.ResetToken}
+	})
+}
+
+func TestAddUserLogin(t *testing.T) {
+	assert := assert.New(t)
+
+	tc := NewTownCenter()
+	user := &models.User{Email: "ada@example.com", PassHash: "password"}
+	tc.AddUser(user)
+
+	got, token, err := tc.Login(context.Background(), "ada@example.com", "password")
+
+	assert.NoError(err)
+	assert.NotEmpty(token)
+	assert.True(uuid.Equal(user.ID, got.ID))
+	assert.Empty(got.PassHash)
+}
+
+func TestResetPasswordUnknown(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	tc := NewTownCenter()
+
+	assert.IsType(&gateways.ValidationError{}, tc.RequestReset(ctx, "nobody@example.com"))
+	assert.Empty(tc.ResetToken("nobody@example.com"))
+	assert.IsType(&gateways.NotFoundError{}, tc.ResetPassword(ctx, uuid.New(), "password"))
+}
+
+func TestRoasterPhotoInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	tc := NewTownCenter()
+	roaster := &models.Roaster{Name: "Kaldi"}
+	tc.AddRoaster(roaster)
+
+	err := tc.UploadRoasterPhoto(context.Background(), roaster.ID, bytes.NewReader([]byte("not a photo")))
+
+	assert.IsType(&gateways.ValidationError{}, err)
+}
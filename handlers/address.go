@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"github.com/imdario/mergo"
+	"gopkg.in/alexcesaro/statsd.v2"
+	"gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/ghmeier/bloodlines/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type AddressI interface {
+	New(ctx *gin.Context)
+	ViewAll(ctx *gin.Context)
+	View(ctx *gin.Context)
+	Update(ctx *gin.Context)
+	Delete(ctx *gin.Context)
+	Time() gin.HandlerFunc
+	GetJWT() gin.HandlerFunc
+}
+
+type Address struct {
+	*handlers.BaseHandler
+	Helper     helpers.AddressI
+	UserHelper helpers.UserI
+}
+
+func NewAddress(ctx *handlers.GatewayContext) AddressI {
+	stats := ctx.Stats.Clone(statsd.Prefix("api.address"))
+	return &Address{
+		BaseHandler: &handlers.BaseHandler{Stats: stats},
+		Helper:      helpers.NewAddress(ctx.Sql),
+		UserHelper:  helpers.NewUser(ctx.Sql, ctx.S3),
+	}
+}
+
+func (a *Address) New(ctx *gin.Context) {
+	userID := ctx.Param("userId")
+
+	var json models.Address
+	err := ctx.BindJSON(&json)
+	if err != nil {
+		a.UserError(ctx, "Error: Unable to parse json", err)
+		return
+	}
+
+	json.AddressCountry = models.NormalizeCountry(json.AddressCountry)
+	errs := models.Validate(&json)
+	if errs != nil {
+		a.UserError(ctx, "Error: invalid address", errs)
+		return
+	}
+
+	user, err := a.UserHelper.GetByID(userID)
+	if err != nil {
+		a.ServerError(ctx, err, userID)
+		return
+	}
+	if user == nil {
+		a.NotFoundError(ctx, "Error: User with ID "+userID+" does not exist")
+		return
+	}
+
+	existing, err := a.Helper.GetByUser(userID)
+	if err != nil {
+		a.ServerError(ctx, err, userID)
+		return
+	}
+
+	address := models.NewAddress(user.ID, json.Label, json.AddressLine1, json.AddressLine2, json.AddressCity,
+		json.AddressState, json.AddressZip, json.AddressCountry)
+
+	//The first address in the book is the default for everything
+	address.DefaultShipping = json.DefaultShipping || len(existing) == 0
+	address.DefaultBilling = json.DefaultBilling || len(existing) == 0
+
+	err = a.Helper.Insert(address)
+	if err != nil {
+		a.ServerError(ctx, err, json)
+		return
+	}
+
+	if !a.sync(ctx, address) {
+		return
+	}
+
+	a.Success(ctx, address)
+}
+
+func (a *Address) ViewAll(ctx *gin.Context) {
+	userID := ctx.Param("userId")
+
+	addresses, err := a.Helper.GetByUser(userID)
+	if err != nil {
+		a.ServerError(ctx, err, userID)
+		return
+	}
+
+	a.Success(ctx, addresses)
+}
+
+func (a *Address) View(ctx *gin.Context) {
+	address, ok := a.get(ctx)
+	if !ok {
+		return
+	}
+
+	a.Success(ctx, address)
+}
+
+func (a *Address) Update(ctx *gin.Context) {
+	var json models.Address
+	err := ctx.BindJSON(&json)
+	if err != nil {
+		a.UserError(ctx, "Error: Unable to parse json", err)
+		return
+	}
+
+	address, ok := a.get(ctx)
+	if !ok {
+		return
+	}
+
+	// merge existing address to json so empty fields don't override
+	json.AddressCountry = models.NormalizeCountry(json.AddressCountry)
+	err = mergo.Merge(&json, address)
+	if err != nil {
+		a.ServerError(ctx, err, json)
+		return
+	}
+	json.ID = address.ID
+	json.UserID = address.UserID
+	json.CreatedAt = address.CreatedAt
+
+	errs := models.ValidateChanges(&json, address)
+	if errs != nil {
+		a.UserError(ctx, "Error: invalid address", errs)
+		return
+	}
+
+	err = a.Helper.Update(&json, address.ID.String())
+	if err != nil {
+		a.ServerError(ctx, err, json)
+		return
+	}
+
+	if !a.sync(ctx, &json) {
+		return
+	}
+
+	a.Success(ctx, json)
+}
+
+func (a *Address) Delete(ctx *gin.Context) {
+	address, ok := a.get(ctx)
+	if !ok {
+		return
+	}
+
+	err := a.Helper.Delete(address.ID.String())
+	if err != nil {
+		a.ServerError(ctx, err, address.ID)
+		return
+	}
+
+	if !address.DefaultShipping && !address.DefaultBilling {
+		a.Success(ctx, nil)
+		return
+	}
+
+	//Hand the deleted address's defaults to the oldest remaining address
+	remaining, err := a.Helper.GetByUser(address.UserID.String())
+	if err != nil {
+		a.ServerError(ctx, err, address.UserID)
+		return
+	}
+	if len(remaining) == 0 {
+		a.Success(ctx, nil)
+		return
+	}
+
+	next := remaining[0]
+	next.DefaultShipping = next.DefaultShipping || address.DefaultShipping
+	next.DefaultBilling = next.DefaultBilling || address.DefaultBilling
+	err = a.Helper.Update(next, next.ID.String())
+	if err != nil {
+		a.ServerError(ctx, err, next.ID)
+		return
+	}
+
+	if !a.sync(ctx, next) {
+		return
+	}
+
+	a.Success(ctx, nil)
+}
+
+/*get loads the address in the path, writing a 404 when it doesn't exist or belongs to another user*/
+func (a *Address) get(ctx *gin.Context) (*models.Address, bool) {
+	userID := ctx.Param("userId")
+	id := ctx.Param("addressId")
+
+	address, err := a.Helper.GetByID(id)
+	if err != nil {
+		a.ServerError(ctx, err, id)
+		return nil, false
+	}
+	if address == nil || address.UserID.String() != userID {
+		a.NotFoundError(ctx, "Error: Address with ID "+id+" does not exist")
+		return nil, false
+	}
+
+	return address, true
+}
+
+/*sync copies the default shipping address into the user's legacy address fields*/
+func (a *Address) sync(ctx *gin.Context, address *models.Address) bool {
+	if !address.DefaultShipping {
+		return true
+	}
+
+	err := a.UserHelper.SetAddress(address.UserID.String(), address)
+	if err != nil {
+		a.ServerError(ctx, err, address.UserID)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+/*AdminUsers is the environment variable listing the comma separated IDs of admin users*/
+const AdminUsers = "ADMIN_USER_IDS"
+
+/*IsAdmin reports whether the authenticated user is listed in ADMIN_USER_IDS*/
+func IsAdmin(ctx *gin.Context) bool {
+	userID := ctx.Request.Header.Get("X-UserId")
+	if userID == "" {
+		return false
+	}
+
+	for _, id := range strings.Split(os.Getenv(AdminUsers), ",") {
+		if strings.TrimSpace(id) == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*forbidden writes a 403 in the same shape as the other error responses*/
+func forbidden(ctx *gin.Context, msg string) {
+	ctx.JSON(http.StatusForbidden, gin.H{"success": false, "msg": msg})
+}
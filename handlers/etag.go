@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+// notModified sets the ETag of obj, the data of a response. If the request's
+// If-None-Match already has it, a 304 is written and notModified returns true.
+func notModified(ctx *gin.Context, obj interface{}) bool {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return false
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	ctx.Header("ETag", etag)
+
+	for _, match := range strings.Split(ctx.Request.Header.Get("If-None-Match"), ",") {
+		match = strings.TrimPrefix(strings.TrimSpace(match), "W/")
+		if match == etag || match == "*" {
+			ctx.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/alexcesaro/statsd.v2"
+	"gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/ghmeier/bloodlines/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type ExportI interface {
+	Users(ctx *gin.Context)
+	Roasters(ctx *gin.Context)
+	Time() gin.HandlerFunc
+	GetJWT() gin.HandlerFunc
+}
+
+/*Export streams every user or roaster to admins as CSV or JSONL*/
+type Export struct {
+	*handlers.BaseHandler
+	Helper helpers.ExportI
+}
+
+func NewExport(ctx *handlers.GatewayContext) ExportI {
+	stats := ctx.Stats.Clone(statsd.Prefix("api.export"))
+	return &Export{
+		BaseHandler: &handlers.BaseHandler{Stats: stats},
+		Helper:      helpers.NewExport(ctx.Sql),
+	}
+}
+
+/*Users streams the users matching the createdAfter, createdBefore and updatedSince query parameters*/
+func (e *Export) Users(ctx *gin.Context) {
+	if !IsAdmin(ctx) {
+		forbidden(ctx, "Error: admin access required")
+		return
+	}
+
+	e.stream(ctx, "users", models.UserExportFields, func(filter *models.ListFilter, w *models.ExportWriter) error {
+		return e.Helper.Users(filter, func(user *models.User) error {
+			return w.Write(user)
+		})
+	})
+}
+
+/*Roasters streams the roasters matching the filter query parameters, status narrows them to one status*/
+func (e *Export) Roasters(ctx *gin.Context) {
+	if !IsAdmin(ctx) {
+		forbidden(ctx, "Error: admin access required")
+		return
+	}
+
+	status := ctx.Query("status")
+	if status != "" && !models.IsStatus(status) {
+		e.UserError(ctx, "Error: status must be one of "+strings.Join(models.Statuses, ", "), nil)
+		return
+	}
+
+	e.stream(ctx, "roasters", models.RoasterExportFields, func(filter *models.ListFilter, w *models.ExportWriter) error {
+		filter.Status = status
+		return e.Helper.Roasters(filter, func(roaster *models.Roaster) error {
+			return w.Write(roaster)
+		})
+	})
+}
+
+// stream writes the export straight to the response as it's read. Once any
+// of it has been sent the status can't change, so a failure part way through
+// just ends the response early.
+func (e *Export) stream(ctx *gin.Context, name string, allowed []string, export func(*models.ListFilter, *models.ExportWriter) error) {
+	filter, err := GetFilter(ctx)
+	if err != nil {
+		e.UserError(ctx, err.Error(), nil)
+		return
+	}
+
+	fields, err := models.ExportFields(ctx.Query("fields"), allowed)
+	if err != nil {
+		e.UserError(ctx, err.Error(), nil)
+		return
+	}
+
+	format := ctx.DefaultQuery("format", models.EXPORT_CSV)
+	w, err := models.NewExportWriter(ctx.Writer, format, fields)
+	if err != nil {
+		e.UserError(ctx, err.Error(), nil)
+		return
+	}
+
+	ctx.Header("Content-Type", w.ContentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.%s", name, time.Now().UTC().Format("20060102"), format))
+
+	err = export(filter, w)
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	if !ctx.Writer.Written() {
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		e.ServerError(ctx, err, nil)
+		return
+	}
+	fmt.Println(err.Error())
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/jakelong95/TownCenter/models"
+)
+
+/*GetFilter reads the createdAfter, createdBefore and updatedSince query parameters as RFC3339 timestamps*/
+func GetFilter(ctx *gin.Context) (*models.ListFilter, error) {
+	filter := &models.ListFilter{}
+
+	params := map[string]*time.Time{
+		"createdAfter":  &filter.CreatedAfter,
+		"createdBefore": &filter.CreatedBefore,
+		"updatedSince":  &filter.UpdatedSince,
+	}
+	for name, dest := range params {
+		raw := ctx.Query(name)
+		if raw == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, fmt.Errorf("Error: %s must be an RFC3339 timestamp", name)
+		}
+		*dest = t
+	}
+
+	return filter, nil
+}
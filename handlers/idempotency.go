@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pborman/uuid"
+	"gopkg.in/alexcesaro/statsd.v2"
+	"gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/ghmeier/bloodlines/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+/*Headers for idempotent requests, IdempotencyReplayed is set on responses that were replayed*/
+const (
+	IdempotencyKey      = "Idempotency-Key"
+	IdempotencyReplayed = "Idempotent-Replayed"
+)
+
+/*MaxIdempotencyKey is the longest key a client can send*/
+const MaxIdempotencyKey = 255
+
+// IdempotentHeaders are the response headers kept along with the body. Tokens
+// aren't stored, a replayed user signup is sent a new token for the user it
+// created instead.
+var IdempotentHeaders = []string{"Content-Type"}
+
+type IdempotencyI interface {
+	Idempotent() gin.HandlerFunc
+}
+
+// Idempotency lets clients retry a request safely by sending the same
+// Idempotency-Key. The first response is stored and replayed to every retry
+// of the same request, by the same caller, within helpers.IdempotencyWindow.
+type Idempotency struct {
+	*handlers.BaseHandler
+	Helper helpers.IdempotencyI
+}
+
+func NewIdempotency(ctx *handlers.GatewayContext) IdempotencyI {
+	stats := ctx.Stats.Clone(statsd.Prefix("api.idempotency"))
+	return &Idempotency{
+		BaseHandler: &handlers.BaseHandler{Stats: stats},
+		Helper:      helpers.NewIdempotency(ctx.Sql),
+	}
+}
+
+// Idempotent is middleware for the routes that create things. Requests
+// without a key are handled as usual. Server errors aren't stored, so a
+// request that failed that way runs again when it's retried.
+func (i *Idempotency) Idempotent() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.Request.Header.Get(IdempotencyKey)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > MaxIdempotencyKey {
+			i.UserError(ctx, fmt.Sprintf("Error: %s must be at most %d characters", IdempotencyKey, MaxIdempotencyKey), nil)
+			ctx.Abort()
+			return
+		}
+
+		body, err := ioutil.ReadAll(ctx.Request.Body)
+		if err != nil {
+			i.UserError(ctx, "Error: unable to read request", nil)
+			ctx.Abort()
+			return
+		}
+		ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+		scope := ctx.Request.Method + " " + ctx.Request.URL.Path + " " + ctx.Request.Header.Get("X-UserId")
+
+		record, claimed, err := i.Helper.Claim(key, scope, hash)
+		if err != nil {
+			i.ServerError(ctx, err, nil)
+			ctx.Abort()
+			return
+		}
+		if !claimed {
+			i.replay(ctx, record, hash)
+			ctx.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		ctx.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			err = i.Helper.Release(record)
+		} else {
+			record.ResponseCode = recorder.Status()
+			record.ResponseBody = recorder.body.String()
+			for _, header := range IdempotentHeaders {
+				if value := recorder.Header().Get(header); value != "" {
+					record.Headers[header] = value
+				}
+			}
+			if id, ok := ctx.Get(TokenUser); ok {
+				record.TokenUserID = id.(string)
+			}
+			err = i.Helper.Save(record)
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+		}
+	}
+}
+
+/*replay writes the stored response, or an error if the key's request is still running or was different*/
+func (i *Idempotency) replay(ctx *gin.Context, record *models.IdempotencyRecord, hash string) {
+	if record.RequestHash != hash {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"success": false, "msg": "Error: " + IdempotencyKey + " was already used for a different request"})
+		return
+	}
+	if !record.Finished() {
+		ctx.JSON(http.StatusConflict, gin.H{"success": false, "msg": "Error: a request with this " + IdempotencyKey + " is still in progress"})
+		return
+	}
+
+	for _, header := range IdempotentHeaders {
+		if value, ok := record.Headers[header]; ok {
+			ctx.Header(header, value)
+		}
+	}
+	if record.TokenUserID != "" {
+		issueToken(ctx, uuid.Parse(record.TokenUserID))
+	}
+	ctx.Header(IdempotencyReplayed, "true")
+	ctx.Writer.WriteHeader(record.ResponseCode)
+	ctx.Writer.WriteString(record.ResponseBody)
+}
+
+/*responseRecorder keeps a copy of everything written to the response*/
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"gopkg.in/alexcesaro/statsd.v2"
+	"gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/ghmeier/bloodlines/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type ImportI interface {
+	New(ctx *gin.Context)
+	View(ctx *gin.Context)
+	Resume(ctx *gin.Context)
+	Time() gin.HandlerFunc
+	GetJWT() gin.HandlerFunc
+}
+
+/*Import lets admins upsert users and roasters in bulk, imports run in the background and are polled for progress*/
+type Import struct {
+	*handlers.BaseHandler
+	Helper   helpers.ImportI
+	Importer helpers.ImporterI
+}
+
+func NewImport(ctx *handlers.GatewayContext) ImportI {
+	stats := ctx.Stats.Clone(statsd.Prefix("api.import"))
+	return &Import{
+		BaseHandler: &handlers.BaseHandler{Stats: stats},
+		Helper:      helpers.NewImport(ctx.Sql),
+		Importer:    helpers.NewImporter(ctx.Sql, ctx.S3, ctx.Coinage, ctx.Bloodlines),
+	}
+}
+
+// New starts importing the file in the request body. The kind query
+// parameter says whether it holds users or roasters, format whether it's
+// csv (the default) or jsonl, and dryRun=true only checks the rows.
+func (i *Import) New(ctx *gin.Context) {
+	if !IsAdmin(ctx) {
+		forbidden(ctx, "Error: admin access required")
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(ctx.Request.Body, models.MaxImportBytes+1))
+	if err != nil {
+		i.UserError(ctx, "Error: unable to read body", nil)
+		return
+	}
+
+	job, err := models.NewImport(ctx.Query("kind"), ctx.DefaultQuery("format", models.IMPORT_CSV), ctx.Query("dryRun") == "true", body)
+	if err != nil {
+		i.UserError(ctx, err.Error(), nil)
+		return
+	}
+
+	err = i.Helper.Insert(job, body)
+	if err != nil {
+		i.ServerError(ctx, err, job)
+		return
+	}
+
+	go i.run(job.ID.String())
+
+	i.Success(ctx, job)
+}
+
+/*View returns the import's progress and the errors of any rows that failed*/
+func (i *Import) View(ctx *gin.Context) {
+	if !IsAdmin(ctx) {
+		forbidden(ctx, "Error: admin access required")
+		return
+	}
+
+	job, ok := i.get(ctx)
+	if !ok {
+		return
+	}
+
+	i.Success(ctx, job)
+}
+
+/*Resume carries on with a failed import from the last row it saved*/
+func (i *Import) Resume(ctx *gin.Context) {
+	if !IsAdmin(ctx) {
+		forbidden(ctx, "Error: admin access required")
+		return
+	}
+
+	job, ok := i.get(ctx)
+	if !ok {
+		return
+	}
+	if job.Status != models.IMPORT_FAILED {
+		i.UserError(ctx, "Error: only failed imports can be resumed, this one is "+job.Status, job)
+		return
+	}
+
+	job.Status = models.IMPORT_PENDING
+	err := i.Helper.Save(job, "")
+	if err != nil {
+		i.ServerError(ctx, err, job)
+		return
+	}
+
+	go i.run(job.ID.String())
+
+	i.Success(ctx, job)
+}
+
+func (i *Import) get(ctx *gin.Context) (*models.Import, bool) {
+	id := ctx.Param("importId")
+
+	job, err := i.Helper.GetByID(id)
+	if err != nil {
+		i.ServerError(ctx, err, id)
+		return nil, false
+	}
+	if job == nil {
+		i.NotFoundError(ctx, "Error: Import with ID "+id+" does not exist")
+		return nil, false
+	}
+
+	return job, true
+}
+
+/*run imports in the background, its progress and any failure are saved on the import*/
+func (i *Import) run(id string) {
+	_, err := i.Importer.Run(id)
+	if err != nil && err != helpers.ErrImportClaimed {
+		fmt.Println(err.Error())
+	}
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/alexcesaro/statsd.v2"
+	"gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/ghmeier/bloodlines/handlers"
+	bmodels "github.com/ghmeier/bloodlines/models"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type OnboardingI interface {
+	ViewDocuments(ctx *gin.Context)
+	UploadDocument(ctx *gin.Context)
+	History(ctx *gin.Context)
+	Submit(ctx *gin.Context)
+	Approve(ctx *gin.Context)
+	Reject(ctx *gin.Context)
+	Suspend(ctx *gin.Context)
+	Reinstate(ctx *gin.Context)
+	Close(ctx *gin.Context)
+	Time() gin.HandlerFunc
+	GetJWT() gin.HandlerFunc
+}
+
+/*Onboarding moves roasters through verification, from pending to active and on to suspended or closed*/
+type Onboarding struct {
+	*handlers.BaseHandler
+	Helper     helpers.StatusI
+	Document   helpers.DocumentI
+	Roaster    helpers.RoasterI
+	UserHelper helpers.UserI
+	Bloodlines gateways.Bloodlines
+}
+
+func NewOnboarding(ctx *handlers.GatewayContext) OnboardingI {
+	stats := ctx.Stats.Clone(statsd.Prefix("api.onboarding"))
+	return &Onboarding{
+		BaseHandler: &handlers.BaseHandler{Stats: stats},
+		Helper:      helpers.NewStatus(ctx.Sql),
+		Document:    helpers.NewDocument(ctx.Sql, ctx.S3),
+		Roaster:     helpers.NewRoaster(ctx.Sql, ctx.S3, ctx.Coinage),
+		UserHelper:  helpers.NewUser(ctx.Sql, ctx.S3),
+		Bloodlines:  ctx.Bloodlines,
+	}
+}
+
+/*ViewDocuments returns the roaster's documents with links that download them for a few minutes*/
+func (o *Onboarding) ViewDocuments(ctx *gin.Context) {
+	roaster, ok := o.authorize(ctx)
+	if !ok {
+		return
+	}
+	roasterID := roaster.ID.String()
+
+	documents, err := o.Document.GetByRoaster(roasterID)
+	if err != nil {
+		o.ServerError(ctx, err, roasterID)
+		return
+	}
+
+	o.Success(ctx, documents)
+}
+
+/*UploadDocument stores the multipart "document" file as verification of the "kind" form value*/
+func (o *Onboarding) UploadDocument(ctx *gin.Context) {
+	kind := ctx.Request.FormValue("kind")
+	if !models.IsDocumentKind(kind) {
+		o.UserError(ctx, "Error: kind must be one of "+strings.Join(models.DocumentKinds, ", "), kind)
+		return
+	}
+
+	file, headers, err := ctx.Request.FormFile("document")
+	if err != nil || file == nil {
+		o.UserError(ctx, "Error: document file is required", err)
+		return
+	}
+	defer file.Close()
+
+	roaster, ok := o.authorize(ctx)
+	if !ok {
+		return
+	}
+
+	document := models.NewRoasterDocument(roaster.ID, kind, headers.Filename)
+	err = o.Document.Insert(document, file)
+	if err != nil {
+		o.ServerError(ctx, err, document)
+		return
+	}
+
+	o.Success(ctx, document)
+}
+
+/*History returns every status change of the roaster, oldest first*/
+func (o *Onboarding) History(ctx *gin.Context) {
+	roaster, ok := o.authorize(ctx)
+	if !ok {
+		return
+	}
+	roasterID := roaster.ID.String()
+
+	history, err := o.Helper.GetHistory(roasterID)
+	if err != nil {
+		o.ServerError(ctx, err, roasterID)
+		return
+	}
+
+	o.Success(ctx, history)
+}
+
+/*Submit sends a pending roaster to the admins for review once it has uploaded a document*/
+func (o *Onboarding) Submit(ctx *gin.Context) {
+	roasterID := ctx.Param("roasterId")
+
+	documents, err := o.Document.GetByRoaster(roasterID)
+	if err != nil {
+		o.ServerError(ctx, err, roasterID)
+		return
+	}
+	if len(documents) == 0 {
+		o.UserError(ctx, "Error: upload a verification document before submitting", nil)
+		return
+	}
+
+	o.transition(ctx, models.STATUS_UNDER_REVIEW, false, false)
+}
+
+func (o *Onboarding) Approve(ctx *gin.Context) {
+	o.transition(ctx, models.STATUS_ACTIVE, true, false)
+}
+
+/*Reject sends a roaster under review back to pending with a reason for its owner*/
+func (o *Onboarding) Reject(ctx *gin.Context) {
+	o.transition(ctx, models.STATUS_PENDING, true, true)
+}
+
+func (o *Onboarding) Suspend(ctx *gin.Context) {
+	o.transition(ctx, models.STATUS_SUSPENDED, true, true)
+}
+
+func (o *Onboarding) Reinstate(ctx *gin.Context) {
+	o.transition(ctx, models.STATUS_ACTIVE, true, false)
+}
+
+/*Close shuts the roaster for good, owners closing their own roaster don't need to give a reason*/
+func (o *Onboarding) Close(ctx *gin.Context) {
+	o.transition(ctx, models.STATUS_CLOSED, false, false)
+}
+
+// transition moves the roaster in the path to status to. adminOnly
+// transitions need an admin, the others need the roaster's owner or an admin.
+// The owner is sent a notification named roaster_<status> after the change.
+func (o *Onboarding) transition(ctx *gin.Context, to string, adminOnly bool, needsReason bool) {
+	roasterID := ctx.Param("roasterId")
+	admin := IsAdmin(ctx)
+	if adminOnly && !admin {
+		forbidden(ctx, "Error: admin access required")
+		return
+	}
+
+	var json models.StatusRequest
+	if ctx.Request.ContentLength != 0 {
+		err := ctx.BindJSON(&json)
+		if err != nil {
+			o.UserError(ctx, "Error: Unable to parse json", err)
+			return
+		}
+	}
+
+	if json.Reason != "" && !models.IsReason(json.Reason) {
+		o.UserError(ctx, "Error: reason must be one of "+strings.Join(models.Reasons, ", "), json)
+		return
+	}
+	if needsReason && json.Reason == "" {
+		o.UserError(ctx, "Error: reason is required", json)
+		return
+	}
+
+	roaster, err := o.Roaster.GetByID(roasterID)
+	if err != nil {
+		o.ServerError(ctx, err, roasterID)
+		return
+	}
+	if roaster == nil {
+		o.NotFoundError(ctx, "Error: Roaster with ID "+roasterID+" does not exist")
+		return
+	}
+
+	owner, err := o.UserHelper.GetByRoaster(roasterID)
+	if err != nil {
+		o.ServerError(ctx, err, roasterID)
+		return
+	}
+
+	actorID := ctx.Request.Header.Get("X-UserId")
+	if !admin && (owner == nil || owner.ID.String() != actorID) {
+		forbidden(ctx, "Error: only the roaster's owner or an admin can do that")
+		return
+	}
+
+	if !models.CanTransition(roaster.Status, to) {
+		o.UserError(ctx, fmt.Sprintf("Error: a %s roaster can't be moved to %s", roaster.Status, to), nil)
+		return
+	}
+
+	if to == models.STATUS_CLOSED && json.Reason == "" && !admin {
+		json.Reason = models.REASON_OWNER_REQUEST
+	}
+
+	change := models.NewStatusChange(roaster.ID, roaster.Status, to, json.Reason, json.Note, actorID)
+	errs := models.Validate(change)
+	if errs != nil {
+		o.UserError(ctx, "Error: invalid status change", errs)
+		return
+	}
+
+	err = o.Helper.Set(change)
+	if err != nil {
+		o.ServerError(ctx, err, change)
+		return
+	}
+	roaster.Status = to
+
+	o.notify(owner, roaster, change)
+
+	o.Success(ctx, roaster)
+}
+
+/*notify tells the roaster's owner about a status change, failures are logged since the change has already been made*/
+func (o *Onboarding) notify(owner *models.User, roaster *models.Roaster, change *models.StatusChange) {
+	if owner == nil {
+		return
+	}
+
+	values := make(map[string]string)
+	values["roaster"] = roaster.Name
+	values["status"] = change.To
+	values["previous"] = change.From
+	values["reason"] = change.Reason
+	values["note"] = change.Note
+
+	_, err := o.Bloodlines.ActivateTrigger("roaster_"+change.To, &bmodels.Receipt{
+		UserID: owner.ID,
+		Values: values,
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+/*authorize loads the roaster in the path, writing an error unless the caller is its owner or an admin*/
+func (o *Onboarding) authorize(ctx *gin.Context) (*models.Roaster, bool) {
+	roasterID := ctx.Param("roasterId")
+
+	roaster, err := o.Roaster.GetByID(roasterID)
+	if err != nil {
+		o.ServerError(ctx, err, roasterID)
+		return nil, false
+	}
+	if roaster == nil {
+		o.NotFoundError(ctx, "Error: Roaster with ID "+roasterID+" does not exist")
+		return nil, false
+	}
+
+	owner, err := o.UserHelper.GetByRoaster(roasterID)
+	if err != nil {
+		o.ServerError(ctx, err, roasterID)
+		return nil, false
+	}
+
+	if !IsAdmin(ctx) && (owner == nil || owner.ID.String() != ctx.Request.Header.Get("X-UserId")) {
+		forbidden(ctx, "Error: only the roaster's owner or an admin can do that")
+		return nil, false
+	}
+
+	return roaster, true
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/alexcesaro/statsd.v2"
+	"gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/ghmeier/bloodlines/handlers"
+	bmodels "github.com/ghmeier/bloodlines/models"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+)
+
+/*MaxAttempts is the number of wrong codes allowed before a new one must be requested*/
+const MaxAttempts = 5
+
+/*Cooldown is how long a user or roaster must wait between requests for a code*/
+const Cooldown = time.Minute
+
+type PhoneI interface {
+	RequestUser(ctx *gin.Context)
+	VerifyUser(ctx *gin.Context)
+	RequestRoaster(ctx *gin.Context)
+	VerifyRoaster(ctx *gin.Context)
+	Time() gin.HandlerFunc
+	GetJWT() gin.HandlerFunc
+}
+
+type Phone struct {
+	*handlers.BaseHandler
+	User         helpers.UserI
+	Roaster      helpers.RoasterI
+	Verification helpers.VerificationI
+	Bloodlines   gateways.Bloodlines
+	Expiration   time.Duration
+	Cooldown     time.Duration
+}
+
+func NewPhone(ctx *handlers.GatewayContext) PhoneI {
+	stats := ctx.Stats.Clone(statsd.Prefix("api.phone"))
+	return &Phone{
+		BaseHandler:  &handlers.BaseHandler{Stats: stats},
+		User:         helpers.NewUser(ctx.Sql, ctx.S3),
+		Roaster:      helpers.NewRoaster(ctx.Sql, ctx.S3, ctx.Coinage),
+		Verification: helpers.NewVerification(ctx.Sql),
+		Bloodlines:   ctx.Bloodlines,
+		Expiration:   time.Duration(time.Minute * 15),
+		Cooldown:     Cooldown,
+	}
+}
+
+/*RequestUser texts a verification code to the user's phone*/
+func (p *Phone) RequestUser(ctx *gin.Context) {
+	id := ctx.Param("userId")
+
+	user, err := p.User.GetByID(id)
+	if err != nil {
+		p.ServerError(ctx, err, id)
+		return
+	}
+	if user == nil {
+		p.NotFoundError(ctx, "Error: User with ID "+id+" does not exist")
+		return
+	}
+
+	p.send(ctx, user.ID, user.ID, user.Phone, user.PhoneVerified)
+}
+
+/*VerifyUser marks the user's phone verified when the code matches*/
+func (p *Phone) VerifyUser(ctx *gin.Context) {
+	id := ctx.Param("userId")
+
+	user, err := p.User.GetByID(id)
+	if err != nil {
+		p.ServerError(ctx, err, id)
+		return
+	}
+	if user == nil {
+		p.NotFoundError(ctx, "Error: User with ID "+id+" does not exist")
+		return
+	}
+
+	if !p.verify(ctx, id, user.Phone) {
+		return
+	}
+
+	err = p.User.VerifyPhone(id, user.Phone)
+	if err != nil {
+		p.ServerError(ctx, err, id)
+		return
+	}
+
+	p.Verification.Delete(id)
+	p.Success(ctx, nil)
+}
+
+/*RequestRoaster texts a verification code to the roaster's phone, the receipt goes to the roaster's owner*/
+func (p *Phone) RequestRoaster(ctx *gin.Context) {
+	id := ctx.Param("roasterId")
+
+	roaster, err := p.Roaster.GetByID(id)
+	if err != nil {
+		p.ServerError(ctx, err, id)
+		return
+	}
+	if roaster == nil {
+		p.NotFoundError(ctx, "Error: Roaster with ID "+id+" does not exist")
+		return
+	}
+
+	owner, err := p.User.GetByRoaster(id)
+	if err != nil {
+		p.ServerError(ctx, err, id)
+		return
+	}
+	if owner == nil {
+		p.NotFoundError(ctx, "Error: no user for that roaster")
+		return
+	}
+
+	p.send(ctx, roaster.ID, owner.ID, roaster.Phone, roaster.PhoneVerified)
+}
+
+/*VerifyRoaster marks the roaster's phone verified when the code matches*/
+func (p *Phone) VerifyRoaster(ctx *gin.Context) {
+	id := ctx.Param("roasterId")
+
+	roaster, err := p.Roaster.GetByID(id)
+	if err != nil {
+		p.ServerError(ctx, err, id)
+		return
+	}
+	if roaster == nil {
+		p.NotFoundError(ctx, "Error: Roaster with ID "+id+" does not exist")
+		return
+	}
+
+	if !p.verify(ctx, id, roaster.Phone) {
+		return
+	}
+
+	err = p.Roaster.VerifyPhone(id, roaster.Phone)
+	if err != nil {
+		p.ServerError(ctx, err, id)
+		return
+	}
+
+	p.Verification.Delete(id)
+	p.Success(ctx, nil)
+}
+
+func (p *Phone) send(ctx *gin.Context, id uuid.UUID, userID uuid.UUID, phone string, verified bool) {
+	if phone == "" {
+		p.UserError(ctx, "Error: no phone number to verify", nil)
+		return
+	}
+	if verified {
+		p.UserError(ctx, "Error: phone number is already verified", nil)
+		return
+	}
+
+	//Each request texts the phone, so they're limited to one per cooldown
+	last, err := p.Verification.Get(id.String())
+	if err != nil {
+		p.ServerError(ctx, err, nil)
+		return
+	}
+	if last != nil && time.Since(last.CreatedAt) < p.Cooldown {
+		p.UserError(ctx, "Error: a code was sent recently, wait before requesting another", nil)
+		return
+	}
+
+	code, err := newCode()
+	if err != nil {
+		p.ServerError(ctx, err, nil)
+		return
+	}
+
+	err = p.Verification.Insert(models.NewVerification(id, phone, code))
+	if err != nil {
+		p.ServerError(ctx, err, nil)
+		return
+	}
+
+	values := make(map[string]string)
+	values["phone"] = phone
+	values["code"] = code
+
+	//The receipt holds the code, so it's never sent back to the caller
+	_, err = p.Bloodlines.ActivateTrigger("phone_verification", &bmodels.Receipt{
+		UserID: userID,
+		Values: values,
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		p.ServerError(ctx, fmt.Errorf("Error: unable to send verification code"), nil)
+		return
+	}
+
+	p.Success(ctx, nil)
+}
+
+/*verify checks the code in the request body, writing the error response and returning false when it doesn't match*/
+func (p *Phone) verify(ctx *gin.Context, id string, phone string) bool {
+	var json models.VerificationRequest
+	err := ctx.BindJSON(&json)
+	if err != nil || json.Code == "" {
+		p.UserError(ctx, "Error: unable to parse request", json)
+		return false
+	}
+
+	verification, err := p.Verification.Get(id)
+	if err != nil {
+		p.ServerError(ctx, err, nil)
+		return false
+	}
+	if verification == nil {
+		p.NotFoundError(ctx, "Error: no verification code was requested")
+		return false
+	}
+
+	if verification.Phone != phone {
+		p.UserError(ctx, "Error: phone number has changed, request a new code", nil)
+		return false
+	}
+
+	if verification.Attempts >= MaxAttempts || time.Since(verification.CreatedAt) >= p.Expiration {
+		p.UserError(ctx, "Error: code has expired, request a new one", nil)
+		return false
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(verification.Code), []byte(json.Code))
+	if err != nil {
+		p.Verification.Attempt(verification)
+		p.UserError(ctx, "Error: incorrect verification code", nil)
+		return false
+	}
+
+	return true
+}
+
+/*newCode returns a random six digit verification code*/
+func newCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+/*normalizePhone converts phone to E.164 when possible, otherwise it is left for validation to report*/
+func normalizePhone(phone, country string) string {
+	if phone == "" {
+		return phone
+	}
+
+	normalized, ok := models.NormalizePhone(phone, country)
+	if !ok {
+		return phone
+	}
+
+	return normalized
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"github.com/jakelong95/TownCenter/helpers"
+)
+
+/*isPhotoError reports whether err is the client's fault, from a bad photo or upload key*/
+func isPhotoError(err error) bool {
+	switch err {
+	case helpers.ErrPhotoType, helpers.ErrPhotoSize, helpers.ErrUploadKey, helpers.ErrUploadMissing:
+		return true
+	}
+
+	return false
+}
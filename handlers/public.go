@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pborman/uuid"
+	"gopkg.in/alexcesaro/statsd.v2"
+	"gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/ghmeier/bloodlines/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type PublicI interface {
+	ViewRoaster(ctx *gin.Context)
+	UserAvatar(ctx *gin.Context)
+	RoasterAvatar(ctx *gin.Context)
+	Time() gin.HandlerFunc
+}
+
+/*Public serves the routes that don't need a signed in user*/
+type Public struct {
+	*handlers.BaseHandler
+	Roaster helpers.RoasterI
+	User    helpers.UserI
+	Profile helpers.RoasterProfileI
+	Gallery helpers.GalleryI
+}
+
+func NewPublic(ctx *handlers.GatewayContext) PublicI {
+	stats := ctx.Stats.Clone(statsd.Prefix("api.public"))
+	return &Public{
+		BaseHandler: &handlers.BaseHandler{Stats: stats},
+		Roaster:     helpers.NewRoaster(ctx.Sql, ctx.S3, ctx.Coinage),
+		User:        helpers.NewUser(ctx.Sql, ctx.S3),
+		Profile:     helpers.NewRoasterProfile(ctx.Sql),
+		Gallery:     helpers.NewGallery(ctx.Sql, ctx.S3),
+	}
+}
+
+/*ViewRoaster returns the public profile of the active roaster with the slug in the path, redirecting slugs the roaster has since changed*/
+func (p *Public) ViewRoaster(ctx *gin.Context) {
+	slug := ctx.Param("slug")
+
+	roaster, err := p.Roaster.GetBySlug(slug)
+	if err != nil {
+		p.ServerError(ctx, err, slug)
+		return
+	}
+	if roaster != nil && roaster.Status == models.STATUS_ACTIVE {
+		p.storefront(ctx, roaster)
+		return
+	}
+	if roaster != nil {
+		p.NotFoundError(ctx, "Error: Roaster "+slug+" does not exist")
+		return
+	}
+
+	current, err := p.Roaster.GetRedirect(slug)
+	if err != nil {
+		p.ServerError(ctx, err, slug)
+		return
+	}
+	if current == "" {
+		p.NotFoundError(ctx, "Error: Roaster "+slug+" does not exist")
+		return
+	}
+
+	ctx.Redirect(http.StatusMovedPermanently, "/api/public/roaster/"+current)
+}
+
+/*storefront writes the roaster's public fields along with its profile and gallery*/
+func (p *Public) storefront(ctx *gin.Context, roaster *models.Roaster) {
+	id := roaster.ID.String()
+	public := roaster.Public()
+
+	profile, err := p.Profile.Get(id)
+	if err != nil {
+		p.ServerError(ctx, err, id)
+		return
+	}
+	if profile == nil {
+		profile = models.NewRoasterProfile(roaster.ID)
+	}
+
+	gallery, err := p.Gallery.GetByRoaster(id)
+	if err != nil {
+		p.ServerError(ctx, err, id)
+		return
+	}
+
+	public.Profile = profile
+	public.Gallery = gallery
+	public.OpenNow = profile.IsOpen(time.Now())
+
+	p.Success(ctx, public)
+}
+
+/*UserAvatar serves the generated avatar of the user in the path*/
+func (p *Public) UserAvatar(ctx *gin.Context) {
+	id := ctx.Param("userId")
+
+	user, err := p.User.GetByID(id)
+	if err != nil {
+		p.ServerError(ctx, err, id)
+		return
+	}
+	if user == nil {
+		p.NotFoundError(ctx, "Error: User with ID "+id+" does not exist")
+		return
+	}
+
+	p.avatar(ctx, user.Initials(), user.ID.String())
+}
+
+/*RoasterAvatar serves the generated avatar of the roaster with the ID or slug in the path*/
+func (p *Public) RoasterAvatar(ctx *gin.Context) {
+	value := ctx.Param("slug")
+
+	var roaster *models.Roaster
+	var err error
+	if uuid.Parse(value) != nil {
+		roaster, err = p.Roaster.GetByID(value)
+	} else {
+		roaster, err = p.Roaster.GetBySlug(value)
+	}
+	if err != nil {
+		p.ServerError(ctx, err, value)
+		return
+	}
+	if roaster == nil {
+		p.NotFoundError(ctx, "Error: Roaster "+value+" does not exist")
+		return
+	}
+
+	p.avatar(ctx, roaster.Initials(), roaster.ID.String())
+}
+
+// avatar writes the avatar for initials in the requested format. Avatars only
+// change when the name does, so they can be cached for a day.
+func (p *Public) avatar(ctx *gin.Context, initials string, seed string) {
+	format := ctx.DefaultQuery("format", helpers.AVATAR_SVG)
+	switch format {
+	case helpers.AVATAR_SVG:
+		ctx.Header("Cache-Control", "public, max-age=86400")
+		ctx.Data(http.StatusOK, "image/svg+xml", helpers.AvatarSVG(initials, seed))
+	case helpers.AVATAR_PNG:
+		size, err := strconv.Atoi(ctx.DefaultQuery("size", strconv.Itoa(helpers.DefaultAvatarSize)))
+		if err != nil || size < helpers.MinAvatarSize || size > helpers.MaxAvatarSize {
+			p.UserError(ctx, "Error: size must be between "+strconv.Itoa(helpers.MinAvatarSize)+" and "+strconv.Itoa(helpers.MaxAvatarSize), nil)
+			return
+		}
+
+		body, err := helpers.AvatarPNG(initials, seed, size)
+		if err != nil {
+			p.ServerError(ctx, err, nil)
+			return
+		}
+
+		ctx.Header("Cache-Control", "public, max-age=86400")
+		ctx.Data(http.StatusOK, "image/png", body)
+	default:
+		p.UserError(ctx, "Error: format must be svg or png", format)
+	}
+}
@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"gopkg.in/alexcesaro/statsd.v2"
 	"gopkg.in/gin-gonic/gin.v1"
 
@@ -18,10 +22,25 @@ type RoasterI interface {
 	Update(ctx *gin.Context)
 	Delete(ctx *gin.Context)
 	Upload(ctx *gin.Context)
+	DeletePhoto(ctx *gin.Context)
+	PresignPhoto(ctx *gin.Context)
+	CompletePhoto(ctx *gin.Context)
+	Nearby(ctx *gin.Context)
+	Search(ctx *gin.Context)
+	Batch(ctx *gin.Context)
 	Time() gin.HandlerFunc
 	GetJWT() gin.HandlerFunc
 }
 
+/*AllStatuses is the status query parameter that lists roasters in every status*/
+const AllStatuses = "all"
+
+/*Search radius limits for Nearby, in kilometers*/
+const (
+	DefaultRadius = 25.0
+	MaxRadius     = 500.0
+)
+
 type Roaster struct {
 	*handlers.BaseHandler
 	Helper     helpers.RoasterI
@@ -51,8 +70,21 @@ func (r *Roaster) New(ctx *gin.Context) {
 		return
 	}
 
+	json.Roaster.AddressCountry = models.NormalizeCountry(json.Roaster.AddressCountry)
+	json.Roaster.Phone = normalizePhone(json.Roaster.Phone, json.Roaster.AddressCountry)
+	errs := models.Validate(&json.Roaster)
+	if errs != nil {
+		r.UserError(ctx, "Error: invalid roaster", errs)
+		return
+	}
+
 	//Create the new roaster in the database
 	roaster := models.NewRoaster(json.Roaster.Name, json.Roaster.Email, json.Roaster.Phone, json.Roaster.AddressLine1, json.Roaster.AddressLine2, json.Roaster.AddressCity, json.Roaster.AddressState, json.Roaster.AddressZip, json.Roaster.AddressCountry, json.Roaster.Birthday)
+	roaster.Slug = json.Roaster.Slug
+	if roaster.Slug != "" && !r.slugAvailable(ctx, roaster.Slug, roaster.ID.String()) {
+		return
+	}
+
 	err = r.Helper.Insert(roaster)
 	if err != nil {
 		r.ServerError(ctx, err, json)
@@ -93,8 +125,28 @@ func (r *Roaster) ViewAll(ctx *gin.Context) {
 	//Use paging when getting lists of roasters
 	offset, limit := r.GetPaging(ctx)
 
+	filter, err := GetFilter(ctx)
+	if err != nil {
+		r.UserError(ctx, err.Error(), nil)
+		return
+	}
+
+	//Only admins can list roasters that aren't active
+	status := ctx.DefaultQuery("status", models.STATUS_ACTIVE)
+	if status != models.STATUS_ACTIVE && !IsAdmin(ctx) {
+		forbidden(ctx, "Error: admin access required")
+		return
+	}
+	if status != AllStatuses && !models.IsStatus(status) {
+		r.UserError(ctx, "Error: status must be one of "+strings.Join(models.Statuses, ", ")+" or "+AllStatuses, nil)
+		return
+	}
+	if status != AllStatuses {
+		filter.Status = status
+	}
+
 	//Query the database for all roasters
-	roasters, err := r.Helper.GetAll(offset, limit)
+	roasters, err := r.Helper.GetAll(offset, limit, filter)
 	if err != nil {
 		r.ServerError(ctx, err, roasters)
 		return
@@ -103,6 +155,89 @@ func (r *Roaster) ViewAll(ctx *gin.Context) {
 	r.Success(ctx, roasters)
 }
 
+/*Nearby returns the roasters within radiusKm of the lat and lng query parameters, closest first*/
+func (r *Roaster) Nearby(ctx *gin.Context) {
+	offset, limit := r.GetPaging(ctx)
+
+	lat, err := getCoordinate(ctx, "lat", 90)
+	if err != nil {
+		r.UserError(ctx, err.Error(), nil)
+		return
+	}
+
+	lng, err := getCoordinate(ctx, "lng", 180)
+	if err != nil {
+		r.UserError(ctx, err.Error(), nil)
+		return
+	}
+
+	radius, err := strconv.ParseFloat(ctx.DefaultQuery("radiusKm", strconv.FormatFloat(DefaultRadius, 'f', -1, 64)), 64)
+	if err != nil || radius <= 0 || radius > MaxRadius {
+		r.UserError(ctx, fmt.Sprintf("Error: radiusKm must be a number between 0 and %g", MaxRadius), nil)
+		return
+	}
+
+	roasters, err := r.Helper.GetNearby(lat, lng, radius, offset, limit)
+	if err != nil {
+		r.ServerError(ctx, err, nil)
+		return
+	}
+
+	r.Success(ctx, roasters)
+}
+
+/*Search returns the roasters whose name or city match the q query parameter, best match first*/
+func (r *Roaster) Search(ctx *gin.Context) {
+	offset, limit := r.GetPaging(ctx)
+
+	query := ctx.Query("q")
+	if query == "" {
+		r.UserError(ctx, "Error: q is required", nil)
+		return
+	}
+
+	roasters, err := r.Helper.Search(query, offset, limit)
+	if err != nil {
+		r.ServerError(ctx, err, query)
+		return
+	}
+
+	r.Success(ctx, roasters)
+}
+
+/*Batch returns the roasters with the IDs in the request, keyed by ID, with the IDs that weren't found marked*/
+func (r *Roaster) Batch(ctx *gin.Context) {
+	var json models.BatchRequest
+	err := ctx.BindJSON(&json)
+	if err != nil {
+		r.UserError(ctx, "Error: Unable to parse json", err)
+		return
+	}
+
+	errs := json.Validate()
+	if errs != nil {
+		r.UserError(ctx, "Error: invalid batch", errs)
+		return
+	}
+
+	ids := json.Unique()
+	roasters, err := r.Helper.GetByIDs(ids)
+	if err != nil {
+		r.ServerError(ctx, err, ids)
+		return
+	}
+
+	results := make(map[string]*models.RoasterResult)
+	for _, id := range ids {
+		results[id] = &models.RoasterResult{}
+	}
+	for _, roaster := range roasters {
+		results[roaster.ID.String()] = &models.RoasterResult{Found: true, Roaster: roaster}
+	}
+
+	r.Success(ctx, results)
+}
+
 func (r *Roaster) View(ctx *gin.Context) {
 	roasterId := ctx.Param("roasterId")
 
@@ -118,6 +253,10 @@ func (r *Roaster) View(ctx *gin.Context) {
 		return
 	}
 
+	if notModified(ctx, roaster) {
+		return
+	}
+
 	r.Success(ctx, roaster)
 }
 
@@ -132,6 +271,37 @@ func (r *Roaster) Update(ctx *gin.Context) {
 		return
 	}
 
+	json.AddressCountry = models.NormalizeCountry(json.AddressCountry)
+	json.Phone = normalizePhone(json.Phone, json.AddressCountry)
+
+	existing, err := r.Helper.GetByID(roasterId)
+	if err != nil {
+		r.ServerError(ctx, err, roasterId)
+		return
+	}
+	if existing == nil {
+		r.NotFoundError(ctx, "Error: Roaster with ID "+roasterId+" does not exist")
+		return
+	}
+
+	//Stored values are only checked when the update changes them
+	errs := models.ValidateChanges(&json, existing)
+	if errs != nil {
+		r.UserError(ctx, "Error: invalid roaster", errs)
+		return
+	}
+
+	//Leaving out the slug keeps the current one instead of generating a new one
+	if json.Slug == "" {
+		json.Slug = existing.Slug
+	} else if json.Slug != existing.Slug && !r.slugAvailable(ctx, json.Slug, roasterId) {
+		return
+	}
+
+	//Status only changes through the onboarding endpoints
+	json.Status = existing.Status
+	json.SetPhotos()
+
 	//Update the roaster in the database
 	err = r.Helper.Update(&json, roasterId)
 	if err != nil {
@@ -144,7 +314,7 @@ func (r *Roaster) Update(ctx *gin.Context) {
 
 func (r *Roaster) Upload(ctx *gin.Context) {
 	id := ctx.Param("roasterId")
-	file, headers, err := ctx.Request.FormFile("profile")
+	file, _, err := ctx.Request.FormFile("profile")
 	if err != nil {
 		r.ServerError(ctx, err, nil)
 		return
@@ -155,7 +325,88 @@ func (r *Roaster) Upload(ctx *gin.Context) {
 	}
 	defer file.Close()
 
-	err = r.Helper.Profile(id, headers.Filename, file)
+	photo, err := helpers.ProcessPhoto(file)
+	if err != nil {
+		r.UserError(ctx, err.Error(), nil)
+		return
+	}
+
+	err = r.Helper.Profile(id, photo)
+	if err != nil {
+		r.ServerError(ctx, err, id)
+		return
+	}
+
+	r.Success(ctx, nil)
+}
+
+/*PresignPhoto returns a URL to upload the roaster's photo straight to S3, finished with CompletePhoto*/
+func (r *Roaster) PresignPhoto(ctx *gin.Context) {
+	id := ctx.Param("roasterId")
+
+	var json models.UploadRequest
+	err := ctx.BindJSON(&json)
+	if err != nil {
+		r.UserError(ctx, "Error: Unable to parse json", err)
+		return
+	}
+
+	upload, err := r.Helper.PresignProfile(id, json.ContentType)
+	if isPhotoError(err) {
+		r.UserError(ctx, err.Error(), json)
+		return
+	}
+	if err != nil {
+		r.ServerError(ctx, err, id)
+		return
+	}
+
+	r.Success(ctx, upload)
+}
+
+/*CompletePhoto checks the photo uploaded to the presigned URL and makes it the roaster's photo*/
+func (r *Roaster) CompletePhoto(ctx *gin.Context) {
+	id := ctx.Param("roasterId")
+
+	var json models.UploadComplete
+	err := ctx.BindJSON(&json)
+	if err != nil || json.Key == "" {
+		r.UserError(ctx, "Error: key is required", json)
+		return
+	}
+
+	err = r.Helper.CompleteProfile(id, json.Key)
+	if isPhotoError(err) {
+		r.UserError(ctx, err.Error(), json)
+		return
+	}
+	if err != nil {
+		r.ServerError(ctx, err, id)
+		return
+	}
+
+	r.Success(ctx, nil)
+}
+
+/*DeletePhoto removes the roaster's photo, leaving it with the generated avatar*/
+func (r *Roaster) DeletePhoto(ctx *gin.Context) {
+	id := ctx.Param("roasterId")
+
+	roaster, err := r.Helper.GetByID(id)
+	if err != nil {
+		r.ServerError(ctx, err, id)
+		return
+	}
+	if roaster == nil {
+		r.NotFoundError(ctx, "Error: Roaster with ID "+id+" does not exist")
+		return
+	}
+	if roaster.ProfileUrl == "" {
+		r.NotFoundError(ctx, "Error: roaster doesn't have a photo")
+		return
+	}
+
+	err = r.Helper.RemoveProfile(id, roaster.ProfileUrl)
 	if err != nil {
 		r.ServerError(ctx, err, id)
 		return
@@ -176,3 +427,28 @@ func (r *Roaster) Delete(ctx *gin.Context) {
 
 	r.Success(ctx, nil)
 }
+
+/*slugAvailable checks that no other roaster has, or used to have, slug, writing the error response when one does*/
+func (r *Roaster) slugAvailable(ctx *gin.Context, slug string, id string) bool {
+	taken, err := r.Helper.SlugTaken(slug, id)
+	if err != nil {
+		r.ServerError(ctx, err, slug)
+		return false
+	}
+	if taken {
+		r.UserError(ctx, "Error: slug "+slug+" is already taken", nil)
+		return false
+	}
+
+	return true
+}
+
+/*getCoordinate reads a required latitude or longitude query parameter no larger than max in magnitude*/
+func getCoordinate(ctx *gin.Context, name string, max float64) (float64, error) {
+	value, err := strconv.ParseFloat(ctx.Query(name), 64)
+	if err != nil || value < -max || value > max {
+		return 0, fmt.Errorf("Error: %s must be a number between %g and %g", name, -max, max)
+	}
+
+	return value, nil
+}
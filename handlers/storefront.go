@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"gopkg.in/alexcesaro/statsd.v2"
+	"gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/ghmeier/bloodlines/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type StorefrontI interface {
+	ViewProfile(ctx *gin.Context)
+	UpdateProfile(ctx *gin.Context)
+	ViewGallery(ctx *gin.Context)
+	AddImage(ctx *gin.Context)
+	UpdateImage(ctx *gin.Context)
+	OrderGallery(ctx *gin.Context)
+	DeleteImage(ctx *gin.Context)
+	Time() gin.HandlerFunc
+	GetJWT() gin.HandlerFunc
+}
+
+/*Storefront manages a roaster's public facing profile and photo gallery*/
+type Storefront struct {
+	*handlers.BaseHandler
+	Profile helpers.RoasterProfileI
+	Gallery helpers.GalleryI
+	Roaster helpers.RoasterI
+	User    helpers.UserI
+}
+
+/*GalleryOrder lists every image id in a gallery in the order they should be shown*/
+type GalleryOrder struct {
+	IDs []string `json:"ids"`
+}
+
+func NewStorefront(ctx *handlers.GatewayContext) StorefrontI {
+	stats := ctx.Stats.Clone(statsd.Prefix("api.storefront"))
+	return &Storefront{
+		BaseHandler: &handlers.BaseHandler{Stats: stats},
+		Profile:     helpers.NewRoasterProfile(ctx.Sql),
+		Gallery:     helpers.NewGallery(ctx.Sql, ctx.S3),
+		Roaster:     helpers.NewRoaster(ctx.Sql, ctx.S3, ctx.Coinage),
+		User:        helpers.NewUser(ctx.Sql, ctx.S3),
+	}
+}
+
+func (s *Storefront) ViewProfile(ctx *gin.Context) {
+	roasterID := ctx.Param("roasterId")
+
+	profile, err := s.Profile.Get(roasterID)
+	if err != nil {
+		s.ServerError(ctx, err, roasterID)
+		return
+	}
+	if profile != nil {
+		s.Success(ctx, profile)
+		return
+	}
+
+	//Roasters that haven't filled in a profile get an empty one
+	roaster, ok := s.roaster(ctx)
+	if !ok {
+		return
+	}
+
+	s.Success(ctx, models.NewRoasterProfile(roaster.ID))
+}
+
+/*UpdateProfile replaces the roaster's whole profile with the one in the body*/
+func (s *Storefront) UpdateProfile(ctx *gin.Context) {
+	json := models.NewRoasterProfile(nil)
+	err := ctx.BindJSON(json)
+	if err != nil {
+		s.UserError(ctx, "Error: Unable to parse json", err)
+		return
+	}
+
+	json.Normalize()
+	errs := json.Validate()
+	if errs != nil {
+		s.UserError(ctx, "Error: invalid profile", errs)
+		return
+	}
+
+	roaster, ok := s.authorize(ctx)
+	if !ok {
+		return
+	}
+	json.RoasterID = roaster.ID
+
+	err = s.Profile.Set(json)
+	if err != nil {
+		s.ServerError(ctx, err, json)
+		return
+	}
+
+	s.Success(ctx, json)
+}
+
+func (s *Storefront) ViewGallery(ctx *gin.Context) {
+	roasterID := ctx.Param("roasterId")
+
+	images, err := s.Gallery.GetByRoaster(roasterID)
+	if err != nil {
+		s.ServerError(ctx, err, roasterID)
+		return
+	}
+
+	s.Success(ctx, images)
+}
+
+/*AddImage uploads the multipart "image" file to the end of the gallery with the optional "caption" form value*/
+func (s *Storefront) AddImage(ctx *gin.Context) {
+	file, headers, err := ctx.Request.FormFile("image")
+	if err != nil || file == nil {
+		s.UserError(ctx, "Error: image file is required", err)
+		return
+	}
+	defer file.Close()
+
+	roaster, ok := s.authorize(ctx)
+	if !ok {
+		return
+	}
+
+	images, err := s.Gallery.GetByRoaster(roaster.ID.String())
+	if err != nil {
+		s.ServerError(ctx, err, roaster.ID)
+		return
+	}
+	if len(images) >= models.MaxGallerySize {
+		s.UserError(ctx, "Error: gallery is full", models.MaxGallerySize)
+		return
+	}
+
+	position := 0
+	if len(images) > 0 {
+		position = images[len(images)-1].Position + 1
+	}
+
+	image := models.NewGalleryImage(roaster.ID, ctx.Request.FormValue("caption"), position)
+	errs := models.Validate(image)
+	if errs != nil {
+		s.UserError(ctx, "Error: invalid image", errs)
+		return
+	}
+
+	err = s.Gallery.Insert(image, headers.Filename, file)
+	if err != nil {
+		s.ServerError(ctx, err, image)
+		return
+	}
+
+	s.Success(ctx, image)
+}
+
+/*UpdateImage changes an image's caption*/
+func (s *Storefront) UpdateImage(ctx *gin.Context) {
+	var json models.GalleryImage
+	err := ctx.BindJSON(&json)
+	if err != nil {
+		s.UserError(ctx, "Error: Unable to parse json", err)
+		return
+	}
+
+	_, ok := s.authorize(ctx)
+	if !ok {
+		return
+	}
+
+	image, ok := s.image(ctx)
+	if !ok {
+		return
+	}
+
+	image.Caption = json.Caption
+	errs := models.Validate(image)
+	if errs != nil {
+		s.UserError(ctx, "Error: invalid image", errs)
+		return
+	}
+
+	err = s.Gallery.Update(image, image.ID.String())
+	if err != nil {
+		s.ServerError(ctx, err, image)
+		return
+	}
+
+	s.Success(ctx, image)
+}
+
+/*OrderGallery rearranges the gallery, the body must list every image in it exactly once*/
+func (s *Storefront) OrderGallery(ctx *gin.Context) {
+	var json GalleryOrder
+	err := ctx.BindJSON(&json)
+	if err != nil {
+		s.UserError(ctx, "Error: Unable to parse json", err)
+		return
+	}
+
+	roaster, ok := s.authorize(ctx)
+	if !ok {
+		return
+	}
+	roasterID := roaster.ID.String()
+
+	images, err := s.Gallery.GetByRoaster(roasterID)
+	if err != nil {
+		s.ServerError(ctx, err, roasterID)
+		return
+	}
+
+	byID := make(map[string]*models.GalleryImage)
+	for _, image := range images {
+		byID[image.ID.String()] = image
+	}
+
+	ordered := make([]*models.GalleryImage, 0, len(json.IDs))
+	for i, id := range json.IDs {
+		image, ok := byID[id]
+		if !ok {
+			break
+		}
+		delete(byID, id)
+		image.Position = i
+		ordered = append(ordered, image)
+	}
+	if len(ordered) != len(images) || len(json.IDs) != len(images) {
+		s.UserError(ctx, "Error: ids must list every image in the gallery exactly once", json)
+		return
+	}
+
+	err = s.Gallery.Reorder(roasterID, json.IDs)
+	if err != nil {
+		s.ServerError(ctx, err, json)
+		return
+	}
+
+	s.Success(ctx, ordered)
+}
+
+func (s *Storefront) DeleteImage(ctx *gin.Context) {
+	_, ok := s.authorize(ctx)
+	if !ok {
+		return
+	}
+
+	image, ok := s.image(ctx)
+	if !ok {
+		return
+	}
+
+	err := s.Gallery.Delete(image.ID.String())
+	if err != nil {
+		s.ServerError(ctx, err, image.ID)
+		return
+	}
+
+	s.Success(ctx, nil)
+}
+
+/*roaster loads the roaster in the path, writing a 404 when it doesn't exist*/
+func (s *Storefront) roaster(ctx *gin.Context) (*models.Roaster, bool) {
+	roasterID := ctx.Param("roasterId")
+
+	roaster, err := s.Roaster.GetByID(roasterID)
+	if err != nil {
+		s.ServerError(ctx, err, roasterID)
+		return nil, false
+	}
+	if roaster == nil {
+		s.NotFoundError(ctx, "Error: Roaster with ID "+roasterID+" does not exist")
+		return nil, false
+	}
+
+	return roaster, true
+}
+
+/*authorize loads the roaster in the path like roaster, writing an error unless the caller is its owner or an admin*/
+func (s *Storefront) authorize(ctx *gin.Context) (*models.Roaster, bool) {
+	roaster, ok := s.roaster(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	owner, err := s.User.GetByRoaster(roaster.ID.String())
+	if err != nil {
+		s.ServerError(ctx, err, roaster.ID)
+		return nil, false
+	}
+
+	if !IsAdmin(ctx) && (owner == nil || owner.ID.String() != ctx.Request.Header.Get("X-UserId")) {
+		forbidden(ctx, "Error: only the roaster's owner or an admin can do that")
+		return nil, false
+	}
+
+	return roaster, true
+}
+
+/*image loads the gallery image in the path, writing a 404 when it doesn't exist or belongs to another roaster*/
+func (s *Storefront) image(ctx *gin.Context) (*models.GalleryImage, bool) {
+	roasterID := ctx.Param("roasterId")
+	id := ctx.Param("imageId")
+
+	image, err := s.Gallery.GetByID(id)
+	if err != nil {
+		s.ServerError(ctx, err, id)
+		return nil, false
+	}
+	if image == nil || image.RoasterID.String() != roasterID {
+		s.NotFoundError(ctx, "Error: Image with ID "+id+" does not exist")
+		return nil, false
+	}
+
+	return image, true
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pborman/uuid"
+	"gopkg.in/alexcesaro/statsd.v2"
+	"gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/ghmeier/bloodlines/handlers"
+	bmodels "github.com/ghmeier/bloodlines/models"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type TransferI interface {
+	Initiate(ctx *gin.Context)
+	View(ctx *gin.Context)
+	Cancel(ctx *gin.Context)
+	ViewByToken(ctx *gin.Context)
+	Accept(ctx *gin.Context)
+	Decline(ctx *gin.Context)
+	Time() gin.HandlerFunc
+	GetJWT() gin.HandlerFunc
+}
+
+/*Transfer hands a roaster to a new owner, the recipient confirms from the link they're emailed*/
+type Transfer struct {
+	*handlers.BaseHandler
+	Helper     helpers.TransferI
+	Roaster    helpers.RoasterI
+	UserHelper helpers.UserI
+	Bloodlines gateways.Bloodlines
+	Expiration time.Duration
+}
+
+func NewTransfer(ctx *handlers.GatewayContext) TransferI {
+	stats := ctx.Stats.Clone(statsd.Prefix("api.transfer"))
+	return &Transfer{
+		BaseHandler: &handlers.BaseHandler{Stats: stats},
+		Helper:      helpers.NewTransfer(ctx.Sql),
+		Roaster:     helpers.NewRoaster(ctx.Sql, ctx.S3, ctx.Coinage),
+		UserHelper:  helpers.NewUser(ctx.Sql, ctx.S3),
+		Bloodlines:  ctx.Bloodlines,
+		Expiration:  time.Duration(time.Hour * 24 * 7),
+	}
+}
+
+/*Initiate offers the roaster to the user with the requested email, only one transfer can be pending at a time*/
+func (t *Transfer) Initiate(ctx *gin.Context) {
+	var json models.TransferRequest
+	err := ctx.BindJSON(&json)
+	if err != nil || json.Email == "" {
+		t.UserError(ctx, "Error: email is required", json)
+		return
+	}
+
+	roaster, owner, ok := t.authorize(ctx)
+	if !ok {
+		return
+	}
+	if owner == nil {
+		t.UserError(ctx, "Error: roaster has no owner to transfer from", nil)
+		return
+	}
+
+	recipient, err := t.UserHelper.GetByEmail(json.Email)
+	if err != nil {
+		t.ServerError(ctx, err, json)
+		return
+	}
+	if recipient == nil {
+		t.NotFoundError(ctx, "Error: no user found for email")
+		return
+	}
+	if uuid.Equal(recipient.ID, owner.ID) {
+		t.UserError(ctx, "Error: roaster is already owned by that user", json)
+		return
+	}
+	if recipient.RoasterId != nil {
+		t.UserError(ctx, "Error: that user already owns a roaster", json)
+		return
+	}
+
+	pending, err := t.pending(roaster.ID.String())
+	if err != nil {
+		t.ServerError(ctx, err, roaster.ID)
+		return
+	}
+	if pending != nil {
+		t.UserError(ctx, "Error: a transfer is already pending, cancel it first", pending)
+		return
+	}
+
+	transfer := models.NewTransfer(roaster.ID, owner.ID, recipient.ID, recipient.Email, t.Expiration)
+	err = t.Helper.Insert(transfer)
+	if err != nil {
+		t.ServerError(ctx, err, transfer)
+		return
+	}
+
+	values := make(map[string]string)
+	values["roaster"] = roaster.Name
+	values["accept_link"] = fmt.Sprintf("https://expresso.store/transfer/%s", transfer.Token)
+	t.notify("roaster_transfer", recipient.ID, values)
+
+	t.Success(ctx, transfer)
+}
+
+/*View returns the roaster's pending transfer*/
+func (t *Transfer) View(ctx *gin.Context) {
+	roaster, _, ok := t.authorize(ctx)
+	if !ok {
+		return
+	}
+
+	transfer, err := t.pending(roaster.ID.String())
+	if err != nil {
+		t.ServerError(ctx, err, roaster.ID)
+		return
+	}
+	if transfer == nil {
+		t.NotFoundError(ctx, "Error: no pending transfer for roaster")
+		return
+	}
+
+	t.Success(ctx, transfer)
+}
+
+func (t *Transfer) Cancel(ctx *gin.Context) {
+	roaster, _, ok := t.authorize(ctx)
+	if !ok {
+		return
+	}
+
+	transfer, err := t.pending(roaster.ID.String())
+	if err != nil {
+		t.ServerError(ctx, err, roaster.ID)
+		return
+	}
+	if transfer == nil {
+		t.NotFoundError(ctx, "Error: no pending transfer for roaster")
+		return
+	}
+
+	err = t.Helper.SetStatus(transfer, models.TRANSFER_CANCELLED)
+	if err != nil {
+		t.changeError(ctx, err, transfer)
+		return
+	}
+
+	t.Success(ctx, transfer)
+}
+
+/*ViewByToken shows the recipient the transfer they were sent*/
+func (t *Transfer) ViewByToken(ctx *gin.Context) {
+	transfer, ok := t.byToken(ctx)
+	if !ok {
+		return
+	}
+
+	t.Success(ctx, transfer)
+}
+
+// Accept moves the roaster to the recipient. Coinage accounts belong to the
+// owning user, so once the transfer has committed the recipient is given
+// one the same way a new roaster's owner is.
+func (t *Transfer) Accept(ctx *gin.Context) {
+	transfer, ok := t.byToken(ctx)
+	if !ok {
+		return
+	}
+	if transfer.Status != models.TRANSFER_PENDING {
+		t.UserError(ctx, "Error: transfer is "+transfer.Status, transfer)
+		return
+	}
+
+	recipient, err := t.UserHelper.GetByID(transfer.ToUserID.String())
+	if err != nil {
+		t.ServerError(ctx, err, transfer)
+		return
+	}
+	if recipient == nil {
+		t.NotFoundError(ctx, "Error: User with ID "+transfer.ToUserID.String()+" does not exist")
+		return
+	}
+	if recipient.RoasterId != nil {
+		t.UserError(ctx, "Error: you already own a roaster", transfer)
+		return
+	}
+
+	roaster, err := t.Roaster.GetByID(transfer.RoasterID.String())
+	if err != nil {
+		t.ServerError(ctx, err, transfer)
+		return
+	}
+	if roaster == nil {
+		t.NotFoundError(ctx, "Error: Roaster with ID "+transfer.RoasterID.String()+" does not exist")
+		return
+	}
+
+	err = t.Helper.Accept(transfer)
+	if err != nil {
+		t.changeError(ctx, err, transfer)
+		return
+	}
+
+	values := make(map[string]string)
+	values["roaster"] = roaster.Name
+	values["email"] = transfer.ToEmail
+	t.notify("roaster_transfer_accepted", transfer.FromUserID, values)
+
+	err = t.Roaster.CreateAccount(transfer.ToUserID)
+	if err != nil {
+		t.ServerError(ctx, err, transfer)
+		return
+	}
+
+	t.Success(ctx, transfer)
+}
+
+func (t *Transfer) Decline(ctx *gin.Context) {
+	transfer, ok := t.byToken(ctx)
+	if !ok {
+		return
+	}
+	if transfer.Status != models.TRANSFER_PENDING {
+		t.UserError(ctx, "Error: transfer is "+transfer.Status, transfer)
+		return
+	}
+
+	err := t.Helper.SetStatus(transfer, models.TRANSFER_DECLINED)
+	if err != nil {
+		t.changeError(ctx, err, transfer)
+		return
+	}
+
+	values := make(map[string]string)
+	values["email"] = transfer.ToEmail
+	t.notify("roaster_transfer_declined", transfer.FromUserID, values)
+
+	t.Success(ctx, transfer)
+}
+
+/*authorize loads the roaster in the path and its owner, writing an error unless the caller is the owner or an admin*/
+func (t *Transfer) authorize(ctx *gin.Context) (*models.Roaster, *models.User, bool) {
+	roasterID := ctx.Param("roasterId")
+
+	roaster, err := t.Roaster.GetByID(roasterID)
+	if err != nil {
+		t.ServerError(ctx, err, roasterID)
+		return nil, nil, false
+	}
+	if roaster == nil {
+		t.NotFoundError(ctx, "Error: Roaster with ID "+roasterID+" does not exist")
+		return nil, nil, false
+	}
+
+	owner, err := t.UserHelper.GetByRoaster(roasterID)
+	if err != nil {
+		t.ServerError(ctx, err, roasterID)
+		return nil, nil, false
+	}
+
+	if !IsAdmin(ctx) && (owner == nil || owner.ID.String() != ctx.Request.Header.Get("X-UserId")) {
+		forbidden(ctx, "Error: only the roaster's owner or an admin can do that")
+		return nil, nil, false
+	}
+
+	return roaster, owner, true
+}
+
+/*byToken loads the transfer in the path, only its recipient may see it*/
+func (t *Transfer) byToken(ctx *gin.Context) (*models.Transfer, bool) {
+	token := ctx.Param("token")
+
+	transfer, err := t.Helper.GetByToken(token)
+	if err != nil {
+		t.ServerError(ctx, err, nil)
+		return nil, false
+	}
+	if transfer == nil {
+		t.NotFoundError(ctx, "Error: no transfer for that token")
+		return nil, false
+	}
+
+	if transfer.ToUserID.String() != ctx.Request.Header.Get("X-UserId") {
+		forbidden(ctx, "Error: only the recipient can view this transfer")
+		return nil, false
+	}
+
+	t.expire(transfer)
+	return transfer, true
+}
+
+/*pending returns the roaster's pending transfer, expiring it first if it's run out*/
+func (t *Transfer) pending(roasterID string) (*models.Transfer, error) {
+	transfer, err := t.Helper.GetPending(roasterID)
+	if err != nil || transfer == nil {
+		return nil, err
+	}
+
+	if t.expire(transfer) {
+		return nil, nil
+	}
+
+	return transfer, nil
+}
+
+/*changeError reports a transfer another request already changed as a user error*/
+func (t *Transfer) changeError(ctx *gin.Context, err error, transfer *models.Transfer) {
+	if err == helpers.ErrTransferNotPending || err == helpers.ErrRecipientHasRoaster {
+		t.UserError(ctx, err.Error(), transfer)
+		return
+	}
+
+	t.ServerError(ctx, err, transfer)
+}
+
+// expire marks a pending transfer that's past its expiry as expired. Transfers
+// are only expired when they're next read, so there's no background job.
+func (t *Transfer) expire(transfer *models.Transfer) bool {
+	if !transfer.Expired(time.Now()) {
+		return false
+	}
+
+	err := t.Helper.SetStatus(transfer, models.TRANSFER_EXPIRED)
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+	transfer.Status = models.TRANSFER_EXPIRED
+	return true
+}
+
+/*notify sends a transfer email, failures are logged since the transfer has already changed*/
+func (t *Transfer) notify(trigger string, userID uuid.UUID, values map[string]string) {
+	_, err := t.Bloodlines.ActivateTrigger(trigger, &bmodels.Receipt{
+		UserID: userID,
+		Values: values,
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
@@ -29,6 +29,12 @@ type UserI interface {
 	Delete(ctx *gin.Context)
 	Login(ctx *gin.Context)
 	Upload(ctx *gin.Context)
+	DeletePhoto(ctx *gin.Context)
+	PresignPhoto(ctx *gin.Context)
+	CompletePhoto(ctx *gin.Context)
+	Search(ctx *gin.Context)
+	ViewByEmail(ctx *gin.Context)
+	Batch(ctx *gin.Context)
 	Time() gin.HandlerFunc
 	GetJWT() gin.HandlerFunc
 }
@@ -57,6 +63,14 @@ func (u *User) New(ctx *gin.Context) {
 		return
 	}
 
+	json.AddressCountry = models.NormalizeCountry(json.AddressCountry)
+	json.Phone = normalizePhone(json.Phone, json.AddressCountry)
+	errs := models.Validate(&json)
+	if errs != nil {
+		u.UserError(ctx, "Error: invalid user", errs)
+		return
+	}
+
 	existing, err := u.Helper.GetByEmail(json.Email)
 	if err != nil || existing != nil {
 		u.UserError(ctx, "Error: user with that email already exists", json)
@@ -82,9 +96,7 @@ func (u *User) New(ctx *gin.Context) {
 		return
 	}
 
-	signedToken, _ := CreateJWT(user.ID)
-
-	ctx.Header("X-Auth", signedToken)
+	issueToken(ctx, user.ID)
 	u.Success(ctx, user)
 }
 
@@ -92,8 +104,14 @@ func (u *User) ViewAll(ctx *gin.Context) {
 	//Use paging when getting lists of users
 	offset, limit := u.GetPaging(ctx)
 
+	filter, err := GetFilter(ctx)
+	if err != nil {
+		u.UserError(ctx, err.Error(), nil)
+		return
+	}
+
 	//Query the database for all users
-	users, err := u.Helper.GetAll(offset, limit)
+	users, err := u.Helper.GetAll(offset, limit, filter)
 	if err != nil {
 		u.ServerError(ctx, err, users)
 		return
@@ -107,6 +125,100 @@ func (u *User) ViewAll(ctx *gin.Context) {
 	u.Success(ctx, users)
 }
 
+/*Search returns the users whose name, email or phone match the q query parameter, it is limited to admins*/
+func (u *User) Search(ctx *gin.Context) {
+	if !IsAdmin(ctx) {
+		forbidden(ctx, "Error: admin access required")
+		return
+	}
+
+	offset, limit := u.GetPaging(ctx)
+
+	query := ctx.Query("q")
+	if query == "" {
+		u.UserError(ctx, "Error: q is required", nil)
+		return
+	}
+
+	users, err := u.Helper.Search(query, offset, limit)
+	if err != nil {
+		u.ServerError(ctx, err, query)
+		return
+	}
+
+	//Don't pass the password hashes back
+	for _, user := range users {
+		user.PassHash = ""
+	}
+
+	u.Success(ctx, users)
+}
+
+/*ViewByEmail returns the user with exactly the email query parameter, it is limited to admins*/
+func (u *User) ViewByEmail(ctx *gin.Context) {
+	if !IsAdmin(ctx) {
+		forbidden(ctx, "Error: admin access required")
+		return
+	}
+
+	email := ctx.Query("email")
+	if email == "" {
+		u.UserError(ctx, "Error: email is required", nil)
+		return
+	}
+
+	user, err := u.Helper.GetByEmail(email)
+	if err != nil {
+		u.ServerError(ctx, err, email)
+		return
+	}
+
+	if user == nil {
+		u.NotFoundError(ctx, "Error: User with email "+email+" does not exist")
+		return
+	}
+
+	//Don't pass the password hash back
+	user.PassHash = ""
+
+	u.Success(ctx, user)
+}
+
+/*Batch returns the users with the IDs in the request, keyed by ID, with the IDs that weren't found marked*/
+func (u *User) Batch(ctx *gin.Context) {
+	var json models.BatchRequest
+	err := ctx.BindJSON(&json)
+	if err != nil {
+		u.UserError(ctx, "Error: Unable to parse json", err)
+		return
+	}
+
+	errs := json.Validate()
+	if errs != nil {
+		u.UserError(ctx, "Error: invalid batch", errs)
+		return
+	}
+
+	ids := json.Unique()
+	users, err := u.Helper.GetByIDs(ids)
+	if err != nil {
+		u.ServerError(ctx, err, ids)
+		return
+	}
+
+	results := make(map[string]*models.UserResult)
+	for _, id := range ids {
+		results[id] = &models.UserResult{}
+	}
+	for _, user := range users {
+		//Don't pass the password hashes back
+		user.PassHash = ""
+		results[user.ID.String()] = &models.UserResult{Found: true, User: user}
+	}
+
+	u.Success(ctx, results)
+}
+
 func (u *User) View(ctx *gin.Context) {
 	userID := ctx.Param("userId")
 
@@ -161,6 +273,10 @@ func (u *User) viewByID(ctx *gin.Context, id uuid.UUID) {
 	//Don't pass the password hash back
 	user.PassHash = ""
 
+	if notModified(ctx, user) {
+		return
+	}
+
 	u.Success(ctx, user)
 }
 
@@ -186,6 +302,7 @@ func (u *User) Update(ctx *gin.Context) {
 	}
 
 	// merge existing user to json so empty fields don't override
+	json.AddressCountry = models.NormalizeCountry(json.AddressCountry)
 	user.PassHash = ""
 	err = mergo.Merge(&json, user)
 	if err != nil {
@@ -193,6 +310,15 @@ func (u *User) Update(ctx *gin.Context) {
 		return
 	}
 
+	json.Phone = normalizePhone(json.Phone, json.AddressCountry)
+	json.PhoneVerified = user.PhoneVerified && json.Phone == user.Phone
+	json.SetPhotos()
+	errs := models.ValidateChanges(&json, user)
+	if errs != nil {
+		u.UserError(ctx, "Error: invalid user", errs)
+		return
+	}
+
 	//Update the user in the database
 	err = u.Helper.Update(&json, userId)
 	if err != nil {
@@ -251,9 +377,7 @@ func (u *User) Login(ctx *gin.Context) {
 	err = bcrypt.CompareHashAndPassword([]byte(tmpHash), []byte(json.PassHash))
 
 	if err == nil {
-		signedToken, _ := CreateJWT(user.ID)
-
-		ctx.Header("X-Auth", signedToken)
+		issueToken(ctx, user.ID)
 		u.Success(ctx, user)
 	} else {
 		u.UserError(ctx, "Incorrect login credentials", nil)
@@ -262,7 +386,7 @@ func (u *User) Login(ctx *gin.Context) {
 
 func (u *User) Upload(ctx *gin.Context) {
 	id := ctx.Param("userId")
-	file, headers, err := ctx.Request.FormFile("profile")
+	file, _, err := ctx.Request.FormFile("profile")
 	if err != nil {
 		u.ServerError(ctx, err, nil)
 		return
@@ -273,7 +397,13 @@ func (u *User) Upload(ctx *gin.Context) {
 	}
 	defer file.Close()
 
-	err = u.Helper.Profile(id, headers.Filename, file)
+	photo, err := helpers.ProcessPhoto(file)
+	if err != nil {
+		u.UserError(ctx, err.Error(), nil)
+		return
+	}
+
+	err = u.Helper.Profile(id, photo)
 	if err != nil {
 		u.ServerError(ctx, err, id)
 		return
@@ -282,6 +412,92 @@ func (u *User) Upload(ctx *gin.Context) {
 	u.Success(ctx, nil)
 }
 
+/*PresignPhoto returns a URL to upload the user's photo straight to S3, finished with CompletePhoto*/
+func (u *User) PresignPhoto(ctx *gin.Context) {
+	id := ctx.Param("userId")
+
+	var json models.UploadRequest
+	err := ctx.BindJSON(&json)
+	if err != nil {
+		u.UserError(ctx, "Error: Unable to parse json", err)
+		return
+	}
+
+	upload, err := u.Helper.PresignProfile(id, json.ContentType)
+	if isPhotoError(err) {
+		u.UserError(ctx, err.Error(), json)
+		return
+	}
+	if err != nil {
+		u.ServerError(ctx, err, id)
+		return
+	}
+
+	u.Success(ctx, upload)
+}
+
+/*CompletePhoto checks the photo uploaded to the presigned URL and makes it the user's photo*/
+func (u *User) CompletePhoto(ctx *gin.Context) {
+	id := ctx.Param("userId")
+
+	var json models.UploadComplete
+	err := ctx.BindJSON(&json)
+	if err != nil || json.Key == "" {
+		u.UserError(ctx, "Error: key is required", json)
+		return
+	}
+
+	err = u.Helper.CompleteProfile(id, json.Key)
+	if isPhotoError(err) {
+		u.UserError(ctx, err.Error(), json)
+		return
+	}
+	if err != nil {
+		u.ServerError(ctx, err, id)
+		return
+	}
+
+	u.Success(ctx, nil)
+}
+
+/*DeletePhoto removes the user's photo, leaving them with the generated avatar*/
+func (u *User) DeletePhoto(ctx *gin.Context) {
+	id := ctx.Param("userId")
+
+	user, err := u.Helper.GetByID(id)
+	if err != nil {
+		u.ServerError(ctx, err, id)
+		return
+	}
+	if user == nil {
+		u.NotFoundError(ctx, "Error: User with ID "+id+" does not exist")
+		return
+	}
+	if user.ProfileURL == "" {
+		u.NotFoundError(ctx, "Error: user doesn't have a photo")
+		return
+	}
+
+	err = u.Helper.RemoveProfile(id, user.ProfileURL)
+	if err != nil {
+		u.ServerError(ctx, err, id)
+		return
+	}
+
+	u.Success(ctx, nil)
+}
+
+/*TokenUser is the context key of the user issueToken sent a token for*/
+const TokenUser = "tokenUser"
+
+/*issueToken sends a new token for the user with id in the X-Auth header*/
+func issueToken(ctx *gin.Context, id uuid.UUID) {
+	signedToken, _ := CreateJWT(id)
+
+	ctx.Header("X-Auth", signedToken)
+	ctx.Set(TokenUser, id.String())
+}
+
 /*CreateJWT creates a new JSON Web Token that expires in 30 days*/
 func CreateJWT(id uuid.UUID) (string, error) {
 	claims := &handlers.ExpressoClaims{
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"github.com/pborman/uuid"
+	"gopkg.in/alexcesaro/statsd.v2"
+	"gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/ghmeier/bloodlines/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type WebhookI interface {
+	New(ctx *gin.Context)
+	ViewAll(ctx *gin.Context)
+	View(ctx *gin.Context)
+	Update(ctx *gin.Context)
+	Delete(ctx *gin.Context)
+	Deliveries(ctx *gin.Context)
+	Replay(ctx *gin.Context)
+	Time() gin.HandlerFunc
+	GetJWT() gin.HandlerFunc
+}
+
+// Webhook manages a roaster's webhook subscriptions and their deliveries. Only
+// the roaster's owner or an admin can see them, and a webhook's secret is only
+// returned when it's created.
+type Webhook struct {
+	*handlers.BaseHandler
+	Helper     helpers.WebhookI
+	Roaster    helpers.RoasterI
+	UserHelper helpers.UserI
+}
+
+func NewWebhook(ctx *handlers.GatewayContext) WebhookI {
+	stats := ctx.Stats.Clone(statsd.Prefix("api.webhook"))
+	return &Webhook{
+		BaseHandler: &handlers.BaseHandler{Stats: stats},
+		Helper:      helpers.NewWebhook(ctx.Sql),
+		Roaster:     helpers.NewRoaster(ctx.Sql, ctx.S3, ctx.Coinage),
+		UserHelper:  helpers.NewUser(ctx.Sql, ctx.S3),
+	}
+}
+
+/*New subscribes a URL to the roaster's events, the response holds the signing secret*/
+func (w *Webhook) New(ctx *gin.Context) {
+	var json models.WebhookRequest
+	err := ctx.BindJSON(&json)
+	if err != nil {
+		w.UserError(ctx, "Error: Unable to parse json", err)
+		return
+	}
+
+	errs := json.Validate()
+	if errs != nil {
+		w.UserError(ctx, "Error: invalid webhook", errs)
+		return
+	}
+
+	roaster, ok := w.authorize(ctx)
+	if !ok {
+		return
+	}
+
+	webhook, err := models.NewWebhook(roaster.ID, &json)
+	if err != nil {
+		w.ServerError(ctx, err, nil)
+		return
+	}
+
+	err = w.Helper.Insert(webhook)
+	if err != nil {
+		w.ServerError(ctx, err, webhook.ID)
+		return
+	}
+
+	w.Success(ctx, webhook)
+}
+
+func (w *Webhook) ViewAll(ctx *gin.Context) {
+	roaster, ok := w.authorize(ctx)
+	if !ok {
+		return
+	}
+
+	webhooks, err := w.Helper.GetByRoaster(roaster.ID.String())
+	if err != nil {
+		w.ServerError(ctx, err, roaster.ID)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+	w.Success(ctx, webhooks)
+}
+
+func (w *Webhook) View(ctx *gin.Context) {
+	webhook, ok := w.webhook(ctx)
+	if !ok {
+		return
+	}
+
+	webhook.Secret = ""
+	w.Success(ctx, webhook)
+}
+
+/*Update replaces the webhook's URL and events, its secret is only changed when one is given*/
+func (w *Webhook) Update(ctx *gin.Context) {
+	var json models.WebhookRequest
+	err := ctx.BindJSON(&json)
+	if err != nil {
+		w.UserError(ctx, "Error: Unable to parse json", err)
+		return
+	}
+
+	errs := json.Validate()
+	if errs != nil {
+		w.UserError(ctx, "Error: invalid webhook", errs)
+		return
+	}
+
+	webhook, ok := w.webhook(ctx)
+	if !ok {
+		return
+	}
+
+	err = webhook.Apply(&json)
+	if err == nil {
+		err = w.Helper.Update(webhook)
+	}
+	if err != nil {
+		w.ServerError(ctx, err, webhook.ID)
+		return
+	}
+
+	webhook.Secret = ""
+	w.Success(ctx, webhook)
+}
+
+/*Delete removes the webhook, deliveries that haven't been sent yet are dropped*/
+func (w *Webhook) Delete(ctx *gin.Context) {
+	webhook, ok := w.webhook(ctx)
+	if !ok {
+		return
+	}
+
+	err := w.Helper.Delete(webhook.ID.String())
+	if err != nil {
+		w.ServerError(ctx, err, webhook.ID)
+		return
+	}
+
+	w.Success(ctx, nil)
+}
+
+/*Deliveries returns a page of the webhook's delivery log, newest first*/
+func (w *Webhook) Deliveries(ctx *gin.Context) {
+	webhook, ok := w.webhook(ctx)
+	if !ok {
+		return
+	}
+
+	offset, limit := w.GetPaging(ctx)
+	deliveries, err := w.Helper.GetDeliveries(webhook.ID.String(), offset, limit)
+	if err != nil {
+		w.ServerError(ctx, err, webhook.ID)
+		return
+	}
+
+	w.Success(ctx, deliveries)
+}
+
+/*Replay sends a delivery again, whatever happened to it before*/
+func (w *Webhook) Replay(ctx *gin.Context) {
+	webhook, ok := w.webhook(ctx)
+	if !ok {
+		return
+	}
+
+	deliveryID := ctx.Param("deliveryId")
+	delivery, err := w.Helper.GetDelivery(deliveryID)
+	if err != nil {
+		w.ServerError(ctx, err, deliveryID)
+		return
+	}
+	if delivery == nil || !uuid.Equal(delivery.WebhookID, webhook.ID) {
+		w.NotFoundError(ctx, "Error: Delivery with ID "+deliveryID+" does not exist")
+		return
+	}
+
+	err = w.Helper.Replay(delivery)
+	if err != nil {
+		w.ServerError(ctx, err, deliveryID)
+		return
+	}
+
+	w.Success(ctx, delivery)
+}
+
+/*authorize loads the roaster in the path, writing an error unless the caller is its owner or an admin*/
+func (w *Webhook) authorize(ctx *gin.Context) (*models.Roaster, bool) {
+	roasterID := ctx.Param("roasterId")
+
+	roaster, err := w.Roaster.GetByID(roasterID)
+	if err != nil {
+		w.ServerError(ctx, err, roasterID)
+		return nil, false
+	}
+	if roaster == nil {
+		w.NotFoundError(ctx, "Error: Roaster with ID "+roasterID+" does not exist")
+		return nil, false
+	}
+
+	owner, err := w.UserHelper.GetByRoaster(roasterID)
+	if err != nil {
+		w.ServerError(ctx, err, roasterID)
+		return nil, false
+	}
+
+	if !IsAdmin(ctx) && (owner == nil || owner.ID.String() != ctx.Request.Header.Get("X-UserId")) {
+		forbidden(ctx, "Error: only the roaster's owner or an admin can do that")
+		return nil, false
+	}
+
+	return roaster, true
+}
+
+/*webhook loads the webhook in the path once the caller is authorized, it must belong to the roaster in the path*/
+func (w *Webhook) webhook(ctx *gin.Context) (*models.Webhook, bool) {
+	roaster, ok := w.authorize(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	webhookID := ctx.Param("webhookId")
+	webhook, err := w.Helper.GetByID(webhookID)
+	if err != nil {
+		w.ServerError(ctx, err, webhookID)
+		return nil, false
+	}
+	if webhook == nil || !uuid.Equal(webhook.RoasterID, roaster.ID) {
+		w.NotFoundError(ctx, "Error: Webhook with ID "+webhookID+" does not exist")
+		return nil, false
+	}
+
+	return webhook, true
+}
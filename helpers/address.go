@@ -0,0 +1,131 @@
+package helpers
+
+import (
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type AddressI interface {
+	GetByID(string) (*models.Address, error)
+	GetByUser(string) ([]*models.Address, error)
+	Insert(*models.Address) error
+	Update(*models.Address, string) error
+	Delete(string) error
+}
+
+type Address struct {
+	*baseHelper
+}
+
+func NewAddress(sql gateways.SQL) *Address {
+	return &Address{
+		baseHelper: &baseHelper{sql: sql},
+	}
+}
+
+func (a *Address) GetByID(id string) (*models.Address, error) {
+	rows, err := a.sql.Select("SELECT id, userId, label, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, defaultShipping, defaultBilling, createdAt, updatedAt FROM address WHERE id=?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := models.AddressFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	return addresses[0], nil
+}
+
+/*GetByUser returns every address belonging to the user, oldest first*/
+func (a *Address) GetByUser(userID string) ([]*models.Address, error) {
+	rows, err := a.sql.Select("SELECT id, userId, label, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, defaultShipping, defaultBilling, createdAt, updatedAt FROM address WHERE userId=? ORDER BY createdAt ASC", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.AddressFromSQL(rows)
+}
+
+func (a *Address) Insert(address *models.Address) error {
+	address.CreatedAt = now()
+	address.UpdatedAt = address.CreatedAt
+
+	err := a.clearDefaults(address)
+	if err != nil {
+		return err
+	}
+
+	err = a.sql.Modify(
+		"INSERT INTO address (id, userId, label, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, defaultShipping, defaultBilling, createdAt, updatedAt) VALUE (?,?,?,?,?,?,?,?,?,?,?,?,?)",
+		address.ID,
+		address.UserID,
+		address.Label,
+		address.AddressLine1,
+		address.AddressLine2,
+		address.AddressCity,
+		address.AddressState,
+		address.AddressZip,
+		address.AddressCountry,
+		address.DefaultShipping,
+		address.DefaultBilling,
+		address.CreatedAt,
+		address.UpdatedAt,
+	)
+
+	return err
+}
+
+func (a *Address) Update(address *models.Address, id string) error {
+	address.UpdatedAt = now()
+
+	err := a.clearDefaults(address)
+	if err != nil {
+		return err
+	}
+
+	err = a.sql.Modify(
+		"UPDATE address SET label=?, addressLine1=?, addressLine2=?, addressCity=?, addressState=?, addressZip=?, addressCountry=?, defaultShipping=?, defaultBilling=?, updatedAt=? WHERE id=?",
+		address.Label,
+		address.AddressLine1,
+		address.AddressLine2,
+		address.AddressCity,
+		address.AddressState,
+		address.AddressZip,
+		address.AddressCountry,
+		address.DefaultShipping,
+		address.DefaultBilling,
+		address.UpdatedAt,
+		id,
+	)
+
+	return err
+}
+
+func (a *Address) Delete(id string) error {
+	err := a.sql.Modify("DELETE FROM address WHERE id=?", id)
+	return err
+}
+
+/*clearDefaults unsets the defaults on the user's other addresses that address is taking over*/
+func (a *Address) clearDefaults(address *models.Address) error {
+	if address.DefaultShipping {
+		err := a.sql.Modify("UPDATE address SET defaultShipping=FALSE WHERE userId=? AND id<>?", address.UserID, address.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if address.DefaultBilling {
+		err := a.sql.Modify("UPDATE address SET defaultBilling=FALSE WHERE userId=? AND id<>?", address.UserID, address.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressGetByID(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	userID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	a := getMockAddress(s)
+
+	mock.ExpectQuery("SELECT id, userId, label, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, defaultShipping, defaultBilling, createdAt, updatedAt FROM address").
+		WithArgs(id.String()).
+		WillReturnRows(getAddressMockRows().AddRow(id.String(), userID.String(), "Home", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", true, false, time.Now(), time.Now()))
+
+	address, err := a.GetByID(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(id, address.ID)
+	assert.Equal(userID, address.UserID)
+	assert.Equal("Home", address.Label)
+	assert.Equal("AddressLine1", address.AddressLine1)
+	assert.Equal("AddressCountry", address.AddressCountry)
+	assert.True(address.DefaultShipping)
+	assert.False(address.DefaultBilling)
+}
+
+func TestAddressGetByIDDoesNotExist(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	a := getMockAddress(s)
+
+	mock.ExpectQuery("SELECT id, userId, label, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, defaultShipping, defaultBilling, createdAt, updatedAt FROM address").
+		WithArgs(id.String()).
+		WillReturnRows(getAddressMockRows())
+
+	address, err := a.GetByID(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Nil(address)
+}
+
+func TestAddressGetByUser(t *testing.T) {
+	assert := assert.New(t)
+
+	userID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	a := getMockAddress(s)
+
+	mock.ExpectQuery("SELECT id, userId, label, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, defaultShipping, defaultBilling, createdAt, updatedAt FROM address WHERE userId=\\? ORDER BY createdAt ASC").
+		WithArgs(userID.String()).
+		WillReturnRows(getAddressMockRows().
+			AddRow(uuid.New(), userID.String(), "Home", "", "", "", "", "", "", true, true, time.Now(), time.Now()).
+			AddRow(uuid.New(), userID.String(), "Work", "", "", "", "", "", "", false, false, time.Now(), time.Now()))
+
+	addresses, err := a.GetByUser(userID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(2, len(addresses))
+	assert.Equal("Work", addresses[1].Label)
+}
+
+func TestAddressGetByUserError(t *testing.T) {
+	assert := assert.New(t)
+
+	userID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	a := getMockAddress(s)
+
+	mock.ExpectQuery("SELECT id, userId, label, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, defaultShipping, defaultBilling, createdAt, updatedAt FROM address").
+		WithArgs(userID.String()).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	_, err := a.GetByUser(userID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestAddressInsert(t *testing.T) {
+	assert := assert.New(t)
+
+	address := getDefaultAddress()
+	s, mock, _ := sqlmock.New()
+	a := getMockAddress(s)
+
+	mock.ExpectPrepare("INSERT INTO address").
+		ExpectExec().
+		WithArgs(address.ID.String(), address.UserID.String(), address.Label, address.AddressLine1, address.AddressLine2, address.AddressCity, address.AddressState, address.AddressZip, address.AddressCountry, false, false, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := a.Insert(address)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.False(address.CreatedAt.IsZero())
+}
+
+func TestAddressInsertDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	address := getDefaultAddress()
+	address.DefaultShipping = true
+	address.DefaultBilling = true
+	s, mock, _ := sqlmock.New()
+	a := getMockAddress(s)
+
+	mock.ExpectPrepare("UPDATE address SET defaultShipping=FALSE").
+		ExpectExec().
+		WithArgs(address.UserID.String(), address.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("UPDATE address SET defaultBilling=FALSE").
+		ExpectExec().
+		WithArgs(address.UserID.String(), address.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("INSERT INTO address").
+		ExpectExec().
+		WithArgs(address.ID.String(), address.UserID.String(), address.Label, address.AddressLine1, address.AddressLine2, address.AddressCity, address.AddressState, address.AddressZip, address.AddressCountry, true, true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := a.Insert(address)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestAddressInsertDefaultsError(t *testing.T) {
+	assert := assert.New(t)
+
+	address := getDefaultAddress()
+	address.DefaultShipping = true
+	s, mock, _ := sqlmock.New()
+	a := getMockAddress(s)
+
+	mock.ExpectPrepare("UPDATE address SET defaultShipping=FALSE").
+		ExpectExec().
+		WithArgs(address.UserID.String(), address.ID.String()).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	err := a.Insert(address)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestAddressUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	address := getDefaultAddress()
+	s, mock, _ := sqlmock.New()
+	a := getMockAddress(s)
+
+	mock.ExpectPrepare("UPDATE address SET label=\\?").
+		ExpectExec().
+		WithArgs(address.Label, address.AddressLine1, address.AddressLine2, address.AddressCity, address.AddressState, address.AddressZip, address.AddressCountry, false, false, sqlmock.AnyArg(), address.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := a.Update(address, address.ID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestAddressDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	a := getMockAddress(s)
+
+	mock.ExpectPrepare("DELETE FROM address").
+		ExpectExec().
+		WithArgs(id.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := a.Delete(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func getDefaultAddress() *models.Address {
+	return models.NewAddress(uuid.NewUUID(), "Home", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry")
+}
+
+func getAddressMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "userId", "label", "addressLine1", "addressLine2", "addressCity", "addressState", "addressZip", "addressCountry", "defaultShipping", "defaultBilling", "createdAt", "updatedAt"})
+}
+
+func getMockAddress(s *sql.DB) *Address {
+	return NewAddress(&gateways.MySQL{DB: s})
+}
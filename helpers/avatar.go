@@ -0,0 +1,138 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+/*Avatar formats and sizes, PNG avatars can be requested at any size between the limits*/
+const (
+	AVATAR_SVG        = "svg"
+	AVATAR_PNG        = "png"
+	DefaultAvatarSize = 128
+	MinAvatarSize     = 16
+	MaxAvatarSize     = 512
+)
+
+/*avatarColors are the backgrounds avatars pick from, all dark enough for white initials*/
+var avatarColors = []color.NRGBA{
+	{0x6f, 0x4e, 0x37, 0xff},
+	{0x8d, 0x5b, 0x4c, 0xff},
+	{0x3e, 0x6b, 0x48, 0xff},
+	{0x2f, 0x5d, 0x7c, 0xff},
+	{0x5b, 0x4b, 0x8a, 0xff},
+	{0x9c, 0x3d, 0x54, 0xff},
+	{0x4a, 0x5c, 0x5e, 0xff},
+	{0xb0, 0x5a, 0x1e, 0xff},
+}
+
+// glyphs is a 5x7 pixel font for drawing initials into PNG avatars. Each row
+// is the low 5 bits of a byte, most significant bit on the left.
+var glyphs = map[rune][7]uint8{
+	'A': {0x0e, 0x11, 0x11, 0x1f, 0x11, 0x11, 0x11},
+	'B': {0x1e, 0x11, 0x11, 0x1e, 0x11, 0x11, 0x1e},
+	'C': {0x0e, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0e},
+	'D': {0x1e, 0x11, 0x11, 0x11, 0x11, 0x11, 0x1e},
+	'E': {0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x1f},
+	'F': {0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x10},
+	'G': {0x0e, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0f},
+	'H': {0x11, 0x11, 0x11, 0x1f, 0x11, 0x11, 0x11},
+	'I': {0x0e, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0e},
+	'J': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0c},
+	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1f},
+	'M': {0x11, 0x1b, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O': {0x0e, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e},
+	'P': {0x1e, 0x11, 0x11, 0x1e, 0x10, 0x10, 0x10},
+	'Q': {0x0e, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0d},
+	'R': {0x1e, 0x11, 0x11, 0x1e, 0x14, 0x12, 0x11},
+	'S': {0x0f, 0x10, 0x10, 0x0e, 0x01, 0x01, 0x1e},
+	'T': {0x1f, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e},
+	'V': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x04},
+	'W': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0a},
+	'X': {0x11, 0x11, 0x0a, 0x04, 0x0a, 0x11, 0x11},
+	'Y': {0x11, 0x11, 0x11, 0x0a, 0x04, 0x04, 0x04},
+	'Z': {0x1f, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1f},
+	'0': {0x0e, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0e},
+	'1': {0x04, 0x0c, 0x04, 0x04, 0x04, 0x04, 0x0e},
+	'2': {0x0e, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1f},
+	'3': {0x1f, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0e},
+	'4': {0x02, 0x06, 0x0a, 0x12, 0x1f, 0x02, 0x02},
+	'5': {0x1f, 0x10, 0x1e, 0x01, 0x01, 0x11, 0x0e},
+	'6': {0x06, 0x08, 0x10, 0x1e, 0x11, 0x11, 0x0e},
+	'7': {0x1f, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0e, 0x11, 0x11, 0x0e, 0x11, 0x11, 0x0e},
+	'9': {0x0e, 0x11, 0x11, 0x0f, 0x01, 0x02, 0x0c},
+}
+
+/*AvatarSVG draws initials over a background picked from seed, so the same seed always gets the same avatar*/
+func AvatarSVG(initials string, seed string) []byte {
+	c := avatarColor(seed)
+
+	return []byte(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 100 100"><rect width="100" height="100" fill="#%02x%02x%02x"/><text x="50" y="50" dy="0.35em" text-anchor="middle" font-family="Helvetica, Arial, sans-serif" font-size="42" fill="#ffffff">%s</text></svg>`,
+		DefaultAvatarSize, DefaultAvatarSize, c.R, c.G, c.B, html.EscapeString(initials),
+	))
+}
+
+// AvatarPNG draws the same avatar as AvatarSVG as a size pixel square PNG.
+// Initials are drawn with a small built in font, letters it doesn't have are
+// left out.
+func AvatarPNG(initials string, seed string, size int) ([]byte, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{avatarColor(seed)}, image.Point{}, draw.Src)
+
+	letters := make([][7]uint8, 0, len(initials))
+	for _, r := range initials {
+		if glyph, ok := glyphs[r]; ok {
+			letters = append(letters, glyph)
+		}
+	}
+
+	if len(letters) > 0 {
+		// Letters are 5 pixels wide with a 1 pixel gap, scaled up to fill
+		// about half of the avatar.
+		width := len(letters)*6 - 1
+		scale := size / 2 / width
+		if size/2/7 < scale {
+			scale = size / 2 / 7
+		}
+		if scale < 1 {
+			scale = 1
+		}
+
+		x0 := (size - width*scale) / 2
+		y0 := (size - 7*scale) / 2
+		for i, glyph := range letters {
+			for row := 0; row < 7; row++ {
+				for col := 0; col < 5; col++ {
+					if glyph[row]&(0x10>>uint(col)) == 0 {
+						continue
+					}
+
+					x := x0 + (i*6+col)*scale
+					y := y0 + row*scale
+					draw.Draw(img, image.Rect(x, y, x+scale, y+scale), &image.Uniform{color.White}, image.Point{}, draw.Src)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+func avatarColor(seed string) color.NRGBA {
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	return avatarColors[h.Sum32()%uint32(len(avatarColors))]
+}
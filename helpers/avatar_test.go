@@ -0,0 +1,69 @@
+package helpers
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAvatarSVG(t *testing.T) {
+	assert := assert.New(t)
+
+	svg := string(AvatarSVG("JL", "seed"))
+
+	assert.Contains(svg, ">JL</text>")
+	assert.Equal(svg, string(AvatarSVG("JL", "seed")))
+}
+
+func TestAvatarSVGEscapes(t *testing.T) {
+	assert := assert.New(t)
+
+	svg := string(AvatarSVG("<&", "seed"))
+
+	assert.Contains(svg, "&lt;&amp;")
+}
+
+func TestAvatarPNG(t *testing.T) {
+	assert := assert.New(t)
+
+	data, err := AvatarPNG("JL", "seed", 64)
+
+	assert.NoError(err)
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.NoError(err)
+	assert.Equal(image.Rect(0, 0, 64, 64), img.Bounds())
+
+	background := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA)
+	assert.Equal(avatarColor("seed"), background)
+	assert.True(hasWhite(img))
+
+	again, _ := AvatarPNG("JL", "seed", 64)
+	assert.Equal(data, again)
+}
+
+func TestAvatarPNGUnknownLetters(t *testing.T) {
+	assert := assert.New(t)
+
+	data, err := AvatarPNG("Ж", "seed", 32)
+
+	assert.NoError(err)
+	img, _ := png.Decode(bytes.NewReader(data))
+	assert.False(hasWhite(img))
+}
+
+func hasWhite(img image.Image) bool {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.NRGBAModel.Convert(img.At(x, y)) == (color.NRGBA{0xff, 0xff, 0xff, 0xff}) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,230 @@
+package helpers_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	mocks "github.com/jakelong95/TownCenter/_mocks/helpers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDispatcherRun(t *testing.T) {
+	assert := assert.New(t)
+
+	var got *http.Request
+	body := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := ioutil.ReadAll(r.Body)
+		got, body = r, string(raw)
+	}))
+	defer server.Close()
+
+	webhook := getMockWebhook(server.URL)
+	delivery := getMockDelivery(webhook)
+	d, webhookMock := getMockDispatcher()
+	webhookMock.On("ClaimDue", "runner", mock.AnythingOfType("time.Time"), helpers.WebhookBatch).Return([]*models.WebhookDelivery{delivery}, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("SaveDelivery", delivery).Return(nil)
+
+	attempted, err := d.Run()
+
+	assert.NoError(err)
+	assert.Equal(1, attempted)
+	assert.Equal(models.DELIVERY_SUCCEEDED, delivery.Status)
+	assert.Equal(1, delivery.Attempts)
+	assert.Equal(200, delivery.ResponseCode)
+	assert.Equal(delivery.Payload, body)
+	assert.Equal("POST", got.Method)
+	assert.Equal(models.USER_CREATED, got.Header.Get(helpers.HEADER_EVENT))
+	assert.Equal(delivery.ID.String(), got.Header.Get(helpers.HEADER_DELIVERY))
+	timestamp, _ := strconv.ParseInt(got.Header.Get(helpers.HEADER_TIMESTAMP), 10, 64)
+	assert.Equal(models.SignWebhook(webhook.Secret, timestamp, []byte(body)), got.Header.Get(helpers.HEADER_SIGNATURE))
+}
+
+func TestDispatcherRunRetries(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	webhook := getMockWebhook(server.URL)
+	delivery := getMockDelivery(webhook)
+	delivery.Attempts = 2
+	d, webhookMock := getMockDispatcher()
+	webhookMock.On("ClaimDue", "runner", mock.AnythingOfType("time.Time"), helpers.WebhookBatch).Return([]*models.WebhookDelivery{delivery}, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("SaveDelivery", delivery).Return(nil)
+
+	before := time.Now()
+	_, err := d.Run()
+
+	assert.NoError(err)
+	assert.Equal(models.DELIVERY_PENDING, delivery.Status)
+	assert.Equal(3, delivery.Attempts)
+	assert.Equal(503, delivery.ResponseCode)
+	assert.Equal("unexpected status 503", delivery.Error)
+	assert.WithinDuration(before.Add(4*helpers.WebhookBackoff), delivery.NextAttemptAt, 2*time.Second)
+}
+
+func TestDispatcherRunGivesUp(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhook := getMockWebhook(server.URL)
+	delivery := getMockDelivery(webhook)
+	delivery.Attempts = helpers.WebhookMaxAttempts - 1
+	d, webhookMock := getMockDispatcher()
+	webhookMock.On("ClaimDue", "runner", mock.AnythingOfType("time.Time"), helpers.WebhookBatch).Return([]*models.WebhookDelivery{delivery}, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("SaveDelivery", delivery).Return(nil)
+
+	_, err := d.Run()
+
+	assert.NoError(err)
+	assert.Equal(models.DELIVERY_FAILED, delivery.Status)
+	assert.Equal(helpers.WebhookMaxAttempts, delivery.Attempts)
+}
+
+func TestDispatcherRunUnreachable(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	webhook := getMockWebhook(server.URL)
+	server.Close()
+
+	delivery := getMockDelivery(webhook)
+	d, webhookMock := getMockDispatcher()
+	webhookMock.On("ClaimDue", "runner", mock.AnythingOfType("time.Time"), helpers.WebhookBatch).Return([]*models.WebhookDelivery{delivery}, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("SaveDelivery", delivery).Return(nil)
+
+	_, err := d.Run()
+
+	assert.NoError(err)
+	assert.Equal(models.DELIVERY_PENDING, delivery.Status)
+	assert.Equal(1, delivery.Attempts)
+	assert.Equal(0, delivery.ResponseCode)
+	assert.NotEqual("", delivery.Error)
+}
+
+func TestDispatcherRunPrivateAddress(t *testing.T) {
+	assert := assert.New(t)
+
+	reached := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer server.Close()
+
+	webhook := getMockWebhook(server.URL)
+	delivery := getMockDelivery(webhook)
+	d, webhookMock := getMockDispatcher()
+	d.Client = helpers.NewDispatcher(nil).Client
+	webhookMock.On("ClaimDue", "runner", mock.AnythingOfType("time.Time"), helpers.WebhookBatch).Return([]*models.WebhookDelivery{delivery}, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("SaveDelivery", delivery).Return(nil)
+
+	_, err := d.Run()
+
+	assert.NoError(err)
+	assert.False(reached)
+	assert.Equal(models.DELIVERY_PENDING, delivery.Status)
+	assert.Contains(delivery.Error, helpers.ErrWebhookAddress.Error())
+}
+
+func TestDispatcherRunRedirect(t *testing.T) {
+	assert := assert.New(t)
+
+	reached := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	webhook := getMockWebhook(server.URL)
+	delivery := getMockDelivery(webhook)
+	d, webhookMock := getMockDispatcher()
+	d.Client.CheckRedirect = helpers.NewDispatcher(nil).Client.CheckRedirect
+	webhookMock.On("ClaimDue", "runner", mock.AnythingOfType("time.Time"), helpers.WebhookBatch).Return([]*models.WebhookDelivery{delivery}, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("SaveDelivery", delivery).Return(nil)
+
+	_, err := d.Run()
+
+	assert.NoError(err)
+	assert.False(reached)
+	assert.Equal(models.DELIVERY_PENDING, delivery.Status)
+	assert.Contains(delivery.Error, helpers.ErrWebhookRedirect.Error())
+}
+
+func TestDispatcherRunDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	webhook := getMockWebhook("http://localhost:1")
+	webhook.Active = false
+	first, second := getMockDelivery(webhook), getMockDelivery(webhook)
+	d, webhookMock := getMockDispatcher()
+	webhookMock.On("ClaimDue", "runner", mock.AnythingOfType("time.Time"), helpers.WebhookBatch).Return([]*models.WebhookDelivery{first, second}, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil).Once()
+	webhookMock.On("SaveDelivery", mock.AnythingOfType("*models.WebhookDelivery")).Return(nil)
+
+	attempted, err := d.Run()
+
+	assert.NoError(err)
+	assert.Equal(2, attempted)
+	assert.Equal(models.DELIVERY_FAILED, first.Status)
+	assert.Equal(0, first.Attempts)
+	assert.Equal("webhook is disabled", first.Error)
+	webhookMock.AssertExpectations(t)
+}
+
+func TestDispatcherRunError(t *testing.T) {
+	assert := assert.New(t)
+
+	d, webhookMock := getMockDispatcher()
+	webhookMock.On("ClaimDue", "runner", mock.AnythingOfType("time.Time"), helpers.WebhookBatch).Return(nil, fmt.Errorf("some error"))
+
+	attempted, err := d.Run()
+
+	assert.Error(err)
+	assert.Equal(0, attempted)
+}
+
+func getMockWebhook(url string) *models.Webhook {
+	webhook, _ := models.NewWebhook(uuid.NewUUID(), &models.WebhookRequest{URL: url, Events: []string{models.USER_CREATED}})
+	return webhook
+}
+
+func getMockDelivery(webhook *models.Webhook) *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		ID:        uuid.NewUUID(),
+		WebhookID: webhook.ID,
+		EventID:   uuid.NewUUID(),
+		Type:      models.USER_CREATED,
+		Payload:   `{"type":"user.created"}`,
+		Status:    models.DELIVERY_PENDING,
+	}
+}
+
+func getMockDispatcher() (*helpers.Dispatcher, *mocks.WebhookI) {
+	webhookMock := new(mocks.WebhookI)
+	return &helpers.Dispatcher{Webhook: webhookMock, Client: &http.Client{Timeout: time.Second}, Runner: "runner"}, webhookMock
+}
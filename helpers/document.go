@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+/*DocumentExpiration is how long the links to a roaster's documents work for*/
+const DocumentExpiration = 5 * time.Minute
+
+type DocumentI interface {
+	GetByRoaster(string) ([]*models.RoasterDocument, error)
+	Insert(*models.RoasterDocument, multipart.File) error
+}
+
+type Document struct {
+	*baseHelper
+	S3 gateways.S3
+}
+
+func NewDocument(sql gateways.SQL, s3 gateways.S3) *Document {
+	return &Document{
+		baseHelper: &baseHelper{sql: sql},
+		S3:         s3,
+	}
+}
+
+/*GetByRoaster returns the roaster's verification documents, oldest first, with links to download them*/
+func (d *Document) GetByRoaster(roasterID string) ([]*models.RoasterDocument, error) {
+	rows, err := d.sql.Select("SELECT id, roasterId, kind, filename, url, createdAt FROM roasterDocument WHERE roasterId=? ORDER BY createdAt ASC", roasterID)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := models.RoasterDocumentFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.sign(documents...)
+	if err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// Insert uploads body to S3 as a private file and records it as one of the
+// roaster's documents. The url column holds the file's key, documents are
+// only ever read through the links sign makes.
+func (d *Document) Insert(document *models.RoasterDocument, body multipart.File) error {
+	store, err := townCenterS3(d.S3)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("documents/%s-%s", document.ID.String(), document.Filename)
+	err = store.UploadPrivate(key, body)
+	if err != nil {
+		return err
+	}
+
+	document.Key = key
+	document.CreatedAt = now()
+
+	err = d.sql.Modify(
+		"INSERT INTO roasterDocument (id, roasterId, kind, filename, url, createdAt) VALUE (?,?,?,?,?,?)",
+		document.ID,
+		document.RoasterID,
+		document.Kind,
+		document.Filename,
+		document.Key,
+		document.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return d.sign(document)
+}
+
+/*sign sets each document's Url to a link that downloads it for DocumentExpiration*/
+func (d *Document) sign(documents ...*models.RoasterDocument) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	store, err := townCenterS3(d.S3)
+	if err != nil {
+		return err
+	}
+
+	for _, document := range documents {
+		document.Url, err = store.PresignDownload(document.Key, document.Filename, DocumentExpiration)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
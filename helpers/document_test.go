@@ -0,0 +1,113 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	tmocks "github.com/jakelong95/TownCenter/_mocks"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentGetByRoaster(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	d := getMockDocument(s)
+	sMock := &tmocks.S3{}
+	d.S3 = sMock
+
+	mock.ExpectQuery("SELECT id, roasterId, kind, filename, url, createdAt FROM roasterDocument WHERE roasterId=\\? ORDER BY createdAt ASC").
+		WithArgs(roasterID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "roasterId", "kind", "filename", "url", "createdAt"}).
+			AddRow(uuid.New(), roasterID.String(), models.DOCUMENT_BUSINESS_LICENSE, "license.pdf", "documents/1-license.pdf", time.Now()))
+	sMock.On("PresignDownload", "documents/1-license.pdf", "license.pdf", DocumentExpiration).Return("test.com/documents/1-license.pdf?signed", nil)
+
+	documents, err := d.GetByRoaster(roasterID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, len(documents))
+	assert.Equal(models.DOCUMENT_BUSINESS_LICENSE, documents[0].Kind)
+	assert.Equal("license.pdf", documents[0].Filename)
+	assert.Equal("test.com/documents/1-license.pdf?signed", documents[0].Url)
+}
+
+func TestDocumentGetByRoasterPresignError(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	d := getMockDocument(s)
+	sMock := &tmocks.S3{}
+	d.S3 = sMock
+
+	mock.ExpectQuery("SELECT id, roasterId, kind, filename, url, createdAt FROM roasterDocument").
+		WithArgs(roasterID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "roasterId", "kind", "filename", "url", "createdAt"}).
+			AddRow(uuid.New(), roasterID.String(), models.DOCUMENT_BUSINESS_LICENSE, "license.pdf", "documents/1-license.pdf", time.Now()))
+	sMock.On("PresignDownload", "documents/1-license.pdf", "license.pdf", DocumentExpiration).Return("", fmt.Errorf("some error"))
+
+	_, err := d.GetByRoaster(roasterID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestDocumentInsert(t *testing.T) {
+	assert := assert.New(t)
+
+	document := models.NewRoasterDocument(uuid.NewUUID(), models.DOCUMENT_TAX_ID, "ein.pdf")
+	s, mock, _ := sqlmock.New()
+	d := getMockDocument(s)
+	sMock := &tmocks.S3{}
+	d.S3 = sMock
+	file := &os.File{}
+	key := fmt.Sprintf("documents/%s-%s", document.ID.String(), "ein.pdf")
+
+	sMock.On("UploadPrivate", key, file).Return(nil)
+	mock.ExpectPrepare("INSERT INTO roasterDocument").
+		ExpectExec().
+		WithArgs(document.ID.String(), document.RoasterID.String(), models.DOCUMENT_TAX_ID, "ein.pdf", key, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sMock.On("PresignDownload", key, "ein.pdf", DocumentExpiration).Return("test.com/"+key+"?signed", nil)
+
+	err := d.Insert(document, file)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(key, document.Key)
+	assert.Equal("test.com/"+key+"?signed", document.Url)
+	sMock.AssertNotCalled(t, "Upload", "documents", fmt.Sprintf("%s-%s", document.ID.String(), "ein.pdf"), file)
+}
+
+func TestDocumentInsertUploadError(t *testing.T) {
+	assert := assert.New(t)
+
+	document := models.NewRoasterDocument(uuid.NewUUID(), models.DOCUMENT_TAX_ID, "ein.pdf")
+	s, mock, _ := sqlmock.New()
+	d := getMockDocument(s)
+	sMock := &tmocks.S3{}
+	d.S3 = sMock
+	file := &os.File{}
+
+	sMock.On("UploadPrivate", fmt.Sprintf("documents/%s-%s", document.ID.String(), "ein.pdf"), file).
+		Return(fmt.Errorf("some error"))
+
+	err := d.Insert(document, file)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func getMockDocument(s *sql.DB) *Document {
+	return NewDocument(&gateways.MySQL{DB: s}, nil)
+}
@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+/*ExportBatch is how many records an export reads from the database at a time*/
+const ExportBatch = 500
+
+type ExportI interface {
+	Users(*models.ListFilter, func(*models.User) error) error
+	Roasters(*models.ListFilter, func(*models.Roaster) error) error
+}
+
+// Export reads whole tables in batches ordered by id, each batch carrying on
+// after the last id of the one before, so only a batch is in memory at a
+// time and no query runs for the length of the export.
+type Export struct {
+	*baseHelper
+}
+
+func NewExport(sql gateways.SQL) *Export {
+	return &Export{baseHelper: &baseHelper{sql: sql}}
+}
+
+/*Users calls each with every user matching filter, stopping at the first error*/
+func (e *Export) Users(filter *models.ListFilter, each func(*models.User) error) error {
+	after := ""
+	for {
+		where, args := exportClause(filter, after)
+		rows, err := e.sql.Select("SELECT id, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user"+where+" ORDER BY id ASC LIMIT ?", args...)
+		if err != nil {
+			return err
+		}
+
+		users, err := models.ExportUserFromSQL(rows)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			err = each(user)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(users) < ExportBatch {
+			return nil
+		}
+		after = users[len(users)-1].ID.String()
+	}
+}
+
+/*Roasters calls each with every roaster matching filter, stopping at the first error*/
+func (e *Export) Roasters(filter *models.ListFilter, each func(*models.Roaster) error) error {
+	after := ""
+	for {
+		where, args := exportClause(filter, after)
+		rows, err := e.sql.Select("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster"+where+" ORDER BY id ASC LIMIT ?", args...)
+		if err != nil {
+			return err
+		}
+
+		roasters, err := models.RoasterFromSQL(rows)
+		if err != nil {
+			return err
+		}
+
+		for _, roaster := range roasters {
+			err = each(roaster)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(roasters) < ExportBatch {
+			return nil
+		}
+		after = roasters[len(roasters)-1].ID.String()
+	}
+}
+
+func exportClause(filter *models.ListFilter, after string) (string, []interface{}) {
+	where, args := filterClause(filter)
+	if where == "" {
+		where = " WHERE id>?"
+	} else {
+		where += " AND id>?"
+	}
+
+	return where, append(args, after, ExportBatch)
+}
@@ -0,0 +1,152 @@
+package helpers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportUsers(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	e := NewExport(&gateways.MySQL{DB: s})
+
+	mock.ExpectQuery("SELECT id, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user WHERE id>\\? ORDER BY id ASC LIMIT \\?").
+		WithArgs("", ExportBatch).
+		WillReturnRows(getExportUserMockRows().
+			AddRow(uuid.New(), "FirstName", "LastName", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, "", time.Now(), time.Now()).
+			AddRow(uuid.New(), "FirstName", "LastName", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, "", time.Now(), time.Now()))
+
+	users := make([]*models.User, 0)
+	err := e.Users(nil, func(user *models.User) error {
+		users = append(users, user)
+		return nil
+	})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(2, len(users))
+	assert.Equal("", users[0].PassHash)
+	assert.Equal("FirstName", users[0].FirstName)
+	assert.Equal("AddressCountry", users[0].AddressCountry)
+}
+
+func TestExportUsersBatches(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	e := NewExport(&gateways.MySQL{DB: s})
+	after := time.Now()
+
+	rows := getExportUserMockRows()
+	last := ""
+	for i := 0; i < ExportBatch; i++ {
+		last = fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+		rows = rows.AddRow(last, "FirstName", "LastName", "Email", "", false, "", "", "", "", "", "", nil, "", time.Now(), time.Now())
+	}
+	mock.ExpectQuery("SELECT (.+) FROM user WHERE createdAt>=\\? AND id>\\? ORDER BY id ASC LIMIT \\?").
+		WithArgs(after.UTC(), "", ExportBatch).
+		WillReturnRows(rows)
+	mock.ExpectQuery("SELECT (.+) FROM user WHERE createdAt>=\\? AND id>\\? ORDER BY id ASC LIMIT \\?").
+		WithArgs(after.UTC(), last, ExportBatch).
+		WillReturnRows(getExportUserMockRows())
+
+	count := 0
+	err := e.Users(&models.ListFilter{CreatedAfter: after}, func(user *models.User) error {
+		count++
+		return nil
+	})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(ExportBatch, count)
+}
+
+func TestExportUsersStops(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	e := NewExport(&gateways.MySQL{DB: s})
+
+	mock.ExpectQuery("SELECT (.+) FROM user").
+		WithArgs("", ExportBatch).
+		WillReturnRows(getExportUserMockRows().
+			AddRow(uuid.New(), "FirstName", "LastName", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, "", time.Now(), time.Now()).
+			AddRow(uuid.New(), "FirstName", "LastName", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, "", time.Now(), time.Now()))
+
+	count := 0
+	err := e.Users(nil, func(user *models.User) error {
+		count++
+		return fmt.Errorf("This is an error")
+	})
+
+	assert.Error(err)
+	assert.Equal(1, count)
+}
+
+func TestExportUsersError(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	e := NewExport(&gateways.MySQL{DB: s})
+
+	mock.ExpectQuery("SELECT (.+) FROM user").
+		WithArgs("", ExportBatch).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	err := e.Users(nil, func(user *models.User) error { return nil })
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestExportRoasters(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	e := NewExport(&gateways.MySQL{DB: s})
+
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE status=\\? AND id>\\? ORDER BY id ASC LIMIT \\?").
+		WithArgs(models.STATUS_ACTIVE, "", ExportBatch).
+		WillReturnRows(getRoasterMockRows().
+			AddRow(uuid.New(), "Name", "name", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "", "", nil, nil, models.STATUS_ACTIVE, time.Now(), time.Now()))
+
+	roasters := make([]*models.Roaster, 0)
+	err := e.Roasters(&models.ListFilter{Status: models.STATUS_ACTIVE}, func(roaster *models.Roaster) error {
+		roasters = append(roasters, roaster)
+		return nil
+	})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, len(roasters))
+	assert.Equal("name", roasters[0].Slug)
+}
+
+func TestExportRoastersError(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	e := NewExport(&gateways.MySQL{DB: s})
+
+	mock.ExpectQuery("SELECT (.+) FROM roaster").
+		WithArgs("", ExportBatch).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	err := e.Roasters(nil, func(roaster *models.Roaster) error { return nil })
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func getExportUserMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "firstName", "lastName", "email", "phone", "phoneVerified", "addressLine1", "addressLine2", "addressCity", "addressState", "addressZip", "addressCountry", "roasterId", "profileUrl", "createdAt", "updatedAt"})
+}
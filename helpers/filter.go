@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"strings"
+
+	"github.com/jakelong95/TownCenter/models"
+)
+
+/*filterClause builds the WHERE clause and arguments for the given filter, or an empty clause when nothing is set*/
+func filterClause(filter *models.ListFilter) (string, []interface{}) {
+	args := make([]interface{}, 0)
+	if filter == nil {
+		return "", args
+	}
+
+	conditions := make([]string, 0)
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, "createdAt>=?")
+		args = append(args, filter.CreatedAfter.UTC())
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, "createdAt<?")
+		args = append(args, filter.CreatedBefore.UTC())
+	}
+	if !filter.UpdatedSince.IsZero() {
+		conditions = append(conditions, "updatedAt>=?")
+		args = append(args, filter.UpdatedSince.UTC())
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status=?")
+		args = append(args, filter.Status)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
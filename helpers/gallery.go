@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"mime/multipart"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type GalleryI interface {
+	GetByID(string) (*models.GalleryImage, error)
+	GetByRoaster(string) ([]*models.GalleryImage, error)
+	Insert(*models.GalleryImage, string, multipart.File) error
+	Update(*models.GalleryImage, string) error
+	Reorder(string, []string) error
+	Delete(string) error
+}
+
+type Gallery struct {
+	*baseHelper
+	S3 gateways.S3
+}
+
+func NewGallery(sql gateways.SQL, s3 gateways.S3) *Gallery {
+	return &Gallery{
+		baseHelper: &baseHelper{sql: sql},
+		S3:         s3,
+	}
+}
+
+func (g *Gallery) GetByID(id string) (*models.GalleryImage, error) {
+	rows, err := g.sql.Select("SELECT id, roasterId, url, caption, position, createdAt, updatedAt FROM roasterImage WHERE id=?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := models.GalleryImageFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	return images[0], nil
+}
+
+/*GetByRoaster returns the roaster's gallery in display order*/
+func (g *Gallery) GetByRoaster(roasterID string) ([]*models.GalleryImage, error) {
+	rows, err := g.sql.Select("SELECT id, roasterId, url, caption, position, createdAt, updatedAt FROM roasterImage WHERE roasterId=? ORDER BY position ASC, createdAt ASC", roasterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.GalleryImageFromSQL(rows)
+}
+
+/*Insert uploads body to S3 and adds it to the gallery with image's caption and position*/
+func (g *Gallery) Insert(image *models.GalleryImage, name string, body multipart.File) error {
+	url, err := upload(g.S3, "gallery", image.ID.String(), name, body)
+	if err != nil {
+		return err
+	}
+
+	image.Url = url
+	image.CreatedAt = now()
+	image.UpdatedAt = image.CreatedAt
+
+	err = g.sql.Modify(
+		"INSERT INTO roasterImage (id, roasterId, url, caption, position, createdAt, updatedAt) VALUE (?,?,?,?,?,?,?)",
+		image.ID,
+		image.RoasterID,
+		image.Url,
+		image.Caption,
+		image.Position,
+		image.CreatedAt,
+		image.UpdatedAt,
+	)
+
+	return err
+}
+
+/*Update changes the caption of the image*/
+func (g *Gallery) Update(image *models.GalleryImage, id string) error {
+	image.UpdatedAt = now()
+
+	err := g.sql.Modify("UPDATE roasterImage SET caption=?, updatedAt=? WHERE id=?", image.Caption, image.UpdatedAt, id)
+	return err
+}
+
+/*Reorder positions the roaster's images in the order of ids*/
+func (g *Gallery) Reorder(roasterID string, ids []string) error {
+	updatedAt := now()
+	for i, id := range ids {
+		err := g.sql.Modify("UPDATE roasterImage SET position=?, updatedAt=? WHERE id=? AND roasterId=?", i, updatedAt, id, roasterID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*Delete removes the image from the gallery and its file from S3*/
+func (g *Gallery) Delete(id string) error {
+	image, err := g.GetByID(id)
+	if err != nil || image == nil {
+		return err
+	}
+
+	err = g.sql.Modify("DELETE FROM roasterImage WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+
+	store, err := townCenterS3(g.S3)
+	if err != nil {
+		return err
+	}
+
+	return store.Delete(image.Url)
+}
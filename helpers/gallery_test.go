@@ -0,0 +1,272 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	mocks "github.com/ghmeier/bloodlines/_mocks/gateways"
+	"github.com/ghmeier/bloodlines/gateways"
+	tmocks "github.com/jakelong95/TownCenter/_mocks"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGalleryGetByID(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	roasterID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, url, caption, position, createdAt, updatedAt FROM roasterImage WHERE id=\\?").
+		WithArgs(id.String()).
+		WillReturnRows(getGalleryMockRows().AddRow(id.String(), roasterID.String(), "test.com", "Our roaster", 2, time.Now(), time.Now()))
+
+	image, err := g.GetByID(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(id, image.ID)
+	assert.Equal(roasterID, image.RoasterID)
+	assert.Equal("Our roaster", image.Caption)
+	assert.Equal(2, image.Position)
+}
+
+func TestGalleryGetByIDDoesNotExist(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, url, caption, position, createdAt, updatedAt FROM roasterImage").
+		WithArgs(id.String()).
+		WillReturnRows(getGalleryMockRows())
+
+	image, err := g.GetByID(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Nil(image)
+}
+
+func TestGalleryGetByRoaster(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, url, caption, position, createdAt, updatedAt FROM roasterImage WHERE roasterId=\\? ORDER BY position ASC, createdAt ASC").
+		WithArgs(roasterID.String()).
+		WillReturnRows(getGalleryMockRows().
+			AddRow(uuid.New(), roasterID.String(), "one.com", "", 0, time.Now(), time.Now()).
+			AddRow(uuid.New(), roasterID.String(), "two.com", "", 1, time.Now(), time.Now()))
+
+	images, err := g.GetByRoaster(roasterID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(2, len(images))
+	assert.Equal("two.com", images[1].Url)
+}
+
+func TestGalleryGetByRoasterError(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, url, caption, position, createdAt, updatedAt FROM roasterImage").
+		WithArgs(roasterID.String()).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	_, err := g.GetByRoaster(roasterID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestGalleryInsert(t *testing.T) {
+	assert := assert.New(t)
+
+	image := models.NewGalleryImage(uuid.NewUUID(), "Our roaster", 3)
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+	sMock := &mocks.S3{}
+	g.S3 = sMock
+	file := &os.File{}
+
+	sMock.On("Upload", "gallery", fmt.Sprintf("%s-%s", image.ID.String(), "test"), file).
+		Return("test.com", nil)
+	mock.ExpectPrepare("INSERT INTO roasterImage").
+		ExpectExec().
+		WithArgs(image.ID.String(), image.RoasterID.String(), "test.com", "Our roaster", 3, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := g.Insert(image, "test", file)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal("test.com", image.Url)
+	assert.False(image.CreatedAt.IsZero())
+}
+
+func TestGalleryInsertUploadError(t *testing.T) {
+	assert := assert.New(t)
+
+	image := models.NewGalleryImage(uuid.NewUUID(), "", 0)
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+	sMock := &mocks.S3{}
+	g.S3 = sMock
+	file := &os.File{}
+
+	sMock.On("Upload", "gallery", fmt.Sprintf("%s-%s", image.ID.String(), "test"), file).
+		Return("", fmt.Errorf("some error"))
+
+	err := g.Insert(image, "test", file)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestGalleryUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	image := models.NewGalleryImage(uuid.NewUUID(), "New caption", 0)
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+
+	mock.ExpectPrepare("UPDATE roasterImage SET caption=\\?, updatedAt=\\? WHERE id=\\?").
+		ExpectExec().
+		WithArgs("New caption", sqlmock.AnyArg(), image.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := g.Update(image, image.ID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestGalleryReorder(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.New()
+	ids := []string{uuid.New(), uuid.New()}
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+
+	for i, id := range ids {
+		mock.ExpectPrepare("UPDATE roasterImage SET position=\\?").
+			ExpectExec().
+			WithArgs(i, sqlmock.AnyArg(), id, roasterID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+
+	err := g.Reorder(roasterID, ids)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestGalleryReorderError(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.New()
+	ids := []string{uuid.New(), uuid.New()}
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+
+	mock.ExpectPrepare("UPDATE roasterImage SET position=\\?").
+		ExpectExec().
+		WithArgs(0, sqlmock.AnyArg(), ids[0], roasterID).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	err := g.Reorder(roasterID, ids)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestGalleryDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+	sMock := &tmocks.S3{}
+	g.S3 = sMock
+
+	mock.ExpectQuery("SELECT id, roasterId, url, caption, position, createdAt, updatedAt FROM roasterImage WHERE id=\\?").
+		WithArgs(id).
+		WillReturnRows(getGalleryMockRows().AddRow(id, uuid.New(), "test.com/gallery/1-test.png", "", 0, time.Now(), time.Now()))
+	mock.ExpectPrepare("DELETE FROM roasterImage").
+		ExpectExec().
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sMock.On("Delete", "test.com/gallery/1-test.png").Return(nil)
+
+	err := g.Delete(id)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	sMock.AssertCalled(t, "Delete", "test.com/gallery/1-test.png")
+	assert.NoError(err)
+}
+
+func TestGalleryDeleteDoesNotExist(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, url, caption, position, createdAt, updatedAt FROM roasterImage").
+		WithArgs(id).
+		WillReturnRows(getGalleryMockRows())
+
+	err := g.Delete(id)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestGalleryDeleteError(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	g := getMockGallery(s)
+	sMock := &tmocks.S3{}
+	g.S3 = sMock
+
+	mock.ExpectQuery("SELECT id, roasterId, url, caption, position, createdAt, updatedAt FROM roasterImage").
+		WithArgs(id).
+		WillReturnRows(getGalleryMockRows().AddRow(id, uuid.New(), "test.com/gallery/1-test.png", "", 0, time.Now(), time.Now()))
+	mock.ExpectPrepare("DELETE FROM roasterImage").
+		ExpectExec().
+		WithArgs(id).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	err := g.Delete(id)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	sMock.AssertNotCalled(t, "Delete", "test.com/gallery/1-test.png")
+	assert.Error(err)
+}
+
+func getGalleryMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "roasterId", "url", "caption", "position", "createdAt", "updatedAt"})
+}
+
+func getMockGallery(s *sql.DB) *Gallery {
+	return NewGallery(&gateways.MySQL{DB: s}, nil)
+}
@@ -0,0 +1,100 @@
+package helpers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+// Responses are replayed to retries for IdempotencyWindow. A key whose request
+// hasn't finished within IdempotencyLock is assumed to belong to a request
+// that died, and can be claimed again.
+const (
+	IdempotencyWindow = 24 * time.Hour
+	IdempotencyLock   = time.Minute
+)
+
+type IdempotencyI interface {
+	Claim(string, string, string) (*models.IdempotencyRecord, bool, error)
+	Save(*models.IdempotencyRecord) error
+	Release(*models.IdempotencyRecord) error
+}
+
+type Idempotency struct {
+	*baseHelper
+}
+
+func NewIdempotency(sql gateways.SQL) *Idempotency {
+	return &Idempotency{
+		baseHelper: &baseHelper{sql: sql},
+	}
+}
+
+// Claim takes the key for a request in scope, returning the key's record and
+// whether the caller now holds it. When another request already holds the key
+// the record is theirs, with their response once they've finished.
+func (i *Idempotency) Claim(key, scope, requestHash string) (*models.IdempotencyRecord, bool, error) {
+	at := now()
+	err := i.sql.Modify("DELETE FROM idempotencyKey WHERE expiresAt<? OR (responseCode=0 AND createdAt<?)", at, at.Add(-IdempotencyLock))
+	if err != nil {
+		return nil, false, err
+	}
+
+	record := models.NewIdempotencyRecord(key, scope, requestHash)
+	record.CreatedAt = at
+	record.ExpiresAt = at.Add(IdempotencyWindow)
+
+	err = i.sql.Modify(
+		"INSERT IGNORE INTO idempotencyKey (idemKey, scope, requestHash, claim, responseCode, responseBody, headers, createdAt, expiresAt) VALUE (?,?,?,?,0,'','{}',?,?)",
+		record.Key,
+		record.Scope,
+		record.RequestHash,
+		record.Claim,
+		record.CreatedAt,
+		record.ExpiresAt,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rows, err := i.sql.Select("SELECT idemKey, scope, requestHash, claim, responseCode, responseBody, headers, tokenUserId, createdAt, expiresAt FROM idempotencyKey WHERE idemKey=? AND scope=?", key, scope)
+	if err != nil {
+		return nil, false, err
+	}
+
+	records, err := models.IdempotencyRecordFromSQL(rows)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(records) == 0 {
+		return record, true, nil
+	}
+
+	return records[0], records[0].Claim == record.Claim, nil
+}
+
+/*Save stores the response of the request holding the record's key*/
+func (i *Idempotency) Save(record *models.IdempotencyRecord) error {
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return err
+	}
+
+	return i.sql.Modify(
+		"UPDATE idempotencyKey SET responseCode=?, responseBody=?, headers=?, tokenUserId=? WHERE idemKey=? AND scope=? AND claim=?",
+		record.ResponseCode,
+		record.ResponseBody,
+		string(headers),
+		record.TokenUserID,
+		record.Key,
+		record.Scope,
+		record.Claim,
+	)
+}
+
+/*Release gives up the record's key so the request can be tried again*/
+func (i *Idempotency) Release(record *models.IdempotencyRecord) error {
+	return i.sql.Modify("DELETE FROM idempotencyKey WHERE idemKey=? AND scope=? AND claim=?", record.Key, record.Scope, record.Claim)
+}
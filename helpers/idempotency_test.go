@@ -0,0 +1,153 @@
+package helpers
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyClaim(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	i := getMockIdempotency(s)
+
+	// the claim is random, so the row read back is filled in once the insert has seen it
+	claim := &claimArg{value: make([]byte, 36)}
+	mock.ExpectPrepare("DELETE FROM idempotencyKey WHERE expiresAt<\\? OR \\(responseCode=0 AND createdAt<\\?\\)").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	mock.ExpectPrepare("INSERT IGNORE INTO idempotencyKey").
+		ExpectExec().
+		WithArgs("key", "POST /api/user ", "hash", claim, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT idemKey, scope, requestHash, claim, responseCode, responseBody, headers, tokenUserId, createdAt, expiresAt FROM idempotencyKey WHERE idemKey=\\? AND scope=\\?").
+		WithArgs("key", "POST /api/user ").
+		WillReturnRows(getIdempotencyMockRows().
+			AddRow("key", "POST /api/user ", "hash", claim.value, 0, "", "{}", "", time.Now(), time.Now()))
+
+	record, claimed, err := i.Claim("key", "POST /api/user ", "hash")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.True(claimed)
+	assert.Equal(string(claim.value), record.Claim)
+	assert.False(record.Finished())
+}
+
+func TestIdempotencyClaimTaken(t *testing.T) {
+	assert := assert.New(t)
+
+	userID := uuid.New()
+	s, mock, _ := sqlmock.New()
+	i := getMockIdempotency(s)
+
+	mock.ExpectPrepare("DELETE FROM idempotencyKey").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	mock.ExpectPrepare("INSERT IGNORE INTO idempotencyKey").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	mock.ExpectQuery("SELECT idemKey, scope, requestHash, claim, responseCode, responseBody, headers, tokenUserId, createdAt, expiresAt FROM idempotencyKey").
+		WithArgs("key", "POST /api/user ").
+		WillReturnRows(getIdempotencyMockRows().
+			AddRow("key", "POST /api/user ", "hash", "other", 200, `{"success":true}`, `{"Content-Type":"application/json"}`, userID, time.Now(), time.Now()))
+
+	record, claimed, err := i.Claim("key", "POST /api/user ", "hash")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.False(claimed)
+	assert.True(record.Finished())
+	assert.Equal(`{"success":true}`, record.ResponseBody)
+	assert.Equal("application/json", record.Headers["Content-Type"])
+	assert.Equal(userID, record.TokenUserID)
+}
+
+func TestIdempotencyClaimError(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	i := getMockIdempotency(s)
+
+	mock.ExpectPrepare("DELETE FROM idempotencyKey").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	mock.ExpectPrepare("INSERT IGNORE INTO idempotencyKey").
+		ExpectExec().
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	_, _, err := i.Claim("key", "POST /api/user ", "hash")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestIdempotencySave(t *testing.T) {
+	assert := assert.New(t)
+
+	record := models.NewIdempotencyRecord("key", "POST /api/user ", "hash")
+	record.ResponseCode = 200
+	record.ResponseBody = `{"success":true}`
+	record.Headers["Content-Type"] = "application/json"
+	record.TokenUserID = uuid.New()
+	s, mock, _ := sqlmock.New()
+	i := getMockIdempotency(s)
+
+	mock.ExpectPrepare("UPDATE idempotencyKey SET responseCode=\\?, responseBody=\\?, headers=\\?, tokenUserId=\\? WHERE idemKey=\\? AND scope=\\? AND claim=\\?").
+		ExpectExec().
+		WithArgs(200, `{"success":true}`, `{"Content-Type":"application/json"}`, record.TokenUserID, "key", "POST /api/user ", record.Claim).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := i.Save(record)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestIdempotencyRelease(t *testing.T) {
+	assert := assert.New(t)
+
+	record := models.NewIdempotencyRecord("key", "POST /api/user ", "hash")
+	s, mock, _ := sqlmock.New()
+	i := getMockIdempotency(s)
+
+	mock.ExpectPrepare("DELETE FROM idempotencyKey WHERE idemKey=\\? AND scope=\\? AND claim=\\?").
+		ExpectExec().
+		WithArgs("key", "POST /api/user ", record.Claim).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := i.Release(record)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+// claimArg matches any claim, copying it into value
+type claimArg struct {
+	value []byte
+}
+
+func (c *claimArg) Match(v driver.Value) bool {
+	s, _ := v.(string)
+	copy(c.value, s)
+	return true
+}
+
+func getIdempotencyMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"idemKey", "scope", "requestHash", "claim", "responseCode", "responseBody", "headers", "tokenUserId", "createdAt", "expiresAt"})
+}
+
+func getMockIdempotency(s *sql.DB) *Idempotency {
+	return NewIdempotency(&gateways.MySQL{DB: s})
+}
@@ -0,0 +1,493 @@
+package helpers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/imdario/mergo"
+	"github.com/pborman/uuid"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	gcoinage "github.com/ghmeier/coinage/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+/*How often an import saves its progress, and how long it can go without saving before another runner takes it over*/
+const (
+	ImportBatch = 50
+	ImportStale = 5 * time.Minute
+)
+
+/*ErrImportClaimed is returned when another runner is already working on an import*/
+var ErrImportClaimed = errors.New("Error: import is already running")
+
+type ImportI interface {
+	Insert(*models.Import, []byte) error
+	GetByID(string) (*models.Import, error)
+	GetBody(string) ([]byte, error)
+	GetUnfinished() ([]*models.Import, error)
+	Claim(string, string, time.Time) (bool, error)
+	Save(*models.Import, string) error
+}
+
+type Import struct {
+	*baseHelper
+}
+
+func NewImport(sql gateways.SQL) *Import {
+	return &Import{
+		baseHelper: &baseHelper{sql: sql},
+	}
+}
+
+/*Insert stores a new import along with the file it's importing*/
+func (i *Import) Insert(job *models.Import, body []byte) error {
+	job.CreatedAt = now()
+	job.UpdatedAt = job.CreatedAt
+
+	err := i.sql.Modify(
+		"INSERT INTO importJob (id, kind, format, dryRun, status, body, total, processed, created, updated, failed, errors, message, createdAt, updatedAt) VALUE (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
+		job.ID,
+		job.Kind,
+		job.Format,
+		job.DryRun,
+		job.Status,
+		string(body),
+		job.Total,
+		job.Processed,
+		job.Created,
+		job.Updated,
+		job.Failed,
+		"[]",
+		job.Message,
+		job.CreatedAt,
+		job.UpdatedAt,
+	)
+
+	return err
+}
+
+func (i *Import) GetByID(id string) (*models.Import, error) {
+	rows, err := i.sql.Select("SELECT id, kind, format, dryRun, status, total, processed, created, updated, failed, errors, message, createdAt, updatedAt FROM importJob WHERE id=?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	imports, err := models.ImportFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(imports) == 0 {
+		return nil, nil
+	}
+
+	return imports[0], nil
+}
+
+/*GetBody returns the file being imported, it's only read when an import runs*/
+func (i *Import) GetBody(id string) ([]byte, error) {
+	rows, err := i.sql.Select("SELECT body FROM importJob WHERE id=?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var body string
+	if rows.Next() {
+		rows.Scan(&body)
+	}
+
+	return []byte(body), nil
+}
+
+/*GetUnfinished returns every pending or running import, oldest first*/
+func (i *Import) GetUnfinished() ([]*models.Import, error) {
+	rows, err := i.sql.Select("SELECT id, kind, format, dryRun, status, total, processed, created, updated, failed, errors, message, createdAt, updatedAt FROM importJob WHERE status IN (?,?) ORDER BY createdAt", models.IMPORT_PENDING, models.IMPORT_RUNNING)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.ImportFromSQL(rows)
+}
+
+// Claim gives the import to runner unless another runner has saved progress
+// on it since stale. Imports are released when they finish, so a failed
+// import can be claimed again straight away.
+func (i *Import) Claim(id string, runner string, stale time.Time) (bool, error) {
+	err := i.sql.Modify("UPDATE importJob SET runner=?, updatedAt=? WHERE id=? AND (runner IS NULL OR updatedAt<?)", runner, now(), id, stale)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := i.sql.Select("SELECT runner FROM importJob WHERE id=?", id)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	var claimed sql.NullString
+	if rows.Next() {
+		rows.Scan(&claimed)
+	}
+
+	return claimed.Valid && claimed.String == runner, nil
+}
+
+/*Save stores the import's progress, releasing it once it has finished or when there's no runner*/
+func (i *Import) Save(job *models.Import, runner string) error {
+	job.UpdatedAt = now()
+
+	errs, err := json.Marshal(job.Errors)
+	if err != nil {
+		return err
+	}
+
+	var owner interface{}
+	if !job.Finished() && runner != "" {
+		owner = runner
+	}
+
+	err = i.sql.Modify(
+		"UPDATE importJob SET status=?, processed=?, created=?, updated=?, failed=?, errors=?, message=?, runner=?, updatedAt=? WHERE id=?",
+		job.Status,
+		job.Processed,
+		job.Created,
+		job.Updated,
+		job.Failed,
+		string(errs),
+		job.Message,
+		owner,
+		job.UpdatedAt,
+		job.ID,
+	)
+
+	return err
+}
+
+type ImporterI interface {
+	Run(string) (*models.Import, error)
+}
+
+// Importer works through imports, upserting every row by email. Each
+// TownCenter has its own Runner ID, which it claims imports with so that only
+// one of them works on an import at a time.
+type Importer struct {
+	Import     ImportI
+	User       UserI
+	Roaster    RoasterI
+	Bloodlines gateways.Bloodlines
+	Runner     string
+	Progress   func(*models.Import)
+}
+
+func NewImporter(sql gateways.SQL, s3 gateways.S3, coinage gcoinage.Coinage, bloodlines gateways.Bloodlines) *Importer {
+	return &Importer{
+		Import:     NewImport(sql),
+		User:       NewUser(sql, s3),
+		Roaster:    NewRoaster(sql, s3, coinage),
+		Bloodlines: bloodlines,
+		Runner:     uuid.New(),
+	}
+}
+
+// Run imports the rows of the import with id that haven't been processed
+// yet. Progress is saved every ImportBatch rows, so an import that's
+// interrupted repeats at most that many rows when it's resumed, which
+// upserting makes safe. Problems with a row are recorded against it, but
+// anything else stops the import and marks it failed.
+func (i *Importer) Run(id string) (*models.Import, error) {
+	claimed, err := i.Import.Claim(id, i.Runner, now().Add(-ImportStale))
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrImportClaimed
+	}
+
+	job, err := i.Import.GetByID(id)
+	if err != nil || job == nil {
+		return nil, err
+	}
+	if job.Status == models.IMPORT_COMPLETE {
+		return job, i.Import.Save(job, i.Runner)
+	}
+
+	body, err := i.Import.GetBody(id)
+	if err != nil {
+		return i.fail(job, err)
+	}
+
+	rows, err := models.ParseImport(job.Format, body)
+	if err != nil {
+		return i.fail(job, err)
+	}
+
+	job.Status = models.IMPORT_RUNNING
+	job.Message = ""
+	for job.Processed < len(rows) {
+		row := rows[job.Processed]
+
+		created, err := i.row(job, row)
+		if rerr, ok := err.(*models.ImportError); ok {
+			rerr.Row = row.Line
+			job.Fail(rerr)
+		} else if err != nil {
+			return i.fail(job, err)
+		} else if created {
+			job.Created++
+		} else {
+			job.Updated++
+		}
+
+		job.Processed++
+		if job.Processed%ImportBatch == 0 {
+			err = i.save(job)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	job.Status = models.IMPORT_COMPLETE
+	return job, i.save(job)
+}
+
+/*Resume runs every import that was interrupted and that no other runner has picked back up*/
+func (i *Importer) Resume() {
+	imports, err := i.Import.GetUnfinished()
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	for _, job := range imports {
+		_, err = i.Run(job.ID.String())
+		if err != nil && err != ErrImportClaimed {
+			fmt.Println(err.Error())
+		}
+	}
+}
+
+/*Watch resumes interrupted imports every interval, it doesn't return*/
+func (i *Importer) Watch(interval time.Duration) {
+	for {
+		i.Resume()
+		time.Sleep(interval)
+	}
+}
+
+func (i *Importer) row(job *models.Import, row *models.ImportRow) (bool, error) {
+	if job.Kind == models.IMPORT_ROASTER {
+		return i.roaster(job.DryRun, row)
+	}
+
+	return i.user(job.DryRun, row)
+}
+
+// user upserts the user in row. Fields left out of the row keep their
+// current values. New users get a random password, so they have to reset it
+// before they can log in.
+func (i *Importer) user(dryRun bool, row *models.ImportRow) (bool, error) {
+	var user models.User
+	err := json.Unmarshal(row.Data, &user)
+	if err != nil {
+		return false, &models.ImportError{Message: "Error: row isn't a valid user"}
+	}
+
+	user.Email = strings.TrimSpace(user.Email)
+	if user.Email == "" {
+		return false, &models.ImportError{Message: "Error: email is required"}
+	}
+	user.PassHash = ""
+	user.AddressCountry = models.NormalizeCountry(user.AddressCountry)
+
+	existing, err := i.User.GetByEmail(user.Email)
+	if err != nil {
+		return false, err
+	}
+
+	if existing != nil {
+		existing.PassHash = ""
+		err = mergo.Merge(&user, existing)
+		if err != nil {
+			return false, err
+		}
+
+		user.ID = existing.ID
+		user.RoasterId = existing.RoasterId
+		user.ProfileURL = existing.ProfileURL
+	}
+
+	user.Phone = importPhone(user.Phone, user.AddressCountry)
+	//Stored values are only checked when the row changes them
+	errs := models.ValidateChanges(&user, existing)
+	if errs != nil {
+		return false, &models.ImportError{Email: user.Email, Message: "Error: invalid user", Fields: errs}
+	}
+	if dryRun {
+		return existing == nil, nil
+	}
+
+	if existing != nil {
+		user.PhoneVerified = existing.PhoneVerified && user.Phone == existing.Phone
+		return false, i.User.Update(&user, user.ID.String())
+	}
+
+	created := models.NewUser(uuid.New(), user.FirstName, user.LastName, user.Email, user.Phone,
+		user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip,
+		user.AddressCountry)
+	err = i.User.Insert(created)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = i.Bloodlines.NewPreference(created.ID)
+	return true, err
+}
+
+// roaster upserts the roaster in row. New roasters are given to the user
+// with ownerEmail, who has to exist already and not have a roaster, and
+// existing roasters keep their owner and onboarding status.
+func (i *Importer) roaster(dryRun bool, row *models.ImportRow) (bool, error) {
+	var imported models.ImportRoaster
+	err := json.Unmarshal(row.Data, &imported)
+	if err != nil {
+		return false, &models.ImportError{Message: "Error: row isn't a valid roaster"}
+	}
+
+	roaster := &imported.Roaster
+	roaster.Email = strings.TrimSpace(roaster.Email)
+	if roaster.Email == "" {
+		return false, &models.ImportError{Message: "Error: email is required"}
+	}
+	roaster.AddressCountry = models.NormalizeCountry(roaster.AddressCountry)
+
+	existing, err := i.Roaster.GetByEmail(roaster.Email)
+	if err != nil {
+		return false, err
+	}
+
+	var owner *models.User
+	if existing != nil {
+		err = mergo.Merge(roaster, existing)
+		if err != nil {
+			return false, err
+		}
+
+		roaster.ID = existing.ID
+		roaster.Status = existing.Status
+		roaster.ProfileUrl = existing.ProfileUrl
+	} else {
+		owner, err = i.owner(roaster.Email, imported.OwnerEmail)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	roaster.Phone = importPhone(roaster.Phone, roaster.AddressCountry)
+	//Stored values are only checked when the row changes them
+	errs := models.ValidateChanges(roaster, existing)
+	if errs != nil {
+		return false, &models.ImportError{Email: roaster.Email, Message: "Error: invalid roaster", Fields: errs}
+	}
+
+	if roaster.Slug != "" && (existing == nil || roaster.Slug != existing.Slug) {
+		taken, err := i.Roaster.SlugTaken(roaster.Slug, roaster.ID.String())
+		if err != nil {
+			return false, err
+		}
+		if taken {
+			return false, &models.ImportError{Email: roaster.Email, Message: "Error: slug " + roaster.Slug + " is already taken"}
+		}
+	}
+	if dryRun {
+		return existing == nil, nil
+	}
+
+	if existing != nil {
+		roaster.PhoneVerified = existing.PhoneVerified && roaster.Phone == existing.Phone
+		return false, i.Roaster.Update(roaster, roaster.ID.String())
+	}
+
+	created := models.NewRoaster(roaster.Name, roaster.Email, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.Birthday)
+	created.Slug = roaster.Slug
+	err = i.Roaster.Insert(created)
+	if err != nil {
+		return false, err
+	}
+
+	owner.RoasterId = created.ID
+	owner.PassHash = ""
+	err = i.User.Update(owner, owner.ID.String())
+	if err != nil {
+		return false, err
+	}
+
+	return true, i.Roaster.CreateAccount(owner.ID)
+}
+
+/*owner finds the user a new roaster is being imported for*/
+func (i *Importer) owner(email string, ownerEmail string) (*models.User, error) {
+	ownerEmail = strings.TrimSpace(ownerEmail)
+	if ownerEmail == "" {
+		return nil, &models.ImportError{Email: email, Message: "Error: ownerEmail is required for new roasters"}
+	}
+
+	owner, err := i.User.GetByEmail(ownerEmail)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil {
+		return nil, &models.ImportError{Email: email, Message: "Error: no user with email " + ownerEmail + ", import them first"}
+	}
+	if owner.RoasterId != nil {
+		return nil, &models.ImportError{Email: email, Message: "Error: " + ownerEmail + " already has a roaster"}
+	}
+
+	return owner, nil
+}
+
+/*fail stops the import, it can be resumed from where it got to once whatever went wrong is fixed*/
+func (i *Importer) fail(job *models.Import, err error) (*models.Import, error) {
+	job.Status = models.IMPORT_FAILED
+	job.Message = err.Error()
+
+	serr := i.save(job)
+	if serr != nil {
+		fmt.Println(serr.Error())
+	}
+
+	return job, err
+}
+
+func (i *Importer) save(job *models.Import) error {
+	err := i.Import.Save(job, i.Runner)
+	if err != nil {
+		return err
+	}
+
+	if i.Progress != nil {
+		i.Progress(job)
+	}
+
+	return nil
+}
+
+/*importPhone normalizes phone the same way the API does, leaving it for validation to reject when it can't*/
+func importPhone(phone string, country string) string {
+	if phone == "" {
+		return phone
+	}
+
+	normalized, ok := models.NormalizePhone(phone, country)
+	if !ok {
+		return phone
+	}
+
+	return normalized
+}
@@ -0,0 +1,216 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportInsert(t *testing.T) {
+	assert := assert.New(t)
+
+	body := []byte("email\njake@expresso.store\n")
+	job, _ := models.NewImport(models.IMPORT_USER, models.IMPORT_CSV, true, body)
+	s, mock, _ := sqlmock.New()
+	i := getMockImport(s)
+
+	mock.ExpectPrepare("INSERT INTO importJob").
+		ExpectExec().
+		WithArgs(job.ID.String(), models.IMPORT_USER, models.IMPORT_CSV, true, models.IMPORT_PENDING, string(body), 1, 0, 0, 0, 0, "[]", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := i.Insert(job, body)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.False(job.CreatedAt.IsZero())
+}
+
+func TestImportGetByID(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	i := getMockImport(s)
+
+	mock.ExpectQuery("SELECT id, kind, format, dryRun, status, total, processed, created, updated, failed, errors, message, createdAt, updatedAt FROM importJob WHERE id=\\?").
+		WithArgs(id).
+		WillReturnRows(getImportMockRows().
+			AddRow(id, models.IMPORT_USER, models.IMPORT_CSV, false, models.IMPORT_RUNNING, 10, 4, 2, 1, 1, `[{"row":3,"message":"Error: email is required"}]`, "", time.Now(), time.Now()))
+
+	job, err := i.GetByID(id)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(4, job.Processed)
+	assert.Equal(1, len(job.Errors))
+	assert.Equal(3, job.Errors[0].Row)
+}
+
+func TestImportGetByIDNone(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	i := getMockImport(s)
+
+	mock.ExpectQuery("SELECT id, kind, format, dryRun, status, total, processed, created, updated, failed, errors, message, createdAt, updatedAt FROM importJob WHERE id=\\?").
+		WillReturnRows(getImportMockRows())
+
+	job, err := i.GetByID(uuid.New())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Nil(job)
+}
+
+func TestImportGetBody(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	i := getMockImport(s)
+
+	mock.ExpectQuery("SELECT body FROM importJob WHERE id=\\?").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"body"}).AddRow("email\njake@expresso.store\n"))
+
+	body, err := i.GetBody(id)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal("email\njake@expresso.store\n", string(body))
+}
+
+func TestImportGetUnfinished(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	i := getMockImport(s)
+
+	mock.ExpectQuery("SELECT .* FROM importJob WHERE status IN \\(\\?,\\?\\) ORDER BY createdAt").
+		WithArgs(models.IMPORT_PENDING, models.IMPORT_RUNNING).
+		WillReturnRows(getImportMockRows().
+			AddRow(uuid.New(), models.IMPORT_USER, models.IMPORT_CSV, false, models.IMPORT_PENDING, 10, 0, 0, 0, 0, "[]", "", time.Now(), time.Now()))
+
+	imports, err := i.GetUnfinished()
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, len(imports))
+}
+
+func TestImportClaim(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	runner := uuid.New()
+	stale := time.Now()
+	s, mock, _ := sqlmock.New()
+	i := getMockImport(s)
+
+	mock.ExpectPrepare("UPDATE importJob SET runner=\\?, updatedAt=\\? WHERE id=\\? AND \\(runner IS NULL OR updatedAt<\\?\\)").
+		ExpectExec().
+		WithArgs(runner, sqlmock.AnyArg(), id, stale).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT runner FROM importJob WHERE id=\\?").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"runner"}).AddRow(runner))
+
+	claimed, err := i.Claim(id, runner, stale)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.True(claimed)
+}
+
+func TestImportClaimTaken(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	i := getMockImport(s)
+
+	mock.ExpectPrepare("UPDATE importJob SET runner").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT runner FROM importJob WHERE id=\\?").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"runner"}).AddRow(uuid.New()))
+
+	claimed, err := i.Claim(id, uuid.New(), time.Now())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.False(claimed)
+}
+
+func TestImportClaimError(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	i := getMockImport(s)
+
+	mock.ExpectPrepare("UPDATE importJob SET runner").
+		ExpectExec().
+		WillReturnError(fmt.Errorf("some error"))
+
+	claimed, err := i.Claim(uuid.New(), uuid.New(), time.Now())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+	assert.False(claimed)
+}
+
+func TestImportSave(t *testing.T) {
+	assert := assert.New(t)
+
+	runner := uuid.New()
+	job := &models.Import{ID: uuid.NewUUID(), Status: models.IMPORT_RUNNING, Processed: 50, Created: 49, Errors: make([]*models.ImportError, 0)}
+	job.Fail(&models.ImportError{Row: 2, Message: "Error: email is required"})
+	s, mock, _ := sqlmock.New()
+	i := getMockImport(s)
+
+	mock.ExpectPrepare("UPDATE importJob SET status=\\?, processed=\\?, created=\\?, updated=\\?, failed=\\?, errors=\\?, message=\\?, runner=\\?, updatedAt=\\? WHERE id=\\?").
+		ExpectExec().
+		WithArgs(models.IMPORT_RUNNING, 50, 49, 0, 1, `[{"row":2,"message":"Error: email is required"}]`, "", runner, sqlmock.AnyArg(), job.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := i.Save(job, runner)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestImportSaveFinished(t *testing.T) {
+	assert := assert.New(t)
+
+	job := &models.Import{ID: uuid.NewUUID(), Status: models.IMPORT_COMPLETE, Errors: make([]*models.ImportError, 0)}
+	s, mock, _ := sqlmock.New()
+	i := getMockImport(s)
+
+	mock.ExpectPrepare("UPDATE importJob SET").
+		ExpectExec().
+		WithArgs(models.IMPORT_COMPLETE, 0, 0, 0, 0, "[]", "", nil, sqlmock.AnyArg(), job.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := i.Save(job, uuid.New())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func getImportMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "kind", "format", "dryRun", "status", "total", "processed", "created", "updated", "failed", "errors", "message", "createdAt", "updatedAt"})
+}
+
+func getMockImport(s *sql.DB) *Import {
+	return NewImport(&gateways.MySQL{DB: s})
+}
@@ -0,0 +1,255 @@
+package helpers_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	mockg "github.com/ghmeier/bloodlines/_mocks/gateways"
+	m "github.com/ghmeier/bloodlines/models"
+	mocks "github.com/jakelong95/TownCenter/_mocks/helpers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImporterRunUsers(t *testing.T) {
+	assert := assert.New(t)
+
+	body := "email,firstName,lastName\nnew@expresso.store,New,User\nold@expresso.store,,Renamed\nnot-an-email,Bad,Row\n"
+	job := getImportJob(models.IMPORT_USER, models.IMPORT_CSV, false, body)
+	existing := models.NewUser("hash", "Old", "Name", "old@expresso.store", "", "", "", "", "", "", "")
+	i, importMock, userMock, _, bloodlines := getMockImporter(job, body)
+	userMock.On("GetByEmail", "new@expresso.store").Return(nil, nil)
+	userMock.On("GetByEmail", "old@expresso.store").Return(existing, nil)
+	userMock.On("GetByEmail", "not-an-email").Return(nil, nil)
+	userMock.On("Insert", mock.AnythingOfType("*models.User")).Return(nil)
+	userMock.On("Update", mock.AnythingOfType("*models.User"), existing.ID.String()).Return(nil)
+	bloodlines.On("NewPreference", mock.AnythingOfType("uuid.UUID")).Return(&m.Preference{}, nil)
+	importMock.On("Save", job, i.Runner).Return(nil)
+
+	result, err := i.Run(job.ID.String())
+
+	assert.NoError(err)
+	assert.Equal(models.IMPORT_COMPLETE, result.Status)
+	assert.Equal(3, result.Processed)
+	assert.Equal(1, result.Created)
+	assert.Equal(1, result.Updated)
+	assert.Equal(1, result.Failed)
+	assert.Equal(4, result.Errors[0].Row)
+	assert.Equal(models.INVALID_EMAIL, result.Errors[0].Fields[0].Code)
+
+	created := userMock.Calls[1].Arguments.Get(0).(*models.User)
+	assert.Equal("New", created.FirstName)
+	assert.NotEqual("", created.PassHash)
+
+	updated := userMock.Calls[3].Arguments.Get(0).(*models.User)
+	assert.Equal("Old", updated.FirstName)
+	assert.Equal("Renamed", updated.LastName)
+	assert.Equal("", updated.PassHash)
+	importMock.AssertNumberOfCalls(t, "Save", 1)
+}
+
+func TestImporterRunUsersLegacyCountry(t *testing.T) {
+	assert := assert.New(t)
+
+	body := "email,lastName,addressCountry\nold@expresso.store,Renamed,\nlower@expresso.store,,ca\n"
+	job := getImportJob(models.IMPORT_USER, models.IMPORT_CSV, false, body)
+	old := models.NewUser("hash", "Old", "Name", "old@expresso.store", "", "", "", "", "", "", "USA")
+	lower := models.NewUser("hash", "Lower", "Name", "lower@expresso.store", "", "", "", "", "", "", "")
+	i, importMock, userMock, _, _ := getMockImporter(job, body)
+	userMock.On("GetByEmail", "old@expresso.store").Return(old, nil)
+	userMock.On("GetByEmail", "lower@expresso.store").Return(lower, nil)
+	userMock.On("Update", mock.AnythingOfType("*models.User"), mock.AnythingOfType("string")).Return(nil)
+	importMock.On("Save", job, i.Runner).Return(nil)
+
+	result, err := i.Run(job.ID.String())
+
+	assert.NoError(err)
+	assert.Equal(2, result.Updated)
+	assert.Equal(0, result.Failed)
+	assert.Equal("USA", userMock.Calls[1].Arguments.Get(0).(*models.User).AddressCountry)
+	assert.Equal("CA", userMock.Calls[3].Arguments.Get(0).(*models.User).AddressCountry)
+}
+
+func TestImporterRunDryRun(t *testing.T) {
+	assert := assert.New(t)
+
+	body := "{\"email\":\"new@expresso.store\"}\n\n{\"email\":\"old@expresso.store\"}\n[1,2]\n"
+	job := getImportJob(models.IMPORT_USER, models.IMPORT_JSONL, true, body)
+	existing := models.NewUser("hash", "Old", "Name", "old@expresso.store", "", "", "", "", "", "", "")
+	i, importMock, userMock, _, _ := getMockImporter(job, body)
+	userMock.On("GetByEmail", "new@expresso.store").Return(nil, nil)
+	userMock.On("GetByEmail", "old@expresso.store").Return(existing, nil)
+	importMock.On("Save", job, i.Runner).Return(nil)
+
+	result, err := i.Run(job.ID.String())
+
+	assert.NoError(err)
+	assert.Equal(1, result.Created)
+	assert.Equal(1, result.Updated)
+	assert.Equal(1, result.Failed)
+	assert.Equal(4, result.Errors[0].Row)
+	userMock.AssertNotCalled(t, "Insert", mock.Anything)
+	userMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestImporterRunRoaster(t *testing.T) {
+	assert := assert.New(t)
+
+	body := "email,name,ownerEmail\nhello@kaldis.com,Kaldi's,owner@kaldis.com\n"
+	job := getImportJob(models.IMPORT_ROASTER, models.IMPORT_CSV, false, body)
+	owner := models.NewUser("hash", "Owner", "", "owner@kaldis.com", "", "", "", "", "", "", "")
+	i, importMock, userMock, roasterMock, _ := getMockImporter(job, body)
+	roasterMock.On("GetByEmail", "hello@kaldis.com").Return(nil, nil)
+	userMock.On("GetByEmail", "owner@kaldis.com").Return(owner, nil)
+	roasterMock.On("Insert", mock.AnythingOfType("*models.Roaster")).Return(nil)
+	userMock.On("Update", owner, owner.ID.String()).Return(nil)
+	roasterMock.On("CreateAccount", owner.ID).Return(nil)
+	importMock.On("Save", job, i.Runner).Return(nil)
+
+	result, err := i.Run(job.ID.String())
+
+	assert.NoError(err)
+	assert.Equal(1, result.Created)
+	roaster := roasterMock.Calls[1].Arguments.Get(0).(*models.Roaster)
+	assert.Equal("Kaldi's", roaster.Name)
+	assert.Equal(roaster.ID, owner.RoasterId)
+	assert.Equal("", owner.PassHash)
+}
+
+func TestImporterRunRoasterOwnerMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	body := "email,name,ownerEmail\nhello@kaldis.com,Kaldi's,owner@kaldis.com\nbye@kaldis.com,Kaldi's 2,\n"
+	job := getImportJob(models.IMPORT_ROASTER, models.IMPORT_CSV, false, body)
+	i, importMock, userMock, roasterMock, _ := getMockImporter(job, body)
+	roasterMock.On("GetByEmail", mock.AnythingOfType("string")).Return(nil, nil)
+	userMock.On("GetByEmail", "owner@kaldis.com").Return(nil, nil)
+	importMock.On("Save", job, i.Runner).Return(nil)
+
+	result, err := i.Run(job.ID.String())
+
+	assert.NoError(err)
+	assert.Equal(2, result.Failed)
+	assert.True(strings.Contains(result.Errors[0].Message, "import them first"))
+	assert.True(strings.Contains(result.Errors[1].Message, "ownerEmail is required"))
+	roasterMock.AssertNotCalled(t, "Insert", mock.Anything)
+}
+
+func TestImporterRunRoasterUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	body := "email,addressCity,slug\nhello@kaldis.com,Ames,kaldis\n"
+	job := getImportJob(models.IMPORT_ROASTER, models.IMPORT_CSV, false, body)
+	existing := models.NewRoaster("Kaldi's", "hello@kaldis.com", "", "", "", "Des Moines", "IA", "", "US", "")
+	existing.Slug = "kaldis"
+	existing.Status = models.STATUS_ACTIVE
+	i, importMock, _, roasterMock, _ := getMockImporter(job, body)
+	roasterMock.On("GetByEmail", "hello@kaldis.com").Return(existing, nil)
+	roasterMock.On("Update", mock.AnythingOfType("*models.Roaster"), existing.ID.String()).Return(nil)
+	importMock.On("Save", job, i.Runner).Return(nil)
+
+	result, err := i.Run(job.ID.String())
+
+	assert.NoError(err)
+	assert.Equal(1, result.Updated)
+	roaster := roasterMock.Calls[1].Arguments.Get(0).(*models.Roaster)
+	assert.Equal("Ames", roaster.AddressCity)
+	assert.Equal("Kaldi's", roaster.Name)
+	assert.Equal(models.STATUS_ACTIVE, roaster.Status)
+	roasterMock.AssertNotCalled(t, "SlugTaken", mock.Anything, mock.Anything)
+}
+
+func TestImporterRunResumes(t *testing.T) {
+	assert := assert.New(t)
+
+	body := "email\nfirst@expresso.store\nsecond@expresso.store\n"
+	job := getImportJob(models.IMPORT_USER, models.IMPORT_CSV, true, body)
+	job.Processed = 1
+	job.Created = 1
+	i, importMock, userMock, _, _ := getMockImporter(job, body)
+	userMock.On("GetByEmail", "second@expresso.store").Return(nil, nil)
+	importMock.On("Save", job, i.Runner).Return(nil)
+
+	result, err := i.Run(job.ID.String())
+
+	assert.NoError(err)
+	assert.Equal(2, result.Created)
+	userMock.AssertNotCalled(t, "GetByEmail", "first@expresso.store")
+}
+
+func TestImporterRunFails(t *testing.T) {
+	assert := assert.New(t)
+
+	body := "email\nfirst@expresso.store\nsecond@expresso.store\n"
+	job := getImportJob(models.IMPORT_USER, models.IMPORT_CSV, true, body)
+	i, importMock, userMock, _, _ := getMockImporter(job, body)
+	userMock.On("GetByEmail", "first@expresso.store").Return(nil, nil)
+	userMock.On("GetByEmail", "second@expresso.store").Return(nil, fmt.Errorf("some error"))
+	importMock.On("Save", job, i.Runner).Return(nil)
+
+	result, err := i.Run(job.ID.String())
+
+	assert.Error(err)
+	assert.Equal(models.IMPORT_FAILED, result.Status)
+	assert.Equal("some error", result.Message)
+	assert.Equal(1, result.Processed)
+}
+
+func TestImporterRunSavesBatches(t *testing.T) {
+	assert := assert.New(t)
+
+	body := "email\n" + strings.Repeat("new@expresso.store\n", helpers.ImportBatch+1)
+	job := getImportJob(models.IMPORT_USER, models.IMPORT_CSV, true, body)
+	i, importMock, userMock, _, _ := getMockImporter(job, body)
+	userMock.On("GetByEmail", "new@expresso.store").Return(nil, nil)
+	importMock.On("Save", job, i.Runner).Return(nil)
+
+	_, err := i.Run(job.ID.String())
+
+	assert.NoError(err)
+	importMock.AssertNumberOfCalls(t, "Save", 2)
+}
+
+func TestImporterRunClaimed(t *testing.T) {
+	assert := assert.New(t)
+
+	importMock := &mocks.ImportI{}
+	i := &helpers.Importer{Import: importMock, Runner: uuid.New()}
+	importMock.On("Claim", "id", i.Runner, mock.AnythingOfType("time.Time")).Return(false, nil)
+
+	_, err := i.Run("id")
+
+	assert.Equal(helpers.ErrImportClaimed, err)
+	importMock.AssertNotCalled(t, "GetByID", "id")
+}
+
+func getImportJob(kind string, format string, dryRun bool, body string) *models.Import {
+	job, _ := models.NewImport(kind, format, dryRun, []byte(body))
+	return job
+}
+
+func getMockImporter(job *models.Import, body string) (*helpers.Importer, *mocks.ImportI, *mocks.UserI, *mocks.RoasterI, *mockg.Bloodlines) {
+	importMock := &mocks.ImportI{}
+	userMock := &mocks.UserI{}
+	roasterMock := &mocks.RoasterI{}
+	bloodlines := &mockg.Bloodlines{}
+
+	i := &helpers.Importer{
+		Import:     importMock,
+		User:       userMock,
+		Roaster:    roasterMock,
+		Bloodlines: bloodlines,
+		Runner:     uuid.New(),
+	}
+
+	importMock.On("Claim", job.ID.String(), i.Runner, mock.AnythingOfType("time.Time")).Return(true, nil)
+	importMock.On("GetByID", job.ID.String()).Return(job, nil)
+	importMock.On("GetBody", job.ID.String()).Return([]byte(body), nil)
+
+	return i, importMock, userMock, roasterMock, bloodlines
+}
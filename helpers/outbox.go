@@ -0,0 +1,260 @@
+package helpers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	tgateways "github.com/jakelong95/TownCenter/gateways"
+	"github.com/jakelong95/TownCenter/models"
+	"github.com/pborman/uuid"
+)
+
+// Relay settings. Events a relay claims are held for OutboxClaim, and
+// published events are deleted from the outbox once they're older than
+// OutboxRetention.
+const (
+	OutboxBatch     = 100
+	OutboxInterval  = 5 * time.Second
+	OutboxClaim     = time.Minute
+	OutboxRetention = 7 * 24 * time.Hour
+)
+
+type OutboxI interface {
+	Claim(string, int) ([]*models.Event, error)
+	Release(string) error
+	MarkPublished(string) error
+	Prune(time.Time) error
+}
+
+/*Outbox reads back the events written alongside user and roaster changes so they can be published*/
+type Outbox struct {
+	*baseHelper
+}
+
+func NewOutbox(sql gateways.SQL) *Outbox {
+	return &Outbox{baseHelper: &baseHelper{sql: sql}}
+}
+
+// Claim gives runner up to limit of the oldest unpublished events that
+// another runner isn't holding, and returns the ones it can publish, in the
+// order they were written. That stops short of any event another runner
+// still holds, so events never overtake each other between relays.
+func (o *Outbox) Claim(runner string, limit int) ([]*models.Event, error) {
+	at := now()
+	err := o.sql.Modify("UPDATE outboxEvent SET runner=?, claimedUntil=? WHERE publishedAt IS NULL AND (runner IS NULL OR runner=? OR claimedUntil<?) ORDER BY seq ASC LIMIT ?", runner, at.Add(OutboxClaim), runner, at, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := o.sql.Select("SELECT id, type, aggregateId, roasterId, data, createdAt FROM outboxEvent WHERE publishedAt IS NULL AND runner=? AND seq<COALESCE((SELECT MIN(seq) FROM outboxEvent WHERE publishedAt IS NULL AND runner<>? AND claimedUntil>=?), ~0) ORDER BY seq ASC LIMIT ?", runner, runner, at, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.EventFromSQL(rows)
+}
+
+/*Release hands back the events runner claimed but didn't publish*/
+func (o *Outbox) Release(runner string) error {
+	return o.sql.Modify("UPDATE outboxEvent SET runner=NULL, claimedUntil=NULL WHERE runner=? AND publishedAt IS NULL", runner)
+}
+
+func (o *Outbox) MarkPublished(id string) error {
+	return o.sql.Modify("UPDATE outboxEvent SET publishedAt=? WHERE id=?", now(), id)
+}
+
+/*Prune deletes the events that were published before before*/
+func (o *Outbox) Prune(before time.Time) error {
+	return o.sql.Modify("DELETE FROM outboxEvent WHERE publishedAt<?", before)
+}
+
+// Relay publishes the outbox in order, marking each event once the broker has
+// confirmed it. Each TownCenter has its own Runner ID that it claims events
+// with, so running more than one doesn't publish them twice. An event is
+// still published again if TownCenter stops between publishing and marking
+// it, so delivery is at least once.
+type Relay struct {
+	Outbox    OutboxI
+	Publisher tgateways.Publisher
+	Runner    string
+}
+
+func NewRelay(sql gateways.SQL, publisher tgateways.Publisher) *Relay {
+	return &Relay{
+		Outbox:    NewOutbox(sql),
+		Publisher: publisher,
+		Runner:    uuid.New(),
+	}
+}
+
+/*Run publishes every event the relay can claim, stopping at the first that fails so later events don't overtake it*/
+func (r *Relay) Run() (int, error) {
+	published, err := r.publish()
+	rerr := r.Outbox.Release(r.Runner)
+	if err != nil {
+		return published, err
+	}
+	if rerr != nil {
+		return published, rerr
+	}
+
+	return published, r.Outbox.Prune(now().Add(-OutboxRetention))
+}
+
+func (r *Relay) publish() (int, error) {
+	published := 0
+	for {
+		events, err := r.Outbox.Claim(r.Runner, OutboxBatch)
+		if err != nil {
+			return published, err
+		}
+
+		for _, event := range events {
+			err = r.Publisher.Publish(event)
+			if err != nil {
+				return published, err
+			}
+
+			err = r.Outbox.MarkPublished(event.ID.String())
+			if err != nil {
+				return published, err
+			}
+			published++
+		}
+
+		if len(events) < OutboxBatch {
+			return published, nil
+		}
+	}
+}
+
+/*Watch runs the relay every interval*/
+func (r *Relay) Watch(interval time.Duration) {
+	for {
+		_, err := r.Run()
+		if err != nil {
+			fmt.Println(err.Error())
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// addEvent writes an event to the outbox with sql, which should be the
+// transaction that made the change. Events about a roaster are also queued for
+// delivery to each of its active webhooks subscribed to the event's type.
+func addEvent(sql gateways.SQL, kind string, aggregateID uuid.UUID, roasterID uuid.UUID, data interface{}) error {
+	event, err := models.NewEvent(kind, aggregateID, data)
+	if err != nil {
+		return err
+	}
+	event.RoasterID = roasterID
+	event.CreatedAt = now()
+
+	err = sql.Modify(
+		"INSERT INTO outboxEvent (id, type, aggregateId, roasterId, data, createdAt) VALUE (?,?,?,?,?,?)",
+		event.ID,
+		event.Type,
+		event.AggregateID,
+		event.RoasterID,
+		string(event.Data),
+		event.CreatedAt,
+	)
+	if err != nil || roasterID == nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return sql.Modify(
+		"INSERT INTO webhookDelivery (id, webhookId, eventId, type, payload, status, attempts, nextAttemptAt, createdAt, updatedAt) SELECT UUID(), id, ?, ?, ?, ?, 0, ?, ?, ? FROM webhook WHERE roasterId=? AND active AND FIND_IN_SET(?, events)",
+		event.ID,
+		event.Type,
+		string(payload),
+		models.DELIVERY_PENDING,
+		event.CreatedAt,
+		event.CreatedAt,
+		event.CreatedAt,
+		roasterID,
+		event.Type,
+	)
+}
+
+// inTx calls fn with a gateways.SQL that runs everything in one transaction,
+// committed if fn succeeds and rolled back if it doesn't. It needs the
+// bloodlines MySQL gateway to begin a transaction, any other gateway is
+// passed to fn as it is.
+func inTx(db gateways.SQL, fn func(gateways.SQL) error) error {
+	mysql, ok := db.(*gateways.MySQL)
+	if !ok || mysql.DB == nil {
+		return fn(db)
+	}
+
+	tx, err := mysql.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = fn(&txSQL{tx: tx})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// modifyCount runs a statement and returns how many rows it changed. SQL
+// gateways other than MySQL can't report it, so they count as one row.
+func modifyCount(db gateways.SQL, query string, args ...interface{}) (int64, error) {
+	var stmt *sql.Stmt
+	var err error
+	switch d := db.(type) {
+	case *txSQL:
+		stmt, err = d.tx.Prepare(query)
+	case *gateways.MySQL:
+		if d.DB == nil {
+			return 1, db.Modify(query, args...)
+		}
+		stmt, err = d.DB.Prepare(query)
+	default:
+		return 1, db.Modify(query, args...)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+/*txSQL is a gateways.SQL that runs statements in a transaction*/
+type txSQL struct {
+	tx *sql.Tx
+}
+
+func (t *txSQL) Select(query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.Query(query, args...)
+}
+
+func (t *txSQL) Modify(query string, args ...interface{}) error {
+	stmt, err := t.tx.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(args...)
+	return err
+}
@@ -0,0 +1,246 @@
+package helpers
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxClaim(t *testing.T) {
+	assert := assert.New(t)
+
+	id, roasterID := uuid.NewUUID(), uuid.NewUUID()
+	runner := uuid.New()
+	s, mock, _ := sqlmock.New()
+	o := NewOutbox(&gateways.MySQL{DB: s})
+
+	mock.ExpectPrepare("UPDATE outboxEvent SET runner=\\?, claimedUntil=\\? WHERE publishedAt IS NULL AND \\(runner IS NULL OR runner=\\? OR claimedUntil<\\?\\) ORDER BY seq ASC LIMIT \\?").
+		ExpectExec().
+		WithArgs(runner, sqlmock.AnyArg(), runner, sqlmock.AnyArg(), OutboxBatch).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT id, type, aggregateId, roasterId, data, createdAt FROM outboxEvent WHERE publishedAt IS NULL AND runner=\\? AND seq<COALESCE\\(\\(SELECT MIN\\(seq\\) FROM outboxEvent WHERE publishedAt IS NULL AND runner<>\\? AND claimedUntil>=\\?\\), ~0\\) ORDER BY seq ASC LIMIT \\?").
+		WithArgs(runner, runner, sqlmock.AnyArg(), OutboxBatch).
+		WillReturnRows(getEventMockRows().
+			AddRow(id.String(), models.ROASTER_DELETED, roasterID.String(), roasterID.String(), `{"id":"`+roasterID.String()+`"}`, time.Now()))
+
+	events, err := o.Claim(runner, OutboxBatch)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, len(events))
+	assert.Equal(id, events[0].ID)
+	assert.Equal(models.ROASTER_DELETED, events[0].Type)
+	assert.Equal(roasterID, events[0].AggregateID)
+	assert.Equal(roasterID, events[0].RoasterID)
+	assert.Equal(`{"id":"`+roasterID.String()+`"}`, string(events[0].Data))
+}
+
+func TestOutboxClaimError(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	o := NewOutbox(&gateways.MySQL{DB: s})
+
+	mock.ExpectPrepare("UPDATE outboxEvent SET runner=\\?").
+		ExpectExec().
+		WithArgs("runner", sqlmock.AnyArg(), "runner", sqlmock.AnyArg(), OutboxBatch).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT id, type, aggregateId, roasterId, data, createdAt FROM outboxEvent").
+		WithArgs("runner", "runner", sqlmock.AnyArg(), OutboxBatch).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	_, err := o.Claim("runner", OutboxBatch)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestOutboxRelease(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	o := NewOutbox(&gateways.MySQL{DB: s})
+
+	mock.ExpectPrepare("UPDATE outboxEvent SET runner=NULL, claimedUntil=NULL WHERE runner=\\? AND publishedAt IS NULL").
+		ExpectExec().
+		WithArgs("runner").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := o.Release("runner")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestOutboxMarkPublished(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	o := NewOutbox(&gateways.MySQL{DB: s})
+
+	mock.ExpectPrepare("UPDATE outboxEvent SET publishedAt=\\? WHERE id=\\?").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), id).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := o.MarkPublished(id)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestOutboxPrune(t *testing.T) {
+	assert := assert.New(t)
+
+	before := time.Now()
+	s, mock, _ := sqlmock.New()
+	o := NewOutbox(&gateways.MySQL{DB: s})
+
+	mock.ExpectPrepare("DELETE FROM outboxEvent WHERE publishedAt<\\?").
+		ExpectExec().
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(1, 3))
+
+	err := o.Prune(before)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestAddEventRollsBack(t *testing.T) {
+	assert := assert.New(t)
+
+	user := getDefaultUser()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO user").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("INSERT INTO outboxEvent").
+		ExpectExec().
+		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
+
+	err := u.Insert(user)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestAddEventNoPassHash(t *testing.T) {
+	assert := assert.New(t)
+
+	user := getDefaultUser()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	data := ""
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO user").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("INSERT INTO outboxEvent").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), models.USER_CREATED, user.ID.String(), "", &captureArg{value: &data}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectIndex(mock, models.SEARCH_USER, user.ID.String())
+	mock.ExpectCommit()
+
+	err := u.Insert(user)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Contains(data, `"passHash":""`)
+	assert.Contains(data, `"email":"Email"`)
+	assert.NotEqual("", user.PassHash)
+}
+
+func TestAddEventQueuesWebhooks(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+
+	payload := ""
+	mock.ExpectPrepare("INSERT INTO outboxEvent").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("INSERT INTO webhookDelivery \\(id, webhookId, eventId, type, payload, status, attempts, nextAttemptAt, createdAt, updatedAt\\) SELECT UUID\\(\\), id, \\?, \\?, \\?, \\?, 0, \\?, \\?, \\? FROM webhook WHERE roasterId=\\? AND active AND FIND_IN_SET\\(\\?, events\\)").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), models.ROASTER_DELETED, &captureArg{value: &payload}, models.DELIVERY_PENDING, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), id.String(), models.ROASTER_DELETED).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+
+	err := addEvent(&gateways.MySQL{DB: s}, models.ROASTER_DELETED, id, id, &models.Deleted{ID: id})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Contains(payload, `"type":"roaster.deleted"`)
+	assert.Contains(payload, `"roasterId":"`+id.String()+`"`)
+	assert.Contains(payload, `"data":{"id":"`+id.String()+`"}`)
+}
+
+func TestInTxOtherGateway(t *testing.T) {
+	assert := assert.New(t)
+
+	db := &otherSQL{}
+	var got gateways.SQL
+	err := inTx(db, func(sql gateways.SQL) error {
+		got = sql
+		return nil
+	})
+
+	assert.NoError(err)
+	assert.Equal(db, got)
+}
+
+// captureArg matches any argument, keeping it so the test can check it
+type captureArg struct {
+	value *string
+}
+
+func (c *captureArg) Match(v driver.Value) bool {
+	*c.value, _ = v.(string)
+	return true
+}
+
+/*otherSQL is a gateways.SQL that isn't the MySQL gateway, so it can't begin transactions*/
+type otherSQL struct{}
+
+func (o *otherSQL) Select(string, ...interface{}) (*sql.Rows, error) {
+	return nil, nil
+}
+
+func (o *otherSQL) Modify(string, ...interface{}) error {
+	return nil
+}
+
+func expectEvent(mock sqlmock.Sqlmock, kind string, aggregateID string, roasterID string) {
+	mock.ExpectPrepare("INSERT INTO outboxEvent").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), kind, aggregateID, roasterID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	if roasterID == "" {
+		return
+	}
+
+	mock.ExpectPrepare("INSERT INTO webhookDelivery").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), kind, sqlmock.AnyArg(), models.DELIVERY_PENDING, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), roasterID, kind).
+		WillReturnResult(sqlmock.NewResult(1, 0))
+}
+
+func getEventMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "type", "aggregateId", "roasterId", "data", "createdAt"})
+}
@@ -0,0 +1,483 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	tgateways "github.com/jakelong95/TownCenter/gateways"
+	"github.com/jakelong95/TownCenter/models"
+	_ "golang.org/x/image/webp"
+)
+
+/*Limits on uploaded photos, larger files or images are rejected before they're decoded*/
+const (
+	MaxPhotoBytes     = 10 << 20
+	MaxPhotoDimension = 6000
+	PhotoQuality      = 90
+)
+
+/*UploadExpiration is how long a presigned upload URL works for*/
+const UploadExpiration = 15 * time.Minute
+
+var (
+	ErrPhotoType     = errors.New("Error: photo must be a JPEG, PNG or WebP image")
+	ErrPhotoSize     = fmt.Errorf("Error: photo must be at most %d MB and %dx%d pixels", MaxPhotoBytes>>20, MaxPhotoDimension, MaxPhotoDimension)
+	ErrUploadKey     = errors.New("Error: key isn't an upload for this profile")
+	ErrUploadMissing = errors.New("Error: nothing has been uploaded to key")
+)
+
+const (
+	PHOTO_JPEG = "jpeg"
+	PHOTO_PNG  = "png"
+	PHOTO_WEBP = "webp"
+)
+
+/*photoTypes maps the content types a photo can be uploaded as to its format*/
+var photoTypes = map[string]string{
+	"image/jpeg": PHOTO_JPEG,
+	"image/png":  PHOTO_PNG,
+	"image/webp": PHOTO_WEBP,
+}
+
+/*Photo is an uploaded image with its metadata removed, encoded at each size it's stored at*/
+type Photo struct {
+	Format string
+	Sizes  map[string][]byte
+}
+
+// ProcessPhoto checks that body is a JPEG, PNG or WebP image within the size
+// limits and prepares it for storage. The photo is re-encoded, which drops its
+// EXIF data after applying its orientation, and gets a square thumbnail of
+// every size in models.PhotoSizes. Go can't encode WebP, so WebP photos are
+// stored as JPEG, or as PNG when they have transparency.
+func ProcessPhoto(body io.Reader) (*Photo, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(body, MaxPhotoBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxPhotoBytes {
+		return nil, ErrPhotoSize
+	}
+
+	photo := &Photo{Format: sniffPhoto(data), Sizes: make(map[string][]byte)}
+	if photo.Format == "" {
+		return nil, ErrPhotoType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrPhotoType
+	}
+	if config.Width > MaxPhotoDimension || config.Height > MaxPhotoDimension {
+		return nil, ErrPhotoSize
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrPhotoType
+	}
+	if photo.Format == PHOTO_JPEG {
+		img = orient(img, jpegOrientation(data))
+	}
+	if photo.Format == PHOTO_WEBP {
+		photo.Format = PHOTO_JPEG
+		if o, ok := img.(interface {
+			Opaque() bool
+		}); !ok || !o.Opaque() {
+			photo.Format = PHOTO_PNG
+		}
+	}
+
+	photo.Sizes[models.PHOTO_ORIGINAL], err = photo.encode(img)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each thumbnail is scaled from the next largest one, which is much
+	// cheaper than going back to the original every time.
+	sizes := make([]string, 0, len(models.PhotoSizes))
+	for size := range models.PhotoSizes {
+		sizes = append(sizes, size)
+	}
+	sort.Sort(byPixels(sizes))
+
+	for _, size := range sizes {
+		img = thumbnail(img, models.PhotoSizes[size])
+		photo.Sizes[size], err = photo.encode(img)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return photo, nil
+}
+
+/*Ext returns the file extension of the photo's format*/
+func (p *Photo) Ext() string {
+	if p.Format == PHOTO_JPEG {
+		return ".jpg"
+	}
+	return "." + p.Format
+}
+
+// Upload stores every size of the photo in the S3 folder and returns the URL
+// of the original. Keys end in the size, which is what models.PhotoURLs
+// relies on to find the thumbnails, and include the time so a new photo never
+// reuses a cached URL.
+func (p *Photo) Upload(s3 gateways.S3, folder string, id string) (string, error) {
+	sizes := make([]string, 0, len(p.Sizes))
+	for size := range p.Sizes {
+		sizes = append(sizes, size)
+	}
+	sort.Strings(sizes)
+
+	stamp := now().Unix()
+	url := ""
+	for _, size := range sizes {
+		name := fmt.Sprintf("%d-%s%s", stamp, size, p.Ext())
+		u, err := upload(s3, folder, id, name, &memoryFile{bytes.NewReader(p.Sizes[size])})
+		if err != nil {
+			return "", err
+		}
+
+		if size == models.PHOTO_ORIGINAL {
+			url = u
+		}
+	}
+
+	return url, nil
+}
+
+/*removePhoto deletes every size of the photo at url from S3*/
+func removePhoto(s3 gateways.S3, url string) error {
+	store, err := townCenterS3(s3)
+	if err != nil {
+		return err
+	}
+
+	urls := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, u := range models.PhotoURLs(url) {
+		if !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	sort.Strings(urls)
+
+	for _, u := range urls {
+		err := store.Delete(u)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*profileURL returns the profile photo currently saved for the user or roaster with id*/
+func profileURL(sql gateways.SQL, table string, id string) (string, error) {
+	rows, err := sql.Select("SELECT profileUrl FROM "+table+" WHERE id=?", id)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	url := ""
+	if rows.Next() {
+		err = rows.Scan(&url)
+	}
+
+	return url, err
+}
+
+// removeReplaced deletes the photo at previous once url has replaced it. The
+// new photo is already saved, so a failed delete is only logged.
+func removeReplaced(s3 gateways.S3, previous string, url string) {
+	if previous == "" || previous == url {
+		return
+	}
+
+	err := removePhoto(s3, previous)
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// presignPhoto returns a URL the client can upload a photo of contentType
+// straight to S3 at. Uploads land under upload/ rather than in folder, since
+// nothing has checked them yet.
+func presignPhoto(s3 gateways.S3, folder string, id string, contentType string) (*models.PresignedUpload, error) {
+	store, err := townCenterS3(s3)
+	if err != nil {
+		return nil, err
+	}
+
+	format, ok := photoTypes[contentType]
+	if !ok {
+		return nil, ErrPhotoType
+	}
+
+	key := fmt.Sprintf("%s%d%s", uploadPrefix(folder, id), now().Unix(), (&Photo{Format: format}).Ext())
+	url, err := store.PresignUpload(key, contentType, UploadExpiration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PresignedUpload{
+		Key:       key,
+		URL:       url,
+		Method:    "PUT",
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: now().Add(UploadExpiration),
+	}, nil
+}
+
+/*fetchPhoto reads the upload at key back from S3, processes it like a photo sent to the API and deletes it*/
+func fetchPhoto(s3 gateways.S3, folder string, id string, key string) (*Photo, error) {
+	store, err := townCenterS3(s3)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(key, uploadPrefix(folder, id)) || strings.Contains(key, "..") {
+		return nil, ErrUploadKey
+	}
+
+	body, size, err := store.Open(key)
+	if err == tgateways.ErrNoObject {
+		return nil, ErrUploadMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var photo *Photo
+	if size > MaxPhotoBytes {
+		err = ErrPhotoSize
+	} else {
+		photo, err = ProcessPhoto(body)
+	}
+
+	derr := store.Delete(key)
+	if err != nil {
+		return nil, err
+	}
+	if derr != nil {
+		return nil, fmt.Errorf("Error: unable to delete upload %s: %s", key, derr.Error())
+	}
+
+	return photo, nil
+}
+
+func uploadPrefix(folder string, id string) string {
+	return fmt.Sprintf("upload/%s/%s-", folder, id)
+}
+
+/*townCenterS3 returns s3 as the TownCenter gateway, the bloodlines one can only upload*/
+func townCenterS3(s3 gateways.S3) (tgateways.S3, error) {
+	store, ok := s3.(tgateways.S3)
+	if !ok {
+		return nil, fmt.Errorf("Error: S3 gateway can only upload")
+	}
+
+	return store, nil
+}
+
+func (p *Photo) encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var err error
+	if p.Format == PHOTO_PNG {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: PhotoQuality})
+	}
+
+	return buf.Bytes(), err
+}
+
+/*memoryFile lets processed photos be passed to S3 as a multipart.File*/
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (m *memoryFile) Close() error {
+	return nil
+}
+
+/*byPixels sorts photo sizes from largest to smallest*/
+type byPixels []string
+
+func (b byPixels) Len() int           { return len(b) }
+func (b byPixels) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byPixels) Less(i, j int) bool { return models.PhotoSizes[b[i]] > models.PhotoSizes[b[j]] }
+
+/*sniffPhoto returns the format of data from its magic bytes, ignoring whatever the client said it was*/
+func sniffPhoto(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return PHOTO_JPEG
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return PHOTO_PNG
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return PHOTO_WEBP
+	}
+
+	return ""
+}
+
+// thumbnail crops the middle square out of img and scales it down to px
+// pixels a side by averaging the pixels that fall in each output pixel.
+// Images smaller than px aren't scaled up.
+func thumbnail(img image.Image, px int) image.Image {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	if px > side {
+		px = side
+	}
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewNRGBA(image.Rect(0, 0, px, px))
+	for y := 0; y < px; y++ {
+		sy0, sy1 := y0+y*side/px, y0+(y+1)*side/px
+		for x := 0; x < px; x++ {
+			sx0, sx1 := x0+x*side/px, x0+(x+1)*side/px
+
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(bl / n), uint16(a / n)})
+		}
+	}
+
+	return dst
+}
+
+// orient turns img the right way up for its EXIF orientation, which would
+// otherwise be lost when the EXIF data is stripped.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if orientation >= 5 {
+		w, h = h, w
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, w-1-x
+			case 7:
+				sx, sy = h-1-y, w-1-x
+			case 8:
+				sx, sy = h-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
+
+/*jpegOrientation returns the EXIF orientation of a JPEG, 1 when it doesn't have one*/
+func jpegOrientation(data []byte) int {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return 1
+		}
+
+		marker := data[i+1]
+		if marker == 0xda || marker == 0xd9 {
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : end]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+
+		i = end
+	}
+
+	return 1
+}
+
+/*exifOrientation reads the orientation tag from the first IFD of a TIFF header*/
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < entries; e++ {
+		offset := ifd + 2 + e*12
+		if offset+12 > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[offset:]) == 0x0112 {
+			return int(order.Uint16(tiff[offset+8:]))
+		}
+	}
+
+	return 1
+}
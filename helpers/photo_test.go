@@ -0,0 +1,337 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	mocks "github.com/ghmeier/bloodlines/_mocks/gateways"
+	tgateways "github.com/jakelong95/TownCenter/gateways"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	tmock "github.com/stretchr/testify/mock"
+)
+
+func TestProcessPhotoJPEG(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, getTestImage(600, 400), nil)
+
+	photo, err := ProcessPhoto(&buf)
+
+	assert.NoError(err)
+	assert.Equal(PHOTO_JPEG, photo.Format)
+	assert.Equal(".jpg", photo.Ext())
+	assert.Equal(4, len(photo.Sizes))
+	assert.Equal(image.Pt(600, 400), getPhotoSize(photo.Sizes[models.PHOTO_ORIGINAL]))
+	assert.Equal(image.Pt(400, 400), getPhotoSize(photo.Sizes[models.PHOTO_LARGE]))
+	assert.Equal(image.Pt(256, 256), getPhotoSize(photo.Sizes[models.PHOTO_MEDIUM]))
+	assert.Equal(image.Pt(64, 64), getPhotoSize(photo.Sizes[models.PHOTO_SMALL]))
+}
+
+func TestProcessPhotoPNG(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	png.Encode(&buf, getTestImage(100, 100))
+
+	photo, err := ProcessPhoto(&buf)
+
+	assert.NoError(err)
+	assert.Equal(PHOTO_PNG, photo.Format)
+	assert.Equal(image.Pt(64, 64), getPhotoSize(photo.Sizes[models.PHOTO_SMALL]))
+	assert.Equal(image.Pt(100, 100), getPhotoSize(photo.Sizes[models.PHOTO_LARGE]))
+}
+
+func TestProcessPhotoStripsExif(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, getTestImage(40, 20), nil)
+	data := buf.Bytes()
+	withExif := append(append(append([]byte{}, data[:2]...), getExifSegment(6)...), data[2:]...)
+
+	photo, err := ProcessPhoto(bytes.NewReader(withExif))
+
+	assert.NoError(err)
+	assert.False(bytes.Contains(photo.Sizes[models.PHOTO_ORIGINAL], []byte("Exif")))
+	assert.Equal(image.Pt(20, 40), getPhotoSize(photo.Sizes[models.PHOTO_ORIGINAL]))
+}
+
+func TestProcessPhotoUnsupported(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	gif.Encode(&buf, getTestImage(10, 10), nil)
+
+	_, err := ProcessPhoto(&buf)
+	assert.Equal(ErrPhotoType, err)
+
+	_, err = ProcessPhoto(strings.NewReader("<svg></svg>"))
+	assert.Equal(ErrPhotoType, err)
+}
+
+func TestProcessPhotoWebP(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := base64.StdEncoding.DecodeString("UklGRiIAAABXRUJQVlA4IBYAAAAwAQCdASoBAAEADsD+JaQAA3AAAAAA")
+
+	photo, err := ProcessPhoto(bytes.NewReader(data))
+
+	assert.NoError(err)
+	assert.Equal(PHOTO_JPEG, photo.Format)
+	assert.Equal(len(models.PhotoSizes)+1, len(photo.Sizes))
+	_, format, err := image.Decode(bytes.NewReader(photo.Sizes[models.PHOTO_ORIGINAL]))
+	assert.NoError(err)
+	assert.Equal("jpeg", format)
+}
+
+func TestProcessPhotoWebPTransparent(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+
+	photo, err := ProcessPhoto(bytes.NewReader(data))
+
+	assert.NoError(err)
+	assert.Equal(PHOTO_PNG, photo.Format)
+	assert.Equal(".png", photo.Ext())
+	_, format, err := image.Decode(bytes.NewReader(photo.Sizes[models.PHOTO_SMALL]))
+	assert.NoError(err)
+	assert.Equal("png", format)
+}
+
+func TestProcessPhotoCorrupt(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := ProcessPhoto(strings.NewReader("\x89PNG\r\n\x1a\nnot really a png"))
+	assert.Equal(ErrPhotoType, err)
+
+	_, err = ProcessPhoto(strings.NewReader("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x0f\xc0\x01\x00"))
+	assert.Equal(ErrPhotoType, err)
+}
+
+func TestProcessPhotoTooLarge(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := ProcessPhoto(bytes.NewReader(make([]byte, MaxPhotoBytes+1)))
+	assert.Equal(ErrPhotoSize, err)
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, MaxPhotoDimension+1, 1)))
+	_, err = ProcessPhoto(&buf)
+	assert.Equal(ErrPhotoSize, err)
+}
+
+func TestPhotoUpload(t *testing.T) {
+	assert := assert.New(t)
+
+	photo := &Photo{Format: PHOTO_JPEG, Sizes: map[string][]byte{
+		models.PHOTO_ORIGINAL: []byte("original"),
+		models.PHOTO_SMALL:    []byte("small"),
+	}}
+	sMock := &mocks.S3{}
+	sMock.On("Upload", "profile", tmock.MatchedBy(func(name string) bool { return strings.HasSuffix(name, "-original.jpg") }), tmock.Anything).
+		Return("https://s3.com/profile/id-1-original.jpg", nil)
+	sMock.On("Upload", "profile", tmock.MatchedBy(func(name string) bool { return strings.HasSuffix(name, "-small.jpg") }), tmock.Anything).
+		Return("https://s3.com/profile/id-1-small.jpg", nil)
+
+	url, err := photo.Upload(sMock, "profile", "id")
+
+	assert.NoError(err)
+	assert.Equal("https://s3.com/profile/id-1-original.jpg", url)
+	sMock.AssertNumberOfCalls(t, "Upload", 2)
+}
+
+func TestPhotoUploadError(t *testing.T) {
+	assert := assert.New(t)
+
+	sMock := &mocks.S3{}
+	sMock.On("Upload", "profile", tmock.AnythingOfType("string"), tmock.Anything).
+		Return("", fmt.Errorf("some error"))
+
+	_, err := getMockPhoto().Upload(sMock, "profile", "id")
+
+	assert.Error(err)
+}
+
+func TestPresignPhoto(t *testing.T) {
+	assert := assert.New(t)
+
+	s3 := tgateways.NewLocalS3("bucket")
+
+	upload, err := presignPhoto(s3, "profile", "id", "image/jpeg")
+
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(upload.Key, "upload/profile/id-"))
+	assert.True(strings.HasSuffix(upload.Key, ".jpg"))
+	assert.True(strings.HasPrefix(upload.URL, "/bucket/"+upload.Key+"?"))
+	assert.Equal("PUT", upload.Method)
+	assert.Equal("image/jpeg", upload.Headers["Content-Type"])
+}
+
+func TestPresignPhotoType(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := presignPhoto(tgateways.NewLocalS3("bucket"), "profile", "id", "image/gif")
+	assert.Equal(ErrPhotoType, err)
+
+	upload, err := presignPhoto(tgateways.NewLocalS3("bucket"), "profile", "id", "image/webp")
+	assert.NoError(err)
+	assert.True(strings.HasSuffix(upload.Key, ".webp"))
+}
+
+func TestPresignPhotoBloodlines(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := presignPhoto(&mocks.S3{}, "profile", "id", "image/png")
+
+	assert.Error(err)
+}
+
+func TestFetchPhoto(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	png.Encode(&buf, getTestImage(300, 200))
+	s3 := tgateways.NewLocalS3("bucket")
+	s3.Put("upload/profile/id-1.png", buf.Bytes())
+
+	photo, err := fetchPhoto(s3, "profile", "id", "upload/profile/id-1.png")
+
+	assert.NoError(err)
+	assert.Equal(PHOTO_PNG, photo.Format)
+	assert.Equal(4, len(photo.Sizes))
+	_, ok := s3.Get("upload/profile/id-1.png")
+	assert.False(ok)
+}
+
+func TestFetchPhotoNotPhoto(t *testing.T) {
+	assert := assert.New(t)
+
+	s3 := tgateways.NewLocalS3("bucket")
+	s3.Put("upload/profile/id-1.png", []byte("<html>surprise</html>"))
+
+	_, err := fetchPhoto(s3, "profile", "id", "upload/profile/id-1.png")
+
+	assert.Equal(ErrPhotoType, err)
+	_, ok := s3.Get("upload/profile/id-1.png")
+	assert.False(ok)
+}
+
+func TestFetchPhotoDeleteFails(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	png.Encode(&buf, getTestImage(30, 20))
+	s3 := &failingDeleteS3{tgateways.NewLocalS3("bucket")}
+	s3.Put("upload/profile/id-1.png", buf.Bytes())
+
+	photo, err := fetchPhoto(s3, "profile", "id", "upload/profile/id-1.png")
+
+	assert.Error(err)
+	assert.Contains(err.Error(), "upload/profile/id-1.png")
+	assert.Nil(photo)
+}
+
+func TestFetchPhotoTooLarge(t *testing.T) {
+	assert := assert.New(t)
+
+	s3 := tgateways.NewLocalS3("bucket")
+	s3.Put("upload/profile/id-1.png", make([]byte, MaxPhotoBytes+1))
+
+	_, err := fetchPhoto(s3, "profile", "id", "upload/profile/id-1.png")
+
+	assert.Equal(ErrPhotoSize, err)
+}
+
+func TestFetchPhotoOtherProfile(t *testing.T) {
+	assert := assert.New(t)
+
+	s3 := tgateways.NewLocalS3("bucket")
+	s3.Put("upload/profile/other-1.png", []byte("photo"))
+
+	_, err := fetchPhoto(s3, "profile", "id", "upload/profile/other-1.png")
+	assert.Equal(ErrUploadKey, err)
+
+	_, err = fetchPhoto(s3, "profile", "id", "upload/profile/id-/../other-1.png")
+	assert.Equal(ErrUploadKey, err)
+
+	_, ok := s3.Get("upload/profile/other-1.png")
+	assert.True(ok)
+}
+
+func TestFetchPhotoMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := fetchPhoto(tgateways.NewLocalS3("bucket"), "profile", "id", "upload/profile/id-1.png")
+
+	assert.Equal(ErrUploadMissing, err)
+}
+
+func expectProfileURL(mock sqlmock.Sqlmock, table string, id string, url string) {
+	mock.ExpectQuery("SELECT profileUrl FROM " + table + " WHERE id=\\?").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"profileUrl"}).AddRow(url))
+}
+
+func getMockPhoto() *Photo {
+	return &Photo{Format: PHOTO_PNG, Sizes: map[string][]byte{models.PHOTO_ORIGINAL: []byte("original")}}
+}
+
+func getTestImage(width, height int) image.Image {
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.Black, color.White})
+	for x := 0; x < width/2; x++ {
+		for y := 0; y < height; y++ {
+			img.SetColorIndex(x, y, 1)
+		}
+	}
+
+	return img
+}
+
+func getPhotoSize(data []byte) image.Point {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return image.Point{}
+	}
+
+	return image.Pt(config.Width, config.Height)
+}
+
+/*getExifSegment builds a JPEG APP1 segment holding just an orientation tag*/
+func getExifSegment(orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(append(tiff, entry...), 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+
+	return append(segment, payload...)
+}
+
+/*failingDeleteS3 is a local S3 that can't delete anything*/
+type failingDeleteS3 struct {
+	*tgateways.LocalS3
+}
+
+func (f *failingDeleteS3) Delete(location string) error {
+	return fmt.Errorf("This is an error")
+}
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"encoding/json"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type RoasterProfileI interface {
+	Get(string) (*models.RoasterProfile, error)
+	Set(*models.RoasterProfile) error
+}
+
+type RoasterProfile struct {
+	*baseHelper
+}
+
+func NewRoasterProfile(sql gateways.SQL) *RoasterProfile {
+	return &RoasterProfile{
+		baseHelper: &baseHelper{sql: sql},
+	}
+}
+
+/*Get returns the storefront profile of the roaster, or nil when it hasn't set one up*/
+func (r *RoasterProfile) Get(roasterID string) (*models.RoasterProfile, error) {
+	rows, err := r.sql.Select("SELECT roasterId, description, founded, website, social, hours, timezone, tags, updatedAt FROM roasterProfile WHERE roasterId=?", roasterID)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := models.RoasterProfileFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(profiles) == 0 {
+		return nil, nil
+	}
+
+	return profiles[0], nil
+}
+
+/*Set creates or replaces the roaster's storefront profile*/
+func (r *RoasterProfile) Set(profile *models.RoasterProfile) error {
+	profile.UpdatedAt = now()
+
+	social, err := json.Marshal(profile.Social)
+	if err != nil {
+		return err
+	}
+	hours, err := json.Marshal(profile.Hours)
+	if err != nil {
+		return err
+	}
+	tags, err := json.Marshal(profile.Tags)
+	if err != nil {
+		return err
+	}
+
+	err = r.sql.Modify(
+		"INSERT INTO roasterProfile (roasterId, description, founded, website, social, hours, timezone, tags, updatedAt) VALUE (?,?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE description=VALUES(description), founded=VALUES(founded), website=VALUES(website), social=VALUES(social), hours=VALUES(hours), timezone=VALUES(timezone), tags=VALUES(tags), updatedAt=VALUES(updatedAt)",
+		profile.RoasterID,
+		profile.Description,
+		profile.Founded,
+		profile.Website,
+		string(social),
+		string(hours),
+		profile.Timezone,
+		string(tags),
+		profile.UpdatedAt,
+	)
+
+	return err
+}
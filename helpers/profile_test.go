@@ -0,0 +1,144 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoasterProfileGet(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	p := getMockRoasterProfile(s)
+
+	mock.ExpectQuery("SELECT roasterId, description, founded, website, social, hours, timezone, tags, updatedAt FROM roasterProfile WHERE roasterId=\\?").
+		WithArgs(id.String()).
+		WillReturnRows(getRoasterProfileMockRows().AddRow(id.String(), "Small batch", "2009-04-01", "https://kaldis.com", `{"instagram":"https://instagram.com/kaldis"}`, `[{"day":"mon","open":"07:00","close":"15:00"}]`, "America/Chicago", `["light roast","single origin"]`, time.Now()))
+
+	profile, err := p.Get(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(id, profile.RoasterID)
+	assert.Equal("Small batch", profile.Description)
+	assert.Equal(models.NewDate(2009, time.April, 1), profile.Founded)
+	assert.Equal("https://instagram.com/kaldis", profile.Social["instagram"])
+	assert.Equal(&models.OpeningHours{Day: "mon", Open: "07:00", Close: "15:00"}, profile.Hours[0])
+	assert.Equal([]string{"light roast", "single origin"}, profile.Tags)
+}
+
+func TestRoasterProfileGetEmptyColumns(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	p := getMockRoasterProfile(s)
+
+	mock.ExpectQuery("SELECT roasterId, description, founded, website, social, hours, timezone, tags, updatedAt FROM roasterProfile").
+		WithArgs(id.String()).
+		WillReturnRows(getRoasterProfileMockRows().AddRow(id.String(), "", nil, "", "", "", "", "", time.Now()))
+
+	profile, err := p.Get(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.True(profile.Founded.IsZero())
+	assert.Equal(0, len(profile.Social))
+	assert.NotNil(profile.Hours)
+	assert.NotNil(profile.Tags)
+}
+
+func TestRoasterProfileGetDoesNotExist(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	p := getMockRoasterProfile(s)
+
+	mock.ExpectQuery("SELECT roasterId, description, founded, website, social, hours, timezone, tags, updatedAt FROM roasterProfile").
+		WithArgs(id.String()).
+		WillReturnRows(getRoasterProfileMockRows())
+
+	profile, err := p.Get(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Nil(profile)
+}
+
+func TestRoasterProfileGetError(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	p := getMockRoasterProfile(s)
+
+	mock.ExpectQuery("SELECT roasterId, description, founded, website, social, hours, timezone, tags, updatedAt FROM roasterProfile").
+		WithArgs(id.String()).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	profile, err := p.Get(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+	assert.Nil(profile)
+}
+
+func TestRoasterProfileSet(t *testing.T) {
+	assert := assert.New(t)
+
+	profile := models.NewRoasterProfile(uuid.NewUUID())
+	profile.Description = "Small batch"
+	profile.Founded = models.NewDate(2009, time.April, 1)
+	profile.Social["instagram"] = "https://instagram.com/kaldis"
+	profile.Tags = append(profile.Tags, "espresso")
+	s, mock, _ := sqlmock.New()
+	p := getMockRoasterProfile(s)
+
+	mock.ExpectPrepare("INSERT INTO roasterProfile .* ON DUPLICATE KEY UPDATE").
+		ExpectExec().
+		WithArgs(profile.RoasterID.String(), "Small batch", "2009-04-01", "", `{"instagram":"https://instagram.com/kaldis"}`, "[]", "", `["espresso"]`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := p.Set(profile)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.False(profile.UpdatedAt.IsZero())
+}
+
+func TestRoasterProfileSetError(t *testing.T) {
+	assert := assert.New(t)
+
+	profile := models.NewRoasterProfile(uuid.NewUUID())
+	s, mock, _ := sqlmock.New()
+	p := getMockRoasterProfile(s)
+
+	mock.ExpectPrepare("INSERT INTO roasterProfile").
+		ExpectExec().
+		WithArgs(profile.RoasterID.String(), "", nil, "", "{}", "[]", "", "[]", sqlmock.AnyArg()).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	err := p.Set(profile)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func getRoasterProfileMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"roasterId", "description", "founded", "website", "social", "hours", "timezone", "tags", "updatedAt"})
+}
+
+func getMockRoasterProfile(s *sql.DB) *RoasterProfile {
+	return NewRoasterProfile(&gateways.MySQL{DB: s})
+}
@@ -0,0 +1,105 @@
+package helpers_test
+
+import (
+	"fmt"
+	"testing"
+
+	tmocks "github.com/jakelong95/TownCenter/_mocks"
+	mocks "github.com/jakelong95/TownCenter/_mocks/helpers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRelayRun(t *testing.T) {
+	assert := assert.New(t)
+
+	events := []*models.Event{getMockEvent(models.USER_CREATED), getMockEvent(models.USER_UPDATED)}
+	r, outbox, publisher := getMockRelay()
+
+	outbox.On("Claim", "runner", helpers.OutboxBatch).Return(events, nil)
+	publisher.On("Publish", events[0]).Return(nil)
+	publisher.On("Publish", events[1]).Return(nil)
+	outbox.On("MarkPublished", events[0].ID.String()).Return(nil)
+	outbox.On("MarkPublished", events[1].ID.String()).Return(nil)
+	outbox.On("Release", "runner").Return(nil)
+	outbox.On("Prune", mock.AnythingOfType("time.Time")).Return(nil)
+
+	published, err := r.Run()
+
+	assert.NoError(err)
+	assert.Equal(2, published)
+	outbox.AssertExpectations(t)
+}
+
+func TestRelayRunStopsAtFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	events := []*models.Event{getMockEvent(models.USER_CREATED), getMockEvent(models.USER_UPDATED)}
+	r, outbox, publisher := getMockRelay()
+
+	outbox.On("Claim", "runner", helpers.OutboxBatch).Return(events, nil)
+	publisher.On("Publish", events[0]).Return(fmt.Errorf("some error"))
+	outbox.On("Release", "runner").Return(nil)
+
+	published, err := r.Run()
+
+	assert.Error(err)
+	assert.Equal(0, published)
+	outbox.AssertCalled(t, "Release", "runner")
+	publisher.AssertNotCalled(t, "Publish", events[1])
+	outbox.AssertNotCalled(t, "MarkPublished", mock.Anything)
+	outbox.AssertNotCalled(t, "Prune", mock.Anything)
+}
+
+func TestRelayRunBatches(t *testing.T) {
+	assert := assert.New(t)
+
+	first := make([]*models.Event, helpers.OutboxBatch)
+	for i := range first {
+		first[i] = getMockEvent(models.ROASTER_UPDATED)
+	}
+	r, outbox, publisher := getMockRelay()
+
+	outbox.On("Claim", "runner", helpers.OutboxBatch).Return(first, nil).Once()
+	outbox.On("Claim", "runner", helpers.OutboxBatch).Return([]*models.Event{}, nil).Once()
+	publisher.On("Publish", mock.AnythingOfType("*models.Event")).Return(nil)
+	outbox.On("MarkPublished", mock.AnythingOfType("string")).Return(nil)
+	outbox.On("Release", "runner").Return(nil)
+	outbox.On("Prune", mock.AnythingOfType("time.Time")).Return(nil)
+
+	published, err := r.Run()
+
+	assert.NoError(err)
+	assert.Equal(helpers.OutboxBatch, published)
+	outbox.AssertNumberOfCalls(t, "Claim", 2)
+	outbox.AssertNumberOfCalls(t, "Release", 1)
+}
+
+func TestRelayRunReleaseError(t *testing.T) {
+	assert := assert.New(t)
+
+	r, outbox, _ := getMockRelay()
+
+	outbox.On("Claim", "runner", helpers.OutboxBatch).Return([]*models.Event{}, nil)
+	outbox.On("Release", "runner").Return(fmt.Errorf("some error"))
+
+	_, err := r.Run()
+
+	assert.Error(err)
+	outbox.AssertNotCalled(t, "Prune", mock.Anything)
+}
+
+func getMockEvent(kind string) *models.Event {
+	event, _ := models.NewEvent(kind, uuid.NewUUID(), &models.Deleted{ID: uuid.NewUUID()})
+	return event
+}
+
+func getMockRelay() (*helpers.Relay, *mocks.OutboxI, *tmocks.Publisher) {
+	outbox := &mocks.OutboxI{}
+	publisher := &tmocks.Publisher{}
+	return &helpers.Relay{Outbox: outbox, Publisher: publisher, Runner: "runner"}, outbox, publisher
+}
@@ -1,8 +1,7 @@
 package helpers
 
 import (
-	"fmt"
-	"mime/multipart"
+	"sort"
 
 	"github.com/ghmeier/bloodlines/gateways"
 	gcoinage "github.com/ghmeier/coinage/gateways"
@@ -14,18 +13,30 @@ import (
 
 type RoasterI interface {
 	GetByID(string) (*models.Roaster, error)
-	GetAll(int, int) ([]*models.Roaster, error)
+	GetByIDs([]string) ([]*models.Roaster, error)
+	GetAll(int, int, *models.ListFilter) ([]*models.Roaster, error)
 	Insert(*models.Roaster) error
 	Update(*models.Roaster, string) error
 	CreateAccount(id uuid.UUID) error
-	Profile(string, string, multipart.File) error
+	Profile(string, *Photo) error
+	RemoveProfile(string, string) error
+	PresignProfile(string, string) (*models.PresignedUpload, error)
+	CompleteProfile(string, string) error
 	Delete(string) error
+	VerifyPhone(string, string) error
+	GetNearby(float64, float64, float64, int, int) ([]*models.NearbyRoaster, error)
+	Search(string, int, int) ([]*models.Roaster, error)
+	GetBySlug(string) (*models.Roaster, error)
+	GetByEmail(string) (*models.Roaster, error)
+	GetRedirect(string) (string, error)
+	SlugTaken(string, string) (bool, error)
 }
 
 type Roaster struct {
 	*baseHelper
 	S3      gateways.S3
 	Coinage gcoinage.Coinage
+	Index   SearchI
 }
 
 func NewRoaster(sql gateways.SQL, s3 gateways.S3, coinage gcoinage.Coinage) *Roaster {
@@ -33,11 +44,12 @@ func NewRoaster(sql gateways.SQL, s3 gateways.S3, coinage gcoinage.Coinage) *Roa
 		baseHelper: &baseHelper{sql: sql},
 		S3:         s3,
 		Coinage:    coinage,
+		Index:      NewSearch(sql),
 	}
 }
 
 func (r *Roaster) GetByID(id string) (*models.Roaster, error) {
-	rows, err := r.sql.Select("SELECT id, name, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth FROM roaster WHERE id=?", id)
+	rows, err := r.sql.Select("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE id=?", id)
 	if err != nil {
 		return nil, err
 	}
@@ -54,8 +66,106 @@ func (r *Roaster) GetByID(id string) (*models.Roaster, error) {
 	return roasters[0], err
 }
 
-func (r *Roaster) GetAll(offset int, limit int) ([]*models.Roaster, error) {
-	rows, err := r.sql.Select("SELECT id, name, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth FROM roaster ORDER BY id ASC LIMIT ?,?", offset, limit)
+/*GetByIDs returns the roasters with any of the given IDs, in no particular order*/
+func (r *Roaster) GetByIDs(ids []string) ([]*models.Roaster, error) {
+	if len(ids) == 0 {
+		return make([]*models.Roaster, 0), nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := r.sql.Select("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE id IN ("+placeholders(len(ids))+")", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.RoasterFromSQL(rows)
+}
+
+func (r *Roaster) GetBySlug(slug string) (*models.Roaster, error) {
+	rows, err := r.sql.Select("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE slug=?", slug)
+	if err != nil {
+		return nil, err
+	}
+
+	roasters, err := models.RoasterFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roasters) == 0 {
+		return nil, nil
+	}
+
+	return roasters[0], err
+}
+
+/*GetByEmail returns the roaster with email, or nil when there isn't one*/
+func (r *Roaster) GetByEmail(email string) (*models.Roaster, error) {
+	rows, err := r.sql.Select("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE email=? ORDER BY createdAt LIMIT 1", email)
+	if err != nil {
+		return nil, err
+	}
+
+	roasters, err := models.RoasterFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roasters) == 0 {
+		return nil, nil
+	}
+
+	return roasters[0], err
+}
+
+/*GetRedirect returns the current slug of the roaster that used to have slug, or "" when no roaster did*/
+func (r *Roaster) GetRedirect(slug string) (string, error) {
+	rows, err := r.sql.Select("SELECT r.slug FROM roasterSlug h JOIN roaster r ON r.id=h.roasterId WHERE h.slug=?", slug)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	current := ""
+	if rows.Next() {
+		err = rows.Scan(&current)
+	}
+
+	return current, err
+}
+
+/*SlugTaken reports whether a roaster other than id has, or used to have, slug*/
+func (r *Roaster) SlugTaken(slug string, id string) (bool, error) {
+	rows, err := r.sql.Select("SELECT id FROM roaster WHERE slug=? AND id<>? UNION SELECT roasterId FROM roasterSlug WHERE slug=? AND roasterId<>?", slug, id, slug, id)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	return rows.Next(), nil
+}
+
+/*uniqueSlug returns the first free slug built from the roaster's name*/
+func (r *Roaster) uniqueSlug(roaster *models.Roaster) (string, error) {
+	base := models.Slugify(roaster.Name)
+	for n := 1; ; n++ {
+		slug := models.SlugCandidate(base, n)
+		taken, err := r.SlugTaken(slug, roaster.ID.String())
+		if err != nil || !taken {
+			return slug, err
+		}
+	}
+}
+
+func (r *Roaster) GetAll(offset int, limit int, filter *models.ListFilter) ([]*models.Roaster, error) {
+	where, args := filterClause(filter)
+	args = append(args, offset, limit)
+
+	rows, err := r.sql.Select("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster"+where+" ORDER BY id ASC LIMIT ?,?", args...)
 	if err != nil {
 		return nil, err
 	}
@@ -68,43 +178,209 @@ func (r *Roaster) GetAll(offset int, limit int) ([]*models.Roaster, error) {
 	return roasters, err
 }
 
+/*GetNearby returns the active roasters within radius kilometers of lat, lng ordered by distance*/
+func (r *Roaster) GetNearby(lat float64, lng float64, radius float64, offset int, limit int) ([]*models.NearbyRoaster, error) {
+	minLat, maxLat, minLng, maxLng := models.BoundingBox(lat, lng, radius)
+
+	rows, err := r.sql.Select("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE status=? AND latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", models.STATUS_ACTIVE, minLat, maxLat, minLng, maxLng)
+	if err != nil {
+		return nil, err
+	}
+
+	roasters, err := models.RoasterFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	//The bounding box includes its corners, so trim to the actual radius
+	nearby := make([]*models.NearbyRoaster, 0)
+	for _, roaster := range roasters {
+		if roaster.Latitude == nil || roaster.Longitude == nil {
+			continue
+		}
+
+		distance := models.Distance(lat, lng, *roaster.Latitude, *roaster.Longitude)
+		if distance <= radius {
+			nearby = append(nearby, &models.NearbyRoaster{Roaster: roaster, Distance: distance})
+		}
+	}
+
+	sort.Stable(models.ByDistance(nearby))
+
+	if offset >= len(nearby) {
+		return make([]*models.NearbyRoaster, 0), nil
+	}
+	if offset+limit < len(nearby) {
+		nearby = nearby[:offset+limit]
+	}
+
+	return nearby[offset:], nil
+}
+
+/*Search returns the active roasters whose name or city match query, best match first*/
+func (r *Roaster) Search(query string, offset int, limit int) ([]*models.Roaster, error) {
+	ids, err := r.Index.Search(models.SEARCH_ROASTER, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return make([]*models.Roaster, 0), nil
+	}
+
+	//Inactive roasters are dropped before paging so pages stay full
+	args := make([]interface{}, len(ids)+1)
+	args[0] = models.STATUS_ACTIVE
+	for i, id := range ids {
+		args[i+1] = id
+	}
+
+	rows, err := r.sql.Select("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE status=? AND id IN ("+placeholders(len(ids))+")", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	roasters, err := models.RoasterFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	//Put the roasters back in ranked order
+	byID := make(map[string]*models.Roaster)
+	for _, roaster := range roasters {
+		byID[roaster.ID.String()] = roaster
+	}
+
+	ranked := make([]string, 0, len(roasters))
+	for _, id := range ids {
+		if _, ok := byID[id]; ok {
+			ranked = append(ranked, id)
+		}
+	}
+
+	ranked = page(ranked, offset, limit)
+	results := make([]*models.Roaster, len(ranked))
+	for i, id := range ranked {
+		results[i] = byID[id]
+	}
+
+	return results, nil
+}
+
 func (r *Roaster) Insert(roaster *models.Roaster) error {
-	err := r.sql.Modify(
-		"INSERT INTO roaster (id, name, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth) VALUE (?,?,?,?,?,?,?,?,?,?,?,?)",
-		roaster.ID,
-		roaster.Name,
-		roaster.Email,
-		roaster.Phone,
-		roaster.AddressLine1,
-		roaster.AddressLine2,
-		roaster.AddressCity,
-		roaster.AddressState,
-		roaster.AddressZip,
-		roaster.AddressCountry,
-		roaster.ProfileUrl,
-		roaster.Birthday,
-	)
+	roaster.CreatedAt = now()
+	roaster.UpdatedAt = roaster.CreatedAt
+	roaster.Geocode()
+
+	if roaster.Slug == "" {
+		slug, err := r.uniqueSlug(roaster)
+		if err != nil {
+			return err
+		}
+		roaster.Slug = slug
+	}
+
+	err := inTx(r.sql, func(sql gateways.SQL) error {
+		err := sql.Modify(
+			"INSERT INTO roaster (id, name, slug, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt) VALUE (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
+			roaster.ID,
+			roaster.Name,
+			roaster.Slug,
+			roaster.Email,
+			roaster.Phone,
+			roaster.AddressLine1,
+			roaster.AddressLine2,
+			roaster.AddressCity,
+			roaster.AddressState,
+			roaster.AddressZip,
+			roaster.AddressCountry,
+			roaster.ProfileUrl,
+			roaster.Birthday,
+			roaster.Latitude,
+			roaster.Longitude,
+			roaster.Status,
+			roaster.CreatedAt,
+			roaster.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = addEvent(sql, models.ROASTER_CREATED, roaster.ID, roaster.ID, roaster)
+		if err != nil {
+			return err
+		}
+
+		return index(sql, models.SEARCH_ROASTER, roaster.ID.String(), roaster.SearchTerms())
+	})
+
 	return err
 }
 
 func (r *Roaster) Update(roaster *models.Roaster, roasterId string) error {
-	err := r.sql.Modify(
-		"UPDATE roaster SET name=?, email=?, phone=?, addressLine1=?, addressLine2=?, addressCity=?, addressState=?, addressZip=?, addressCountry=?, profileUrl=?, birth=? WHERE id=?",
-		roaster.Name,
-		roaster.Email,
-		roaster.Phone,
-		roaster.AddressLine1,
-		roaster.AddressLine2,
-		roaster.AddressCity,
-		roaster.AddressState,
-		roaster.AddressZip,
-		roaster.AddressCountry,
-		roaster.ProfileUrl,
-		roaster.Birthday,
-		roasterId,
-	)
+	roaster.UpdatedAt = now()
+	roaster.Geocode()
 
-	return err
+	if roaster.Slug == "" {
+		slug, err := r.uniqueSlug(roaster)
+		if err != nil {
+			return err
+		}
+		roaster.Slug = slug
+	}
+
+	return inTx(r.sql, func(sql gateways.SQL) error {
+		// keep the slug being replaced so links to it can be redirected, and free
+		// up the new slug if this roaster is taking back one it used before
+		err := sql.Modify(
+			"INSERT INTO roasterSlug (slug, roasterId, createdAt) SELECT slug, id, ? FROM roaster WHERE id=? AND slug<>? ON DUPLICATE KEY UPDATE roasterId=VALUES(roasterId), createdAt=VALUES(createdAt)",
+			roaster.UpdatedAt,
+			roasterId,
+			roaster.Slug,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = sql.Modify("DELETE FROM roasterSlug WHERE slug=?", roaster.Slug)
+		if err != nil {
+			return err
+		}
+
+		// phoneVerified is assigned before phone so it is cleared when the number changes
+		err = sql.Modify(
+			"UPDATE roaster SET name=?, slug=?, email=?, phoneVerified=(phoneVerified AND phone<=>?), phone=?, addressLine1=?, addressLine2=?, addressCity=?, addressState=?, addressZip=?, addressCountry=?, profileUrl=?, birth=?, latitude=?, longitude=?, updatedAt=? WHERE id=?",
+			roaster.Name,
+			roaster.Slug,
+			roaster.Email,
+			roaster.Phone,
+			roaster.Phone,
+			roaster.AddressLine1,
+			roaster.AddressLine2,
+			roaster.AddressCity,
+			roaster.AddressState,
+			roaster.AddressZip,
+			roaster.AddressCountry,
+			roaster.ProfileUrl,
+			roaster.Birthday,
+			roaster.Latitude,
+			roaster.Longitude,
+			roaster.UpdatedAt,
+			roasterId,
+		)
+		if err != nil {
+			return err
+		}
+
+		// the event carries the stored row, since roaster doesn't have fields
+		// like status or createdAt that the update doesn't change
+		err = r.updated(sql, roasterId)
+		if err != nil {
+			return err
+		}
+
+		return index(sql, models.SEARCH_ROASTER, roasterId, roaster.SearchTerms())
+	})
 }
 
 func (r *Roaster) CreateAccount(id uuid.UUID) error {
@@ -114,18 +390,108 @@ func (r *Roaster) CreateAccount(id uuid.UUID) error {
 	return err
 }
 
-func (r *Roaster) Profile(id string, name string, body multipart.File) error {
-	filename := fmt.Sprintf("%s-%s", id, name)
-	url, err := r.S3.Upload("profile", filename, body)
+func (r *Roaster) Profile(id string, photo *Photo) error {
+	url, err := photo.Upload(r.S3, "profile", id)
 	if err != nil {
 		return err
 	}
 
-	err = r.sql.Modify("UPDATE roaster SET profileUrl=? WHERE id=?", url, id)
-	return err
+	previous := ""
+	err = inTx(r.sql, func(sql gateways.SQL) error {
+		var err error
+		previous, err = profileURL(sql, "roaster", id)
+		if err != nil {
+			return err
+		}
+
+		err = sql.Modify("UPDATE roaster SET profileUrl=?, updatedAt=? WHERE id=?", url, now(), id)
+		if err != nil {
+			return err
+		}
+
+		return r.updated(sql, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	removeReplaced(r.S3, previous, url)
+	return nil
+}
+
+/*PresignProfile returns a URL the roaster can upload their photo straight to S3 at*/
+func (r *Roaster) PresignProfile(id string, contentType string) (*models.PresignedUpload, error) {
+	return presignPhoto(r.S3, "profile", id, contentType)
+}
+
+/*CompleteProfile makes the photo uploaded to key the roaster's profile photo*/
+func (r *Roaster) CompleteProfile(id string, key string) error {
+	photo, err := fetchPhoto(r.S3, "profile", id, key)
+	if err != nil {
+		return err
+	}
+
+	return r.Profile(id, photo)
+}
+
+/*RemoveProfile clears the roaster's photo and deletes every size of it at url from S3*/
+func (r *Roaster) RemoveProfile(id string, url string) error {
+	err := inTx(r.sql, func(sql gateways.SQL) error {
+		err := sql.Modify("UPDATE roaster SET profileUrl=?, updatedAt=? WHERE id=?", "", now(), id)
+		if err != nil {
+			return err
+		}
+
+		return r.updated(sql, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	return removePhoto(r.S3, url)
 }
 
 func (r *Roaster) Delete(id string) error {
-	err := r.sql.Modify("DELETE FROM roaster WHERE id=?", id)
-	return err
+	return inTx(r.sql, func(sql gateways.SQL) error {
+		err := sql.Modify("DELETE FROM roaster WHERE id=?", id)
+		if err != nil {
+			return err
+		}
+
+		roasterID := uuid.Parse(id)
+		err = addEvent(sql, models.ROASTER_DELETED, roasterID, roasterID, &models.Deleted{ID: roasterID})
+		if err != nil {
+			return err
+		}
+
+		return unindex(sql, models.SEARCH_ROASTER, id)
+	})
+}
+
+/*VerifyPhone marks the roaster's phone as verified as long as it still matches phone*/
+func (r *Roaster) VerifyPhone(id string, phone string) error {
+	return inTx(r.sql, func(sql gateways.SQL) error {
+		err := sql.Modify("UPDATE roaster SET phoneVerified=TRUE, updatedAt=? WHERE id=? AND phone=?", now(), id, phone)
+		if err != nil {
+			return err
+		}
+
+		return r.updated(sql, id)
+	})
+}
+
+/*updated saves a roaster.updated event holding the roaster as tx sees it, for changes made to only some fields*/
+func (r *Roaster) updated(tx gateways.SQL, id string) error {
+	rows, err := tx.Select("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+
+	roasters, err := models.RoasterFromSQL(rows)
+	rows.Close()
+	if err != nil || len(roasters) == 0 {
+		return err
+	}
+
+	return addEvent(tx, models.ROASTER_UPDATED, roasters[0].ID, roasters[0].ID, roasters[0])
 }
@@ -3,18 +3,21 @@ package helpers
 import (
 	"database/sql"
 	"fmt"
-	"os"
+	"strings"
 	"testing"
+	"time"
 
 	mocks "github.com/ghmeier/bloodlines/_mocks/gateways"
 	"github.com/ghmeier/bloodlines/gateways"
 	cmocks "github.com/ghmeier/coinage/_mocks/gateways"
 	cmodels "github.com/ghmeier/coinage/models"
+	tmocks "github.com/jakelong95/TownCenter/_mocks"
 	"github.com/jakelong95/TownCenter/models"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
+	tmock "github.com/stretchr/testify/mock"
 )
 
 func TestRoasterGetByID(t *testing.T) {
@@ -24,9 +27,9 @@ func TestRoasterGetByID(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	r := getMockRoaster(s)
 
-	mock.ExpectQuery("SELECT id, name, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth FROM roaster").
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster").
 		WithArgs(id.String()).
-		WillReturnRows(getRoasterMockRows().AddRow(id.String(), "Name", "Email", "Phone", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "", "01/01/1990"))
+		WillReturnRows(getRoasterMockRows().AddRow(id.String(), "Name", "", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "", "01/01/1990", nil, nil, "active", time.Now(), time.Now()))
 
 	roaster, err := r.GetByID(id.String())
 
@@ -53,7 +56,7 @@ func TestRoasterGetByIDError(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	r := getMockRoaster(s)
 
-	mock.ExpectQuery("SELECT id, name, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth FROM roaster").
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster").
 		WithArgs(id.String()).
 		WillReturnError(fmt.Errorf("This is an error"))
 
@@ -70,7 +73,7 @@ func TestRoasterGetByIDDoesNotExist(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	r := getMockRoaster(s)
 
-	mock.ExpectQuery("SELECT id, name, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth FROM roaster").
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster").
 		WithArgs(id.String()).
 		WillReturnRows(getRoasterMockRows())
 
@@ -81,6 +84,42 @@ func TestRoasterGetByIDDoesNotExist(t *testing.T) {
 	assert.NoError(err)
 }
 
+func TestRoasterGetByIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	id1, id2 := uuid.NewUUID(), uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE id IN \\(\\?,\\?\\)").
+		WithArgs(id1.String(), id2.String()).
+		WillReturnRows(getRoasterMockRows().
+			AddRow(id1.String(), "Name", "", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "", "01/01/1990", nil, nil, "active", time.Now(), time.Now()).
+			AddRow(id2.String(), "Closed", "", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "", "01/01/1990", nil, nil, "closed", time.Now(), time.Now()))
+
+	roasters, err := r.GetByIDs([]string{id1.String(), id2.String()})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(2, len(roasters))
+	assert.Equal("closed", roasters[1].Status)
+}
+
+func TestRoasterGetByIDsError(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE id IN").
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	_, err := r.GetByIDs([]string{"1"})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
 func TestRoasterGetAll(t *testing.T) {
 	assert := assert.New(t)
 
@@ -88,13 +127,13 @@ func TestRoasterGetAll(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	r := getMockRoaster(s)
 
-	mock.ExpectQuery("SELECT id, name, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth FROM roaster").
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster").
 		WithArgs(offset, limit).
 		WillReturnRows(getRoasterMockRows().
-			AddRow(uuid.New(), "Name", "Email", "Phone", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "", "01/01/1990").
-			AddRow(uuid.New(), "Name", "Email", "Phone", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "", "01/01/1990"))
+			AddRow(uuid.New(), "Name", "", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "", "01/01/1990", nil, nil, "active", time.Now(), time.Now()).
+			AddRow(uuid.New(), "Name", "", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "", "01/01/1990", nil, nil, "active", time.Now(), time.Now()))
 
-	roasters, err := r.GetAll(offset, limit)
+	roasters, err := r.GetAll(offset, limit, nil)
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
 	assert.NoError(err)
@@ -108,16 +147,37 @@ func TestRoasterGetAllError(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	r := getMockRoaster(s)
 
-	mock.ExpectQuery("SELECT id, name, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth FROM roaster").
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster").
 		WithArgs(offset, limit).
 		WillReturnError(fmt.Errorf("This is an error"))
 
-	_, err := r.GetAll(offset, limit)
+	_, err := r.GetAll(offset, limit, nil)
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
 	assert.Error(err)
 }
 
+func TestRoasterGetAllFiltered(t *testing.T) {
+	assert := assert.New(t)
+
+	offset, limit := 0, 20
+	since := time.Now()
+	before := since.Add(time.Hour)
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE createdAt<\\? AND updatedAt>=\\? ORDER BY").
+		WithArgs(before.UTC(), since.UTC(), offset, limit).
+		WillReturnRows(getRoasterMockRows().
+			AddRow(uuid.New(), "Name", "", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "", "01/01/1990", nil, nil, "active", time.Now(), time.Now()))
+
+	roasters, err := r.GetAll(offset, limit, &models.ListFilter{CreatedBefore: before, UpdatedSince: since})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, len(roasters))
+}
+
 func TestRoasterInsert(t *testing.T) {
 	assert := assert.New(t)
 
@@ -131,17 +191,47 @@ func TestRoasterInsert(t *testing.T) {
 	}
 
 	coinage.On("NewRoaster", rrequest).Return(nil, nil)
+	expectSlugTaken(mock, "name", roaster.ID.String(), false)
+	mock.ExpectBegin()
 	mock.ExpectPrepare("INSERT INTO roaster").
 		ExpectExec().
-		WithArgs(roaster.ID.String(), roaster.Name, roaster.Email, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.ProfileUrl, roaster.Birthday).
+		WithArgs(roaster.ID.String(), roaster.Name, "name", roaster.Email, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.ProfileUrl, roaster.Birthday, nil, nil, models.STATUS_PENDING, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	expectEvent(mock, models.ROASTER_CREATED, roaster.ID.String(), roaster.ID.String())
+	expectIndex(mock, models.SEARCH_ROASTER, roaster.ID.String())
+	mock.ExpectCommit()
+
 	err := r.Insert(roaster)
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
 	assert.NoError(err)
 }
 
+func TestRoasterInsertIndexError(t *testing.T) {
+	assert := assert.New(t)
+
+	roaster := getDefaultRoaster()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	expectSlugTaken(mock, "name", roaster.ID.String(), false)
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO roaster").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, models.ROASTER_CREATED, roaster.ID.String(), roaster.ID.String())
+	mock.ExpectPrepare("DELETE FROM searchTerm").
+		ExpectExec().
+		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
+
+	err := r.Insert(roaster)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
 func TestRoasterInsertCoinageError(t *testing.T) {
 	assert := assert.New(t)
 
@@ -174,10 +264,13 @@ func TestRoasterInsertError(t *testing.T) {
 	}
 
 	coinage.On("NewRoaster", rrequest).Return(nil, nil)
+	expectSlugTaken(mock, "name", roaster.ID.String(), false)
+	mock.ExpectBegin()
 	mock.ExpectPrepare("INSERT INTO roaster").
 		ExpectExec().
-		WithArgs(roaster.ID.String(), roaster.Name, roaster.Email, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.ProfileUrl, roaster.Birthday).
+		WithArgs(roaster.ID.String(), roaster.Name, "name", roaster.Email, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.ProfileUrl, roaster.Birthday, nil, nil, models.STATUS_PENDING, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
 
 	err := r.Insert(roaster)
 
@@ -192,11 +285,18 @@ func TestRoasterUpdate(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	r := getMockRoaster(s)
 
+	expectSlugTaken(mock, "name", roaster.ID.String(), false)
+	mock.ExpectBegin()
+	expectSlugHistory(mock, roaster.ID.String(), "name")
 	mock.ExpectPrepare("UPDATE roaster").
 		ExpectExec().
-		WithArgs(roaster.Name, roaster.Email, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.ProfileUrl, roaster.Birthday, roaster.ID.String()).
+		WithArgs(roaster.Name, "name", roaster.Email, roaster.Phone, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.ProfileUrl, roaster.Birthday, nil, nil, sqlmock.AnyArg(), roaster.ID.String()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	expectRoasterUpdated(mock, roaster.ID.String())
+	expectIndex(mock, models.SEARCH_ROASTER, roaster.ID.String())
+	mock.ExpectCommit()
+
 	err := r.Update(roaster, roaster.ID.String())
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
@@ -210,10 +310,14 @@ func TestRoasterUpdateError(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	r := getMockRoaster(s)
 
+	expectSlugTaken(mock, "name", roaster.ID.String(), false)
+	mock.ExpectBegin()
+	expectSlugHistory(mock, roaster.ID.String(), "name")
 	mock.ExpectPrepare("UPDATE roaster").
 		ExpectExec().
-		WithArgs(roaster.Name, roaster.Email, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.ProfileUrl, roaster.Birthday, roaster.ID.String()).
+		WithArgs(roaster.Name, "name", roaster.Email, roaster.Phone, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.ProfileUrl, roaster.Birthday, nil, nil, sqlmock.AnyArg(), roaster.ID.String()).
 		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
 
 	err := r.Update(roaster, roaster.ID.String())
 
@@ -228,11 +332,16 @@ func TestDeleteRoaster(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	r := getMockRoaster(s)
 
+	mock.ExpectBegin()
 	mock.ExpectPrepare("DELETE FROM roaster").
 		ExpectExec().
 		WithArgs(id.String()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	expectEvent(mock, models.ROASTER_DELETED, id.String(), id.String())
+	expectRemove(mock, models.SEARCH_ROASTER, id.String())
+	mock.ExpectCommit()
+
 	err := r.Delete(id.String())
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
@@ -246,10 +355,12 @@ func TestDeleteRoasterError(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	r := getMockRoaster(s)
 
+	mock.ExpectBegin()
 	mock.ExpectPrepare("DELETE FROM roaster").
 		ExpectExec().
 		WithArgs(id.String()).
 		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
 
 	err := r.Delete(id.String())
 
@@ -265,21 +376,55 @@ func TestRoasterProfile(t *testing.T) {
 	r := getMockRoaster(s)
 	sMock := &mocks.S3{}
 	r.S3 = sMock
-	file := &os.File{}
+	photo := getMockPhoto()
 
-	sMock.On("Upload", "profile", fmt.Sprintf("%s-%s", id.String(), "test"), file).
+	sMock.On("Upload", "profile", tmock.MatchedBy(func(name string) bool {
+		return strings.HasPrefix(name, id.String()+"-") && strings.HasSuffix(name, "-original.png")
+	}), tmock.Anything).
 		Return("test.com", nil)
+	mock.ExpectBegin()
+	expectProfileURL(mock, "roaster", id.String(), "")
 	mock.ExpectPrepare("UPDATE roaster SET").
 		ExpectExec().
-		WithArgs("test.com", id.String()).
+		WithArgs("test.com", sqlmock.AnyArg(), id.String()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRoasterUpdated(mock, id.String())
+	mock.ExpectCommit()
 
-	err := r.Profile(id.String(), "test", file)
+	err := r.Profile(id.String(), photo)
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
 	assert.NoError(err)
 }
 
+func TestRoasterProfileReplaced(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+	sMock := &tmocks.S3{}
+	r.S3 = sMock
+	photo := getMockPhoto()
+
+	sMock.On("Upload", "profile", tmock.Anything, tmock.Anything).Return("test.com/2-original.png", nil)
+	mock.ExpectBegin()
+	expectProfileURL(mock, "roaster", id.String(), "test.com/1-original.png")
+	mock.ExpectPrepare("UPDATE roaster SET").
+		ExpectExec().
+		WithArgs("test.com/2-original.png", sqlmock.AnyArg(), id.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRoasterUpdated(mock, id.String())
+	mock.ExpectCommit()
+	sMock.On("Delete", tmock.AnythingOfType("string")).Return(fmt.Errorf("This is an error"))
+
+	err := r.Profile(id.String(), photo)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	sMock.AssertCalled(t, "Delete", "test.com/1-large.png")
+}
+
 func TestRoasterProfileError(t *testing.T) {
 	assert := assert.New(t)
 
@@ -288,25 +433,335 @@ func TestRoasterProfileError(t *testing.T) {
 	r := getMockRoaster(s)
 	sMock := &mocks.S3{}
 	r.S3 = sMock
-	file := &os.File{}
+	photo := getMockPhoto()
 
-	sMock.On("Upload", "profile", fmt.Sprintf("%s-%s", id.String(), "test"), file).
+	sMock.On("Upload", "profile", tmock.MatchedBy(func(name string) bool {
+		return strings.HasPrefix(name, id.String()+"-") && strings.HasSuffix(name, "-original.png")
+	}), tmock.Anything).
 		Return("", fmt.Errorf("some error"))
 
-	err := r.Profile(id.String(), "test", file)
+	err := r.Profile(id.String(), photo)
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
 	assert.Error(err)
 }
 
+func TestRoasterRemoveProfile(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+	sMock := &tmocks.S3{}
+	r.S3 = sMock
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE roaster SET profileUrl=\\?, updatedAt=\\? WHERE id=\\?").
+		ExpectExec().
+		WithArgs("", sqlmock.AnyArg(), id.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRoasterUpdated(mock, id.String())
+	mock.ExpectCommit()
+	for _, size := range []string{"original", "small", "medium", "large"} {
+		sMock.On("Delete", "test.com/1-"+size+".png").Return(nil)
+	}
+
+	err := r.RemoveProfile(id.String(), "test.com/1-original.png")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	sMock.AssertNumberOfCalls(t, "Delete", 4)
+	assert.NoError(err)
+}
+
+func TestRoasterRemoveProfileNoDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+	r.S3 = &mocks.S3{}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE roaster SET profileUrl=\\?, updatedAt=\\? WHERE id=\\?").
+		ExpectExec().
+		WithArgs("", sqlmock.AnyArg(), id.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRoasterUpdated(mock, id.String())
+	mock.ExpectCommit()
+
+	err := r.RemoveProfile(id.String(), "test.com/1-original.png")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestRoasterVerifyPhone(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE roaster SET phoneVerified=TRUE").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), id.String(), "+15155550123").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRoasterUpdated(mock, id.String())
+	mock.ExpectCommit()
+
+	err := r.VerifyPhone(id.String(), "+15155550123")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestRoasterUpdateGeocodes(t *testing.T) {
+	assert := assert.New(t)
+
+	roaster := models.NewRoaster("Name", "Email", "Phone", "AddressLine1", "AddressLine2", "Ames", "IA", "50010", "US", "Birthday")
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	expectSlugTaken(mock, "name", roaster.ID.String(), false)
+	mock.ExpectBegin()
+	expectSlugHistory(mock, roaster.ID.String(), "name")
+	mock.ExpectPrepare("UPDATE roaster").
+		ExpectExec().
+		WithArgs(roaster.Name, "name", roaster.Email, roaster.Phone, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.ProfileUrl, roaster.Birthday, 42.03, -93.62, sqlmock.AnyArg(), roaster.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	expectRoasterUpdated(mock, roaster.ID.String())
+	expectIndex(mock, models.SEARCH_ROASTER, roaster.ID.String())
+	mock.ExpectCommit()
+
+	err := r.Update(roaster, roaster.ID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(42.03, *roaster.Latitude)
+	assert.Equal(-93.62, *roaster.Longitude)
+}
+
+func TestRoasterGetNearby(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+	ames, desMoines, chicago := uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID()
+
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE status=\\? AND latitude BETWEEN \\? AND \\? AND longitude BETWEEN \\? AND \\?").
+		WithArgs(models.STATUS_ACTIVE, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(getRoasterMockRows().
+			AddRow(desMoines.String(), "Des Moines", "", "", "", false, "", "", "", "", "", "", "", "", 41.59, -93.62, "active", time.Now(), time.Now()).
+			AddRow(chicago.String(), "Chicago", "", "", "", false, "", "", "", "", "", "", "", "", 41.88, -87.63, "active", time.Now(), time.Now()).
+			AddRow(ames.String(), "Ames", "", "", "", false, "", "", "", "", "", "", "", "", 42.03, -93.62, "active", time.Now(), time.Now()))
+
+	nearby, err := r.GetNearby(42.03, -93.62, 100, 0, 20)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(2, len(nearby))
+	assert.Equal(ames, nearby[0].ID)
+	assert.Equal(0.0, nearby[0].Distance)
+	assert.Equal(desMoines, nearby[1].ID)
+	assert.InDelta(48.9, nearby[1].Distance, 0.1)
+}
+
+func TestRoasterGetNearbyPaging(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster").
+		WithArgs(models.STATUS_ACTIVE, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(getRoasterMockRows().
+			AddRow(uuid.New(), "Des Moines", "", "", "", false, "", "", "", "", "", "", "", "", 41.59, -93.62, "active", time.Now(), time.Now()).
+			AddRow(uuid.New(), "Ames", "", "", "", false, "", "", "", "", "", "", "", "", 42.03, -93.62, "active", time.Now(), time.Now()))
+
+	nearby, err := r.GetNearby(42.03, -93.62, 100, 1, 20)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, len(nearby))
+	assert.Equal("Des Moines", nearby[0].Name)
+}
+
+func TestRoasterGetNearbyError(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster").
+		WithArgs(models.STATUS_ACTIVE, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	_, err := r.GetNearby(42.03, -93.62, 100, 0, 20)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestRoasterInsertSlugTaken(t *testing.T) {
+	assert := assert.New(t)
+
+	roaster := getDefaultRoaster()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	expectSlugTaken(mock, "name", roaster.ID.String(), true)
+	expectSlugTaken(mock, "name-2", roaster.ID.String(), false)
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO roaster").
+		ExpectExec().
+		WithArgs(roaster.ID.String(), roaster.Name, "name-2", roaster.Email, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.ProfileUrl, roaster.Birthday, nil, nil, models.STATUS_PENDING, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, models.ROASTER_CREATED, roaster.ID.String(), roaster.ID.String())
+	expectIndex(mock, models.SEARCH_ROASTER, roaster.ID.String())
+	mock.ExpectCommit()
+
+	err := r.Insert(roaster)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal("name-2", roaster.Slug)
+}
+
+func TestRoasterUpdateKeepsSlug(t *testing.T) {
+	assert := assert.New(t)
+
+	roaster := getDefaultRoaster()
+	roaster.Slug = "my-roastery"
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectBegin()
+	expectSlugHistory(mock, roaster.ID.String(), "my-roastery")
+	mock.ExpectPrepare("UPDATE roaster SET name=\\?, slug=\\?").
+		ExpectExec().
+		WithArgs(roaster.Name, "my-roastery", roaster.Email, roaster.Phone, roaster.Phone, roaster.AddressLine1, roaster.AddressLine2, roaster.AddressCity, roaster.AddressState, roaster.AddressZip, roaster.AddressCountry, roaster.ProfileUrl, roaster.Birthday, nil, nil, sqlmock.AnyArg(), roaster.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRoasterUpdated(mock, roaster.ID.String())
+	expectIndex(mock, models.SEARCH_ROASTER, roaster.ID.String())
+	mock.ExpectCommit()
+
+	err := r.Update(roaster, roaster.ID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestRoasterGetBySlug(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE slug=\\?").
+		WithArgs("kaldis").
+		WillReturnRows(getRoasterMockRows().AddRow(id.String(), "Kaldi's", "kaldis", "", "", false, "", "", "", "", "", "", "", "", nil, nil, "active", time.Now(), time.Now()))
+
+	roaster, err := r.GetBySlug("kaldis")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(id, roaster.ID)
+	assert.Equal("kaldis", roaster.Slug)
+}
+
+func TestRoasterGetBySlugNullSlug(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster").
+		WithArgs(id.String()).
+		WillReturnRows(getRoasterMockRows().AddRow(id.String(), "Name", nil, "Email", "", false, "", "", "", "", "", "", "", "", nil, nil, "active", time.Now(), time.Now()))
+
+	roaster, err := r.GetByID(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal("", roaster.Slug)
+	assert.Equal("Email", roaster.Email)
+}
+
+func TestRoasterGetRedirect(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT r.slug FROM roasterSlug h JOIN roaster r").
+		WithArgs("old-name").
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("new-name"))
+
+	slug, err := r.GetRedirect("old-name")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal("new-name", slug)
+}
+
+func TestRoasterGetRedirectNone(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT r.slug FROM roasterSlug h JOIN roaster r").
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}))
+
+	slug, err := r.GetRedirect("unknown")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal("", slug)
+}
+
+func expectSlugTaken(mock sqlmock.Sqlmock, slug string, id string, taken bool) {
+	rows := sqlmock.NewRows([]string{"id"})
+	if taken {
+		rows.AddRow(uuid.New())
+	}
+
+	mock.ExpectQuery("SELECT id FROM roaster WHERE slug=\\? AND id<>\\? UNION SELECT roasterId FROM roasterSlug").
+		WithArgs(slug, id, slug, id).
+		WillReturnRows(rows)
+}
+
+func expectSlugHistory(mock sqlmock.Sqlmock, id string, slug string) {
+	mock.ExpectPrepare("INSERT INTO roasterSlug").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), id, slug).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("DELETE FROM roasterSlug").
+		ExpectExec().
+		WithArgs(slug).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
 func getDefaultRoaster() *models.Roaster {
 	return models.NewRoaster("Name", "Email", "Phone", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "Birthday")
 }
 
 func getRoasterMockRows() sqlmock.Rows {
-	return sqlmock.NewRows([]string{"id", "name", "email", "phone", "addressLine1", "addressLine2", "addressCity", "addressState", "addressZip", "addressCountry", "profileUrl", "birth"})
+	return sqlmock.NewRows([]string{"id", "name", "slug", "email", "phone", "phoneVerified", "addressLine1", "addressLine2", "addressCity", "addressState", "addressZip", "addressCountry", "profileUrl", "birth", "latitude", "longitude", "status", "createdAt", "updatedAt"})
 }
 
 func getMockRoaster(s *sql.DB) *Roaster {
 	return NewRoaster(&gateways.MySQL{DB: s}, nil, nil)
 }
+
+func expectRoasterUpdated(mock sqlmock.Sqlmock, id string) {
+	mock.ExpectQuery("SELECT (.+) FROM roaster WHERE id=\\?").
+		WithArgs(id).
+		WillReturnRows(getRoasterMockRows().AddRow(id, "Name", "", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", "", "01/01/1990", nil, nil, "active", time.Now(), time.Now()))
+	expectEvent(mock, models.ROASTER_UPDATED, id, id)
+}
@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+// MaxCandidates is the most indexed terms a search reads on each side of a
+// query token, so searches stay fast however large the index grows.
+const MaxCandidates = 500
+
+type SearchI interface {
+	Index(string, string, []*models.SearchTerm) error
+	Remove(string, string) error
+	Search(string, string) ([]string, error)
+}
+
+type Search struct {
+	*baseHelper
+}
+
+func NewSearch(sql gateways.SQL) *Search {
+	return &Search{
+		baseHelper: &baseHelper{sql: sql},
+	}
+}
+
+/*Index replaces the terms the record with the given kind and id is indexed under*/
+func (s *Search) Index(kind string, id string, terms []*models.SearchTerm) error {
+	return index(s.sql, kind, id, terms)
+}
+
+func (s *Search) Remove(kind string, id string) error {
+	return unindex(s.sql, kind, id)
+}
+
+// Search returns the IDs of every record of kind matching query, best match
+// first. Each query token reads at most MaxCandidates terms from the
+// (kind, term) index: the terms starting with it and, when it's long enough
+// to allow typos, the terms sharing its first letter nearest to it in index
+// order. Typos are tolerated anywhere but the first letter.
+func (s *Search) Search(kind string, query string) ([]string, error) {
+	tokens := models.Tokenize(query)
+	if len(tokens) == 0 {
+		return make([]string, 0), nil
+	}
+
+	seen := make(map[string]bool)
+	selects := make([]string, 0, len(tokens)*2)
+	args := make([]interface{}, 0, len(tokens)*8)
+	for _, token := range tokens {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+
+		prefix := token
+		if models.AllowedTypos(token) > 0 {
+			r, _ := utf8.DecodeRuneInString(token)
+			prefix = string(r)
+		}
+
+		selects = append(selects, "(SELECT id, term, weight FROM searchTerm WHERE kind=? AND term LIKE ? AND term>=? ORDER BY term ASC LIMIT ?)")
+		args = append(args, kind, prefix+"%", token, MaxCandidates)
+		if prefix != token {
+			selects = append(selects, "(SELECT id, term, weight FROM searchTerm WHERE kind=? AND term LIKE ? AND term<? ORDER BY term DESC LIMIT ?)")
+			args = append(args, kind, prefix+"%", token, MaxCandidates)
+		}
+	}
+
+	rows, err := s.sql.Select(strings.Join(selects, " UNION "), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	terms, err := models.IndexedTermFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.Rank(tokens, terms), nil
+}
+
+/*index replaces the terms the record is indexed under with sql, so it can be part of the transaction writing the record*/
+func index(sql gateways.SQL, kind string, id string, terms []*models.SearchTerm) error {
+	err := unindex(sql, kind, id)
+	if err != nil || len(terms) == 0 {
+		return err
+	}
+
+	values := make([]string, len(terms))
+	args := make([]interface{}, 0, len(terms)*4)
+	for i, t := range terms {
+		values[i] = "(?,?,?,?)"
+		args = append(args, kind, id, t.Term, t.Weight)
+	}
+
+	err = sql.Modify("INSERT INTO searchTerm (kind, id, term, weight) VALUES "+strings.Join(values, ","), args...)
+	return err
+}
+
+/*unindex removes the record from the search index with sql, so it can be part of the transaction deleting the record*/
+func unindex(sql gateways.SQL, kind string, id string) error {
+	err := sql.Modify("DELETE FROM searchTerm WHERE kind=? AND id=?", kind, id)
+	return err
+}
+
+/*page returns the slice of ids for the given offset and limit*/
+func page(ids []string, offset int, limit int) []string {
+	if offset >= len(ids) {
+		return make([]string, 0)
+	}
+	if offset+limit < len(ids) {
+		ids = ids[:offset+limit]
+	}
+
+	return ids[offset:]
+}
+
+/*placeholders returns n comma separated sql placeholders*/
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,243 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	i := getMockSearch(s)
+	terms := []*models.SearchTerm{{Term: "ames", Weight: 1}, {Term: "kaldi", Weight: 3}}
+
+	mock.ExpectPrepare("DELETE FROM searchTerm").
+		ExpectExec().
+		WithArgs(models.SEARCH_ROASTER, id).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("INSERT INTO searchTerm \\(kind, id, term, weight\\) VALUES \\(\\?,\\?,\\?,\\?\\),\\(\\?,\\?,\\?,\\?\\)").
+		ExpectExec().
+		WithArgs(models.SEARCH_ROASTER, id, "ames", 1.0, models.SEARCH_ROASTER, id, "kaldi", 3.0).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+
+	err := i.Index(models.SEARCH_ROASTER, id, terms)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestSearchIndexNoTerms(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	i := getMockSearch(s)
+
+	expectRemove(mock, models.SEARCH_USER, id)
+
+	err := i.Index(models.SEARCH_USER, id, make([]*models.SearchTerm, 0))
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestSearchIndexError(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	i := getMockSearch(s)
+
+	mock.ExpectPrepare("DELETE FROM searchTerm").
+		ExpectExec().
+		WithArgs(models.SEARCH_USER, id).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	err := i.Index(models.SEARCH_USER, id, []*models.SearchTerm{{Term: "jake", Weight: 3}})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestSearchSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	kaldi, ames := uuid.NewUUID(), uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	i := getMockSearch(s)
+
+	mock.ExpectQuery("\\(SELECT id, term, weight FROM searchTerm WHERE kind=\\? AND term LIKE \\? AND term>=\\? ORDER BY term ASC LIMIT \\?\\) UNION \\(SELECT id, term, weight FROM searchTerm WHERE kind=\\? AND term LIKE \\? AND term<\\? ORDER BY term DESC LIMIT \\?\\) UNION \\(SELECT id, term, weight FROM searchTerm WHERE kind=\\? AND term LIKE \\? AND term>=\\? ORDER BY term ASC LIMIT \\?\\)$").
+		WithArgs(models.SEARCH_ROASTER, "k%", "kadli", MaxCandidates, models.SEARCH_ROASTER, "k%", "kadli", MaxCandidates, models.SEARCH_ROASTER, "ame%", "ame", MaxCandidates).
+		WillReturnRows(getSearchMockRows().
+			AddRow(ames.String(), "ames", 3.0).
+			AddRow(ames.String(), "ames", 1.0).
+			AddRow(kaldi.String(), "kaldi", 3.0).
+			AddRow(kaldi.String(), "ames", 1.0))
+
+	ids, err := i.Search(models.SEARCH_ROASTER, "Kadli, Ame")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal([]string{kaldi.String()}, ids)
+}
+
+func TestSearchSearchEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	i := getMockSearch(s)
+
+	ids, err := i.Search(models.SEARCH_ROASTER, " ,. ")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(0, len(ids))
+}
+
+func TestSearchSearchError(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	i := getMockSearch(s)
+
+	mock.ExpectQuery("SELECT id, term, weight FROM searchTerm").
+		WithArgs(models.SEARCH_USER, "j%", "jake", MaxCandidates, models.SEARCH_USER, "j%", "jake", MaxCandidates).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	_, err := i.Search(models.SEARCH_USER, "jake")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestRoasterSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	first, second := uuid.NewUUID(), uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT id, term, weight FROM searchTerm").
+		WithArgs(models.SEARCH_ROASTER, "c%", "coffee", MaxCandidates, models.SEARCH_ROASTER, "c%", "coffee", MaxCandidates).
+		WillReturnRows(getSearchMockRows().
+			AddRow(second.String(), "coffee", 1.0).
+			AddRow(first.String(), "coffee", 3.0))
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE status=\\? AND id IN \\(\\?,\\?\\)").
+		WithArgs(models.STATUS_ACTIVE, first.String(), second.String()).
+		WillReturnRows(getRoasterMockRows().
+			AddRow(second.String(), "Second", "", "", "", false, "", "", "Coffee", "", "", "", "", "", nil, nil, "active", time.Now(), time.Now()).
+			AddRow(first.String(), "Coffee", "", "", "", false, "", "", "", "", "", "", "", "", nil, nil, "active", time.Now(), time.Now()))
+
+	roasters, err := r.Search("coffee", 0, 20)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(2, len(roasters))
+	assert.Equal(first, roasters[0].ID)
+	assert.Equal(second, roasters[1].ID)
+}
+
+func TestRoasterSearchPastEnd(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT id, term, weight FROM searchTerm").
+		WithArgs(models.SEARCH_ROASTER, "c%", "coffee", MaxCandidates, models.SEARCH_ROASTER, "c%", "coffee", MaxCandidates).
+		WillReturnRows(getSearchMockRows().AddRow(id, "coffee", 3.0))
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster").
+		WithArgs(models.STATUS_ACTIVE, id).
+		WillReturnRows(getRoasterMockRows().
+			AddRow(id, "Coffee", "", "", "", false, "", "", "", "", "", "", "", "", nil, nil, "active", time.Now(), time.Now()))
+
+	roasters, err := r.Search("coffee", 20, 20)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(0, len(roasters))
+}
+
+func TestRoasterSearchOnlyActive(t *testing.T) {
+	assert := assert.New(t)
+
+	active, suspended := uuid.NewUUID(), uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	r := getMockRoaster(s)
+
+	mock.ExpectQuery("SELECT id, term, weight FROM searchTerm").
+		WithArgs(models.SEARCH_ROASTER, "c%", "coffee", MaxCandidates, models.SEARCH_ROASTER, "c%", "coffee", MaxCandidates).
+		WillReturnRows(getSearchMockRows().
+			AddRow(suspended.String(), "coffee", 3.0).
+			AddRow(active.String(), "coffee", 1.0))
+	mock.ExpectQuery("SELECT id, name, slug, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, profileUrl, birth, latitude, longitude, status, createdAt, updatedAt FROM roaster WHERE status=\\?").
+		WithArgs(models.STATUS_ACTIVE, suspended.String(), active.String()).
+		WillReturnRows(getRoasterMockRows().
+			AddRow(active.String(), "Coffee", "", "", "", false, "", "", "", "", "", "", "", "", nil, nil, "active", time.Now(), time.Now()))
+
+	roasters, err := r.Search("coffee", 0, 1)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, len(roasters))
+	assert.Equal(active, roasters[0].ID)
+}
+
+func TestUserSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	mock.ExpectQuery("SELECT id, term, weight FROM searchTerm").
+		WithArgs(models.SEARCH_USER, "0%", "0123", MaxCandidates, models.SEARCH_USER, "0%", "0123", MaxCandidates).
+		WillReturnRows(getSearchMockRows().AddRow(id.String(), "0123", 2.0))
+	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user WHERE id IN \\(\\?\\)").
+		WithArgs(id.String()).
+		WillReturnRows(getUserMockRows().AddRow(id.String(), "", "FirstName", "LastName", "Email", "+15155550123", false, "", "", "", "", "", "", nil, "", time.Now(), time.Now()))
+
+	users, err := u.Search("0123", 0, 20)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, len(users))
+	assert.Equal(id, users[0].ID)
+}
+
+func expectIndex(mock sqlmock.Sqlmock, kind string, id string) {
+	mock.ExpectPrepare("DELETE FROM searchTerm").
+		ExpectExec().
+		WithArgs(kind, id).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("INSERT INTO searchTerm").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectRemove(mock sqlmock.Sqlmock, kind string, id string) {
+	mock.ExpectPrepare("DELETE FROM searchTerm").
+		ExpectExec().
+		WithArgs(kind, id).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func getSearchMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "term", "weight"})
+}
+
+func getMockSearch(s *sql.DB) *Search {
+	return NewSearch(&gateways.MySQL{DB: s})
+}
@@ -0,0 +1,60 @@
+package helpers
+
+import (
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type StatusI interface {
+	Set(*models.StatusChange) error
+	GetHistory(string) ([]*models.StatusChange, error)
+}
+
+type Status struct {
+	*baseHelper
+}
+
+func NewStatus(sql gateways.SQL) *Status {
+	return &Status{
+		baseHelper: &baseHelper{sql: sql},
+	}
+}
+
+/*Set moves the roaster to change.To as long as it's still in change.From, and records the change*/
+func (s *Status) Set(change *models.StatusChange) error {
+	change.CreatedAt = now()
+
+	return inTx(s.sql, func(sql gateways.SQL) error {
+		err := sql.Modify("UPDATE roaster SET status=?, updatedAt=? WHERE id=? AND status=?", change.To, change.CreatedAt, change.RoasterID, change.From)
+		if err != nil {
+			return err
+		}
+
+		err = sql.Modify(
+			"INSERT INTO roasterStatus (id, roasterId, fromStatus, toStatus, reason, note, actorId, createdAt) VALUE (?,?,?,?,?,?,?,?)",
+			change.ID,
+			change.RoasterID,
+			change.From,
+			change.To,
+			change.Reason,
+			change.Note,
+			change.ActorID,
+			change.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		return addEvent(sql, models.ROASTER_STATUS_CHANGED, change.RoasterID, change.RoasterID, change)
+	})
+}
+
+/*GetHistory returns every status change of the roaster, oldest first*/
+func (s *Status) GetHistory(roasterID string) ([]*models.StatusChange, error) {
+	rows, err := s.sql.Select("SELECT id, roasterId, fromStatus, toStatus, reason, note, actorId, createdAt FROM roasterStatus WHERE roasterId=? ORDER BY createdAt ASC", roasterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.StatusChangeFromSQL(rows)
+}
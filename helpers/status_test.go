@@ -0,0 +1,104 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusSet(t *testing.T) {
+	assert := assert.New(t)
+
+	change := models.NewStatusChange(uuid.NewUUID(), models.STATUS_ACTIVE, models.STATUS_SUSPENDED, models.REASON_FRAUD, "Chargebacks", "admin")
+	s, mock, _ := sqlmock.New()
+	h := getMockStatus(s)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE roaster SET status=\\?, updatedAt=\\? WHERE id=\\? AND status=\\?").
+		ExpectExec().
+		WithArgs(models.STATUS_SUSPENDED, sqlmock.AnyArg(), change.RoasterID.String(), models.STATUS_ACTIVE).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("INSERT INTO roasterStatus").
+		ExpectExec().
+		WithArgs(change.ID.String(), change.RoasterID.String(), models.STATUS_ACTIVE, models.STATUS_SUSPENDED, models.REASON_FRAUD, "Chargebacks", "admin", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, models.ROASTER_STATUS_CHANGED, change.RoasterID.String(), change.RoasterID.String())
+	mock.ExpectCommit()
+
+	err := h.Set(change)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.False(change.CreatedAt.IsZero())
+}
+
+func TestStatusSetError(t *testing.T) {
+	assert := assert.New(t)
+
+	change := models.NewStatusChange(uuid.NewUUID(), models.STATUS_PENDING, models.STATUS_UNDER_REVIEW, "", "", "owner")
+	s, mock, _ := sqlmock.New()
+	h := getMockStatus(s)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE roaster SET status").
+		ExpectExec().
+		WithArgs(models.STATUS_UNDER_REVIEW, sqlmock.AnyArg(), change.RoasterID.String(), models.STATUS_PENDING).
+		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
+
+	err := h.Set(change)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestStatusGetHistory(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	h := getMockStatus(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, fromStatus, toStatus, reason, note, actorId, createdAt FROM roasterStatus WHERE roasterId=\\? ORDER BY createdAt ASC").
+		WithArgs(roasterID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "roasterId", "fromStatus", "toStatus", "reason", "note", "actorId", "createdAt"}).
+			AddRow(uuid.New(), roasterID.String(), models.STATUS_PENDING, models.STATUS_UNDER_REVIEW, "", "", "owner", time.Now()).
+			AddRow(uuid.New(), roasterID.String(), models.STATUS_UNDER_REVIEW, models.STATUS_PENDING, models.REASON_MISSING_DOCUMENTS, "Need a permit", "admin", time.Now()))
+
+	history, err := h.GetHistory(roasterID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(2, len(history))
+	assert.Equal(models.REASON_MISSING_DOCUMENTS, history[1].Reason)
+	assert.Equal("Need a permit", history[1].Note)
+}
+
+func TestStatusGetHistoryError(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.New()
+	s, mock, _ := sqlmock.New()
+	h := getMockStatus(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, fromStatus, toStatus, reason, note, actorId, createdAt FROM roasterStatus").
+		WithArgs(roasterID).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	_, err := h.GetHistory(roasterID)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func getMockStatus(s *sql.DB) *Status {
+	return NewStatus(&gateways.MySQL{DB: s})
+}
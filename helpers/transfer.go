@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+/*ErrTransferNotPending is returned when a transfer was accepted, declined, cancelled or expired by another request*/
+var ErrTransferNotPending = errors.New("Error: transfer is no longer pending")
+
+/*ErrRecipientHasRoaster is returned when the recipient took on another roaster before accepting*/
+var ErrRecipientHasRoaster = errors.New("Error: you already own a roaster")
+
+type TransferI interface {
+	Insert(*models.Transfer) error
+	GetByToken(string) (*models.Transfer, error)
+	GetPending(string) (*models.Transfer, error)
+	SetStatus(*models.Transfer, string) error
+	Accept(*models.Transfer) error
+}
+
+type Transfer struct {
+	*baseHelper
+}
+
+func NewTransfer(sql gateways.SQL) *Transfer {
+	return &Transfer{
+		baseHelper: &baseHelper{sql: sql},
+	}
+}
+
+func (t *Transfer) Insert(transfer *models.Transfer) error {
+	transfer.CreatedAt = now()
+	transfer.UpdatedAt = transfer.CreatedAt
+
+	err := t.sql.Modify(
+		"INSERT INTO roasterTransfer (id, roasterId, fromUserId, toUserId, toEmail, token, status, expiresAt, createdAt, updatedAt) VALUE (?,?,?,?,?,?,?,?,?,?)",
+		transfer.ID,
+		transfer.RoasterID,
+		transfer.FromUserID,
+		transfer.ToUserID,
+		transfer.ToEmail,
+		transfer.Token,
+		transfer.Status,
+		transfer.ExpiresAt,
+		transfer.CreatedAt,
+		transfer.UpdatedAt,
+	)
+
+	return err
+}
+
+func (t *Transfer) GetByToken(token string) (*models.Transfer, error) {
+	rows, err := t.sql.Select("SELECT id, roasterId, fromUserId, toUserId, toEmail, token, status, expiresAt, createdAt, updatedAt FROM roasterTransfer WHERE token=?", token)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.getOne(rows)
+}
+
+/*GetPending returns the roaster's most recent pending transfer, or nil when there isn't one*/
+func (t *Transfer) GetPending(roasterID string) (*models.Transfer, error) {
+	rows, err := t.sql.Select("SELECT id, roasterId, fromUserId, toUserId, toEmail, token, status, expiresAt, createdAt, updatedAt FROM roasterTransfer WHERE roasterId=? AND status=? ORDER BY createdAt DESC LIMIT 1", roasterID, models.TRANSFER_PENDING)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.getOne(rows)
+}
+
+func (t *Transfer) getOne(rows *sql.Rows) (*models.Transfer, error) {
+	transfers, err := models.TransferFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(transfers) == 0 {
+		return nil, nil
+	}
+
+	return transfers[0], nil
+}
+
+/*SetStatus moves a pending transfer to status, failing with ErrTransferNotPending if it has already moved*/
+func (t *Transfer) SetStatus(transfer *models.Transfer, status string) error {
+	return setTransferStatus(t.sql, transfer, status)
+}
+
+// Accept moves the roaster from the transfer's sender to its recipient and
+// marks the transfer accepted. The transfer has to still be pending and the
+// recipient can't own a roaster, otherwise nothing changes. The sender only
+// loses the roaster if they still own it.
+func (t *Transfer) Accept(transfer *models.Transfer) error {
+	updatedAt := now()
+	previous := *transfer
+
+	err := inTx(t.sql, func(sql gateways.SQL) error {
+		err := setTransferStatus(sql, transfer, models.TRANSFER_ACCEPTED)
+		if err != nil {
+			return err
+		}
+
+		changed, err := modifyCount(sql, "UPDATE user SET roasterId=?, updatedAt=? WHERE id=? AND roasterId IS NULL", transfer.RoasterID, updatedAt, transfer.ToUserID)
+		if err != nil {
+			return err
+		}
+		if changed == 0 {
+			return ErrRecipientHasRoaster
+		}
+
+		err = sql.Modify("UPDATE user SET roasterId=NULL, updatedAt=? WHERE id=? AND roasterId=?", updatedAt, transfer.FromUserID, transfer.RoasterID)
+		if err != nil {
+			return err
+		}
+
+		return addEvent(sql, models.ROASTER_TRANSFERRED, transfer.RoasterID, transfer.RoasterID, transfer)
+	})
+	if err != nil {
+		*transfer = previous
+	}
+
+	return err
+}
+
+func setTransferStatus(sql gateways.SQL, transfer *models.Transfer, status string) error {
+	updatedAt := now()
+
+	changed, err := modifyCount(sql, "UPDATE roasterTransfer SET status=?, updatedAt=? WHERE id=? AND status=?", status, updatedAt, transfer.ID, models.TRANSFER_PENDING)
+	if err != nil {
+		return err
+	}
+	if changed == 0 {
+		return ErrTransferNotPending
+	}
+
+	transfer.Status = status
+	transfer.UpdatedAt = updatedAt
+	return nil
+}
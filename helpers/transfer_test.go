@@ -0,0 +1,250 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferInsert(t *testing.T) {
+	assert := assert.New(t)
+
+	transfer := models.NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", time.Hour)
+	s, mock, _ := sqlmock.New()
+	h := getMockTransfer(s)
+
+	mock.ExpectPrepare("INSERT INTO roasterTransfer").
+		ExpectExec().
+		WithArgs(transfer.ID.String(), transfer.RoasterID.String(), transfer.FromUserID.String(), transfer.ToUserID.String(), "new@owner.com", transfer.Token, models.TRANSFER_PENDING, transfer.ExpiresAt, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := h.Insert(transfer)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.False(transfer.CreatedAt.IsZero())
+}
+
+func TestTransferGetByToken(t *testing.T) {
+	assert := assert.New(t)
+
+	token := uuid.New()
+	s, mock, _ := sqlmock.New()
+	h := getMockTransfer(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, fromUserId, toUserId, toEmail, token, status, expiresAt, createdAt, updatedAt FROM roasterTransfer WHERE token=\\?").
+		WithArgs(token).
+		WillReturnRows(getTransferMockRows().
+			AddRow(uuid.New(), uuid.New(), uuid.New(), uuid.New(), "new@owner.com", token, models.TRANSFER_PENDING, time.Now(), time.Now(), time.Now()))
+
+	transfer, err := h.GetByToken(token)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(token, transfer.Token)
+	assert.Equal("new@owner.com", transfer.ToEmail)
+}
+
+func TestTransferGetByTokenNone(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	h := getMockTransfer(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, fromUserId, toUserId, toEmail, token, status, expiresAt, createdAt, updatedAt FROM roasterTransfer WHERE token=\\?").
+		WithArgs("missing").
+		WillReturnRows(getTransferMockRows())
+
+	transfer, err := h.GetByToken("missing")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Nil(transfer)
+}
+
+func TestTransferGetPending(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.New()
+	s, mock, _ := sqlmock.New()
+	h := getMockTransfer(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, fromUserId, toUserId, toEmail, token, status, expiresAt, createdAt, updatedAt FROM roasterTransfer WHERE roasterId=\\? AND status=\\? ORDER BY createdAt DESC LIMIT 1").
+		WithArgs(roasterID, models.TRANSFER_PENDING).
+		WillReturnRows(getTransferMockRows().
+			AddRow(uuid.New(), roasterID, uuid.New(), uuid.New(), "new@owner.com", uuid.New(), models.TRANSFER_PENDING, time.Now(), time.Now(), time.Now()))
+
+	transfer, err := h.GetPending(roasterID)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(models.TRANSFER_PENDING, transfer.Status)
+}
+
+func TestTransferGetPendingError(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.New()
+	s, mock, _ := sqlmock.New()
+	h := getMockTransfer(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, fromUserId, toUserId, toEmail, token, status, expiresAt, createdAt, updatedAt FROM roasterTransfer WHERE roasterId=\\?").
+		WithArgs(roasterID, models.TRANSFER_PENDING).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	_, err := h.GetPending(roasterID)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestTransferSetStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	transfer := models.NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", time.Hour)
+	s, mock, _ := sqlmock.New()
+	h := getMockTransfer(s)
+
+	mock.ExpectPrepare("UPDATE roasterTransfer SET status=\\?, updatedAt=\\? WHERE id=\\? AND status=\\?").
+		ExpectExec().
+		WithArgs(models.TRANSFER_CANCELLED, sqlmock.AnyArg(), transfer.ID.String(), models.TRANSFER_PENDING).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := h.SetStatus(transfer, models.TRANSFER_CANCELLED)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(models.TRANSFER_CANCELLED, transfer.Status)
+}
+
+func TestTransferSetStatusNotPending(t *testing.T) {
+	assert := assert.New(t)
+
+	transfer := models.NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", time.Hour)
+	s, mock, _ := sqlmock.New()
+	h := getMockTransfer(s)
+
+	mock.ExpectPrepare("UPDATE roasterTransfer SET status=\\?").
+		ExpectExec().
+		WithArgs(models.TRANSFER_DECLINED, sqlmock.AnyArg(), transfer.ID.String(), models.TRANSFER_PENDING).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := h.SetStatus(transfer, models.TRANSFER_DECLINED)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Equal(ErrTransferNotPending, err)
+	assert.Equal(models.TRANSFER_PENDING, transfer.Status)
+}
+
+func TestTransferAccept(t *testing.T) {
+	assert := assert.New(t)
+
+	transfer := models.NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", time.Hour)
+	s, mock, _ := sqlmock.New()
+	h := getMockTransfer(s)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE roasterTransfer SET status=\\?, updatedAt=\\? WHERE id=\\? AND status=\\?").
+		ExpectExec().
+		WithArgs(models.TRANSFER_ACCEPTED, sqlmock.AnyArg(), transfer.ID.String(), models.TRANSFER_PENDING).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("UPDATE user SET roasterId=\\?, updatedAt=\\? WHERE id=\\? AND roasterId IS NULL").
+		ExpectExec().
+		WithArgs(transfer.RoasterID.String(), sqlmock.AnyArg(), transfer.ToUserID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("UPDATE user SET roasterId=NULL, updatedAt=\\? WHERE id=\\? AND roasterId=\\?").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), transfer.FromUserID.String(), transfer.RoasterID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, models.ROASTER_TRANSFERRED, transfer.RoasterID.String(), transfer.RoasterID.String())
+	mock.ExpectCommit()
+
+	err := h.Accept(transfer)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(models.TRANSFER_ACCEPTED, transfer.Status)
+}
+
+func TestTransferAcceptError(t *testing.T) {
+	assert := assert.New(t)
+
+	transfer := models.NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", time.Hour)
+	s, mock, _ := sqlmock.New()
+	h := getMockTransfer(s)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE roasterTransfer SET status=\\?").
+		ExpectExec().
+		WithArgs(models.TRANSFER_ACCEPTED, sqlmock.AnyArg(), transfer.ID.String(), models.TRANSFER_PENDING).
+		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
+
+	err := h.Accept(transfer)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+	assert.Equal(models.TRANSFER_PENDING, transfer.Status)
+}
+
+func TestTransferAcceptNotPending(t *testing.T) {
+	assert := assert.New(t)
+
+	transfer := models.NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", time.Hour)
+	s, mock, _ := sqlmock.New()
+	h := getMockTransfer(s)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE roasterTransfer SET status=\\?").
+		ExpectExec().
+		WithArgs(models.TRANSFER_ACCEPTED, sqlmock.AnyArg(), transfer.ID.String(), models.TRANSFER_PENDING).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := h.Accept(transfer)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Equal(ErrTransferNotPending, err)
+	assert.Equal(models.TRANSFER_PENDING, transfer.Status)
+}
+
+func TestTransferAcceptRecipientHasRoaster(t *testing.T) {
+	assert := assert.New(t)
+
+	transfer := models.NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", time.Hour)
+	s, mock, _ := sqlmock.New()
+	h := getMockTransfer(s)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE roasterTransfer SET status=\\?").
+		ExpectExec().
+		WithArgs(models.TRANSFER_ACCEPTED, sqlmock.AnyArg(), transfer.ID.String(), models.TRANSFER_PENDING).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("UPDATE user SET roasterId=\\?, updatedAt=\\? WHERE id=\\? AND roasterId IS NULL").
+		ExpectExec().
+		WithArgs(transfer.RoasterID.String(), sqlmock.AnyArg(), transfer.ToUserID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := h.Accept(transfer)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Equal(ErrRecipientHasRoaster, err)
+	assert.Equal(models.TRANSFER_PENDING, transfer.Status)
+}
+
+func getTransferMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "roasterId", "fromUserId", "toUserId", "toEmail", "token", "status", "expiresAt", "createdAt", "updatedAt"})
+}
+
+func getMockTransfer(s *sql.DB) *Transfer {
+	return NewTransfer(&gateways.MySQL{DB: s})
+}
@@ -4,12 +4,14 @@ import (
 	"database/sql"
 	"fmt"
 	"mime/multipart"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/alexcesaro/statsd.v2"
 
 	"github.com/ghmeier/bloodlines/gateways"
 	"github.com/jakelong95/TownCenter/models"
+	"github.com/pborman/uuid"
 )
 
 type baseHelper struct {
@@ -19,29 +21,38 @@ type baseHelper struct {
 
 type UserI interface {
 	GetByID(string) (*models.User, error)
+	GetByIDs([]string) ([]*models.User, error)
 	GetByRoaster(string) (*models.User, error)
-	GetAll(int, int) ([]*models.User, error)
+	GetAll(int, int, *models.ListFilter) ([]*models.User, error)
 	Insert(*models.User) error
 	Update(*models.User, string) error
 	Delete(string) error
 	GetByEmail(string) (*models.User, error)
-	Profile(string, string, multipart.File) error
+	Profile(string, *Photo) error
+	RemoveProfile(string, string) error
+	PresignProfile(string, string) (*models.PresignedUpload, error)
+	CompleteProfile(string, string) error
+	VerifyPhone(string, string) error
+	SetAddress(string, *models.Address) error
+	Search(string, int, int) ([]*models.User, error)
 }
 
 type User struct {
 	*baseHelper
-	S3 gateways.S3
+	S3    gateways.S3
+	Index SearchI
 }
 
 func NewUser(sql gateways.SQL, s3 gateways.S3) *User {
 	return &User{
 		baseHelper: &baseHelper{sql: sql},
 		S3:         s3,
+		Index:      NewSearch(sql),
 	}
 }
 
 func (u *User) GetByID(id string) (*models.User, error) {
-	rows, err := u.sql.Select("SELECT id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl FROM user WHERE id=?", id)
+	rows, err := u.sql.Select("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user WHERE id=?", id)
 
 	if err != nil {
 		return nil, err
@@ -50,8 +61,27 @@ func (u *User) GetByID(id string) (*models.User, error) {
 	return u.getOne(rows)
 }
 
+/*GetByIDs returns the users with any of the given IDs, in no particular order*/
+func (u *User) GetByIDs(ids []string) ([]*models.User, error) {
+	if len(ids) == 0 {
+		return make([]*models.User, 0), nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := u.sql.Select("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user WHERE id IN ("+placeholders(len(ids))+")", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.UserFromSQL(rows)
+}
+
 func (u *User) GetByRoaster(id string) (*models.User, error) {
-	rows, err := u.sql.Select("SELECT id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl FROM user WHERE roasterId=?", id)
+	rows, err := u.sql.Select("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user WHERE roasterId=?", id)
 
 	if err != nil {
 		return nil, err
@@ -73,8 +103,11 @@ func (u *User) getOne(rows *sql.Rows) (*models.User, error) {
 	return users[0], err
 }
 
-func (u *User) GetAll(offset int, limit int) ([]*models.User, error) {
-	rows, err := u.sql.Select("SELECT id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl FROM user ORDER BY id ASC LIMIT ?,?", offset, limit)
+func (u *User) GetAll(offset int, limit int, filter *models.ListFilter) ([]*models.User, error) {
+	where, args := filterClause(filter)
+	args = append(args, offset, limit)
+
+	rows, err := u.sql.Select("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user"+where+" ORDER BY id ASC LIMIT ?,?", args...)
 	if err != nil {
 		return nil, err
 	}
@@ -89,70 +122,224 @@ func (u *User) GetAll(offset int, limit int) ([]*models.User, error) {
 
 func (u *User) Insert(user *models.User) error {
 	user.PassHash = hash(user.PassHash)
+	user.CreatedAt = now()
+	user.UpdatedAt = user.CreatedAt
 
-	err := u.sql.Modify(
-		"INSERT INTO user (id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl) VALUE (?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
-		user.ID,
-		user.PassHash,
-		user.FirstName,
-		user.LastName,
-		user.Email,
-		user.Phone,
-		user.AddressLine1,
-		user.AddressLine2,
-		user.AddressCity,
-		user.AddressState,
-		user.AddressZip,
-		user.AddressCountry,
-		user.RoasterId,
-		user.ProfileURL,
-	)
+	err := inTx(u.sql, func(sql gateways.SQL) error {
+		err := sql.Modify(
+			"INSERT INTO user (id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt) VALUE (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
+			user.ID,
+			user.PassHash,
+			user.FirstName,
+			user.LastName,
+			user.Email,
+			user.Phone,
+			user.AddressLine1,
+			user.AddressLine2,
+			user.AddressCity,
+			user.AddressState,
+			user.AddressZip,
+			user.AddressCountry,
+			user.RoasterId,
+			user.ProfileURL,
+			user.CreatedAt,
+			user.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = addEvent(sql, models.USER_CREATED, user.ID, user.RoasterId, userEvent(user))
+		if err != nil {
+			return err
+		}
+
+		return index(sql, models.SEARCH_USER, user.ID.String(), user.SearchTerms())
+	})
 
 	return err
 }
 
 func (u *User) Update(user *models.User, id string) error {
+	user.UpdatedAt = now()
+
+	return inTx(u.sql, func(sql gateways.SQL) error {
+		previous, roasterID, err := u.current(sql, id)
+		if err != nil {
+			return err
+		}
+
+		// phoneVerified is assigned before phone so it is cleared when the number changes
+		err = sql.Modify(
+			"UPDATE user SET firstName=?, lastName=?, email=?, phoneVerified=(phoneVerified AND phone<=>?), phone=?, addressLine1=?, addressLine2=?, addressCity=?, addressState=?, addressZip=?, addressCountry=?, roasterId=?, profileUrl=?, updatedAt=? WHERE id=?",
+			user.FirstName,
+			user.LastName,
+			user.Email,
+			user.Phone,
+			user.Phone,
+			user.AddressLine1,
+			user.AddressLine2,
+			user.AddressCity,
+			user.AddressState,
+			user.AddressZip,
+			user.AddressCountry,
+			user.RoasterId,
+			user.ProfileURL,
+			user.UpdatedAt,
+			id,
+		)
 
-	err := u.sql.Modify(
-		"UPDATE user SET firstName=?, lastName=?, email=?, phone=?, addressLine1=?, addressLine2=?, addressCity=?, addressState=?, addressZip=?, addressCountry=?, roasterId=?, profileUrl=? WHERE id=?",
-		user.FirstName,
-		user.LastName,
-		user.Email,
-		user.Phone,
-		user.AddressLine1,
-		user.AddressLine2,
-		user.AddressCity,
-		user.AddressState,
-		user.AddressZip,
-		user.AddressCountry,
-		user.RoasterId,
-		user.ProfileURL,
-		id,
-	)
+		if err != nil {
+			return err
+		}
+
+		if user.PassHash != "" {
+			user.PassHash = hash(user.PassHash)
+			err = sql.Modify(
+				"UPDATE user SET passHash=?, updatedAt=? WHERE id=?",
+				user.PassHash,
+				user.UpdatedAt,
+				id,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		// a user joining a roaster is news to the new roaster, one leaving is news to the old
+		if user.RoasterId != nil {
+			roasterID = user.RoasterId
+		}
+
+		userID := uuid.Parse(id)
+		err = addEvent(sql, models.USER_UPDATED, userID, roasterID, userEvent(user))
+		if err != nil {
+			return err
+		}
+
+		if previous != user.Email {
+			err = addEvent(sql, models.USER_EMAIL_CHANGED, userID, roasterID, &models.EmailChange{ID: userID, Email: user.Email, PreviousEmail: previous})
+			if err != nil {
+				return err
+			}
+		}
+
+		return index(sql, models.SEARCH_USER, id, user.SearchTerms())
+	})
+}
 
+func (u *User) Delete(id string) error {
+	return inTx(u.sql, func(sql gateways.SQL) error {
+		_, roasterID, err := u.current(sql, id)
+		if err != nil {
+			return err
+		}
+
+		err = sql.Modify("DELETE FROM address WHERE userId=?", id)
+		if err != nil {
+			return err
+		}
+
+		err = sql.Modify("DELETE FROM user WHERE id=?", id)
+		if err != nil {
+			return err
+		}
+
+		userID := uuid.Parse(id)
+		err = addEvent(sql, models.USER_DELETED, userID, roasterID, &models.Deleted{ID: userID})
+		if err != nil {
+			return err
+		}
+
+		return unindex(sql, models.SEARCH_USER, id)
+	})
+}
+
+/*current returns the user's current email and roaster, read with sql so it can be part of a transaction*/
+func (u *User) current(tx gateways.SQL, id string) (string, uuid.UUID, error) {
+	rows, err := tx.Select("SELECT email, roasterId FROM user WHERE id=?", id)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	email := ""
+	var roasterID sql.NullString
+	if rows.Next() {
+		err = rows.Scan(&email, &roasterID)
+	}
+
+	return email, uuid.Parse(roasterID.String), err
+}
+
+/*updated saves a user.updated event holding the user as tx sees them, for changes made to only some fields*/
+func (u *User) updated(tx gateways.SQL, id string) error {
+	rows, err := tx.Select("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user WHERE id=?", id)
 	if err != nil {
 		return err
 	}
 
-	if user.PassHash != "" {
-		user.PassHash = hash(user.PassHash)
-		err = u.sql.Modify(
-			"UPDATE user SET passHash=? WHERE id=?",
-			user.PassHash,
-			id,
-		)
+	user, err := u.getOne(rows)
+	rows.Close()
+	if err != nil || user == nil {
+		return err
 	}
 
-	return err
+	return addEvent(tx, models.USER_UPDATED, user.ID, user.RoasterId, userEvent(user))
 }
 
-func (u *User) Delete(id string) error {
-	err := u.sql.Modify("DELETE FROM user WHERE id=?", id)
-	return err
+/*userEvent is the data of user.created and user.updated events, a copy of user without its password hash*/
+func userEvent(user *models.User) *models.User {
+	event := *user
+	event.PassHash = ""
+	return &event
+}
+
+/*Search returns the users whose name, email or phone match query, best match first*/
+func (u *User) Search(query string, offset int, limit int) ([]*models.User, error) {
+	ids, err := u.Index.Search(models.SEARCH_USER, query)
+	if err != nil {
+		return nil, err
+	}
+
+	ids = page(ids, offset, limit)
+	if len(ids) == 0 {
+		return make([]*models.User, 0), nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := u.sql.Select("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user WHERE id IN ("+placeholders(len(ids))+")", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := models.UserFromSQL(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	//Put the users back in ranked order
+	byID := make(map[string]*models.User)
+	for _, user := range users {
+		byID[user.ID.String()] = user
+	}
+
+	ranked := make([]*models.User, 0, len(users))
+	for _, id := range ids {
+		if user, ok := byID[id]; ok {
+			ranked = append(ranked, user)
+		}
+	}
+
+	return ranked, nil
 }
 
 func (u *User) GetByEmail(email string) (*models.User, error) {
-	rows, err := u.sql.Select("SELECT id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl FROM user WHERE email=?", email)
+	rows, err := u.sql.Select("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user WHERE email=?", email)
 	if err != nil {
 		return nil, err
 	}
@@ -169,15 +356,109 @@ func (u *User) GetByEmail(email string) (*models.User, error) {
 	return users[0], err
 }
 
-func (u *User) Profile(id string, name string, body multipart.File) error {
-	filename := fmt.Sprintf("%s-%s", id, name)
-	url, err := u.S3.Upload("profile", filename, body)
+func (u *User) Profile(id string, photo *Photo) error {
+	url, err := photo.Upload(u.S3, "profile", id)
 	if err != nil {
 		return err
 	}
 
-	err = u.sql.Modify("UPDATE user SET profileUrl=? WHERE id=?", url, id)
-	return err
+	previous := ""
+	err = inTx(u.sql, func(sql gateways.SQL) error {
+		var err error
+		previous, err = profileURL(sql, "user", id)
+		if err != nil {
+			return err
+		}
+
+		err = sql.Modify("UPDATE user SET profileUrl=?, updatedAt=? WHERE id=?", url, now(), id)
+		if err != nil {
+			return err
+		}
+
+		return u.updated(sql, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	removeReplaced(u.S3, previous, url)
+	return nil
+}
+
+/*PresignProfile returns a URL the user can upload their photo straight to S3 at*/
+func (u *User) PresignProfile(id string, contentType string) (*models.PresignedUpload, error) {
+	return presignPhoto(u.S3, "profile", id, contentType)
+}
+
+/*CompleteProfile makes the photo uploaded to key the user's profile photo*/
+func (u *User) CompleteProfile(id string, key string) error {
+	photo, err := fetchPhoto(u.S3, "profile", id, key)
+	if err != nil {
+		return err
+	}
+
+	return u.Profile(id, photo)
+}
+
+/*RemoveProfile clears the user's photo and deletes every size of it at url from S3*/
+func (u *User) RemoveProfile(id string, url string) error {
+	err := inTx(u.sql, func(sql gateways.SQL) error {
+		err := sql.Modify("UPDATE user SET profileUrl=?, updatedAt=? WHERE id=?", "", now(), id)
+		if err != nil {
+			return err
+		}
+
+		return u.updated(sql, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	return removePhoto(u.S3, url)
+}
+
+/*VerifyPhone marks the user's phone as verified as long as it still matches phone*/
+func (u *User) VerifyPhone(id string, phone string) error {
+	return inTx(u.sql, func(sql gateways.SQL) error {
+		err := sql.Modify("UPDATE user SET phoneVerified=TRUE, updatedAt=? WHERE id=? AND phone=?", now(), id, phone)
+		if err != nil {
+			return err
+		}
+
+		return u.updated(sql, id)
+	})
+}
+
+/*SetAddress copies address into the user's legacy flat address fields*/
+func (u *User) SetAddress(id string, address *models.Address) error {
+	return inTx(u.sql, func(sql gateways.SQL) error {
+		err := sql.Modify(
+			"UPDATE user SET addressLine1=?, addressLine2=?, addressCity=?, addressState=?, addressZip=?, addressCountry=?, updatedAt=? WHERE id=?",
+			address.AddressLine1,
+			address.AddressLine2,
+			address.AddressCity,
+			address.AddressState,
+			address.AddressZip,
+			address.AddressCountry,
+			now(),
+			id,
+		)
+		if err != nil {
+			return err
+		}
+
+		return u.updated(sql, id)
+	})
+}
+
+/*now returns the current time truncated to the second precision stored by mysql*/
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+/*upload stores body in the S3 folder under a name prefixed with id so uploads from different owners can't collide*/
+func upload(s3 gateways.S3, folder string, id string, name string, body multipart.File) (string, error) {
+	return s3.Upload(folder, fmt.Sprintf("%s-%s", id, name), body)
 }
 
 func hash(s string) string {
@@ -1,18 +1,26 @@
 package helpers
 
 import (
+	"bytes"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	"os"
+	"image"
+	"image/png"
+	"strings"
 	"testing"
+	"time"
 
 	mocks "github.com/ghmeier/bloodlines/_mocks/gateways"
 	"github.com/ghmeier/bloodlines/gateways"
+	tmocks "github.com/jakelong95/TownCenter/_mocks"
+	tgateways "github.com/jakelong95/TownCenter/gateways"
 	"github.com/jakelong95/TownCenter/models"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
+	tmock "github.com/stretchr/testify/mock"
 )
 
 func TestUserGetByID(t *testing.T) {
@@ -22,9 +30,9 @@ func TestUserGetByID(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
-	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl FROM user").
+	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user").
 		WithArgs(id.String()).
-		WillReturnRows(getUserMockRows().AddRow(id.String(), "", "FirstName", "LastName", "Email", "Phone", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, ""))
+		WillReturnRows(getUserMockRows().AddRow(id.String(), "", "FirstName", "LastName", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, "", time.Now(), time.Now()))
 
 	user, err := u.GetByID(id.String())
 
@@ -53,7 +61,7 @@ func TestUserGetByIDError(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
-	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl FROM user").
+	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user").
 		WithArgs(id.String()).
 		WillReturnError(fmt.Errorf("This is an error"))
 
@@ -70,7 +78,7 @@ func TestUserGetByIDDoesNotExist(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
-	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl FROM user").
+	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user").
 		WithArgs(id.String()).
 		WillReturnRows(getUserMockRows())
 
@@ -81,6 +89,53 @@ func TestUserGetByIDDoesNotExist(t *testing.T) {
 	assert.NoError(err)
 }
 
+func TestUserGetByIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	id1, id2 := uuid.NewUUID(), uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user WHERE id IN \\(\\?,\\?\\)").
+		WithArgs(id1.String(), id2.String()).
+		WillReturnRows(getUserMockRows().AddRow(id2.String(), "", "FirstName", "LastName", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, "", time.Now(), time.Now()))
+
+	users, err := u.GetByIDs([]string{id1.String(), id2.String()})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, len(users))
+	assert.Equal(id2, users[0].ID)
+}
+
+func TestUserGetByIDsEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	users, err := u.GetByIDs([]string{})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(0, len(users))
+}
+
+func TestUserGetByIDsError(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user WHERE id IN").
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	_, err := u.GetByIDs([]string{"1"})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
 func TestUserGetByEmail(t *testing.T) {
 	assert := assert.New(t)
 
@@ -88,9 +143,9 @@ func TestUserGetByEmail(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
-	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl FROM user").
+	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user").
 		WithArgs("Email").
-		WillReturnRows(getUserMockRows().AddRow(id.String(), "", "FirstName", "LastName", "Email", "Phone", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, ""))
+		WillReturnRows(getUserMockRows().AddRow(id.String(), "", "FirstName", "LastName", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, "", time.Now(), time.Now()))
 
 	user, err := u.GetByEmail("Email")
 
@@ -118,7 +173,7 @@ func TestUserGetByEmailError(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
-	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl FROM user").
+	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user").
 		WithArgs("Email").
 		WillReturnError(fmt.Errorf("This is an error"))
 
@@ -135,13 +190,13 @@ func TestUserGetAll(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
-	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl FROM user").
+	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user").
 		WithArgs(offset, limit).
 		WillReturnRows(getUserMockRows().
-			AddRow(uuid.New(), "PassHash", "FirstName", "LastName", "Email", "Phone", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, "").
-			AddRow(uuid.New(), "PassHash", "FirstName", "LastName", "Email", "Phone", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, ""))
+			AddRow(uuid.New(), "PassHash", "FirstName", "LastName", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, "", time.Now(), time.Now()).
+			AddRow(uuid.New(), "PassHash", "FirstName", "LastName", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, "", time.Now(), time.Now()))
 
-	users, err := u.GetAll(offset, limit)
+	users, err := u.GetAll(offset, limit, nil)
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
 	assert.NoError(err)
@@ -155,16 +210,35 @@ func TestUserGetAllError(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
-	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl FROM user").
+	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user").
 		WithArgs(offset, limit).
 		WillReturnError(fmt.Errorf("This is an error"))
 
-	_, err := u.GetAll(offset, limit)
+	_, err := u.GetAll(offset, limit, nil)
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
 	assert.Error(err)
 }
 
+func TestUserGetAllFiltered(t *testing.T) {
+	assert := assert.New(t)
+
+	offset, limit := 0, 20
+	after := time.Now()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	mock.ExpectQuery("SELECT id, passHash, firstName, lastName, email, phone, phoneVerified, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, roasterId, profileUrl, createdAt, updatedAt FROM user WHERE createdAt>=\\? ORDER BY").
+		WithArgs(after.UTC(), offset, limit).
+		WillReturnRows(getUserMockRows())
+
+	users, err := u.GetAll(offset, limit, &models.ListFilter{CreatedAfter: after})
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(0, len(users))
+}
+
 func TestUserInsert(t *testing.T) {
 	assert := assert.New(t)
 
@@ -172,11 +246,16 @@ func TestUserInsert(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
+	mock.ExpectBegin()
 	mock.ExpectPrepare("INSERT INTO user").
 		ExpectExec().
-		WithArgs(user.ID.String(), sqlmock.AnyArg(), user.FirstName, user.LastName, user.Email, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.ProfileURL, user.RoasterId.String()).
+		WithArgs(user.ID.String(), sqlmock.AnyArg(), user.FirstName, user.LastName, user.Email, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.ProfileURL, user.RoasterId.String(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	expectEvent(mock, models.USER_CREATED, user.ID.String(), "")
+	expectIndex(mock, models.SEARCH_USER, user.ID.String())
+	mock.ExpectCommit()
+
 	err := u.Insert(user)
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
@@ -190,10 +269,12 @@ func TestUserInsertError(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
+	mock.ExpectBegin()
 	mock.ExpectPrepare("INSERT INTO user").
 		ExpectExec().
-		WithArgs(user.ID.String(), sqlmock.AnyArg(), user.FirstName, user.LastName, user.Email, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.ProfileURL, user.RoasterId.String()).
+		WithArgs(user.ID.String(), sqlmock.AnyArg(), user.FirstName, user.LastName, user.Email, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.ProfileURL, user.RoasterId.String(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
 
 	err := u.Insert(user)
 
@@ -208,15 +289,21 @@ func TestUpdateWithPassword(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
+	mock.ExpectBegin()
+	expectUserCurrent(mock, user.ID.String(), user.Email, "")
 	mock.ExpectPrepare("UPDATE user").
 		ExpectExec().
-		WithArgs(user.FirstName, user.LastName, user.Email, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.RoasterId.String(), user.ProfileURL, user.ID.String()).
+		WithArgs(user.FirstName, user.LastName, user.Email, user.Phone, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.RoasterId.String(), user.ProfileURL, sqlmock.AnyArg(), user.ID.String()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	mock.ExpectPrepare("UPDATE user").
-		ExpectExec().WithArgs(sqlmock.AnyArg(), user.ID.String()).
+		ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), user.ID.String()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	expectEvent(mock, models.USER_UPDATED, user.ID.String(), "")
+	expectIndex(mock, models.SEARCH_USER, user.ID.String())
+	mock.ExpectCommit()
+
 	err := u.Update(user, user.ID.String())
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
@@ -231,10 +318,69 @@ func TestUpdateWithoutPassword(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
+	mock.ExpectBegin()
+	expectUserCurrent(mock, user.ID.String(), user.Email, "")
+	mock.ExpectPrepare("UPDATE user").
+		ExpectExec().
+		WithArgs(user.FirstName, user.LastName, user.Email, user.Phone, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.RoasterId.String(), user.ProfileURL, sqlmock.AnyArg(), user.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	expectEvent(mock, models.USER_UPDATED, user.ID.String(), "")
+	expectIndex(mock, models.SEARCH_USER, user.ID.String())
+	mock.ExpectCommit()
+
+	err := u.Update(user, user.ID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestUpdateEmailChanged(t *testing.T) {
+	assert := assert.New(t)
+
+	user := getDefaultUser()
+	user.PassHash = ""
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	mock.ExpectBegin()
+	expectUserCurrent(mock, user.ID.String(), "old@expresso.store", "")
+	mock.ExpectPrepare("UPDATE user").
+		ExpectExec().
+		WithArgs(user.FirstName, user.LastName, user.Email, user.Phone, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.RoasterId.String(), user.ProfileURL, sqlmock.AnyArg(), user.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, models.USER_UPDATED, user.ID.String(), "")
+	mock.ExpectPrepare("INSERT INTO outboxEvent").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), models.USER_EMAIL_CHANGED, user.ID.String(), "", `{"id":"`+user.ID.String()+`","email":"Email","previousEmail":"old@expresso.store"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectIndex(mock, models.SEARCH_USER, user.ID.String())
+	mock.ExpectCommit()
+
+	err := u.Update(user, user.ID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestUpdateLeavesRoaster(t *testing.T) {
+	assert := assert.New(t)
+
+	user := getDefaultUser()
+	user.PassHash = ""
+	roasterID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	mock.ExpectBegin()
+	expectUserCurrent(mock, user.ID.String(), user.Email, roasterID.String())
 	mock.ExpectPrepare("UPDATE user").
 		ExpectExec().
-		WithArgs(user.FirstName, user.LastName, user.Email, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.RoasterId.String(), user.ProfileURL, user.ID.String()).
+		WithArgs(user.FirstName, user.LastName, user.Email, user.Phone, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, "", user.ProfileURL, sqlmock.AnyArg(), user.ID.String()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, models.USER_UPDATED, user.ID.String(), roasterID.String())
+	expectIndex(mock, models.SEARCH_USER, user.ID.String())
+	mock.ExpectCommit()
 
 	err := u.Update(user, user.ID.String())
 
@@ -249,14 +395,17 @@ func TestUpdateErrorWithPassword(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
+	mock.ExpectBegin()
+	expectUserCurrent(mock, user.ID.String(), user.Email, "")
 	mock.ExpectPrepare("UPDATE user").
 		ExpectExec().
-		WithArgs(user.FirstName, user.LastName, user.Email, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.RoasterId.String(), user.ProfileURL, user.ID.String()).
+		WithArgs(user.FirstName, user.LastName, user.Email, user.Phone, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.RoasterId.String(), user.ProfileURL, sqlmock.AnyArg(), user.ID.String()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	mock.ExpectPrepare("UPDATE user").
-		ExpectExec().WithArgs(sqlmock.AnyArg(), user.ID.String()).
+		ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), user.ID.String()).
 		WillReturnError(fmt.Errorf("This is another error"))
+	mock.ExpectRollback()
 
 	err := u.Update(user, user.ID.String())
 
@@ -272,10 +421,13 @@ func TestUpdateErrorNoPassword(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
+	mock.ExpectBegin()
+	expectUserCurrent(mock, user.ID.String(), user.Email, "")
 	mock.ExpectPrepare("UPDATE user").
 		ExpectExec().
-		WithArgs(user.FirstName, user.LastName, user.Email, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.RoasterId.String(), user.ProfileURL, user.ID.String()).
+		WithArgs(user.FirstName, user.LastName, user.Email, user.Phone, user.Phone, user.AddressLine1, user.AddressLine2, user.AddressCity, user.AddressState, user.AddressZip, user.AddressCountry, user.RoasterId.String(), user.ProfileURL, sqlmock.AnyArg(), user.ID.String()).
 		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
 
 	err := u.Update(user, user.ID.String())
 
@@ -290,10 +442,41 @@ func TestDeleteUser(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
+	mock.ExpectBegin()
+	expectUserCurrent(mock, id.String(), "Email", "")
+	expectDeleteAddresses(mock, id.String())
+	mock.ExpectPrepare("DELETE FROM user").
+		ExpectExec().
+		WithArgs(id.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	expectEvent(mock, models.USER_DELETED, id.String(), "")
+	expectRemove(mock, models.SEARCH_USER, id.String())
+	mock.ExpectCommit()
+
+	err := u.Delete(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestDeleteRoasterMember(t *testing.T) {
+	assert := assert.New(t)
+
+	id, roasterID := uuid.NewUUID(), uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	mock.ExpectBegin()
+	expectUserCurrent(mock, id.String(), "Email", roasterID.String())
+	expectDeleteAddresses(mock, id.String())
 	mock.ExpectPrepare("DELETE FROM user").
 		ExpectExec().
 		WithArgs(id.String()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, models.USER_DELETED, id.String(), roasterID.String())
+	expectRemove(mock, models.SEARCH_USER, id.String())
+	mock.ExpectCommit()
 
 	err := u.Delete(id.String())
 
@@ -308,10 +491,35 @@ func TestDeleteUserError(t *testing.T) {
 	s, mock, _ := sqlmock.New()
 	u := getMockUser(s)
 
+	mock.ExpectBegin()
+	expectUserCurrent(mock, id.String(), "Email", "")
+	expectDeleteAddresses(mock, id.String())
 	mock.ExpectPrepare("DELETE FROM user").
 		ExpectExec().
 		WithArgs(id.String()).
 		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
+
+	err := u.Delete(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestDeleteUserAddressError(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	mock.ExpectBegin()
+	expectUserCurrent(mock, id.String(), "Email", "")
+	mock.ExpectPrepare("DELETE FROM address").
+		ExpectExec().
+		WithArgs(id.String()).
+		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
 
 	err := u.Delete(id.String())
 
@@ -319,6 +527,13 @@ func TestDeleteUserError(t *testing.T) {
 	assert.Error(err)
 }
 
+func expectDeleteAddresses(mock sqlmock.Sqlmock, id string) {
+	mock.ExpectPrepare("DELETE FROM address WHERE userId").
+		ExpectExec().
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+}
+
 func TestUserProfile(t *testing.T) {
 	assert := assert.New(t)
 
@@ -327,19 +542,55 @@ func TestUserProfile(t *testing.T) {
 	u := getMockUser(s)
 	sMock := &mocks.S3{}
 	u.S3 = sMock
-	file := &os.File{}
+	photo := getMockPhoto()
 
-	sMock.On("Upload", "profile", fmt.Sprintf("%s-%s", id.String(), "test"), file).
+	sMock.On("Upload", "profile", tmock.MatchedBy(func(name string) bool {
+		return strings.HasPrefix(name, id.String()+"-") && strings.HasSuffix(name, "-original.png")
+	}), tmock.Anything).
 		Return("test.com", nil)
+	mock.ExpectBegin()
+	expectProfileURL(mock, "user", id.String(), "")
+	mock.ExpectPrepare("UPDATE user SET").
+		ExpectExec().
+		WithArgs("test.com", sqlmock.AnyArg(), id.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectUserUpdated(mock, id.String())
+	mock.ExpectCommit()
+
+	err := u.Profile(id.String(), photo)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestUserProfileReplaced(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+	sMock := &tmocks.S3{}
+	u.S3 = sMock
+	photo := getMockPhoto()
+
+	sMock.On("Upload", "profile", tmock.Anything, tmock.Anything).Return("test.com/2-original.png", nil)
+	mock.ExpectBegin()
+	expectProfileURL(mock, "user", id.String(), "test.com/1-original.png")
 	mock.ExpectPrepare("UPDATE user SET").
 		ExpectExec().
-		WithArgs("test.com", id.String()).
+		WithArgs("test.com/2-original.png", sqlmock.AnyArg(), id.String()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectUserUpdated(mock, id.String())
+	mock.ExpectCommit()
+	for _, size := range []string{"original", "small", "medium", "large"} {
+		sMock.On("Delete", "test.com/1-"+size+".png").Return(nil)
+	}
 
-	err := u.Profile(id.String(), "test", file)
+	err := u.Profile(id.String(), photo)
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
 	assert.NoError(err)
+	sMock.AssertNumberOfCalls(t, "Delete", 4)
 }
 
 func TestUserProfileError(t *testing.T) {
@@ -350,12 +601,173 @@ func TestUserProfileError(t *testing.T) {
 	u := getMockUser(s)
 	sMock := &mocks.S3{}
 	u.S3 = sMock
-	file := &os.File{}
+	photo := getMockPhoto()
 
-	sMock.On("Upload", "profile", fmt.Sprintf("%s-%s", id.String(), "test"), file).
+	sMock.On("Upload", "profile", tmock.MatchedBy(func(name string) bool {
+		return strings.HasPrefix(name, id.String()+"-") && strings.HasSuffix(name, "-original.png")
+	}), tmock.Anything).
 		Return("", fmt.Errorf("some error"))
 
-	err := u.Profile(id.String(), "test", file)
+	err := u.Profile(id.String(), photo)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestUserCompleteProfile(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+	s3 := tgateways.NewLocalS3("bucket")
+	u.S3 = s3
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 300, 200)))
+	key := "upload/profile/" + id.String() + "-1.png"
+	s3.Put(key, buf.Bytes())
+
+	mock.ExpectBegin()
+	expectProfileURL(mock, "user", id.String(), "")
+	mock.ExpectPrepare("UPDATE user SET").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), id.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectUserUpdated(mock, id.String())
+	mock.ExpectCommit()
+
+	err := u.CompleteProfile(id.String(), key)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	_, ok := s3.Get(key)
+	assert.False(ok)
+}
+
+func TestUserCompleteProfileMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+	u.S3 = tgateways.NewLocalS3("bucket")
+
+	err := u.CompleteProfile(id.String(), "upload/profile/"+id.String()+"-1.png")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Equal(ErrUploadMissing, err)
+}
+
+func TestUserRemoveProfile(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+	sMock := &tmocks.S3{}
+	u.S3 = sMock
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE user SET profileUrl=\\?, updatedAt=\\? WHERE id=\\?").
+		ExpectExec().
+		WithArgs("", sqlmock.AnyArg(), id.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectUserUpdated(mock, id.String())
+	mock.ExpectCommit()
+	for _, size := range []string{"original", "small", "medium", "large"} {
+		sMock.On("Delete", "test.com/1-"+size+".png").Return(nil)
+	}
+
+	err := u.RemoveProfile(id.String(), "test.com/1-original.png")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	sMock.AssertNumberOfCalls(t, "Delete", 4)
+	assert.NoError(err)
+}
+
+func TestUserRemoveProfileNoDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+	u.S3 = &mocks.S3{}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE user SET profileUrl=\\?, updatedAt=\\? WHERE id=\\?").
+		ExpectExec().
+		WithArgs("", sqlmock.AnyArg(), id.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectUserUpdated(mock, id.String())
+	mock.ExpectCommit()
+
+	err := u.RemoveProfile(id.String(), "test.com/1-original.png")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestUserVerifyPhone(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE user SET phoneVerified=TRUE").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), id.String(), "+15155550123").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectUserUpdated(mock, id.String())
+	mock.ExpectCommit()
+
+	err := u.VerifyPhone(id.String(), "+15155550123")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestUserSetAddress(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+	address := models.NewAddress(id, "Home", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry")
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE user SET addressLine1=\\?, addressLine2=\\?, addressCity=\\?, addressState=\\?, addressZip=\\?, addressCountry=\\?").
+		ExpectExec().
+		WithArgs("AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", sqlmock.AnyArg(), id.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectUserUpdated(mock, id.String())
+	mock.ExpectCommit()
+
+	err := u.SetAddress(id.String(), address)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestUserSetAddressEventError(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	u := getMockUser(s)
+	address := models.NewAddress(id, "Home", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry")
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE user SET addressLine1").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT (.+) FROM user WHERE id=\\?").
+		WithArgs(id.String()).
+		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
+
+	err := u.SetAddress(id.String(), address)
 
 	assert.Equal(mock.ExpectationsWereMet(), nil)
 	assert.Error(err)
@@ -365,10 +777,28 @@ func getDefaultUser() *models.User {
 	return models.NewUser("passhash", "Firstname", "Lastname", "Email", "Phone", "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry")
 }
 
+func expectUserCurrent(mock sqlmock.Sqlmock, id string, email string, roasterID string) {
+	var roaster driver.Value
+	if roasterID != "" {
+		roaster = roasterID
+	}
+
+	mock.ExpectQuery("SELECT email, roasterId FROM user WHERE id=\\?").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "roasterId"}).AddRow(email, roaster))
+}
+
 func getUserMockRows() sqlmock.Rows {
-	return sqlmock.NewRows([]string{"id", "passHash", "firstName", "lastName", "email", "phone", "addressLine1", "addressLine2", "addressCity", "addressState", "addressZip", "addressCountry", "roasterId", "profileUrl"})
+	return sqlmock.NewRows([]string{"id", "passHash", "firstName", "lastName", "email", "phone", "phoneVerified", "addressLine1", "addressLine2", "addressCity", "addressState", "addressZip", "addressCountry", "roasterId", "profileUrl", "createdAt", "updatedAt"})
 }
 
 func getMockUser(s *sql.DB) *User {
 	return NewUser(&gateways.MySQL{DB: s}, nil)
 }
+
+func expectUserUpdated(mock sqlmock.Sqlmock, id string) {
+	mock.ExpectQuery("SELECT (.+) FROM user WHERE id=\\?").
+		WithArgs(id).
+		WillReturnRows(getUserMockRows().AddRow(id, "", "FirstName", "LastName", "Email", "Phone", false, "AddressLine1", "AddressLine2", "AddressCity", "AddressState", "AddressZip", "AddressCountry", nil, "", time.Now(), time.Now()))
+	expectEvent(mock, models.USER_UPDATED, id, "")
+}
@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+type VerificationI interface {
+	Insert(*models.Verification) error
+	Get(string) (*models.Verification, error)
+	Attempt(*models.Verification) error
+	Delete(string) error
+}
+
+type Verification struct {
+	*baseHelper
+}
+
+func NewVerification(sql gateways.SQL) *Verification {
+	return &Verification{
+		baseHelper: &baseHelper{sql: sql},
+	}
+}
+
+/*Insert stores a hash of the verification code, replacing any earlier code for the same id*/
+func (v *Verification) Insert(verification *models.Verification) error {
+	code := hash(verification.Code)
+
+	err := v.sql.Modify("INSERT INTO verification (id, phone, code, createdAt, attempts) VALUE (?,?,?,?,?) ON DUPLICATE KEY UPDATE phone=?, code=?, createdAt=?, attempts=?",
+		verification.ID,
+		verification.Phone,
+		code,
+		verification.CreatedAt,
+		verification.Attempts,
+		verification.Phone,
+		code,
+		verification.CreatedAt,
+		verification.Attempts,
+	)
+
+	return err
+}
+
+func (v *Verification) Get(id string) (*models.Verification, error) {
+	rows, err := v.sql.Select("SELECT id, phone, code, createdAt, attempts FROM verification WHERE id=?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	// cannot return an error
+	verifications, _ := models.VerificationFromSQL(rows)
+
+	if len(verifications) == 0 {
+		return nil, nil
+	}
+
+	return verifications[0], nil
+}
+
+/*Attempt records a failed attempt at entering the code*/
+func (v *Verification) Attempt(verification *models.Verification) error {
+	err := v.sql.Modify("UPDATE verification SET attempts=attempts+1 WHERE id=?", verification.ID)
+	if err != nil {
+		return err
+	}
+
+	verification.Attempts++
+	return nil
+}
+
+func (v *Verification) Delete(id string) error {
+	err := v.sql.Modify("DELETE FROM verification WHERE id=?", id)
+	return err
+}
@@ -0,0 +1,121 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestVerificationInsert(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	v := getMockVerification(s)
+	verification := models.NewVerification(uuid.NewUUID(), "+15155550123", "123456")
+
+	mock.ExpectPrepare("INSERT INTO verification").
+		ExpectExec().
+		WithArgs(verification.ID.String(), verification.Phone, sqlmock.AnyArg(), verification.CreatedAt, 0, verification.Phone, sqlmock.AnyArg(), verification.CreatedAt, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := v.Insert(verification)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal("123456", verification.Code)
+}
+
+func TestVerificationGet(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	v := getMockVerification(s)
+	id := uuid.NewUUID()
+	code, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+
+	mock.ExpectQuery("SELECT id, phone, code, createdAt, attempts FROM verification").
+		WithArgs(id.String()).
+		WillReturnRows(getVerificationMockRows().AddRow(id.String(), "+15155550123", string(code), time.Now(), 2))
+
+	verification, err := v.Get(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(id, verification.ID)
+	assert.Equal("+15155550123", verification.Phone)
+	assert.Equal(2, verification.Attempts)
+	assert.NoError(bcrypt.CompareHashAndPassword([]byte(verification.Code), []byte("123456")))
+}
+
+func TestVerificationGetEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	v := getMockVerification(s)
+	id := uuid.NewUUID()
+
+	mock.ExpectQuery("SELECT id, phone, code, createdAt, attempts FROM verification").
+		WithArgs(id.String()).
+		WillReturnRows(getVerificationMockRows())
+
+	verification, err := v.Get(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Nil(verification)
+}
+
+func TestVerificationAttempt(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	v := getMockVerification(s)
+	verification := models.NewVerification(uuid.NewUUID(), "+15155550123", "123456")
+
+	mock.ExpectPrepare("UPDATE verification SET attempts=attempts\\+1").
+		ExpectExec().
+		WithArgs(verification.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := v.Attempt(verification)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, verification.Attempts)
+}
+
+func TestVerificationAttemptError(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	v := getMockVerification(s)
+	verification := models.NewVerification(uuid.NewUUID(), "+15155550123", "123456")
+
+	mock.ExpectPrepare("UPDATE verification").
+		ExpectExec().
+		WithArgs(verification.ID.String()).
+		WillReturnError(fmt.Errorf("some error"))
+
+	err := v.Attempt(verification)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+	assert.Equal(0, verification.Attempts)
+}
+
+func getVerificationMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "phone", "code", "createdAt", "attempts"})
+}
+
+func getMockVerification(s *sql.DB) *Verification {
+	return NewVerification(&gateways.MySQL{DB: s})
+}
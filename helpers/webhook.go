@@ -0,0 +1,362 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+	"github.com/pborman/uuid"
+)
+
+// Dispatcher settings. A delivery that fails is tried again after
+// WebhookBackoff, doubling each time, and is given up on after
+// WebhookMaxAttempts. Claimed deliveries are held for WebhookClaim, long
+// enough for every delivery in a batch to time out.
+const (
+	WebhookBatch       = 100
+	WebhookInterval    = 10 * time.Second
+	WebhookMaxAttempts = 8
+	WebhookBackoff     = 30 * time.Second
+	WebhookTimeout     = 10 * time.Second
+	WebhookClaim       = WebhookBatch * WebhookTimeout
+)
+
+var (
+	ErrWebhookAddress  = errors.New("Error: webhook host resolves to a private address")
+	ErrWebhookRedirect = errors.New("Error: webhook redirects aren't followed")
+)
+
+/*Headers sent with every delivery*/
+const (
+	HEADER_SIGNATURE = "X-TownCenter-Signature"
+	HEADER_TIMESTAMP = "X-TownCenter-Timestamp"
+	HEADER_EVENT     = "X-TownCenter-Event"
+	HEADER_DELIVERY  = "X-TownCenter-Delivery"
+)
+
+type WebhookI interface {
+	Insert(*models.Webhook) error
+	GetByID(string) (*models.Webhook, error)
+	GetByRoaster(string) ([]*models.Webhook, error)
+	Update(*models.Webhook) error
+	Delete(string) error
+	GetDeliveries(string, int, int) ([]*models.WebhookDelivery, error)
+	GetDelivery(string) (*models.WebhookDelivery, error)
+	ClaimDue(string, time.Time, int) ([]*models.WebhookDelivery, error)
+	SaveDelivery(*models.WebhookDelivery) error
+	Replay(*models.WebhookDelivery) error
+}
+
+type Webhook struct {
+	*baseHelper
+}
+
+func NewWebhook(sql gateways.SQL) *Webhook {
+	return &Webhook{
+		baseHelper: &baseHelper{sql: sql},
+	}
+}
+
+func (w *Webhook) Insert(webhook *models.Webhook) error {
+	webhook.CreatedAt = now()
+	webhook.UpdatedAt = webhook.CreatedAt
+
+	return w.sql.Modify(
+		"INSERT INTO webhook (id, roasterId, url, secret, events, active, createdAt, updatedAt) VALUE (?,?,?,?,?,?,?,?)",
+		webhook.ID,
+		webhook.RoasterID,
+		webhook.URL,
+		webhook.Secret,
+		strings.Join(webhook.Events, ","),
+		webhook.Active,
+		webhook.CreatedAt,
+		webhook.UpdatedAt,
+	)
+}
+
+func (w *Webhook) GetByID(id string) (*models.Webhook, error) {
+	rows, err := w.sql.Select("SELECT id, roasterId, url, secret, events, active, createdAt, updatedAt FROM webhook WHERE id=?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := models.WebhookFromSQL(rows)
+	if err != nil || len(webhooks) == 0 {
+		return nil, err
+	}
+
+	return webhooks[0], nil
+}
+
+/*GetByRoaster returns every webhook the roaster has, oldest first*/
+func (w *Webhook) GetByRoaster(roasterID string) ([]*models.Webhook, error) {
+	rows, err := w.sql.Select("SELECT id, roasterId, url, secret, events, active, createdAt, updatedAt FROM webhook WHERE roasterId=? ORDER BY createdAt ASC", roasterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.WebhookFromSQL(rows)
+}
+
+func (w *Webhook) Update(webhook *models.Webhook) error {
+	webhook.UpdatedAt = now()
+
+	return w.sql.Modify(
+		"UPDATE webhook SET url=?, secret=?, events=?, active=?, updatedAt=? WHERE id=?",
+		webhook.URL,
+		webhook.Secret,
+		strings.Join(webhook.Events, ","),
+		webhook.Active,
+		webhook.UpdatedAt,
+		webhook.ID,
+	)
+}
+
+/*Delete removes the webhook and its deliveries, including any still pending*/
+func (w *Webhook) Delete(id string) error {
+	return inTx(w.sql, func(sql gateways.SQL) error {
+		err := sql.Modify("DELETE FROM webhookDelivery WHERE webhookId=?", id)
+		if err != nil {
+			return err
+		}
+
+		return sql.Modify("DELETE FROM webhook WHERE id=?", id)
+	})
+}
+
+/*GetDeliveries returns a page of the webhook's deliveries, newest first*/
+func (w *Webhook) GetDeliveries(webhookID string, offset, limit int) ([]*models.WebhookDelivery, error) {
+	rows, err := w.sql.Select("SELECT id, webhookId, eventId, type, payload, status, attempts, responseCode, error, nextAttemptAt, createdAt, updatedAt FROM webhookDelivery WHERE webhookId=? ORDER BY createdAt DESC LIMIT ?,?", webhookID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.WebhookDeliveryFromSQL(rows)
+}
+
+func (w *Webhook) GetDelivery(id string) (*models.WebhookDelivery, error) {
+	rows, err := w.sql.Select("SELECT id, webhookId, eventId, type, payload, status, attempts, responseCode, error, nextAttemptAt, createdAt, updatedAt FROM webhookDelivery WHERE id=?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := models.WebhookDeliveryFromSQL(rows)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	return deliveries[0], nil
+}
+
+// ClaimDue gives runner up to limit pending deliveries whose next attempt is
+// at or before at, oldest first, and returns every delivery runner holds.
+// Claiming pushes a delivery's next attempt back by WebhookClaim, so one
+// whose runner stops is picked up again after that.
+func (w *Webhook) ClaimDue(runner string, at time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	err := w.sql.Modify("UPDATE webhookDelivery SET runner=?, nextAttemptAt=? WHERE status=? AND nextAttemptAt<=? ORDER BY nextAttemptAt ASC LIMIT ?", runner, at.Add(WebhookClaim), models.DELIVERY_PENDING, at, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := w.sql.Select("SELECT id, webhookId, eventId, type, payload, status, attempts, responseCode, error, nextAttemptAt, createdAt, updatedAt FROM webhookDelivery WHERE runner=? AND status=? ORDER BY createdAt ASC LIMIT ?", runner, models.DELIVERY_PENDING, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.WebhookDeliveryFromSQL(rows)
+}
+
+/*SaveDelivery stores the outcome of an attempt and releases the delivery*/
+func (w *Webhook) SaveDelivery(d *models.WebhookDelivery) error {
+	d.UpdatedAt = now()
+
+	return w.sql.Modify(
+		"UPDATE webhookDelivery SET status=?, attempts=?, responseCode=?, error=?, nextAttemptAt=?, runner=NULL, updatedAt=? WHERE id=?",
+		d.Status,
+		d.Attempts,
+		d.ResponseCode,
+		d.Error,
+		d.NextAttemptAt,
+		d.UpdatedAt,
+		d.ID,
+	)
+}
+
+/*Replay queues the delivery to be sent again straight away with a fresh set of attempts*/
+func (w *Webhook) Replay(d *models.WebhookDelivery) error {
+	d.Status = models.DELIVERY_PENDING
+	d.Attempts = 0
+	d.ResponseCode = 0
+	d.Error = ""
+	d.NextAttemptAt = now()
+
+	return w.SaveDelivery(d)
+}
+
+// Dispatcher posts due deliveries to their webhooks, signing each with the
+// webhook's secret. Each TownCenter has its own Runner ID that it claims
+// deliveries with, so running more than one doesn't send them twice. A
+// delivery is still sent again if TownCenter stops mid attempt, so receivers
+// should ignore delivery IDs they've already handled.
+type Dispatcher struct {
+	Webhook WebhookI
+	Client  *http.Client
+	Runner  string
+}
+
+// NewDispatcher's client only connects to public addresses, checked after
+// the host is resolved so a name can't be pointed somewhere private once the
+// webhook has been saved, and doesn't follow redirects.
+func NewDispatcher(sql gateways.SQL) *Dispatcher {
+	return &Dispatcher{
+		Webhook: NewWebhook(sql),
+		Runner:  uuid.New(),
+		Client: &http.Client{
+			Timeout:   WebhookTimeout,
+			Transport: &http.Transport{Dial: dialPublic},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return ErrWebhookRedirect
+			},
+		},
+	}
+}
+
+/*Run attempts every due delivery it can claim once, returning how many were attempted*/
+func (d *Dispatcher) Run() (int, error) {
+	attempted := 0
+	webhooks := make(map[string]*models.Webhook)
+	for {
+		deliveries, err := d.Webhook.ClaimDue(d.Runner, now(), WebhookBatch)
+		if err != nil {
+			return attempted, err
+		}
+
+		for _, delivery := range deliveries {
+			id := delivery.WebhookID.String()
+			webhook, ok := webhooks[id]
+			if !ok {
+				webhook, err = d.Webhook.GetByID(id)
+				if err != nil {
+					return attempted, err
+				}
+				webhooks[id] = webhook
+			}
+
+			d.attempt(webhook, delivery)
+			err = d.Webhook.SaveDelivery(delivery)
+			if err != nil {
+				return attempted, err
+			}
+			attempted++
+		}
+
+		if len(deliveries) < WebhookBatch {
+			break
+		}
+	}
+
+	return attempted, nil
+}
+
+/*Watch runs the dispatcher every interval*/
+func (d *Dispatcher) Watch(interval time.Duration) {
+	for {
+		_, err := d.Run()
+		if err != nil {
+			fmt.Println(err.Error())
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// attempt posts the delivery to webhook and records the outcome on it. A
+// disabled or deleted webhook fails the delivery without sending it, it can
+// be replayed once the webhook is turned back on.
+func (d *Dispatcher) attempt(webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	if webhook == nil || !webhook.Active {
+		delivery.Status = models.DELIVERY_FAILED
+		delivery.Error = "webhook is disabled"
+		return
+	}
+
+	delivery.Attempts++
+	delivery.ResponseCode = 0
+	code, err := d.post(webhook, delivery)
+	delivery.ResponseCode = code
+	if err == nil && code >= 200 && code < 300 {
+		delivery.Status = models.DELIVERY_SUCCEEDED
+		delivery.Error = ""
+		return
+	}
+
+	if err != nil {
+		delivery.Error = err.Error()
+	} else {
+		delivery.Error = "unexpected status " + strconv.Itoa(code)
+	}
+	if len(delivery.Error) > 255 {
+		delivery.Error = delivery.Error[:255]
+	}
+
+	if delivery.Attempts >= WebhookMaxAttempts {
+		delivery.Status = models.DELIVERY_FAILED
+		return
+	}
+	delivery.NextAttemptAt = now().Add(WebhookBackoff << uint(delivery.Attempts-1))
+}
+
+/*dialPublic connects to addr after checking every address its host resolves to is public*/
+func dialPublic(network string, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if !models.IsPublicIP(ip) {
+			return nil, ErrWebhookAddress
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: WebhookTimeout}
+	return dialer.Dial(network, net.JoinHostPort(ips[0].String(), port))
+}
+
+func (d *Dispatcher) post(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := now().Unix()
+
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TownCenter-Webhooks")
+	req.Header.Set(HEADER_SIGNATURE, models.SignWebhook(webhook.Secret, timestamp, body))
+	req.Header.Set(HEADER_TIMESTAMP, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HEADER_EVENT, delivery.Type)
+	req.Header.Set(HEADER_DELIVERY, delivery.ID.String())
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,281 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookInsert(t *testing.T) {
+	assert := assert.New(t)
+
+	webhook := getDefaultWebhook()
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectPrepare("INSERT INTO webhook").
+		ExpectExec().
+		WithArgs(webhook.ID.String(), webhook.RoasterID.String(), "https://roaster.com/hooks", webhook.Secret, "user.created,roaster.updated", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := h.Insert(webhook)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.False(webhook.CreatedAt.IsZero())
+}
+
+func TestWebhookGetByID(t *testing.T) {
+	assert := assert.New(t)
+
+	id, roasterID := uuid.NewUUID(), uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, url, secret, events, active, createdAt, updatedAt FROM webhook WHERE id=\\?").
+		WithArgs(id.String()).
+		WillReturnRows(getWebhookMockRows().
+			AddRow(id.String(), roasterID.String(), "https://roaster.com/hooks", "secret", "user.created,roaster.updated", true, time.Now(), time.Now()))
+
+	webhook, err := h.GetByID(id.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(id, webhook.ID)
+	assert.Equal(roasterID, webhook.RoasterID)
+	assert.Equal([]string{models.USER_CREATED, models.ROASTER_UPDATED}, webhook.Events)
+	assert.True(webhook.Active)
+}
+
+func TestWebhookGetByIDNone(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, url, secret, events, active, createdAt, updatedAt FROM webhook WHERE id=\\?").
+		WithArgs("missing").
+		WillReturnRows(getWebhookMockRows())
+
+	webhook, err := h.GetByID("missing")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Nil(webhook)
+}
+
+func TestWebhookGetByRoaster(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectQuery("SELECT id, roasterId, url, secret, events, active, createdAt, updatedAt FROM webhook WHERE roasterId=\\? ORDER BY createdAt ASC").
+		WithArgs(roasterID.String()).
+		WillReturnRows(getWebhookMockRows().
+			AddRow(uuid.New(), roasterID.String(), "https://roaster.com/a", "secret", "user.created", true, time.Now(), time.Now()).
+			AddRow(uuid.New(), roasterID.String(), "https://roaster.com/b", "secret", "roaster.deleted", false, time.Now(), time.Now()))
+
+	webhooks, err := h.GetByRoaster(roasterID.String())
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(2, len(webhooks))
+	assert.Equal("https://roaster.com/b", webhooks[1].URL)
+	assert.False(webhooks[1].Active)
+}
+
+func TestWebhookUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	webhook := getDefaultWebhook()
+	webhook.Active = false
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectPrepare("UPDATE webhook SET url=\\?, secret=\\?, events=\\?, active=\\?, updatedAt=\\? WHERE id=\\?").
+		ExpectExec().
+		WithArgs("https://roaster.com/hooks", webhook.Secret, "user.created,roaster.updated", false, sqlmock.AnyArg(), webhook.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := h.Update(webhook)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestWebhookDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("DELETE FROM webhookDelivery WHERE webhookId=\\?").
+		ExpectExec().
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(1, 4))
+	mock.ExpectPrepare("DELETE FROM webhook WHERE id=\\?").
+		ExpectExec().
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := h.Delete(id)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+}
+
+func TestWebhookDeleteError(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.New()
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("DELETE FROM webhookDelivery").
+		ExpectExec().
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(1, 4))
+	mock.ExpectPrepare("DELETE FROM webhook").
+		ExpectExec().
+		WithArgs(id).
+		WillReturnError(fmt.Errorf("This is an error"))
+	mock.ExpectRollback()
+
+	err := h.Delete(id)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestWebhookGetDeliveries(t *testing.T) {
+	assert := assert.New(t)
+
+	webhookID := uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectQuery("SELECT id, webhookId, eventId, type, payload, status, attempts, responseCode, error, nextAttemptAt, createdAt, updatedAt FROM webhookDelivery WHERE webhookId=\\? ORDER BY createdAt DESC LIMIT \\?,\\?").
+		WithArgs(webhookID.String(), 20, 10).
+		WillReturnRows(getDeliveryMockRows().
+			AddRow(uuid.New(), webhookID.String(), uuid.New(), models.USER_CREATED, "{}", models.DELIVERY_FAILED, 8, 500, "unexpected status 500", time.Now(), time.Now(), time.Now()))
+
+	deliveries, err := h.GetDeliveries(webhookID.String(), 20, 10)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, len(deliveries))
+	assert.Equal(webhookID, deliveries[0].WebhookID)
+	assert.Equal(models.DELIVERY_FAILED, deliveries[0].Status)
+	assert.Equal(8, deliveries[0].Attempts)
+	assert.Equal(500, deliveries[0].ResponseCode)
+}
+
+func TestWebhookGetDeliveryNone(t *testing.T) {
+	assert := assert.New(t)
+
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectQuery("SELECT id, webhookId, eventId, type, payload, status, attempts, responseCode, error, nextAttemptAt, createdAt, updatedAt FROM webhookDelivery WHERE id=\\?").
+		WithArgs("missing").
+		WillReturnRows(getDeliveryMockRows())
+
+	delivery, err := h.GetDelivery("missing")
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Nil(delivery)
+}
+
+func TestWebhookClaimDue(t *testing.T) {
+	assert := assert.New(t)
+
+	at := time.Now()
+	runner, id := uuid.New(), uuid.NewUUID()
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectPrepare("UPDATE webhookDelivery SET runner=\\?, nextAttemptAt=\\? WHERE status=\\? AND nextAttemptAt<=\\? ORDER BY nextAttemptAt ASC LIMIT \\?").
+		ExpectExec().
+		WithArgs(runner, at.Add(WebhookClaim), models.DELIVERY_PENDING, at, WebhookBatch).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT id, webhookId, eventId, type, payload, status, attempts, responseCode, error, nextAttemptAt, createdAt, updatedAt FROM webhookDelivery WHERE runner=\\? AND status=\\? ORDER BY createdAt ASC LIMIT \\?").
+		WithArgs(runner, models.DELIVERY_PENDING, WebhookBatch).
+		WillReturnRows(getDeliveryMockRows().
+			AddRow(id.String(), uuid.New(), uuid.New(), models.USER_CREATED, "{}", models.DELIVERY_PENDING, 0, 0, "", at.Add(WebhookClaim), at, at))
+
+	deliveries, err := h.ClaimDue(runner, at, WebhookBatch)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.Equal(1, len(deliveries))
+	assert.Equal(id, deliveries[0].ID)
+}
+
+func TestWebhookClaimDueError(t *testing.T) {
+	assert := assert.New(t)
+
+	at := time.Now()
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectPrepare("UPDATE webhookDelivery SET runner=\\?").
+		ExpectExec().
+		WithArgs("runner", at.Add(WebhookClaim), models.DELIVERY_PENDING, at, WebhookBatch).
+		WillReturnError(fmt.Errorf("This is an error"))
+
+	_, err := h.ClaimDue("runner", at, WebhookBatch)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.Error(err)
+}
+
+func TestWebhookReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	delivery := &models.WebhookDelivery{ID: uuid.NewUUID(), Status: models.DELIVERY_FAILED, Attempts: WebhookMaxAttempts, ResponseCode: 500, Error: "unexpected status 500"}
+	s, mock, _ := sqlmock.New()
+	h := getMockWebhook(s)
+
+	mock.ExpectPrepare("UPDATE webhookDelivery SET status=\\?, attempts=\\?, responseCode=\\?, error=\\?, nextAttemptAt=\\?, runner=NULL, updatedAt=\\? WHERE id=\\?").
+		ExpectExec().
+		WithArgs(models.DELIVERY_PENDING, 0, 0, "", sqlmock.AnyArg(), sqlmock.AnyArg(), delivery.ID.String()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := h.Replay(delivery)
+
+	assert.Equal(mock.ExpectationsWereMet(), nil)
+	assert.NoError(err)
+	assert.False(delivery.NextAttemptAt.IsZero())
+}
+
+func getDefaultWebhook() *models.Webhook {
+	webhook, _ := models.NewWebhook(uuid.NewUUID(), &models.WebhookRequest{URL: "https://roaster.com/hooks", Events: []string{models.USER_CREATED, models.ROASTER_UPDATED}})
+	return webhook
+}
+
+func getWebhookMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "roasterId", "url", "secret", "events", "active", "createdAt", "updatedAt"})
+}
+
+func getDeliveryMockRows() sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "webhookId", "eventId", "type", "payload", "status", "attempts", "responseCode", "error", "nextAttemptAt", "createdAt", "updatedAt"})
+}
+
+func getMockWebhook(s *sql.DB) *Webhook {
+	return NewWebhook(&gateways.MySQL{DB: s})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghmeier/bloodlines/config"
+	"github.com/ghmeier/bloodlines/gateways"
+	c "github.com/ghmeier/coinage/gateways"
+	tg "github.com/jakelong95/TownCenter/gateways"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+// runImport imports a file from the command line, the same way as
+// POST /api/import but in the foreground, printing progress as it goes:
+//
+//	TownCenter import -kind user [-format csv] [-dry-run] users.csv
+//	TownCenter import -resume <importId>
+func runImport(config *config.Root, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	kind := flags.String("kind", "", "what the file holds, user or roaster")
+	format := flags.String("format", models.IMPORT_CSV, "csv or jsonl")
+	dryRun := flags.Bool("dry-run", false, "check every row without changing anything")
+	resume := flags.String("resume", "", "id of a failed import to carry on with")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	sql, err := gateways.NewSQL(config.SQL)
+	if err != nil {
+		return err
+	}
+
+	importer := helpers.NewImporter(sql, tg.NewS3(config.S3), c.NewCoinage(config.Coinage), gateways.NewBloodlines(config.Bloodlines))
+	importer.Progress = func(job *models.Import) {
+		fmt.Printf("%d/%d rows, %d created, %d updated, %d failed\n", job.Processed, job.Total, job.Created, job.Updated, job.Failed)
+	}
+
+	id := *resume
+	if id == "" {
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: TownCenter import -kind user|roaster [-format csv|jsonl] [-dry-run] <file>")
+		}
+
+		body, err := ioutil.ReadFile(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		job, err := models.NewImport(*kind, *format, *dryRun, body)
+		if err != nil {
+			return err
+		}
+
+		err = importer.Import.Insert(job, body)
+		if err != nil {
+			return err
+		}
+
+		id = job.ID.String()
+		fmt.Printf("Import %s has %d rows\n", id, job.Total)
+	}
+
+	job, err := importer.Run(id)
+	if job != nil {
+		for _, e := range job.Errors {
+			fmt.Printf("row %d %s: %s %s\n", e.Row, e.Email, e.Message, e.Fields.Error())
+		}
+		if job.Failed > len(job.Errors) {
+			fmt.Printf("and %d more rows failed\n", job.Failed-len(job.Errors))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s, resume it with -resume %s", err.Error(), id)
+	}
+
+	return nil
+}
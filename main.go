@@ -6,9 +6,8 @@ import (
 	"path"
 	"path/filepath"
 
+	"github.com/jakelong95/TownCenter/config"
 	"github.com/jakelong95/TownCenter/router"
-
-	"github.com/ghmeier/bloodlines/config"
 )
 
 func main() {
@@ -21,6 +20,24 @@ func main() {
 		return
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		err = runImport(config.Root, os.Args[2:])
+		if err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		err = runReindex(config.Root, os.Args[2:])
+		if err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	tc, err := router.New(config)
 	if err != nil {
 		fmt.Printf("Error: %s\n", err.Error())
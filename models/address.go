@@ -0,0 +1,54 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+/*Address is one of the shipping or billing addresses in a user's address book*/
+type Address struct {
+	ID              uuid.UUID `json:"id"`
+	UserID          uuid.UUID `json:"userId"`
+	Label           string    `json:"label" validate:"max=30"`
+	AddressLine1    string    `json:"addressLine1" validate:"required,max=200"`
+	AddressLine2    string    `json:"addressLine2" validate:"max=200"`
+	AddressCity     string    `json:"addressCity" validate:"required,max=30"`
+	AddressState    string    `json:"addressState" validate:"max=30"`
+	AddressZip      string    `json:"addressZip" validate:"max=10,postal=AddressCountry"`
+	AddressCountry  string    `json:"addressCountry" validate:"required,country"`
+	DefaultShipping bool      `json:"defaultShipping"`
+	DefaultBilling  bool      `json:"defaultBilling"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+func NewAddress(userID uuid.UUID, label, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry string) *Address {
+	return &Address{
+		ID:             uuid.NewUUID(),
+		UserID:         userID,
+		Label:          label,
+		AddressLine1:   addressLine1,
+		AddressLine2:   addressLine2,
+		AddressCity:    addressCity,
+		AddressState:   addressState,
+		AddressZip:     addressZip,
+		AddressCountry: addressCountry,
+	}
+}
+
+func AddressFromSQL(rows *sql.Rows) ([]*Address, error) {
+	addresses := make([]*Address, 0)
+
+	for rows.Next() {
+		a := &Address{}
+
+		rows.Scan(&a.ID, &a.UserID, &a.Label, &a.AddressLine1, &a.AddressLine2, &a.AddressCity, &a.AddressState,
+			&a.AddressZip, &a.AddressCountry, &a.DefaultShipping, &a.DefaultBilling, &a.CreatedAt, &a.UpdatedAt)
+
+		addresses = append(addresses, a)
+	}
+
+	return addresses, nil
+}
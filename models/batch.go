@@ -0,0 +1,51 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*MaxBatch is the most IDs a batch lookup can ask for*/
+const MaxBatch = 100
+
+/*BatchRequest lists the IDs to look up*/
+type BatchRequest struct {
+	IDs []string `json:"ids"`
+}
+
+/*Validate checks there are between 1 and MaxBatch IDs*/
+func (b *BatchRequest) Validate() ValidationErrors {
+	if len(b.IDs) == 0 {
+		return ValidationErrors{&FieldError{"ids", REQUIRED, "ids is required"}}
+	}
+	if len(b.IDs) > MaxBatch {
+		return ValidationErrors{&FieldError{"ids", TOO_MANY, fmt.Sprintf("ids can have at most %d entries", MaxBatch)}}
+	}
+
+	return nil
+}
+
+/*Unique returns the IDs lower cased with the repeats removed, keeping their order*/
+func (b *BatchRequest) Unique() []string {
+	ids := make([]string, 0, len(b.IDs))
+	for _, id := range b.IDs {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if !contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+/*UserResult is what a batch lookup found for one ID, User is left out when Found is false*/
+type UserResult struct {
+	Found bool  `json:"found"`
+	User  *User `json:"user,omitempty"`
+}
+
+/*RoasterResult is what a batch lookup found for one ID, Roaster is left out when Found is false*/
+type RoasterResult struct {
+	Found   bool     `json:"found"`
+	Roaster *Roaster `json:"roaster,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchRequestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	b := &BatchRequest{IDs: []string{"1", "2"}}
+
+	assert.Nil(b.Validate())
+}
+
+func TestBatchRequestValidateEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	errs := (&BatchRequest{}).Validate()
+
+	assert.Equal(1, len(errs))
+	assert.Equal(REQUIRED, errs[0].Code)
+}
+
+func TestBatchRequestValidateTooMany(t *testing.T) {
+	assert := assert.New(t)
+
+	b := &BatchRequest{}
+	for i := 0; i <= MaxBatch; i++ {
+		b.IDs = append(b.IDs, fmt.Sprintf("%d", i))
+	}
+	errs := b.Validate()
+
+	assert.Equal(1, len(errs))
+	assert.Equal(TOO_MANY, errs[0].Code)
+}
+
+func TestBatchRequestUnique(t *testing.T) {
+	assert := assert.New(t)
+
+	b := &BatchRequest{IDs: []string{"B", "a", " b", "c", "a"}}
+
+	assert.Equal([]string{"b", "a", "c"}, b.Unique())
+}
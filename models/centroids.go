@@ -0,0 +1,65 @@
+package models
+
+// centroids is the postal code dataset used for geocoding. Each country maps
+// whole postal codes, written without spaces or hyphens, to the latitude and
+// longitude of the area they cover. Where a country's codes have a district
+// part before a space, like Canada's forward sortation areas and the UK's
+// outward codes, the district is listed instead.
+//
+// Only a sample is bundled, enough for development and the tests. The
+// Docker image ships the full GeoNames dataset, built by
+// scripts/postal_centroids.sh, and loads it with LoadCentroids at startup,
+// see CentroidsFile.
+var centroids = map[string]map[string][2]float64{
+	"US": {
+		"02108": {42.36, -71.06},
+		"10001": {40.75, -74.00},
+		"19103": {39.95, -75.17},
+		"20001": {38.91, -77.02},
+		"30303": {33.75, -84.39},
+		"33130": {25.77, -80.20},
+		"37201": {36.17, -86.78},
+		"44113": {41.48, -81.70},
+		"48226": {42.33, -83.05},
+		"50010": {42.03, -93.62},
+		"50309": {41.59, -93.62},
+		"55401": {44.98, -93.27},
+		"60601": {41.89, -87.62},
+		"63101": {38.63, -90.19},
+		"64105": {39.10, -94.59},
+		"75201": {32.79, -96.80},
+		"77002": {29.76, -95.36},
+		"78701": {30.27, -97.74},
+		"80202": {39.75, -104.99},
+		"84101": {40.76, -111.90},
+		"85004": {33.45, -112.07},
+		"89101": {36.17, -115.13},
+		"90012": {34.06, -118.24},
+		"92101": {32.72, -117.16},
+		"94103": {37.77, -122.41},
+		"97204": {45.52, -122.68},
+		"98101": {47.61, -122.33},
+	},
+	"CA": {
+		"H2Y": {45.50, -73.55},
+		"K1A": {45.42, -75.70},
+		"M5V": {43.64, -79.39},
+		"T2P": {51.05, -114.07},
+		"V6B": {49.28, -123.11},
+	},
+	"GB": {
+		"EC1A": {51.52, -0.10},
+		"EH1":  {55.95, -3.19},
+		"M1":   {53.48, -2.24},
+		"SW1A": {51.50, -0.14},
+	},
+	"DE": {
+		"10115": {52.53, 13.38},
+		"20095": {53.55, 10.00},
+		"80331": {48.14, 11.57},
+	},
+	"AU": {
+		"2000": {-33.87, 151.21},
+		"3000": {-37.81, 144.96},
+	},
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+/*DateFormat is how a Date is written in json and stored in mysql*/
+const DateFormat = "2006-01-02"
+
+/*Date is a calendar day with no time of day, stored in UTC*/
+type Date struct {
+	time.Time
+}
+
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+/*ParseDate reads a YYYY-MM-DD date, or a bare YYYY year which is taken as January 1st*/
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateFormat, s)
+	if err == nil {
+		return Date{t}, nil
+	}
+
+	t, err = time.Parse("2006", s)
+	if err != nil {
+		return Date{}, fmt.Errorf("%s is not a YYYY-MM-DD date", s)
+	}
+
+	return Date{t}, nil
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.Format(DateFormat)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var s *string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	if s == nil || *s == "" {
+		*d = Date{}
+		return nil
+	}
+
+	*d, err = ParseDate(*s)
+	return err
+}
+
+/*Scan reads a DATE column, which the driver returns as either a time or its text*/
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = NewDate(v.Year(), v.Month(), v.Day())
+	case []byte:
+		return d.Scan(string(v))
+	case string:
+		parsed, err := ParseDate(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	default:
+		return fmt.Errorf("can't scan %T into a Date", value)
+	}
+
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+
+	return d.String(), nil
+}
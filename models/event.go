@@ -0,0 +1,84 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+/*Event types published to other services, they're also the routing keys*/
+const (
+	USER_CREATED           = "user.created"
+	USER_UPDATED           = "user.updated"
+	USER_EMAIL_CHANGED     = "user.email_changed"
+	USER_DELETED           = "user.deleted"
+	ROASTER_CREATED        = "roaster.created"
+	ROASTER_UPDATED        = "roaster.updated"
+	ROASTER_STATUS_CHANGED = "roaster.status_changed"
+	ROASTER_TRANSFERRED    = "roaster.transferred"
+	ROASTER_DELETED        = "roaster.deleted"
+)
+
+/*EventTypes are every type of event, in the order they're documented*/
+var EventTypes = []string{USER_CREATED, USER_UPDATED, USER_EMAIL_CHANGED, USER_DELETED, ROASTER_CREATED, ROASTER_UPDATED, ROASTER_STATUS_CHANGED, ROASTER_TRANSFERRED, ROASTER_DELETED}
+
+// Event is something that happened to a user or roaster that other services
+// may want to know about. It's written to the outbox in the same transaction
+// as the change and published afterwards, so it can be delivered more than
+// once and consumers should ignore IDs they've already seen. RoasterID is the
+// roaster the event concerns, either the roaster itself or the one a user
+// belongs to, and is what webhook subscriptions are matched on.
+type Event struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregateId"`
+	RoasterID   uuid.UUID       `json:"roasterId,omitempty"`
+	Data        json.RawMessage `json:"data"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+/*EmailChange is the data of a user.email_changed event*/
+type EmailChange struct {
+	ID            uuid.UUID `json:"id"`
+	Email         string    `json:"email"`
+	PreviousEmail string    `json:"previousEmail"`
+}
+
+/*Deleted is the data of user.deleted and roaster.deleted events*/
+type Deleted struct {
+	ID uuid.UUID `json:"id"`
+}
+
+/*NewEvent returns an event of the given type about aggregateID, data is marshalled as its payload*/
+func NewEvent(kind string, aggregateID uuid.UUID, data interface{}) (*Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		ID:          uuid.NewUUID(),
+		Type:        kind,
+		AggregateID: aggregateID,
+		Data:        raw,
+	}, nil
+}
+
+func EventFromSQL(rows *sql.Rows) ([]*Event, error) {
+	events := make([]*Event, 0)
+
+	for rows.Next() {
+		e := &Event{}
+		var roasterID sql.NullString
+		var data string
+		rows.Scan(&e.ID, &e.Type, &e.AggregateID, &roasterID, &data, &e.CreatedAt)
+		e.RoasterID = uuid.Parse(roasterID.String)
+		e.Data = json.RawMessage(data)
+
+		events = append(events, e)
+	}
+
+	return events, nil
+}
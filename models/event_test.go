@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEvent(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	event, err := NewEvent(USER_EMAIL_CHANGED, id, &EmailChange{ID: id, Email: "new@expresso.store", PreviousEmail: "old@expresso.store"})
+
+	assert.NoError(err)
+	assert.NotNil(event.ID)
+	assert.Equal(USER_EMAIL_CHANGED, event.Type)
+	assert.Equal(id, event.AggregateID)
+	assert.Equal(`{"id":"`+id.String()+`","email":"new@expresso.store","previousEmail":"old@expresso.store"}`, string(event.Data))
+}
+
+func TestNewEventError(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewEvent(USER_UPDATED, uuid.NewUUID(), make(chan int))
+
+	assert.Error(err)
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+/*Formats users and roasters can be exported in*/
+const (
+	EXPORT_CSV   = "csv"
+	EXPORT_JSONL = "jsonl"
+)
+
+// Fields that can be exported, in the order they're written when no fields
+// are asked for. passHash is never exported, and photos is left out because
+// it's worked out from profileUrl.
+var (
+	UserExportFields    = []string{"id", "firstName", "lastName", "email", "phone", "phoneVerified", "addressLine1", "addressLine2", "addressCity", "addressState", "addressZip", "addressCountry", "roasterId", "profileUrl", "createdAt", "updatedAt"}
+	RoasterExportFields = []string{"id", "name", "slug", "email", "phone", "phoneVerified", "addressLine1", "addressLine2", "addressCity", "addressState", "addressZip", "addressCountry", "profileUrl", "birth", "latitude", "longitude", "status", "createdAt", "updatedAt"}
+)
+
+var ErrExportFormat = errors.New("Error: format must be csv or jsonl")
+
+/*ExportFields returns the comma separated fields asked for, or every allowed field if none were*/
+func ExportFields(requested string, allowed []string) ([]string, error) {
+	if strings.TrimSpace(requested) == "" {
+		return allowed, nil
+	}
+
+	fields := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(requested, ",") {
+		field = strings.TrimSpace(field)
+		if seen[field] {
+			continue
+		}
+		if !contains(allowed, field) {
+			return nil, errors.New("Error: fields must be some of " + strings.Join(allowed, ", "))
+		}
+
+		seen[field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// ExportWriter writes records one at a time as CSV rows or JSONL lines holding
+// just the chosen fields, so an export never has to be held in memory. CSV
+// cells hold strings as they are and everything else as JSON, with null left
+// blank.
+type ExportWriter struct {
+	format  string
+	fields  []string
+	csv     *csv.Writer
+	jsonl   io.Writer
+	started bool
+}
+
+/*NewExportWriter returns a writer of the given format, nothing is written until the first record or Close*/
+func NewExportWriter(w io.Writer, format string, fields []string) (*ExportWriter, error) {
+	e := &ExportWriter{format: format, fields: fields}
+	switch format {
+	case EXPORT_CSV:
+		e.csv = csv.NewWriter(w)
+	case EXPORT_JSONL:
+		e.jsonl = w
+	default:
+		return nil, ErrExportFormat
+	}
+
+	return e, nil
+}
+
+/*ContentType is the MIME type of the writer's format*/
+func (e *ExportWriter) ContentType() string {
+	if e.format == EXPORT_CSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+/*Write writes record's chosen fields, the CSV header is written before the first record*/
+func (e *ExportWriter) Write(record interface{}) error {
+	err := e.start()
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]json.RawMessage)
+	err = json.Unmarshal(raw, &values)
+	if err != nil {
+		return err
+	}
+
+	if e.csv != nil {
+		return e.writeCSV(values)
+	}
+	return e.writeJSONL(values)
+}
+
+/*Flush sends anything buffered on to the underlying writer*/
+func (e *ExportWriter) Flush() error {
+	if e.csv == nil {
+		return nil
+	}
+
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+/*Close writes the CSV header if there were no records and flushes*/
+func (e *ExportWriter) Close() error {
+	err := e.start()
+	if err != nil {
+		return err
+	}
+
+	return e.Flush()
+}
+
+func (e *ExportWriter) start() error {
+	if e.started {
+		return nil
+	}
+
+	e.started = true
+	if e.csv != nil {
+		return e.csv.Write(e.fields)
+	}
+	return nil
+}
+
+func (e *ExportWriter) writeCSV(values map[string]json.RawMessage) error {
+	record := make([]string, len(e.fields))
+	for i, field := range e.fields {
+		raw := values[field]
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+
+		if raw[0] == '"' {
+			err := json.Unmarshal(raw, &record[i])
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		record[i] = string(raw)
+	}
+
+	return e.csv.Write(record)
+}
+
+func (e *ExportWriter) writeJSONL(values map[string]json.RawMessage) error {
+	var line bytes.Buffer
+	line.WriteByte('{')
+	for i, field := range e.fields {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+
+		name, _ := json.Marshal(field)
+		line.Write(name)
+		line.WriteByte(':')
+
+		raw := values[field]
+		if len(raw) == 0 {
+			raw = json.RawMessage("null")
+		}
+		line.Write(raw)
+	}
+	line.WriteString("}\n")
+
+	_, err := e.jsonl.Write(line.Bytes())
+	return err
+}
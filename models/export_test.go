@@ -0,0 +1,91 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportFields(t *testing.T) {
+	assert := assert.New(t)
+
+	fields, err := ExportFields("", UserExportFields)
+	assert.NoError(err)
+	assert.Equal(UserExportFields, fields)
+
+	fields, err = ExportFields("email, id,email", UserExportFields)
+	assert.NoError(err)
+	assert.Equal([]string{"email", "id"}, fields)
+
+	_, err = ExportFields("id,passHash", UserExportFields)
+	assert.Error(err)
+
+	_, err = ExportFields("status", UserExportFields)
+	assert.Error(err)
+}
+
+func TestExportWriterCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	var out bytes.Buffer
+	w, err := NewExportWriter(&out, EXPORT_CSV, []string{"name", "phoneVerified", "latitude", "addressCity"})
+	assert.NoError(err)
+
+	lat := 42.03
+	roaster := NewRoaster("Kaldi's, Ames", "hello@kaldis.com", "", "", "", "Ames", "IA", "", "US", "")
+	assert.NoError(w.Write(roaster))
+	roaster.Latitude = &lat
+	roaster.PhoneVerified = true
+	roaster.AddressCity = ""
+	assert.NoError(w.Write(roaster))
+	assert.NoError(w.Close())
+
+	assert.Equal("name,phoneVerified,latitude,addressCity\n\"Kaldi's, Ames\",false,,Ames\n\"Kaldi's, Ames\",true,42.03,\n", out.String())
+	assert.Equal("text/csv; charset=utf-8", w.ContentType())
+}
+
+func TestExportWriterCSVEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	var out bytes.Buffer
+	w, _ := NewExportWriter(&out, EXPORT_CSV, []string{"id", "email"})
+	assert.NoError(w.Close())
+
+	assert.Equal("id,email\n", out.String())
+}
+
+func TestExportWriterJSONL(t *testing.T) {
+	assert := assert.New(t)
+
+	var out bytes.Buffer
+	w, err := NewExportWriter(&out, EXPORT_JSONL, []string{"id", "email", "roasterId", "createdAt"})
+	assert.NoError(err)
+
+	user := NewUser("hash", "Jake", "Long", "jake@expresso.store", "", "", "", "", "", "", "")
+	user.CreatedAt = time.Date(2017, 1, 13, 18, 22, 5, 0, time.UTC)
+	assert.NoError(w.Write(user))
+	assert.NoError(w.Close())
+
+	assert.Equal(`{"id":"`+user.ID.String()+`","email":"jake@expresso.store","roasterId":"","createdAt":"2017-01-13T18:22:05Z"}`+"\n", out.String())
+	assert.Equal("application/x-ndjson", w.ContentType())
+}
+
+func TestExportWriterJSONLEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	var out bytes.Buffer
+	w, _ := NewExportWriter(&out, EXPORT_JSONL, []string{"id"})
+	assert.NoError(w.Close())
+
+	assert.Equal("", out.String())
+}
+
+func TestExportWriterFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewExportWriter(&bytes.Buffer{}, "xml", []string{"id"})
+
+	assert.Equal(ErrExportFormat, err)
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+/*ListFilter narrows list queries by when records were created or last updated and by status, zero values are ignored*/
+type ListFilter struct {
+	CreatedAfter  time.Time `json:"createdAfter"`
+	CreatedBefore time.Time `json:"createdBefore"`
+	UpdatedSince  time.Time `json:"updatedSince"`
+	Status        string    `json:"status"`
+}
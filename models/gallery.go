@@ -0,0 +1,43 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+/*MaxGallerySize is the most images a roaster's gallery can hold*/
+const MaxGallerySize = 30
+
+/*GalleryImage is one captioned photo in a roaster's storefront gallery, shown in ascending Position*/
+type GalleryImage struct {
+	ID        uuid.UUID `json:"id"`
+	RoasterID uuid.UUID `json:"roasterId"`
+	Url       string    `json:"url"`
+	Caption   string    `json:"caption" validate:"max=200"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func NewGalleryImage(roasterID uuid.UUID, caption string, position int) *GalleryImage {
+	return &GalleryImage{
+		ID:        uuid.NewUUID(),
+		RoasterID: roasterID,
+		Caption:   caption,
+		Position:  position,
+	}
+}
+
+func GalleryImageFromSQL(rows *sql.Rows) ([]*GalleryImage, error) {
+	images := make([]*GalleryImage, 0)
+
+	for rows.Next() {
+		i := &GalleryImage{}
+		rows.Scan(&i.ID, &i.RoasterID, &i.Url, &i.Caption, &i.Position, &i.CreatedAt, &i.UpdatedAt)
+		images = append(images, i)
+	}
+
+	return images, nil
+}
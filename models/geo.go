@@ -0,0 +1,142 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/*EarthRadius is the mean radius of the earth in kilometers*/
+const EarthRadius = 6371.0
+
+/*CentroidsFile is the environment variable naming the GeoNames postal code file to geocode with*/
+const CentroidsFile = "POSTAL_CENTROIDS"
+
+/*NearbyRoaster is a roaster along with its distance from the searched point*/
+type NearbyRoaster struct {
+	*Roaster
+	Distance float64 `json:"distanceKm"`
+}
+
+/*ByDistance sorts nearby roasters closest first*/
+type ByDistance []*NearbyRoaster
+
+func (b ByDistance) Len() int           { return len(b) }
+func (b ByDistance) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b ByDistance) Less(i, j int) bool { return b[i].Distance < b[j].Distance }
+
+// Geocode looks up the coordinates of a postal code in the centroid
+// dataset. Only whole postal codes, or the district before a space or hyphen
+// like a ZIP+4's ZIP, are matched. Anything coarser would put roasters at
+// the wrong place, so they're left ungeocoded instead.
+func Geocode(zip, country string) (float64, float64, bool) {
+	if country == "" {
+		country = DefaultCountry
+	}
+
+	codes, ok := centroids[strings.ToUpper(country)]
+	if !ok {
+		return 0, 0, false
+	}
+
+	zip = strings.ToUpper(strings.TrimSpace(zip))
+	if c, ok := codes[postalKey(zip)]; ok {
+		return c[0], c[1], true
+	}
+
+	if i := strings.IndexAny(zip, " -"); i > 0 {
+		if c, ok := codes[zip[:i]]; ok {
+			return c[0], c[1], true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// LoadCentroids adds the postal codes in r to the centroid dataset,
+// returning how many were read. r is in the tab separated GeoNames postal
+// code format, with the country and postal code in the first two columns
+// and the latitude and longitude in the tenth and eleventh.
+func LoadCentroids(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	count := 0
+	for line := 1; scanner.Scan(); line++ {
+		columns := strings.Split(scanner.Text(), "\t")
+		if len(columns) < 11 {
+			return count, fmt.Errorf("line %d has %d columns, expected at least 11", line, len(columns))
+		}
+
+		lat, err := strconv.ParseFloat(columns[9], 64)
+		if err != nil {
+			return count, fmt.Errorf("line %d has an invalid latitude %s", line, columns[9])
+		}
+		lng, err := strconv.ParseFloat(columns[10], 64)
+		if err != nil {
+			return count, fmt.Errorf("line %d has an invalid longitude %s", line, columns[10])
+		}
+
+		country := strings.ToUpper(columns[0])
+		if centroids[country] == nil {
+			centroids[country] = make(map[string][2]float64)
+		}
+		centroids[country][postalKey(columns[1])] = [2]float64{lat, lng}
+		count++
+	}
+
+	return count, scanner.Err()
+}
+
+/*postalKey is how a postal code is keyed in the centroid dataset, upper cased without spaces or hyphens*/
+func postalKey(code string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, code))
+}
+
+/*Distance returns the great-circle distance in kilometers between two points*/
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := radians(lat2 - lat1)
+	dLng := radians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(radians(lat1))*math.Cos(radians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+/*BoundingBox returns the latitude and longitude ranges containing every point within radius kilometers*/
+func BoundingBox(lat, lng, radius float64) (float64, float64, float64, float64) {
+	dLat := degrees(radius / EarthRadius)
+	minLat, maxLat := lat-dLat, lat+dLat
+
+	// near the poles or across the antimeridian every longitude is in range
+	if minLat <= -90 || maxLat >= 90 {
+		return math.Max(minLat, -90), math.Min(maxLat, 90), -180, 180
+	}
+
+	ratio := math.Sin(radius/EarthRadius) / math.Cos(radians(lat))
+	if ratio >= 1 {
+		return minLat, maxLat, -180, 180
+	}
+
+	dLng := degrees(math.Asin(ratio))
+	if lng-dLng < -180 || lng+dLng > 180 {
+		return minLat, maxLat, -180, 180
+	}
+
+	return minLat, maxLat, lng - dLng, lng + dLng
+}
+
+func radians(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+func degrees(r float64) float64 {
+	return r * 180 / math.Pi
+}
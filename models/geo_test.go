@@ -0,0 +1,111 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeocode(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		zip     string
+		country string
+		lat     float64
+		lng     float64
+	}{
+		{"50010", "US", 42.03, -93.62},
+		{"50309-1234", "", 41.59, -93.62},
+		{"m5v 2t6", "ca", 43.64, -79.39},
+		{"M5V", "CA", 43.64, -79.39},
+		{"EH1 1YZ", "GB", 55.95, -3.19},
+		{"SW1A 1AA", "GB", 51.50, -0.14},
+	}
+
+	for _, c := range cases {
+		lat, lng, ok := Geocode(c.zip, c.country)
+		assert.True(ok, c.zip)
+		assert.Equal(c.lat, lat, c.zip)
+		assert.Equal(c.lng, lng, c.zip)
+	}
+}
+
+func TestGeocodeUnknownCountry(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, ok := Geocode("12345", "AQ")
+	assert.False(ok)
+}
+
+func TestGeocodeNotWhole(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, c := range [][2]string{{"59801", "US"}, {"5001", "US"}, {"", "US"}, {"M5W 1E6", "CA"}, {"75001", "FR"}} {
+		_, _, ok := Geocode(c[0], c[1])
+		assert.False(ok, c[0])
+	}
+}
+
+func TestLoadCentroids(t *testing.T) {
+	assert := assert.New(t)
+
+	data := "FR\t75001\tParis 01 Louvre\tIle-de-France\t11\tParis\t75\tParis\t751\t48.8592\t2.3417\t5\n" +
+		"NZ\t6011\tWellington\t\t\t\t\t\t\t-41.2787\t174.7760\t4\n"
+	count, err := LoadCentroids(strings.NewReader(data))
+	defer delete(centroids, "FR")
+	defer delete(centroids, "NZ")
+
+	assert.NoError(err)
+	assert.Equal(2, count)
+	lat, lng, ok := Geocode("75001", "fr")
+	assert.True(ok)
+	assert.Equal(48.8592, lat)
+	assert.Equal(2.3417, lng)
+
+	_, err = LoadCentroids(strings.NewReader("US\t50010\tAmes\n"))
+	assert.Error(err)
+}
+
+func TestRoasterGeocode(t *testing.T) {
+	assert := assert.New(t)
+
+	roaster := NewRoaster("", "", "", "", "", "", "", "50010", "US", "")
+	roaster.Geocode()
+	assert.Equal(42.03, *roaster.Latitude)
+	assert.Equal(-93.62, *roaster.Longitude)
+
+	roaster.AddressCountry = "AQ"
+	roaster.Geocode()
+	assert.Nil(roaster.Latitude)
+	assert.Nil(roaster.Longitude)
+}
+
+func TestDistance(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(0.0, Distance(42.03, -93.62, 42.03, -93.62))
+	assert.InDelta(5570, Distance(40.71, -74.01, 51.51, -0.13), 10)
+	assert.InDelta(20015, Distance(0, 0, 0, 180), 1)
+}
+
+func TestBoundingBox(t *testing.T) {
+	assert := assert.New(t)
+
+	minLat, maxLat, minLng, maxLng := BoundingBox(42.03, -93.62, 100)
+	assert.InDelta(41.13, minLat, 0.01)
+	assert.InDelta(42.93, maxLat, 0.01)
+	assert.InDelta(-94.83, minLng, 0.01)
+	assert.InDelta(-92.41, maxLng, 0.01)
+
+	minLat, maxLat, minLng, maxLng = BoundingBox(89.5, 0, 100)
+	assert.Equal(90.0, maxLat)
+	assert.Equal(-180.0, minLng)
+	assert.Equal(180.0, maxLng)
+
+	_, _, minLng, maxLng = BoundingBox(0, 179.9, 100)
+	assert.Equal(-180.0, minLng)
+	assert.Equal(180.0, maxLng)
+	assert.True(minLat < maxLat)
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+// IdempotencyRecord is the first response to a request sent with an
+// Idempotency-Key, replayed to retries of the same request. Claim identifies
+// the request that's handling the key, and ResponseCode stays 0 until it has
+// finished. TokenUserID is the user the response logged in, who is sent a
+// new token when it's replayed.
+type IdempotencyRecord struct {
+	Key          string
+	Scope        string
+	RequestHash  string
+	Claim        string
+	ResponseCode int
+	ResponseBody string
+	Headers      map[string]string
+	TokenUserID  string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+func NewIdempotencyRecord(key, scope, requestHash string) *IdempotencyRecord {
+	return &IdempotencyRecord{
+		Key:         key,
+		Scope:       scope,
+		RequestHash: requestHash,
+		Claim:       uuid.New(),
+		Headers:     make(map[string]string),
+	}
+}
+
+/*Finished reports whether the request holding the key has stored its response*/
+func (r *IdempotencyRecord) Finished() bool {
+	return r.ResponseCode != 0
+}
+
+func IdempotencyRecordFromSQL(rows *sql.Rows) ([]*IdempotencyRecord, error) {
+	records := make([]*IdempotencyRecord, 0)
+
+	for rows.Next() {
+		r := &IdempotencyRecord{}
+		var headers string
+		rows.Scan(&r.Key, &r.Scope, &r.RequestHash, &r.Claim, &r.ResponseCode, &r.ResponseBody, &headers, &r.TokenUserID, &r.CreatedAt, &r.ExpiresAt)
+		r.Headers = make(map[string]string)
+		json.Unmarshal([]byte(headers), &r.Headers)
+
+		records = append(records, r)
+	}
+
+	return records, nil
+}
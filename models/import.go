@@ -0,0 +1,231 @@
+package models
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+/*Kinds of import, the rows of each are upserted by email*/
+const (
+	IMPORT_USER    = "user"
+	IMPORT_ROASTER = "roaster"
+)
+
+/*Formats an import can be uploaded in*/
+const (
+	IMPORT_CSV   = "csv"
+	IMPORT_JSONL = "jsonl"
+)
+
+/*Import statuses, pending and running imports are picked back up when TownCenter restarts*/
+const (
+	IMPORT_PENDING  = "pending"
+	IMPORT_RUNNING  = "running"
+	IMPORT_COMPLETE = "complete"
+	IMPORT_FAILED   = "failed"
+)
+
+/*Limits on imports, larger ones should be split up*/
+const (
+	MaxImportBytes  = 5 << 20
+	MaxImportRows   = 10000
+	MaxImportErrors = 1000
+)
+
+var (
+	ErrImportKind   = errors.New("Error: kind must be user or roaster")
+	ErrImportFormat = errors.New("Error: format must be csv or jsonl")
+	ErrImportSize   = fmt.Errorf("Error: imports must be at most %d MB and %d rows", MaxImportBytes>>20, MaxImportRows)
+	ErrImportEmpty  = errors.New("Error: import has no rows")
+)
+
+// Import is a bulk upsert of users or roasters. Processed is how many rows
+// have been handled so far, which is where a resumed import carries on from.
+// Dry runs check every row and count what would be created or updated
+// without changing anything.
+type Import struct {
+	ID        uuid.UUID      `json:"id"`
+	Kind      string         `json:"kind"`
+	Format    string         `json:"format"`
+	DryRun    bool           `json:"dryRun"`
+	Status    string         `json:"status"`
+	Total     int            `json:"total"`
+	Processed int            `json:"processed"`
+	Created   int            `json:"created"`
+	Updated   int            `json:"updated"`
+	Failed    int            `json:"failed"`
+	Errors    []*ImportError `json:"errors"`
+	Message   string         `json:"message,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+/*ImportError is why a row of an import was skipped, Row is the row's Line*/
+type ImportError struct {
+	Row     int              `json:"row"`
+	Email   string           `json:"email,omitempty"`
+	Message string           `json:"message"`
+	Fields  ValidationErrors `json:"fields,omitempty"`
+}
+
+func (e *ImportError) Error() string {
+	return e.Message
+}
+
+/*ImportRow is a single row of an import as a JSON object, Line is its row in the file counting any CSV header*/
+type ImportRow struct {
+	Line int
+	Data json.RawMessage
+}
+
+/*ImportRoaster is a row of a roaster import, OwnerEmail is the user new roasters are given to*/
+type ImportRoaster struct {
+	Roaster
+	OwnerEmail string `json:"ownerEmail"`
+}
+
+/*NewImport checks that body can be imported and counts its rows*/
+func NewImport(kind string, format string, dryRun bool, body []byte) (*Import, error) {
+	if kind != IMPORT_USER && kind != IMPORT_ROASTER {
+		return nil, ErrImportKind
+	}
+	if len(body) > MaxImportBytes {
+		return nil, ErrImportSize
+	}
+
+	rows, err := ParseImport(format, body)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+	if len(rows) > MaxImportRows {
+		return nil, ErrImportSize
+	}
+
+	return &Import{
+		ID:     uuid.NewUUID(),
+		Kind:   kind,
+		Format: format,
+		DryRun: dryRun,
+		Status: IMPORT_PENDING,
+		Total:  len(rows),
+		Errors: make([]*ImportError, 0),
+	}, nil
+}
+
+// ParseImport splits body into rows. CSV files need a header row naming each
+// column after its JSON field, and blank cells are left out so they don't
+// overwrite anything when a row updates an existing user or roaster. JSONL
+// rows aren't checked here, a line that isn't an object fails on its own.
+func ParseImport(format string, body []byte) ([]*ImportRow, error) {
+	switch format {
+	case IMPORT_CSV:
+		return parseCSV(body)
+	case IMPORT_JSONL:
+		return parseJSONL(body), nil
+	}
+
+	return nil, ErrImportFormat
+}
+
+/*Finished reports whether the import has stopped, it can only be resumed if it failed*/
+func (i *Import) Finished() bool {
+	return i.Status == IMPORT_COMPLETE || i.Status == IMPORT_FAILED
+}
+
+/*Fail records that row couldn't be imported, only the first MaxImportErrors are kept*/
+func (i *Import) Fail(err *ImportError) {
+	i.Failed++
+	if len(i.Errors) < MaxImportErrors {
+		i.Errors = append(i.Errors, err)
+	}
+}
+
+func ImportFromSQL(rows *sql.Rows) ([]*Import, error) {
+	imports := make([]*Import, 0)
+
+	for rows.Next() {
+		i := &Import{}
+		var errs string
+		rows.Scan(&i.ID, &i.Kind, &i.Format, &i.DryRun, &i.Status, &i.Total, &i.Processed, &i.Created, &i.Updated, &i.Failed, &errs, &i.Message, &i.CreatedAt, &i.UpdatedAt)
+
+		i.Errors = make([]*ImportError, 0)
+		if errs != "" {
+			err := json.Unmarshal([]byte(errs), &i.Errors)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		imports = append(imports, i)
+	}
+
+	return imports, nil
+}
+
+func parseCSV(body []byte) ([]*ImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))))
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrImportEmpty
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error: invalid csv, %s", err.Error())
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	rows := make([]*ImportRow, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error: invalid csv, %s", err.Error())
+		}
+
+		fields := make(map[string]string)
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if value != "" && header[i] != "" {
+				fields[header[i]] = value
+			}
+		}
+
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, &ImportRow{Line: line, Data: data})
+	}
+
+	return rows, nil
+}
+
+func parseJSONL(body []byte) []*ImportRow {
+	rows := make([]*ImportRow, 0)
+	for i, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		rows = append(rows, &ImportRow{Line: i + 1, Data: json.RawMessage(line)})
+	}
+
+	return rows
+}
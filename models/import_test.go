@@ -0,0 +1,98 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewImport(t *testing.T) {
+	assert := assert.New(t)
+
+	i, err := NewImport(IMPORT_USER, IMPORT_CSV, true, []byte("email\na@expresso.store\nb@expresso.store\n"))
+
+	assert.NoError(err)
+	assert.Equal(IMPORT_USER, i.Kind)
+	assert.Equal(IMPORT_CSV, i.Format)
+	assert.True(i.DryRun)
+	assert.Equal(IMPORT_PENDING, i.Status)
+	assert.Equal(2, i.Total)
+	assert.Equal(0, len(i.Errors))
+}
+
+func TestNewImportErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewImport("coffee", IMPORT_CSV, false, []byte("email\na@expresso.store\n"))
+	assert.Equal(ErrImportKind, err)
+
+	_, err = NewImport(IMPORT_USER, "xml", false, []byte("email\na@expresso.store\n"))
+	assert.Equal(ErrImportFormat, err)
+
+	_, err = NewImport(IMPORT_USER, IMPORT_CSV, false, []byte("email\n"))
+	assert.Equal(ErrImportEmpty, err)
+
+	_, err = NewImport(IMPORT_USER, IMPORT_JSONL, false, []byte("\n\n"))
+	assert.Equal(ErrImportEmpty, err)
+
+	_, err = NewImport(IMPORT_USER, IMPORT_CSV, false, []byte("email\n"+strings.Repeat("a\n", MaxImportRows+1)))
+	assert.Equal(ErrImportSize, err)
+}
+
+func TestParseImportCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	rows, err := ParseImport(IMPORT_CSV, []byte("\xef\xbb\xbfemail, firstName ,lastName\na@expresso.store,Ann,\nb@expresso.store, ,Bee\n"))
+
+	assert.NoError(err)
+	assert.Equal(2, len(rows))
+	assert.Equal(2, rows[0].Line)
+	assert.Equal(`{"email":"a@expresso.store","firstName":"Ann"}`, string(rows[0].Data))
+	assert.Equal(3, rows[1].Line)
+	assert.Equal(`{"email":"b@expresso.store","lastName":"Bee"}`, string(rows[1].Data))
+}
+
+func TestParseImportCSVInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := ParseImport(IMPORT_CSV, []byte("email,firstName\na@expresso.store\n"))
+
+	assert.Error(err)
+	assert.True(strings.HasPrefix(err.Error(), "Error: invalid csv"))
+}
+
+func TestParseImportJSONL(t *testing.T) {
+	assert := assert.New(t)
+
+	rows, err := ParseImport(IMPORT_JSONL, []byte("{\"email\":\"a@expresso.store\"}\n\n  {\"email\":\"b@expresso.store\"}  \n"))
+
+	assert.NoError(err)
+	assert.Equal(2, len(rows))
+	assert.Equal(1, rows[0].Line)
+	assert.Equal(3, rows[1].Line)
+	assert.Equal(`{"email":"b@expresso.store"}`, string(rows[1].Data))
+}
+
+func TestImportFail(t *testing.T) {
+	assert := assert.New(t)
+
+	i := &Import{Errors: make([]*ImportError, 0)}
+	for n := 0; n < MaxImportErrors+5; n++ {
+		i.Fail(&ImportError{Row: n, Message: "bad row"})
+	}
+
+	assert.Equal(MaxImportErrors+5, i.Failed)
+	assert.Equal(MaxImportErrors, len(i.Errors))
+	assert.Equal("bad row", i.Errors[0].Error())
+}
+
+func TestImportFinished(t *testing.T) {
+	assert := assert.New(t)
+
+	i := &Import{Status: IMPORT_RUNNING}
+	assert.False(i.Finished())
+
+	i.Status = IMPORT_FAILED
+	assert.True(i.Finished())
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"strings"
+)
+
+/*DefaultCountry is assumed for phone numbers when no address country is known*/
+const DefaultCountry = "US"
+
+/*NormalizePhone parses a free-form phone number into E.164, using country when the number has no international prefix*/
+func NormalizePhone(phone, country string) (string, bool) {
+	trimmed := strings.TrimSpace(phone)
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, trimmed)
+
+	var e164 string
+	switch {
+	case strings.HasPrefix(trimmed, "+"):
+		e164 = "+" + digits
+	case strings.HasPrefix(digits, "00"):
+		e164 = "+" + digits[2:]
+	default:
+		if country == "" {
+			country = DefaultCountry
+		}
+
+		code, ok := callingCodes[strings.ToUpper(country)]
+		if !ok {
+			return phone, false
+		}
+
+		if code == "1" && len(digits) == 11 && digits[0] == '1' {
+			digits = digits[1:]
+		} else if code != "39" && strings.HasPrefix(digits, "0") {
+			// drop the national trunk prefix, Italy keeps its leading zero
+			digits = digits[1:]
+		}
+		e164 = "+" + code + digits
+	}
+
+	if !phoneFormat.MatchString(e164) {
+		return phone, false
+	}
+
+	return e164, true
+}
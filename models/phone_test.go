@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		phone   string
+		country string
+		e164    string
+	}{
+		{"(515) 555-0123", "US", "+15155550123"},
+		{"1-515-555-0123", "us", "+15155550123"},
+		{"515.555.0123", "", "+15155550123"},
+		{"020 7946 0018", "GB", "+442079460018"},
+		{"06 12 34 56 78", "FR", "+33612345678"},
+		{"06 1234 5678", "IT", "+390612345678"},
+		{"+49 30 901820", "US", "+4930901820"},
+		{"0049 30 901820", "US", "+4930901820"},
+	}
+
+	for _, c := range cases {
+		e164, ok := NormalizePhone(c.phone, c.country)
+		assert.True(ok, c.phone)
+		assert.Equal(c.e164, e164)
+	}
+}
+
+func TestNormalizePhoneInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	phone, ok := NormalizePhone("call me", "US")
+	assert.False(ok)
+	assert.Equal("call me", phone)
+
+	phone, ok = NormalizePhone("5155550123", "Nowhere")
+	assert.False(ok)
+	assert.Equal("5155550123", phone)
+}
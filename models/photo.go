@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pborman/uuid"
+)
+
+/*Photo sizes, every profile photo is stored at its original size and as a square thumbnail of each other size*/
+const (
+	PHOTO_ORIGINAL = "original"
+	PHOTO_SMALL    = "small"
+	PHOTO_MEDIUM   = "medium"
+	PHOTO_LARGE    = "large"
+)
+
+/*Kinds of avatar, used in the path TownCenter serves them from*/
+const (
+	AVATAR_USER    = "user"
+	AVATAR_ROASTER = "roaster"
+)
+
+/*UploadRequest asks for somewhere to upload a photo of ContentType to*/
+type UploadRequest struct {
+	ContentType string `json:"contentType"`
+}
+
+// PresignedUpload is where the client sends their photo. They PUT it to URL
+// with Headers before ExpiresAt, then complete the upload with Key.
+type PresignedUpload struct {
+	Key       string            `json:"key"`
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+/*UploadComplete tells TownCenter the photo at Key has been uploaded*/
+type UploadComplete struct {
+	Key string `json:"key"`
+}
+
+/*PhotoSizes maps each thumbnail size to the length of its sides in pixels*/
+var PhotoSizes = map[string]int{
+	PHOTO_SMALL:  64,
+	PHOTO_MEDIUM: 256,
+	PHOTO_LARGE:  512,
+}
+
+// PhotoURLs returns the URL of every size of the photo at url. Thumbnails are
+// stored next to the original with the size in place of "original", photos
+// uploaded without thumbnails use the original for every size.
+func PhotoURLs(url string) map[string]string {
+	if url == "" {
+		return nil
+	}
+
+	urls := map[string]string{PHOTO_ORIGINAL: url}
+
+	marker := "-" + PHOTO_ORIGINAL + "."
+	i := strings.LastIndex(url, marker)
+	for size := range PhotoSizes {
+		if i < 0 {
+			urls[size] = url
+			continue
+		}
+		urls[size] = url[:i] + "-" + size + url[i+len(marker)-1:]
+	}
+
+	return urls
+}
+
+// AvatarURLs returns the URL of every size of the generated avatar for the
+// user or roaster with id. The URLs are relative to TownCenter, the original
+// is an SVG and the other sizes are PNGs.
+func AvatarURLs(kind string, id uuid.UUID) map[string]string {
+	path := fmt.Sprintf("/api/public/%s/%s/avatar", kind, id.String())
+
+	urls := map[string]string{PHOTO_ORIGINAL: path}
+	for size, px := range PhotoSizes {
+		urls[size] = fmt.Sprintf("%s?format=png&size=%d", path, px)
+	}
+
+	return urls
+}
+
+/*Initials returns the upper cased first letter of the first two words given that aren't empty*/
+func Initials(words ...string) string {
+	initials := ""
+	count := 0
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" || count == 2 {
+			continue
+		}
+
+		r, _ := utf8.DecodeRuneInString(word)
+		initials += string(unicode.ToUpper(r))
+		count++
+	}
+
+	return initials
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhotoURLs(t *testing.T) {
+	assert := assert.New(t)
+
+	urls := PhotoURLs("https://s3.com/profile/id-1490000000-original.jpg")
+
+	assert.Equal(4, len(urls))
+	assert.Equal("https://s3.com/profile/id-1490000000-original.jpg", urls[PHOTO_ORIGINAL])
+	assert.Equal("https://s3.com/profile/id-1490000000-small.jpg", urls[PHOTO_SMALL])
+	assert.Equal("https://s3.com/profile/id-1490000000-medium.jpg", urls[PHOTO_MEDIUM])
+	assert.Equal("https://s3.com/profile/id-1490000000-large.jpg", urls[PHOTO_LARGE])
+}
+
+func TestPhotoURLsWithoutThumbnails(t *testing.T) {
+	assert := assert.New(t)
+
+	urls := PhotoURLs("https://s3.com/profile/id-me.webp")
+
+	assert.Equal(4, len(urls))
+	assert.Equal("https://s3.com/profile/id-me.webp", urls[PHOTO_SMALL])
+	assert.Nil(PhotoURLs(""))
+}
+
+func TestAvatarURLs(t *testing.T) {
+	assert := assert.New(t)
+
+	id := uuid.NewUUID()
+	urls := AvatarURLs(AVATAR_ROASTER, id)
+
+	assert.Equal(4, len(urls))
+	assert.Equal("/api/public/roaster/"+id.String()+"/avatar", urls[PHOTO_ORIGINAL])
+	assert.Equal("/api/public/roaster/"+id.String()+"/avatar?format=png&size=64", urls[PHOTO_SMALL])
+}
+
+func TestInitials(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("JL", Initials("jake", "long"))
+	assert.Equal("JL", Initials("", "jake", " ", "long", "roasters"))
+	assert.Equal("É", Initials("émile"))
+	assert.Equal("", Initials())
+}
+
+func TestUserSetPhotos(t *testing.T) {
+	assert := assert.New(t)
+
+	u := &User{ID: uuid.NewUUID()}
+	u.SetPhotos()
+	assert.Equal(AvatarURLs(AVATAR_USER, u.ID), u.Photos)
+
+	u.ProfileURL = "https://s3.com/profile/id-1-original.jpg"
+	u.SetPhotos()
+	assert.Equal(PhotoURLs(u.ProfileURL), u.Photos)
+}
+
+func TestUserInitials(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("JL", (&User{FirstName: "Jake", LastName: "Long"}).Initials())
+	assert.Equal("J", (&User{Email: "jake@expresso.store"}).Initials())
+}
+
+func TestRoasterInitials(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("BR", (&Roaster{Name: "blue  ridge coffee"}).Initials())
+}
@@ -0,0 +1,243 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pborman/uuid"
+)
+
+/*Limits on the list fields of a RoasterProfile*/
+const (
+	MaxTags      = 20
+	MaxTagLength = 30
+	MaxHours     = 21
+)
+
+/*Days are the accepted values of OpeningHours.Day, indexed by time.Weekday*/
+var Days = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+/*SocialNetworks are the accepted keys of RoasterProfile.Social*/
+var SocialNetworks = []string{"instagram", "facebook", "twitter", "youtube", "tiktok", "pinterest", "linkedin"}
+
+var clockFormat = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$|^24:00$`)
+
+/*RoasterProfile is the storefront a roaster shows alongside its contact details*/
+type RoasterProfile struct {
+	RoasterID   uuid.UUID         `json:"roasterId"`
+	Description string            `json:"description" validate:"max=2000"`
+	Founded     Date              `json:"founded"`
+	Website     string            `json:"website" validate:"max=200,url"`
+	Social      map[string]string `json:"social"`
+	Hours       []*OpeningHours   `json:"hours"`
+	Timezone    string            `json:"timezone" validate:"max=64,timezone"`
+	Tags        []string          `json:"tags"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+/*OpeningHours is one span of a day the roaster is open, in 24 hour HH:MM local to the profile's timezone*/
+type OpeningHours struct {
+	Day   string `json:"day"`
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+func NewRoasterProfile(roasterID uuid.UUID) *RoasterProfile {
+	return &RoasterProfile{
+		RoasterID: roasterID,
+		Social:    make(map[string]string),
+		Hours:     make([]*OpeningHours, 0),
+		Tags:      make([]string, 0),
+	}
+}
+
+func RoasterProfileFromSQL(rows *sql.Rows) ([]*RoasterProfile, error) {
+	profiles := make([]*RoasterProfile, 0)
+
+	for rows.Next() {
+		p := &RoasterProfile{}
+		var social, hours, tags []byte
+
+		rows.Scan(&p.RoasterID, &p.Description, &p.Founded, &p.Website, &social, &hours, &p.Timezone, &tags, &p.UpdatedAt)
+
+		err := unmarshalColumn(social, &p.Social)
+		if err == nil {
+			err = unmarshalColumn(hours, &p.Hours)
+		}
+		if err == nil {
+			err = unmarshalColumn(tags, &p.Tags)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if p.Social == nil {
+			p.Social = make(map[string]string)
+		}
+		if p.Hours == nil {
+			p.Hours = make([]*OpeningHours, 0)
+		}
+		if p.Tags == nil {
+			p.Tags = make([]string, 0)
+		}
+
+		profiles = append(profiles, p)
+	}
+
+	return profiles, nil
+}
+
+/*unmarshalColumn decodes a json TEXT column, leaving v untouched when the column is empty*/
+func unmarshalColumn(b []byte, v interface{}) error {
+	if len(b) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+/*Normalize trims the profile's fields and lower cases its tags, networks and days, dropping empty and duplicate entries*/
+func (p *RoasterProfile) Normalize() {
+	p.Description = strings.TrimSpace(p.Description)
+	p.Website = strings.TrimSpace(p.Website)
+	p.Timezone = strings.TrimSpace(p.Timezone)
+
+	social := make(map[string]string)
+	for network, link := range p.Social {
+		link = strings.TrimSpace(link)
+		if link != "" {
+			social[strings.ToLower(strings.TrimSpace(network))] = link
+		}
+	}
+	p.Social = social
+
+	hours := make([]*OpeningHours, 0, len(p.Hours))
+	for _, h := range p.Hours {
+		if h == nil {
+			continue
+		}
+		h.Day = strings.ToLower(strings.TrimSpace(h.Day))
+		h.Open = strings.TrimSpace(h.Open)
+		h.Close = strings.TrimSpace(h.Close)
+		hours = append(hours, h)
+	}
+	p.Hours = hours
+
+	seen := make(map[string]bool)
+	tags := make([]string, 0, len(p.Tags))
+	for _, tag := range p.Tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	p.Tags = tags
+}
+
+/*Validate checks the profile's tagged fields along with its social links, hours and tags, returning nil when it's valid*/
+func (p *RoasterProfile) Validate() ValidationErrors {
+	errs := Validate(p)
+	if errs == nil {
+		errs = make(ValidationErrors, 0)
+	}
+
+	networks := make([]string, 0, len(p.Social))
+	for network := range p.Social {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+
+	for _, network := range networks {
+		link := p.Social[network]
+		name := "social." + network
+		if !contains(SocialNetworks, network) {
+			errs = append(errs, &FieldError{name, INVALID_NETWORK, fmt.Sprintf("%s must be one of %s", name, strings.Join(SocialNetworks, ", "))})
+		} else if !IsURL(link) {
+			errs = append(errs, &FieldError{name, INVALID_URL, fmt.Sprintf("%s must be an http or https URL", name)})
+		}
+	}
+
+	if len(p.Hours) > MaxHours {
+		errs = append(errs, &FieldError{"hours", TOO_MANY, fmt.Sprintf("hours can have at most %d entries", MaxHours)})
+	}
+	for i, h := range p.Hours {
+		name := "hours[" + strconv.Itoa(i) + "]"
+		open, okOpen := minutes(h.Open)
+		closes, okClose := minutes(h.Close)
+		if !contains(Days, h.Day) {
+			errs = append(errs, &FieldError{name + ".day", INVALID_HOURS, fmt.Sprintf("%s.day must be one of %s", name, strings.Join(Days, ", "))})
+		} else if !okOpen || !okClose || open >= closes {
+			errs = append(errs, &FieldError{name, INVALID_HOURS, fmt.Sprintf("%s must open and close at HH:MM times with close after open", name)})
+		}
+	}
+	if len(p.Hours) > 0 && p.Timezone == "" {
+		errs = append(errs, &FieldError{"timezone", REQUIRED, "timezone is required when hours are set"})
+	}
+
+	if len(p.Tags) > MaxTags {
+		errs = append(errs, &FieldError{"tags", TOO_MANY, fmt.Sprintf("tags can have at most %d entries", MaxTags)})
+	}
+	for i, tag := range p.Tags {
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			name := "tags[" + strconv.Itoa(i) + "]"
+			errs = append(errs, &FieldError{name, TOO_LONG, fmt.Sprintf("%s must be at most %d characters", name, MaxTagLength)})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+/*IsOpen reports whether t falls within any of the profile's opening hours in its timezone*/
+func (p *RoasterProfile) IsOpen(t time.Time) bool {
+	loc, err := time.LoadLocation(p.Timezone)
+	if p.Timezone == "" || err != nil {
+		return false
+	}
+
+	local := t.In(loc)
+	now := local.Hour()*60 + local.Minute()
+	day := Days[local.Weekday()]
+	for _, h := range p.Hours {
+		open, _ := minutes(h.Open)
+		closes, _ := minutes(h.Close)
+		if h.Day == day && open <= now && now < closes {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*minutes converts an HH:MM clock time to minutes after midnight*/
+func minutes(clock string) (int, bool) {
+	if !clockFormat.MatchString(clock) {
+		return 0, false
+	}
+
+	h, _ := strconv.Atoi(clock[:2])
+	m, _ := strconv.Atoi(clock[3:])
+	return h*60 + m, true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
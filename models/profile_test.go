@@ -0,0 +1,143 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDate(t *testing.T) {
+	assert := assert.New(t)
+
+	d, err := ParseDate("2009-04-01")
+	assert.NoError(err)
+	assert.Equal(NewDate(2009, time.April, 1), d)
+
+	d, err = ParseDate("2009")
+	assert.NoError(err)
+	assert.Equal(NewDate(2009, time.January, 1), d)
+
+	_, err = ParseDate("April 2009")
+	assert.Error(err)
+}
+
+func TestDateJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	b, err := json.Marshal(NewDate(2009, time.April, 1))
+	assert.NoError(err)
+	assert.Equal(`"2009-04-01"`, string(b))
+
+	b, err = json.Marshal(Date{})
+	assert.NoError(err)
+	assert.Equal("null", string(b))
+
+	var d Date
+	assert.NoError(json.Unmarshal([]byte(`"1998"`), &d))
+	assert.Equal(NewDate(1998, time.January, 1), d)
+	assert.NoError(json.Unmarshal([]byte("null"), &d))
+	assert.True(d.IsZero())
+	assert.Error(json.Unmarshal([]byte(`"soon"`), &d))
+}
+
+func TestDateScan(t *testing.T) {
+	assert := assert.New(t)
+
+	var d Date
+	assert.NoError(d.Scan(time.Date(2009, time.April, 1, 0, 0, 0, 0, time.Local)))
+	assert.Equal(NewDate(2009, time.April, 1), d)
+	assert.NoError(d.Scan([]byte("2010-05-02")))
+	assert.Equal(NewDate(2010, time.May, 2), d)
+	assert.NoError(d.Scan(nil))
+	assert.True(d.IsZero())
+	assert.Error(d.Scan(12))
+}
+
+func TestProfileNormalize(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewRoasterProfile(uuid.NewUUID())
+	p.Description = "  Small batch  "
+	p.Social = map[string]string{" Instagram ": "https://instagram.com/kaldis", "twitter": " "}
+	p.Hours = []*OpeningHours{{Day: " MON", Open: "07:00 ", Close: "15:00"}, nil}
+	p.Tags = []string{"Light  Roast", "light roast", " ", "Espresso"}
+	p.Normalize()
+
+	assert.Equal("Small batch", p.Description)
+	assert.Equal(map[string]string{"instagram": "https://instagram.com/kaldis"}, p.Social)
+	assert.Equal([]*OpeningHours{{Day: "mon", Open: "07:00", Close: "15:00"}}, p.Hours)
+	assert.Equal([]string{"light roast", "espresso"}, p.Tags)
+}
+
+func TestProfileValidateSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewRoasterProfile(uuid.NewUUID())
+	p.Website = "https://kaldis.com"
+	p.Social["instagram"] = "https://instagram.com/kaldis"
+	p.Hours = append(p.Hours, &OpeningHours{"sat", "08:00", "24:00"})
+	p.Timezone = "America/Chicago"
+	p.Tags = append(p.Tags, "espresso")
+
+	assert.Nil(p.Validate())
+	assert.Nil(NewRoasterProfile(uuid.NewUUID()).Validate())
+}
+
+func TestProfileValidateErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewRoasterProfile(uuid.NewUUID())
+	p.Website = "kaldis.com"
+	p.Social["myspace"] = "https://myspace.com/kaldis"
+	p.Social["twitter"] = "@kaldis"
+	p.Hours = append(p.Hours, &OpeningHours{"monday", "07:00", "15:00"}, &OpeningHours{"tue", "15:00", "07:00"}, &OpeningHours{"wed", "7am", "15:00"})
+	p.Tags = append(p.Tags, "a roast style name that is far too long")
+	errs := p.Validate()
+
+	codes := make([]string, len(errs))
+	for i, e := range errs {
+		codes[i] = e.Field + ":" + e.Code
+	}
+	assert.Equal([]string{
+		"website:" + INVALID_URL,
+		"social.myspace:" + INVALID_NETWORK,
+		"social.twitter:" + INVALID_URL,
+		"hours[0].day:" + INVALID_HOURS,
+		"hours[1]:" + INVALID_HOURS,
+		"hours[2]:" + INVALID_HOURS,
+		"timezone:" + REQUIRED,
+		"tags[0]:" + TOO_LONG,
+	}, codes)
+}
+
+func TestProfileValidateTimezone(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewRoasterProfile(uuid.NewUUID())
+	p.Timezone = "Mars/Olympus_Mons"
+	errs := p.Validate()
+
+	assert.Equal(1, len(errs))
+	assert.Equal(INVALID_TIMEZONE, errs[0].Code)
+}
+
+func TestProfileIsOpen(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewRoasterProfile(uuid.NewUUID())
+	p.Timezone = "America/Chicago"
+	p.Hours = append(p.Hours, &OpeningHours{"mon", "07:00", "11:00"}, &OpeningHours{"mon", "13:00", "17:00"})
+
+	//Monday 2017-03-06 in Chicago is UTC-6
+	assert.True(p.IsOpen(time.Date(2017, time.March, 6, 13, 0, 0, 0, time.UTC)))
+	assert.False(p.IsOpen(time.Date(2017, time.March, 6, 18, 0, 0, 0, time.UTC)))
+	assert.True(p.IsOpen(time.Date(2017, time.March, 6, 19, 0, 0, 0, time.UTC)))
+	assert.False(p.IsOpen(time.Date(2017, time.March, 6, 23, 0, 0, 0, time.UTC)))
+	assert.False(p.IsOpen(time.Date(2017, time.March, 7, 13, 0, 0, 0, time.UTC)))
+
+	p.Timezone = ""
+	assert.False(p.IsOpen(time.Date(2017, time.March, 6, 13, 0, 0, 0, time.UTC)))
+}
@@ -2,27 +2,37 @@ package models
 
 import (
 	"database/sql"
+	"strings"
+	"time"
 
 	"github.com/pborman/uuid"
 )
 
 type Roaster struct {
-	ID             uuid.UUID `json:"id"`
-	Name           string    `json:"name"`
-	Email          string    `json:"email"`
-	Phone          string    `json:"phone"`
-	AddressLine1   string    `json:"addressLine1"`
-	AddressLine2   string    `json:"addressLine2"`
-	AddressCity    string    `json:"addressCity"`
-	AddressState   string    `json:"addressState"`
-	AddressZip     string    `json:"addressZip"`
-	AddressCountry string    `json:"addressCountry"`
-	ProfileUrl     string    `json:"profileUrl"`
-	Birthday       string    `json:"birth"`
+	ID             uuid.UUID         `json:"id"`
+	Name           string            `json:"name" validate:"max=30"`
+	Slug           string            `json:"slug" validate:"max=60,slug"`
+	Email          string            `json:"email" validate:"max=200,email"`
+	Phone          string            `json:"phone" validate:"max=16,phone"`
+	PhoneVerified  bool              `json:"phoneVerified"`
+	AddressLine1   string            `json:"addressLine1" validate:"max=200"`
+	AddressLine2   string            `json:"addressLine2" validate:"max=200"`
+	AddressCity    string            `json:"addressCity" validate:"max=30"`
+	AddressState   string            `json:"addressState" validate:"max=30"`
+	AddressZip     string            `json:"addressZip" validate:"max=10,postal=AddressCountry"`
+	AddressCountry string            `json:"addressCountry" validate:"country"`
+	ProfileUrl     string            `json:"profileUrl"`
+	Photos         map[string]string `json:"photos"`
+	Birthday       string            `json:"birth"`
+	Latitude       *float64          `json:"latitude"`
+	Longitude      *float64          `json:"longitude"`
+	Status         string            `json:"status"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
 }
 
 func NewRoaster(name, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry, birth string) *Roaster {
-	return &Roaster{
+	roaster := &Roaster{
 		ID:             uuid.NewUUID(),
 		Name:           name,
 		Email:          email,
@@ -35,7 +45,11 @@ func NewRoaster(name, email, phone, addressLine1, addressLine2, addressCity, add
 		AddressCountry: addressCountry,
 		ProfileUrl:     "",
 		Birthday:       birth,
+		Status:         STATUS_PENDING,
 	}
+	roaster.SetPhotos()
+
+	return roaster
 }
 
 func RoasterFromSQL(rows *sql.Rows) ([]*Roaster, error) {
@@ -43,11 +57,44 @@ func RoasterFromSQL(rows *sql.Rows) ([]*Roaster, error) {
 
 	for rows.Next() {
 		r := &Roaster{}
+		var slug sql.NullString
 
-		rows.Scan(&r.ID, &r.Name, &r.Email, &r.Phone, &r.AddressLine1, &r.AddressLine2, &r.AddressCity, &r.AddressState, &r.AddressZip, &r.AddressCountry, &r.ProfileUrl, &r.Birthday)
+		rows.Scan(&r.ID, &r.Name, &slug, &r.Email, &r.Phone, &r.PhoneVerified, &r.AddressLine1, &r.AddressLine2, &r.AddressCity, &r.AddressState, &r.AddressZip, &r.AddressCountry, &r.ProfileUrl, &r.Birthday, &r.Latitude, &r.Longitude, &r.Status, &r.CreatedAt, &r.UpdatedAt)
+		r.Slug = slug.String
+		r.SetPhotos()
 
 		roasters = append(roasters, r)
 	}
 
 	return roasters, nil
 }
+
+/*Geocode sets the roaster's coordinates from its address, clearing them when the postal code can't be located*/
+func (r *Roaster) Geocode() {
+	r.Latitude, r.Longitude = nil, nil
+	if r.AddressZip == "" && r.AddressCountry == "" {
+		return
+	}
+
+	lat, lng, ok := Geocode(r.AddressZip, r.AddressCountry)
+	if !ok {
+		return
+	}
+
+	r.Latitude, r.Longitude = &lat, &lng
+}
+
+/*SetPhotos fills in the roaster's photo URLs, pointing at a generated avatar when it hasn't uploaded a photo*/
+func (r *Roaster) SetPhotos() {
+	if r.ProfileUrl == "" {
+		r.Photos = AvatarURLs(AVATAR_ROASTER, r.ID)
+		return
+	}
+
+	r.Photos = PhotoURLs(r.ProfileUrl)
+}
+
+/*Initials returns the initials of the first two words of the roaster's name*/
+func (r *Roaster) Initials() string {
+	return Initials(strings.Fields(r.Name)...)
+}
@@ -0,0 +1,284 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pborman/uuid"
+)
+
+/*Search index kinds*/
+const (
+	SEARCH_ROASTER = "roaster"
+	SEARCH_USER    = "user"
+)
+
+/*Field weights used when ranking search results*/
+const (
+	NAME_WEIGHT    = 3.0
+	CONTACT_WEIGHT = 2.0
+	CITY_WEIGHT    = 1.0
+)
+
+/*Match scores for a query token against an indexed term*/
+const (
+	EXACT_MATCH  = 1.0
+	PREFIX_MATCH = 0.8
+	TYPO_MATCH   = 0.5
+)
+
+// MaxTermLength is the longest term stored in the search index. Longer
+// tokens are truncated, which still lets them match by prefix.
+const MaxTermLength = 64
+
+// minPhoneTerm is the shortest trailing run of phone digits that is indexed,
+// so a phone can be found by its last few digits.
+const minPhoneTerm = 4
+
+/*SearchTerm is a single indexed term and how much a match on it counts*/
+type SearchTerm struct {
+	Term   string
+	Weight float64
+}
+
+/*SearchTerms returns the terms a roaster is indexed under*/
+func (r *Roaster) SearchTerms() []*SearchTerm {
+	terms := make(map[string]float64)
+	addTerms(terms, r.Name, NAME_WEIGHT)
+	addTerms(terms, r.AddressCity, CITY_WEIGHT)
+
+	return termList(terms)
+}
+
+/*SearchTerms returns the terms a user is indexed under*/
+func (u *User) SearchTerms() []*SearchTerm {
+	terms := make(map[string]float64)
+	addTerms(terms, u.FirstName, NAME_WEIGHT)
+	addTerms(terms, u.LastName, NAME_WEIGHT)
+	addTerms(terms, u.Email, CONTACT_WEIGHT)
+	addTerm(terms, strings.ToLower(u.Email), CONTACT_WEIGHT)
+	addPhoneTerms(terms, u.Phone, CONTACT_WEIGHT)
+
+	return termList(terms)
+}
+
+/*Tokenize lower-cases s and splits it into its runs of letters and digits*/
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+/*MatchScore scores how well a query token matches an indexed term, or returns 0 when it doesn't*/
+func MatchScore(query, term string) float64 {
+	switch {
+	case query == term:
+		return EXACT_MATCH
+	case strings.HasPrefix(term, query):
+		return PREFIX_MATCH
+	}
+
+	typos := AllowedTypos(query)
+	if typos == 0 {
+		return 0
+	}
+
+	// compare against the start of the term as well so a typo in a
+	// partially typed word still matches
+	if editDistance(query, term) <= typos || editDistance(query, truncate(term, utf8.RuneCountInString(query))) <= typos {
+		return TYPO_MATCH
+	}
+
+	return 0
+}
+
+// AllowedTypos is the edit distance tolerated for a query token, short
+// tokens must match exactly or by prefix.
+func AllowedTypos(query string) int {
+	n := utf8.RuneCountInString(query)
+	switch {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	}
+
+	return 0
+}
+
+// editDistance is the Damerau-Levenshtein (optimal string alignment) distance,
+// so a swapped pair of letters counts as a single typo.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(s)][len(t)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		return string(r[:n])
+	}
+
+	return s
+}
+
+func addTerms(terms map[string]float64, value string, weight float64) {
+	for _, token := range Tokenize(value) {
+		addTerm(terms, token, weight)
+	}
+}
+
+func addTerm(terms map[string]float64, term string, weight float64) {
+	if term == "" {
+		return
+	}
+
+	term = truncate(term, MaxTermLength)
+	if weight > terms[term] {
+		terms[term] = weight
+	}
+}
+
+func addPhoneTerms(terms map[string]float64, phone string, weight float64) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+
+	for i := 0; i <= len(digits)-minPhoneTerm; i++ {
+		addTerm(terms, digits[i:], weight)
+	}
+}
+
+func termList(terms map[string]float64) []*SearchTerm {
+	list := make([]*SearchTerm, 0, len(terms))
+	for term, weight := range terms {
+		list = append(list, &SearchTerm{Term: term, Weight: weight})
+	}
+	sort.Sort(byTerm(list))
+
+	return list
+}
+
+type byTerm []*SearchTerm
+
+func (b byTerm) Len() int           { return len(b) }
+func (b byTerm) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byTerm) Less(i, j int) bool { return b[i].Term < b[j].Term }
+
+/*IndexedTerm is a term stored in the search index for the record with ID*/
+type IndexedTerm struct {
+	ID uuid.UUID
+	SearchTerm
+}
+
+func IndexedTermFromSQL(rows *sql.Rows) ([]*IndexedTerm, error) {
+	terms := make([]*IndexedTerm, 0)
+
+	for rows.Next() {
+		t := &IndexedTerm{}
+
+		rows.Scan(&t.ID, &t.Term, &t.Weight)
+
+		terms = append(terms, t)
+	}
+
+	return terms, nil
+}
+
+// Rank scores every record against the query tokens and returns their IDs,
+// best match first. A record's score is the sum over the tokens of its best
+// weighted match, and records that don't match every token are dropped.
+func Rank(tokens []string, terms []*IndexedTerm) []string {
+	byID := make(map[string][]*IndexedTerm)
+	for _, t := range terms {
+		byID[t.ID.String()] = append(byID[t.ID.String()], t)
+	}
+
+	ranked := make(byScore, 0, len(byID))
+	for id, indexed := range byID {
+		total := 0.0
+		for _, token := range tokens {
+			best := 0.0
+			for _, t := range indexed {
+				score := MatchScore(token, t.Term) * t.Weight
+				if score > best {
+					best = score
+				}
+			}
+
+			if best == 0 {
+				total = 0
+				break
+			}
+			total += best
+		}
+
+		if total > 0 {
+			ranked = append(ranked, &scored{id, total})
+		}
+	}
+	sort.Sort(ranked)
+
+	ids := make([]string, len(ranked))
+	for i, s := range ranked {
+		ids[i] = s.id
+	}
+
+	return ids
+}
+
+type scored struct {
+	id    string
+	score float64
+}
+
+type byScore []*scored
+
+func (b byScore) Len() int      { return len(b) }
+func (b byScore) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byScore) Less(i, j int) bool {
+	if b[i].score != b[j].score {
+		return b[i].score > b[j].score
+	}
+	return b[i].id < b[j].id
+}
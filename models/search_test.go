@@ -0,0 +1,81 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"café", "du", "monde", "new", "orleans"}, Tokenize("Café du Monde - New Orleans"))
+	assert.Equal([]string{"jake", "long", "example", "com"}, Tokenize("Jake.Long@example.com"))
+	assert.Equal(0, len(Tokenize(" -- ")))
+}
+
+func TestMatchScore(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(EXACT_MATCH, MatchScore("kaldi", "kaldi"))
+	assert.Equal(PREFIX_MATCH, MatchScore("kal", "kaldi"))
+	assert.Equal(TYPO_MATCH, MatchScore("kadli", "kaldi"))
+	assert.Equal(TYPO_MATCH, MatchScore("kaldu", "kaldi"))
+	assert.Equal(TYPO_MATCH, MatchScore("cofee", "coffee"))
+	assert.Equal(TYPO_MATCH, MatchScore("roastr", "roastery"))
+	assert.Equal(TYPO_MATCH, MatchScore("espersso", "espresso"))
+	assert.Equal(0.0, MatchScore("kod", "kaldi"))
+	assert.Equal(0.0, MatchScore("bean", "kaldi"))
+	assert.Equal(0.0, MatchScore("kald", "ames"))
+}
+
+func TestUserSearchTerms(t *testing.T) {
+	assert := assert.New(t)
+
+	user := NewUser("", "Jake", "Long", "jake@example.com", "+15155550123", "", "", "Ames", "", "", "")
+	terms := make(map[string]float64)
+	for _, t := range user.SearchTerms() {
+		terms[t.Term] = t.Weight
+	}
+
+	assert.Equal(NAME_WEIGHT, terms["jake"])
+	assert.Equal(NAME_WEIGHT, terms["long"])
+	assert.Equal(CONTACT_WEIGHT, terms["jake@example.com"])
+	assert.Equal(CONTACT_WEIGHT, terms["example"])
+	assert.Equal(CONTACT_WEIGHT, terms["15155550123"])
+	assert.Equal(CONTACT_WEIGHT, terms["0123"])
+	assert.NotContains(terms, "123")
+	assert.NotContains(terms, "ames")
+}
+
+func TestRoasterSearchTerms(t *testing.T) {
+	assert := assert.New(t)
+
+	roaster := NewRoaster("Ames Coffee", "", "", "", "", "Ames", "", "", "", "")
+	terms := roaster.SearchTerms()
+
+	assert.Equal(2, len(terms))
+	assert.Equal("ames", terms[0].Term)
+	assert.Equal(NAME_WEIGHT, terms[0].Weight)
+	assert.Equal("coffee", terms[1].Term)
+}
+
+func TestRank(t *testing.T) {
+	assert := assert.New(t)
+
+	exact, prefix, typo, partial := uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID()
+	terms := []*IndexedTerm{
+		{typo, SearchTerm{"kadli", NAME_WEIGHT}},
+		{prefix, SearchTerm{"kaldis", NAME_WEIGHT}},
+		{exact, SearchTerm{"kaldi", NAME_WEIGHT}},
+		{exact, SearchTerm{"ames", CITY_WEIGHT}},
+		{prefix, SearchTerm{"ames", CITY_WEIGHT}},
+		{typo, SearchTerm{"ames", CITY_WEIGHT}},
+		{partial, SearchTerm{"kaldi", NAME_WEIGHT}},
+	}
+
+	ids := Rank([]string{"kaldi", "ames"}, terms)
+
+	assert.Equal([]string{exact.String(), prefix.String(), typo.String()}, ids)
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+/*MaxSlugLength is the longest slug a roaster can have*/
+const MaxSlugLength = 60
+
+/*DefaultSlug is used when a roaster's name has no letters or digits to build a slug from*/
+const DefaultSlug = "roaster"
+
+// folds spells common accented latin letters in ascii so they survive in slugs
+var folds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ß': "ss",
+}
+
+/*PublicRoaster is the part of a roaster shown to visitors who aren't signed in*/
+type PublicRoaster struct {
+	ID             string            `json:"id"`
+	Slug           string            `json:"slug"`
+	Name           string            `json:"name"`
+	AddressCity    string            `json:"addressCity"`
+	AddressState   string            `json:"addressState"`
+	AddressCountry string            `json:"addressCountry"`
+	ProfileUrl     string            `json:"profileUrl"`
+	Photos         map[string]string `json:"photos"`
+
+	Profile *RoasterProfile `json:"profile"`
+	Gallery []*GalleryImage `json:"gallery"`
+	OpenNow bool            `json:"openNow"`
+}
+
+/*Public returns the fields of the roaster that are safe to show anonymously*/
+func (r *Roaster) Public() *PublicRoaster {
+	return &PublicRoaster{
+		ID:             r.ID.String(),
+		Slug:           r.Slug,
+		Name:           r.Name,
+		AddressCity:    r.AddressCity,
+		AddressState:   r.AddressState,
+		AddressCountry: r.AddressCountry,
+		ProfileUrl:     r.ProfileUrl,
+		Photos:         r.Photos,
+	}
+}
+
+/*Slugify turns a name into a lower case, hyphen separated, URL-safe slug*/
+func Slugify(name string) string {
+	words := strings.FieldsFunc(foldAccents(strings.ToLower(name)), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9')
+	})
+
+	slug := strings.Join(words, "-")
+	if len(slug) > MaxSlugLength {
+		slug = strings.TrimRight(slug[:MaxSlugLength], "-")
+	}
+
+	return slug
+}
+
+/*SlugCandidate returns the nth slug to try for base, adding a numeric suffix after the first*/
+func SlugCandidate(base string, n int) string {
+	if base == "" {
+		base = DefaultSlug
+	}
+	if n <= 1 {
+		return base
+	}
+
+	suffix := "-" + strconv.Itoa(n)
+	if len(base)+len(suffix) > MaxSlugLength {
+		base = strings.TrimRight(base[:MaxSlugLength-len(suffix)], "-")
+	}
+
+	return base + suffix
+}
+
+func foldAccents(s string) string {
+	var b bytes.Buffer
+	for _, r := range s {
+		if f, ok := folds[r]; ok {
+			b.WriteString(f)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
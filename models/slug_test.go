@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("kaldi-s-coffee", Slugify("Kaldi's Coffee"))
+	assert.Equal("cafe-du-monde", Slugify("  Café du Monde!! "))
+	assert.Equal("strasse-42", Slugify("Straße 42"))
+	assert.Equal("", Slugify("☕☕"))
+	assert.Equal(MaxSlugLength, len(Slugify(strings.Repeat("a", 100))))
+	assert.False(strings.HasSuffix(Slugify(strings.Repeat("a", 59)+" b"), "-"))
+}
+
+func TestSlugCandidate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("kaldis", SlugCandidate("kaldis", 1))
+	assert.Equal("kaldis-3", SlugCandidate("kaldis", 3))
+	assert.Equal(DefaultSlug, SlugCandidate("", 1))
+	assert.Equal(MaxSlugLength, len(SlugCandidate(strings.Repeat("a", MaxSlugLength), 12)))
+}
+
+func TestValidateSlug(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, slug := range []string{"kaldis", "kaldis-2", "a"} {
+		assert.Nil(Validate(&Roaster{Slug: slug}), slug)
+	}
+
+	for _, slug := range []string{"Kaldis", "kaldis-", "-kaldis", "kal--dis", "kal dis"} {
+		errs := Validate(&Roaster{Slug: slug})
+		assert.Equal(1, len(errs), slug)
+		assert.Equal(INVALID_SLUG, errs[0].Code)
+	}
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+/*Roaster statuses, only active roasters are listed publicly*/
+const (
+	STATUS_PENDING      = "pending"
+	STATUS_UNDER_REVIEW = "under_review"
+	STATUS_ACTIVE       = "active"
+	STATUS_SUSPENDED    = "suspended"
+	STATUS_CLOSED       = "closed"
+)
+
+/*Reasons an admin can give for rejecting, suspending or closing a roaster*/
+const (
+	REASON_MISSING_DOCUMENTS  = "missing_documents"
+	REASON_INVALID_DOCUMENTS  = "invalid_documents"
+	REASON_UNVERIFIED_ADDRESS = "unverified_address"
+	REASON_POLICY_VIOLATION   = "policy_violation"
+	REASON_FRAUD              = "fraud"
+	REASON_OWNER_REQUEST      = "owner_request"
+	REASON_OTHER              = "other"
+)
+
+/*Document kinds a roaster can upload for business verification*/
+const (
+	DOCUMENT_BUSINESS_LICENSE = "business_license"
+	DOCUMENT_TAX_ID           = "tax_id"
+	DOCUMENT_FOOD_PERMIT      = "food_permit"
+	DOCUMENT_OTHER            = "other"
+)
+
+var Statuses = []string{STATUS_PENDING, STATUS_UNDER_REVIEW, STATUS_ACTIVE, STATUS_SUSPENDED, STATUS_CLOSED}
+
+var Reasons = []string{REASON_MISSING_DOCUMENTS, REASON_INVALID_DOCUMENTS, REASON_UNVERIFIED_ADDRESS, REASON_POLICY_VIOLATION, REASON_FRAUD, REASON_OWNER_REQUEST, REASON_OTHER}
+
+var DocumentKinds = []string{DOCUMENT_BUSINESS_LICENSE, DOCUMENT_TAX_ID, DOCUMENT_FOOD_PERMIT, DOCUMENT_OTHER}
+
+// transitions lists the statuses each status can move to. Closed roasters
+// can't be reopened.
+var transitions = map[string][]string{
+	STATUS_PENDING:      {STATUS_UNDER_REVIEW, STATUS_CLOSED},
+	STATUS_UNDER_REVIEW: {STATUS_ACTIVE, STATUS_PENDING, STATUS_CLOSED},
+	STATUS_ACTIVE:       {STATUS_SUSPENDED, STATUS_CLOSED},
+	STATUS_SUSPENDED:    {STATUS_ACTIVE, STATUS_CLOSED},
+}
+
+/*CanTransition reports whether a roaster in status from may move to status to*/
+func CanTransition(from string, to string) bool {
+	return contains(transitions[from], to)
+}
+
+func IsStatus(s string) bool {
+	return contains(Statuses, s)
+}
+
+func IsReason(s string) bool {
+	return contains(Reasons, s)
+}
+
+func IsDocumentKind(s string) bool {
+	return contains(DocumentKinds, s)
+}
+
+/*StatusChange records a roaster moving from one status to another, and who moved it*/
+type StatusChange struct {
+	ID        uuid.UUID `json:"id"`
+	RoasterID uuid.UUID `json:"roasterId"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Reason    string    `json:"reason"`
+	Note      string    `json:"note" validate:"max=500"`
+	ActorID   string    `json:"actorId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+/*StatusRequest is the body of the status change endpoints*/
+type StatusRequest struct {
+	Reason string `json:"reason"`
+	Note   string `json:"note"`
+}
+
+func NewStatusChange(roasterID uuid.UUID, from, to, reason, note, actorID string) *StatusChange {
+	return &StatusChange{
+		ID:        uuid.NewUUID(),
+		RoasterID: roasterID,
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		Note:      note,
+		ActorID:   actorID,
+	}
+}
+
+func StatusChangeFromSQL(rows *sql.Rows) ([]*StatusChange, error) {
+	changes := make([]*StatusChange, 0)
+
+	for rows.Next() {
+		c := &StatusChange{}
+		rows.Scan(&c.ID, &c.RoasterID, &c.From, &c.To, &c.Reason, &c.Note, &c.ActorID, &c.CreatedAt)
+		changes = append(changes, c)
+	}
+
+	return changes, nil
+}
+
+// RoasterDocument is a file a roaster uploaded to prove it's a real business.
+// The file is private, Key is where it's stored in S3 (a public URL for
+// documents uploaded before they were private) and Url is a short-lived
+// link to download it.
+type RoasterDocument struct {
+	ID        uuid.UUID `json:"id"`
+	RoasterID uuid.UUID `json:"roasterId"`
+	Kind      string    `json:"kind"`
+	Filename  string    `json:"filename"`
+	Key       string    `json:"-"`
+	Url       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func NewRoasterDocument(roasterID uuid.UUID, kind, filename string) *RoasterDocument {
+	return &RoasterDocument{
+		ID:        uuid.NewUUID(),
+		RoasterID: roasterID,
+		Kind:      kind,
+		Filename:  filename,
+	}
+}
+
+func RoasterDocumentFromSQL(rows *sql.Rows) ([]*RoasterDocument, error) {
+	documents := make([]*RoasterDocument, 0)
+
+	for rows.Next() {
+		d := &RoasterDocument{}
+		rows.Scan(&d.ID, &d.RoasterID, &d.Kind, &d.Filename, &d.Key, &d.CreatedAt)
+		documents = append(documents, d)
+	}
+
+	return documents, nil
+}
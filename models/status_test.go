@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransition(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(CanTransition(STATUS_PENDING, STATUS_UNDER_REVIEW))
+	assert.True(CanTransition(STATUS_UNDER_REVIEW, STATUS_ACTIVE))
+	assert.True(CanTransition(STATUS_UNDER_REVIEW, STATUS_PENDING))
+	assert.True(CanTransition(STATUS_ACTIVE, STATUS_SUSPENDED))
+	assert.True(CanTransition(STATUS_SUSPENDED, STATUS_ACTIVE))
+	assert.True(CanTransition(STATUS_SUSPENDED, STATUS_CLOSED))
+
+	assert.False(CanTransition(STATUS_PENDING, STATUS_ACTIVE))
+	assert.False(CanTransition(STATUS_ACTIVE, STATUS_ACTIVE))
+	assert.False(CanTransition(STATUS_CLOSED, STATUS_ACTIVE))
+	assert.False(CanTransition("", STATUS_ACTIVE))
+}
+
+func TestNewRoasterIsPending(t *testing.T) {
+	assert := assert.New(t)
+
+	roaster := NewRoaster("Kaldi's", "", "", "", "", "", "", "", "", "")
+
+	assert.Equal(STATUS_PENDING, roaster.Status)
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+/*Transfer statuses, only pending transfers can be accepted*/
+const (
+	TRANSFER_PENDING   = "pending"
+	TRANSFER_ACCEPTED  = "accepted"
+	TRANSFER_DECLINED  = "declined"
+	TRANSFER_CANCELLED = "cancelled"
+	TRANSFER_EXPIRED   = "expired"
+)
+
+/*Transfer hands a roaster from its current owner to another user once the recipient accepts*/
+type Transfer struct {
+	ID         uuid.UUID `json:"id"`
+	RoasterID  uuid.UUID `json:"roasterId"`
+	FromUserID uuid.UUID `json:"fromUserId"`
+	ToUserID   uuid.UUID `json:"toUserId"`
+	ToEmail    string    `json:"toEmail"`
+	Token      string    `json:"-"`
+	Status     string    `json:"status"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+/*TransferRequest is the body of a request to start a transfer*/
+type TransferRequest struct {
+	Email string `json:"email"`
+}
+
+func NewTransfer(roasterID, fromUserID, toUserID uuid.UUID, toEmail string, expiration time.Duration) *Transfer {
+	return &Transfer{
+		ID:         uuid.NewUUID(),
+		RoasterID:  roasterID,
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		ToEmail:    toEmail,
+		Token:      uuid.New(),
+		Status:     TRANSFER_PENDING,
+		ExpiresAt:  now().Add(expiration),
+	}
+}
+
+func TransferFromSQL(rows *sql.Rows) ([]*Transfer, error) {
+	transfers := make([]*Transfer, 0)
+
+	for rows.Next() {
+		t := &Transfer{}
+		rows.Scan(&t.ID, &t.RoasterID, &t.FromUserID, &t.ToUserID, &t.ToEmail, &t.Token, &t.Status, &t.ExpiresAt, &t.CreatedAt, &t.UpdatedAt)
+		transfers = append(transfers, t)
+	}
+
+	return transfers, nil
+}
+
+/*Expired reports whether the transfer is still pending but past its expiry at now*/
+func (t *Transfer) Expired(now time.Time) bool {
+	return t.Status == TRANSFER_PENDING && now.After(t.ExpiresAt)
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransfer(t *testing.T) {
+	assert := assert.New(t)
+
+	transfer := NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", time.Hour)
+
+	assert.Equal(TRANSFER_PENDING, transfer.Status)
+	assert.NotEmpty(transfer.Token)
+	assert.True(transfer.ExpiresAt.After(time.Now()))
+}
+
+func TestTransferExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	transfer := NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", time.Hour)
+
+	assert.False(transfer.Expired(time.Now()))
+	assert.True(transfer.Expired(time.Now().Add(2 * time.Hour)))
+
+	transfer.Status = TRANSFER_ACCEPTED
+	assert.False(transfer.Expired(time.Now().Add(2 * time.Hour)))
+}
@@ -2,29 +2,34 @@ package models
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/pborman/uuid"
 )
 
 type User struct {
-	ID             uuid.UUID `json:"id"`
-	PassHash       string    `json:"passHash"`
-	FirstName      string    `json:"firstName"`
-	LastName       string    `json:"lastName"`
-	Email          string    `json:"email"`
-	Phone          string    `json:"phone"`
-	AddressLine1   string    `json:"addressLine1"`
-	AddressLine2   string    `json:"addressLine2"`
-	AddressCity    string    `json:"addressCity"`
-	AddressState   string    `json:"addressState"`
-	AddressZip     string    `json:"addressZip"`
-	AddressCountry string    `json:"addressCountry"`
-	RoasterId      uuid.UUID `json:"roasterId"`
-	ProfileURL     string    `json:"profileUrl"`
+	ID             uuid.UUID         `json:"id"`
+	PassHash       string            `json:"passHash" validate:"max=72"`
+	FirstName      string            `json:"firstName" validate:"max=20"`
+	LastName       string            `json:"lastName" validate:"max=20"`
+	Email          string            `json:"email" validate:"max=200,email"`
+	Phone          string            `json:"phone" validate:"max=16,phone"`
+	PhoneVerified  bool              `json:"phoneVerified"`
+	AddressLine1   string            `json:"addressLine1" validate:"max=200"`
+	AddressLine2   string            `json:"addressLine2" validate:"max=200"`
+	AddressCity    string            `json:"addressCity" validate:"max=30"`
+	AddressState   string            `json:"addressState" validate:"max=30"`
+	AddressZip     string            `json:"addressZip" validate:"max=10,postal=AddressCountry"`
+	AddressCountry string            `json:"addressCountry" validate:"country"`
+	RoasterId      uuid.UUID         `json:"roasterId"`
+	ProfileURL     string            `json:"profileUrl"`
+	Photos         map[string]string `json:"photos"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
 }
 
 func NewUser(passHash, firstName, lastName, email, phone, addressLine1, addressLine2, addressCity, addressState, addressZip, addressCountry string) *User {
-	return &User{
+	user := &User{
 		ID:             uuid.NewUUID(),
 		PassHash:       passHash,
 		FirstName:      firstName,
@@ -40,6 +45,9 @@ func NewUser(passHash, firstName, lastName, email, phone, addressLine1, addressL
 		RoasterId:      nil,
 		ProfileURL:     "",
 	}
+	user.SetPhotos()
+
+	return user
 }
 
 func UserFromSQL(rows *sql.Rows) ([]*User, error) {
@@ -48,11 +56,49 @@ func UserFromSQL(rows *sql.Rows) ([]*User, error) {
 	for rows.Next() {
 		u := &User{}
 
-		rows.Scan(&u.ID, &u.PassHash, &u.FirstName, &u.LastName, &u.Email, &u.Phone, &u.AddressLine1, &u.AddressLine2,
-			&u.AddressCity, &u.AddressState, &u.AddressZip, &u.AddressCountry, &u.RoasterId, &u.ProfileURL)
+		rows.Scan(&u.ID, &u.PassHash, &u.FirstName, &u.LastName, &u.Email, &u.Phone, &u.PhoneVerified, &u.AddressLine1, &u.AddressLine2,
+			&u.AddressCity, &u.AddressState, &u.AddressZip, &u.AddressCountry, &u.RoasterId, &u.ProfileURL, &u.CreatedAt, &u.UpdatedAt)
+		u.SetPhotos()
 
 		users = append(users, u)
 	}
 
 	return users, nil
 }
+
+/*ExportUserFromSQL reads users selected without their passHash, in the order of UserExportFields*/
+func ExportUserFromSQL(rows *sql.Rows) ([]*User, error) {
+	users := make([]*User, 0)
+
+	for rows.Next() {
+		u := &User{}
+
+		rows.Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.Phone, &u.PhoneVerified, &u.AddressLine1, &u.AddressLine2,
+			&u.AddressCity, &u.AddressState, &u.AddressZip, &u.AddressCountry, &u.RoasterId, &u.ProfileURL, &u.CreatedAt, &u.UpdatedAt)
+		u.SetPhotos()
+
+		users = append(users, u)
+	}
+
+	return users, nil
+}
+
+/*SetPhotos fills in the user's photo URLs, pointing at a generated avatar when they haven't uploaded a photo*/
+func (u *User) SetPhotos() {
+	if u.ProfileURL == "" {
+		u.Photos = AvatarURLs(AVATAR_USER, u.ID)
+		return
+	}
+
+	u.Photos = PhotoURLs(u.ProfileURL)
+}
+
+/*Initials returns the initials of the user's name, or of their email when they haven't given one*/
+func (u *User) Initials() string {
+	initials := Initials(u.FirstName, u.LastName)
+	if initials == "" {
+		initials = Initials(u.Email)
+	}
+
+	return initials
+}
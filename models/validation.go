@@ -0,0 +1,194 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/*Stable error codes returned in FieldError.Code*/
+const (
+	REQUIRED            = "required"
+	TOO_LONG            = "too_long"
+	INVALID_EMAIL       = "invalid_email"
+	INVALID_PHONE       = "invalid_phone"
+	INVALID_COUNTRY     = "invalid_country"
+	INVALID_POSTAL_CODE = "invalid_postal_code"
+	INVALID_SLUG        = "invalid_slug"
+	INVALID_URL         = "invalid_url"
+	INVALID_TIMEZONE    = "invalid_timezone"
+	INVALID_HOURS       = "invalid_hours"
+	INVALID_NETWORK     = "invalid_network"
+	TOO_MANY            = "too_many"
+	TOO_SHORT           = "too_short"
+	INVALID_EVENT       = "invalid_event"
+)
+
+var (
+	emailFormat = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	phoneFormat = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+	slugFormat  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+/*FieldError describes why a single field failed validation*/
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+/*ValidationErrors is the list of every field that failed validation*/
+type ValidationErrors []*FieldError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, e := range v {
+		msgs[i] = e.Message
+	}
+
+	return strings.Join(msgs, ", ")
+}
+
+/*Validate checks the string fields of s against their validate tags and returns every failure, or nil when s is valid*/
+func Validate(s interface{}) ValidationErrors {
+	return validate(s, reflect.Value{})
+}
+
+// ValidateChanges checks s like Validate, but skips the rules on fields that
+// are the same as in old, the stored record s was merged with. Values stored
+// before a rule existed then don't fail updates that leave them alone. A
+// nil old checks every field.
+func ValidateChanges(s interface{}, old interface{}) ValidationErrors {
+	return validate(s, reflect.Indirect(reflect.ValueOf(old)))
+}
+
+/*validate checks s, skipping the fields unchanged from old when it's valid*/
+func validate(s interface{}, old reflect.Value) ValidationErrors {
+	v := reflect.Indirect(reflect.ValueOf(s))
+	t := v.Type()
+
+	errs := make(ValidationErrors, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || field.Type.Kind() != reflect.String {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+
+		value := v.Field(i).String()
+		for _, rule := range strings.Split(tag, ",") {
+			if old.IsValid() && unchanged(v, old, field.Name, rule) {
+				continue
+			}
+
+			e := check(v, name, value, rule)
+			if e != nil {
+				errs = append(errs, e)
+				break
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// check applies a single rule from a validate tag. Rules are required, max=N,
+// email, phone, country, slug, url, timezone and postal=Field, where Field names the sibling
+// holding the country code. Apart from required, rules only apply to non-empty
+// values.
+func check(v reflect.Value, name, value, rule string) *FieldError {
+	arg := ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		rule, arg = rule[:i], rule[i+1:]
+	}
+
+	if rule == REQUIRED {
+		if strings.TrimSpace(value) == "" {
+			return &FieldError{name, REQUIRED, fmt.Sprintf("%s is required", name)}
+		}
+		return nil
+	}
+
+	if value == "" {
+		return nil
+	}
+
+	switch rule {
+	case "max":
+		max, _ := strconv.Atoi(arg)
+		if utf8.RuneCountInString(value) > max {
+			return &FieldError{name, TOO_LONG, fmt.Sprintf("%s must be at most %d characters", name, max)}
+		}
+	case "email":
+		if !emailFormat.MatchString(value) {
+			return &FieldError{name, INVALID_EMAIL, fmt.Sprintf("%s must be a valid email address", name)}
+		}
+	case "phone":
+		if !phoneFormat.MatchString(value) {
+			return &FieldError{name, INVALID_PHONE, fmt.Sprintf("%s must be an E.164 phone number", name)}
+		}
+	case "country":
+		if !IsCountry(value) {
+			return &FieldError{name, INVALID_COUNTRY, fmt.Sprintf("%s must be an ISO 3166-1 alpha-2 country code", name)}
+		}
+	case "slug":
+		if !slugFormat.MatchString(value) {
+			return &FieldError{name, INVALID_SLUG, fmt.Sprintf("%s may only contain lower case letters, digits and single hyphens", name)}
+		}
+	case "url":
+		if !IsURL(value) {
+			return &FieldError{name, INVALID_URL, fmt.Sprintf("%s must be an http or https URL", name)}
+		}
+	case "timezone":
+		if _, err := time.LoadLocation(value); err != nil || value == "Local" {
+			return &FieldError{name, INVALID_TIMEZONE, fmt.Sprintf("%s must be an IANA time zone like America/Chicago", name)}
+		}
+	case "postal":
+		country := v.FieldByName(arg).String()
+		if !IsPostalCode(value, country) {
+			return &FieldError{name, INVALID_POSTAL_CODE, fmt.Sprintf("%s is not a valid postal code for %s", name, country)}
+		}
+	}
+
+	return nil
+}
+
+/*unchanged reports whether the field rule checks, and the field a postal rule checks it against, are the same in v and old*/
+func unchanged(v reflect.Value, old reflect.Value, field string, rule string) bool {
+	fields := []string{field}
+	if strings.HasPrefix(rule, "postal=") {
+		fields = append(fields, strings.TrimPrefix(rule, "postal="))
+	}
+
+	for _, f := range fields {
+		if v.FieldByName(f).String() != old.FieldByName(f).String() {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*IsURL reports whether s is an absolute http or https URL*/
+func IsURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateUserSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	user := NewUser("pass", "First", "Last", "first@expresso.store", "+15555550123", "1 Main St", "", "Ames", "IA", "50010", "US")
+
+	assert.Nil(Validate(user))
+}
+
+func TestValidateEmptyFields(t *testing.T) {
+	assert := assert.New(t)
+
+	user := NewUser("", "", "", "", "", "", "", "", "", "", "")
+
+	assert.Nil(Validate(user))
+}
+
+func TestValidateUserErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	user := NewUser("pass", "Firstnamethatiswaytoolong", "Last", "not-an-email", "555-0123", "", "", "", "", "5001", "USA")
+	errs := Validate(user)
+
+	assert.Equal(4, len(errs))
+	assert.Equal(&FieldError{"firstName", TOO_LONG, "firstName must be at most 20 characters"}, errs[0])
+	assert.Equal("email", errs[1].Field)
+	assert.Equal(INVALID_EMAIL, errs[1].Code)
+	assert.Equal("phone", errs[2].Field)
+	assert.Equal(INVALID_PHONE, errs[2].Code)
+	assert.Equal("addressCountry", errs[3].Field)
+	assert.Equal(INVALID_COUNTRY, errs[3].Code)
+}
+
+func TestValidatePostalCode(t *testing.T) {
+	assert := assert.New(t)
+
+	roaster := NewRoaster("Name", "", "", "", "", "", "", "5001", "US", "")
+	errs := Validate(roaster)
+
+	assert.Equal(1, len(errs))
+	assert.Equal("addressZip", errs[0].Field)
+	assert.Equal(INVALID_POSTAL_CODE, errs[0].Code)
+
+	roaster.AddressZip = "K1A 0B1"
+	roaster.AddressCountry = "ca"
+	assert.Nil(Validate(roaster))
+
+	roaster.AddressZip = "anything"
+	roaster.AddressCountry = "KE"
+	assert.Nil(Validate(roaster))
+}
+
+func TestValidateRequired(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &struct {
+		Name string `json:"name" validate:"required,max=5"`
+	}{" "}
+	errs := Validate(s)
+
+	assert.Equal(1, len(errs))
+	assert.Equal(REQUIRED, errs[0].Code)
+	assert.Equal("name is required", errs.Error())
+}
+
+func TestValidateChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	old := NewUser("", "First", "", "", "", "", "", "", "", "5001", "USA")
+	user := *old
+	user.FirstName = "Other"
+
+	assert.Nil(ValidateChanges(&user, old))
+
+	user.AddressCountry = "CA"
+	errs := ValidateChanges(&user, old)
+	assert.Equal(1, len(errs))
+	assert.Equal(INVALID_POSTAL_CODE, errs[0].Code)
+
+	user.AddressCountry = "Canada"
+	errs = ValidateChanges(&user, old)
+	assert.Equal(INVALID_COUNTRY, errs[0].Code)
+
+	assert.Equal(1, len(ValidateChanges(&user, (*User)(nil))))
+	assert.Equal(1, len(ValidateChanges(old, (*User)(nil))))
+}
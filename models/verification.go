@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+/*Verification is a pending phone verification code for a user or roaster*/
+type Verification struct {
+	ID        uuid.UUID `json:"id"`
+	Phone     string    `json:"phone"`
+	Code      string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	Attempts  int       `json:"attempts"`
+}
+
+/*VerificationRequest is the body sent to confirm a phone number*/
+type VerificationRequest struct {
+	Code string `json:"code"`
+}
+
+/*NewVerification creates a verification for the user or roaster with the given id*/
+func NewVerification(id uuid.UUID, phone, code string) *Verification {
+	return &Verification{
+		ID:        id,
+		Phone:     phone,
+		Code:      code,
+		CreatedAt: now(),
+		Attempts:  0,
+	}
+}
+
+func VerificationFromSQL(rows *sql.Rows) ([]*Verification, error) {
+	verifications := make([]*Verification, 0)
+
+	for rows.Next() {
+		v := &Verification{}
+
+		rows.Scan(&v.ID, &v.Phone, &v.Code, &v.CreatedAt, &v.Attempts)
+
+		verifications = append(verifications, v)
+	}
+
+	return verifications, nil
+}
+
+/*now is the current time in UTC, truncated to the second like the timestamps MySQL stores*/
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
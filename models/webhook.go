@@ -0,0 +1,264 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+/*Delivery statuses, pending deliveries are attempted until they succeed or run out of attempts*/
+const (
+	DELIVERY_PENDING   = "pending"
+	DELIVERY_SUCCEEDED = "succeeded"
+	DELIVERY_FAILED    = "failed"
+)
+
+/*MinSecretLength is the shortest signing secret a webhook can be given*/
+const MinSecretLength = 16
+
+// Webhook subscribes a URL to a roaster's events. Deliveries are signed with
+// Secret, which is only returned when the webhook is created.
+type Webhook struct {
+	ID        uuid.UUID `json:"id"`
+	RoasterID uuid.UUID `json:"roasterId"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// WebhookRequest is the body of a request to create or update a webhook. A
+// secret is generated when none is given, and Active defaults to true.
+type WebhookRequest struct {
+	URL    string   `json:"url" validate:"required,max=2048,url"`
+	Secret string   `json:"secret" validate:"max=255"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// WebhookDelivery is an event queued for a webhook. Payload is the event as
+// JSON, which is exactly the body that's posted.
+type WebhookDelivery struct {
+	ID            uuid.UUID `json:"id"`
+	WebhookID     uuid.UUID `json:"webhookId"`
+	EventID       uuid.UUID `json:"eventId"`
+	Type          string    `json:"type"`
+	Payload       string    `json:"payload"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	ResponseCode  int       `json:"responseCode"`
+	Error         string    `json:"error"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// privateNets are the addresses a webhook can't be sent to, so a roaster
+// can't use one to reach TownCenter's own network.
+var privateNets = parseNets(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+/*Validate checks the request's tags, its event types and the length of any secret given*/
+func (r *WebhookRequest) Validate() ValidationErrors {
+	errs := Validate(r)
+	if errs == nil {
+		errs = make(ValidationErrors, 0)
+	}
+
+	if r.URL != "" && !hasField(errs, "url") && !IsWebhookURL(r.URL) {
+		errs = append(errs, &FieldError{"url", INVALID_URL, "url must be an https URL on a public host"})
+	}
+
+	if r.Secret != "" && len(r.Secret) < MinSecretLength {
+		errs = append(errs, &FieldError{"secret", TOO_SHORT, fmt.Sprintf("secret must be at least %d characters", MinSecretLength)})
+	}
+
+	if len(r.Events) == 0 {
+		errs = append(errs, &FieldError{"events", REQUIRED, "events is required"})
+	}
+	for i, event := range r.Events {
+		if !contains(EventTypes, event) {
+			name := "events[" + strconv.Itoa(i) + "]"
+			errs = append(errs, &FieldError{name, INVALID_EVENT, fmt.Sprintf("%s must be one of %s", name, strings.Join(EventTypes, ", "))})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// IsWebhookURL checks that u is https and doesn't name a private host. Host
+// names are only resolved when a delivery is sent, so the dispatcher checks
+// the address it connects to as well.
+func IsWebhookURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme != "https" {
+		return false
+	}
+
+	host := parsed.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(strings.Trim(host, "[]"), "."))
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+	return ip == nil || IsPublicIP(ip)
+}
+
+/*IsPublicIP is false for loopback, private, link-local, multicast and other reserved addresses*/
+func IsPublicIP(ip net.IP) bool {
+	for _, n := range privateNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseNets(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+
+	return nets
+}
+
+func hasField(errs ValidationErrors, field string) bool {
+	for _, err := range errs {
+		if err.Field == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+func NewWebhook(roasterID uuid.UUID, r *WebhookRequest) (*Webhook, error) {
+	w := &Webhook{
+		ID:        uuid.NewUUID(),
+		RoasterID: roasterID,
+		Active:    true,
+	}
+
+	err := w.Apply(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+/*Apply sets the webhook's fields from r, keeping the current secret and active flag when r leaves them out*/
+func (w *Webhook) Apply(r *WebhookRequest) error {
+	w.URL = r.URL
+	w.Events = r.Events
+	if r.Active != nil {
+		w.Active = *r.Active
+	}
+
+	if r.Secret != "" {
+		w.Secret = r.Secret
+		return nil
+	}
+	if w.Secret != "" {
+		return nil
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return err
+	}
+
+	w.Secret = secret
+	return nil
+}
+
+func WebhookFromSQL(rows *sql.Rows) ([]*Webhook, error) {
+	webhooks := make([]*Webhook, 0)
+
+	for rows.Next() {
+		w := &Webhook{}
+		var events string
+		rows.Scan(&w.ID, &w.RoasterID, &w.URL, &w.Secret, &events, &w.Active, &w.CreatedAt, &w.UpdatedAt)
+		w.Events = strings.Split(events, ",")
+
+		webhooks = append(webhooks, w)
+	}
+
+	return webhooks, nil
+}
+
+func WebhookDeliveryFromSQL(rows *sql.Rows) ([]*WebhookDelivery, error) {
+	deliveries := make([]*WebhookDelivery, 0)
+
+	for rows.Next() {
+		d := &WebhookDelivery{}
+		rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.Type, &d.Payload, &d.Status, &d.Attempts, &d.ResponseCode, &d.Error, &d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt)
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+// SignWebhook returns the signature of a delivery sent at timestamp, the hex
+// HMAC-SHA256 of "timestamp.body" keyed with the webhook's secret. Receivers
+// should recompute it and reject old timestamps to stop replays.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+/*newSecret returns 32 random bytes as hex*/
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
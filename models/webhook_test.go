@@ -0,0 +1,124 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookRequestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	r := &WebhookRequest{URL: "https://roaster.com/hooks", Events: []string{USER_CREATED, ROASTER_UPDATED}}
+
+	assert.Nil(r.Validate())
+}
+
+func TestWebhookRequestValidateErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	r := &WebhookRequest{URL: "ftp://roaster.com", Secret: "short", Events: []string{USER_CREATED, "order.created"}}
+
+	errs := r.Validate()
+
+	assert.Equal(3, len(errs))
+	assert.Equal("url", errs[0].Field)
+	assert.Equal(INVALID_URL, errs[0].Code)
+	assert.Equal("secret", errs[1].Field)
+	assert.Equal(TOO_SHORT, errs[1].Code)
+	assert.Equal("events[1]", errs[2].Field)
+	assert.Equal(INVALID_EVENT, errs[2].Code)
+}
+
+func TestWebhookRequestValidateRequired(t *testing.T) {
+	assert := assert.New(t)
+
+	errs := (&WebhookRequest{}).Validate()
+
+	assert.Equal(2, len(errs))
+	assert.Equal("url", errs[0].Field)
+	assert.Equal(REQUIRED, errs[0].Code)
+	assert.Equal("events", errs[1].Field)
+	assert.Equal(REQUIRED, errs[1].Code)
+}
+
+func TestNewWebhook(t *testing.T) {
+	assert := assert.New(t)
+
+	roasterID := uuid.NewUUID()
+	w, err := NewWebhook(roasterID, &WebhookRequest{URL: "https://roaster.com/hooks", Events: []string{USER_CREATED}})
+
+	assert.NoError(err)
+	assert.NotNil(w.ID)
+	assert.Equal(roasterID, w.RoasterID)
+	assert.Equal("https://roaster.com/hooks", w.URL)
+	assert.Equal([]string{USER_CREATED}, w.Events)
+	assert.True(w.Active)
+	assert.Equal(64, len(w.Secret))
+}
+
+func TestWebhookApplyKeepsSecret(t *testing.T) {
+	assert := assert.New(t)
+
+	active := false
+	w := &Webhook{Secret: "0123456789abcdef", Active: true}
+	err := w.Apply(&WebhookRequest{URL: "https://roaster.com/new", Events: []string{ROASTER_DELETED}, Active: &active})
+
+	assert.NoError(err)
+	assert.Equal("0123456789abcdef", w.Secret)
+	assert.Equal("https://roaster.com/new", w.URL)
+	assert.False(w.Active)
+
+	err = w.Apply(&WebhookRequest{URL: "https://roaster.com/new", Events: []string{ROASTER_DELETED}, Secret: "fedcba9876543210"})
+
+	assert.NoError(err)
+	assert.Equal("fedcba9876543210", w.Secret)
+	assert.False(w.Active)
+}
+
+func TestSignWebhook(t *testing.T) {
+	assert := assert.New(t)
+
+	signature := SignWebhook("secret", 1500000000, []byte(`{"id":"1"}`))
+
+	assert.Equal("sha256=17e0d98d9787b2e3ad1afc700943da740fa3783673b880c200c5692a58ffaa3a", signature)
+	assert.NotEqual(signature, SignWebhook("secret", 1500000001, []byte(`{"id":"1"}`)))
+	assert.NotEqual(signature, SignWebhook("other", 1500000000, []byte(`{"id":"1"}`)))
+}
+
+func TestWebhookRequestValidateHTTPS(t *testing.T) {
+	assert := assert.New(t)
+
+	errs := (&WebhookRequest{URL: "http://roaster.com/hooks", Events: []string{USER_CREATED}}).Validate()
+
+	assert.Equal(1, len(errs))
+	assert.Equal("url", errs[0].Field)
+	assert.Equal(INVALID_URL, errs[0].Code)
+}
+
+func TestIsWebhookURL(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(IsWebhookURL("https://roaster.com/hooks"))
+	assert.True(IsWebhookURL("https://8.8.8.8:8443/hooks"))
+	assert.True(IsWebhookURL("https://[2001:4860:4860::8888]/hooks"))
+
+	for _, u := range []string{
+		"http://roaster.com/hooks",
+		"https://localhost/hooks",
+		"https://api.localhost./hooks",
+		"https://127.0.0.1/hooks",
+		"https://10.1.2.3:8080/hooks",
+		"https://172.16.0.1/hooks",
+		"https://192.168.1.1/hooks",
+		"https://169.254.169.254/latest/meta-data",
+		"https://0.0.0.0/hooks",
+		"https://[::1]/hooks",
+		"https://[fe80::1]/hooks",
+		"https://[fd00::1]/hooks",
+		"https://[::ffff:127.0.0.1]/hooks",
+	} {
+		assert.False(IsWebhookURL(u), u)
+	}
+}
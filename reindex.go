@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/ghmeier/bloodlines/config"
+	"github.com/ghmeier/bloodlines/gateways"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+// runReindex rebuilds the search index from the user and roaster tables, for
+// records saved before they were indexed or after the terms they're indexed
+// under change:
+//
+//	TownCenter reindex [-kind user|roaster]
+func runReindex(config *config.Root, args []string) error {
+	flags := flag.NewFlagSet("reindex", flag.ContinueOnError)
+	kind := flags.String("kind", "", "only reindex users or roasters")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *kind != "" && *kind != models.SEARCH_USER && *kind != models.SEARCH_ROASTER {
+		return fmt.Errorf("usage: TownCenter reindex [-kind user|roaster]")
+	}
+
+	sql, err := gateways.NewSQL(config.SQL)
+	if err != nil {
+		return err
+	}
+
+	export := helpers.NewExport(sql)
+	search := helpers.NewSearch(sql)
+
+	if *kind != models.SEARCH_ROASTER {
+		count := 0
+		err = export.Users(nil, func(user *models.User) error {
+			count++
+			return search.Index(models.SEARCH_USER, user.ID.String(), user.SearchTerms())
+		})
+		fmt.Printf("%d users indexed\n", count)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *kind != models.SEARCH_USER {
+		count := 0
+		err = export.Roasters(nil, func(roaster *models.Roaster) error {
+			count++
+			return search.Index(models.SEARCH_ROASTER, roaster.ID.String(), roaster.SearchTerms())
+		})
+		fmt.Printf("%d roasters indexed\n", count)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,428 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+	"gopkg.in/gin-gonic/gin.v1"
+
+	m "github.com/ghmeier/bloodlines/models"
+	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/models"
+)
+
+/*OpenAPIVersion is the version of the OpenAPI specification the document follows*/
+const OpenAPIVersion = "3.0.3"
+
+// operation documents a route. body is a value of the type sent as JSON and
+// data a value of the type returned in the response's data, nil when there's
+// none. A form body is sent as multipart, and a raw body or data is sent as
+// it is rather than as JSON.
+type operation struct {
+	summary string
+	query   []string
+	body    interface{}
+	data    interface{}
+	public  bool
+}
+
+/*form is a multipart body, its first field is the file*/
+type form []string
+
+/*raw is a body or response that isn't JSON, in one of the listed media types*/
+type raw []string
+
+var (
+	pagingQuery = []string{"offset", "limit"}
+	filterQuery = []string{"offset", "limit", "createdAfter", "createdBefore", "updatedSince"}
+	exportQuery = []string{"fields", "format", "createdAfter", "createdBefore", "updatedSince"}
+	avatarQuery = []string{"format", "size"}
+	exportMedia = raw{"text/csv", "application/x-ndjson"}
+	avatarMedia = raw{"image/svg+xml", "image/png"}
+)
+
+// operations documents every route, keyed by its method and path as it's
+// registered with gin or listed in dispatched. Routes that only dispatch to
+// static routes are nil. TestOpenAPICoversRoutes fails for any route
+// without an entry.
+var operations = map[string]*operation{
+	"GET /api/openapi.json": {summary: "Returns this OpenAPI document", data: raw{"application/json"}, public: true},
+
+	"POST /api/auth/login": {summary: "Logs in with an email and passHash, the token is returned in the X-Auth header", body: models.User{}, data: models.User{}, public: true},
+
+	"POST /api/user":                                {summary: "Creates a user, the token is returned in the X-Auth header", body: models.User{}, data: models.User{}, public: true},
+	"GET /api/user":                                 {summary: "Returns the user the token belongs to", data: models.User{}},
+	"GET /api/user/search":                          {summary: "Returns the users whose name, email or phone match q, best match first, admins only", query: []string{"q", "offset", "limit"}, data: []*models.User{}},
+	"GET /api/user/email":                           {summary: "Returns the user with the given email, admins only", query: []string{"email"}, data: models.User{}},
+	"POST /api/user/batch":                          {summary: "Returns the users with the given IDs", body: models.BatchRequest{}, data: map[string]*models.UserResult{}},
+	"GET /api/user/:userId":                         {summary: "Returns a user", data: models.User{}},
+	"POST /api/user/:userId":                        nil,
+	"PUT /api/user/:userId":                         {summary: "Updates a user", body: models.User{}, data: models.User{}},
+	"DELETE /api/user/:userId":                      {summary: "Deletes a user"},
+	"POST /api/user/:userId/photo":                  {summary: "Uploads the user's profile photo", body: form{"profile"}},
+	"DELETE /api/user/:userId/photo":                {summary: "Removes the user's profile photo"},
+	"POST /api/user/:userId/photo/upload":           {summary: "Returns a URL to upload the user's photo straight to S3", body: models.UploadRequest{}, data: models.PresignedUpload{}},
+	"POST /api/user/:userId/photo/complete":         {summary: "Makes the photo uploaded to S3 the user's profile photo", body: models.UploadComplete{}},
+	"POST /api/user/:userId/phone/code":             {summary: "Texts a verification code to the user's phone"},
+	"POST /api/user/:userId/phone/verify":           {summary: "Marks the user's phone as verified", body: models.VerificationRequest{}},
+	"GET /api/user/:userId/addresses":               {summary: "Returns the user's addresses", data: []*models.Address{}},
+	"POST /api/user/:userId/addresses":              {summary: "Adds an address to the user's address book", body: models.Address{}, data: models.Address{}},
+	"GET /api/user/:userId/addresses/:addressId":    {summary: "Returns one of the user's addresses", data: models.Address{}},
+	"PUT /api/user/:userId/addresses/:addressId":    {summary: "Updates one of the user's addresses", body: models.Address{}, data: models.Address{}},
+	"DELETE /api/user/:userId/addresses/:addressId": {summary: "Removes one of the user's addresses"},
+
+	"POST /api/roaster":                           {summary: "Creates a pending roaster owned by userId", body: handlers.RoasterInfo{}, data: models.Roaster{}},
+	"GET /api/roaster":                            {summary: "Returns a page of roasters with the given status, active by default", query: append([]string{"status"}, filterQuery...), data: []*models.Roaster{}},
+	"GET /api/roaster/nearby":                     {summary: "Returns the active roasters within radiusKm of lat and lng, closest first", query: []string{"lat", "lng", "radiusKm", "offset", "limit"}, data: []*models.NearbyRoaster{}},
+	"GET /api/roaster/search":                     {summary: "Returns the active roasters whose name or address match q, best match first", query: []string{"q", "offset", "limit"}, data: []*models.Roaster{}},
+	"POST /api/roaster/batch":                     {summary: "Returns the roasters with the given IDs", body: models.BatchRequest{}, data: map[string]*models.RoasterResult{}},
+	"GET /api/roaster/:roasterId":                 {summary: "Returns a roaster", data: models.Roaster{}},
+	"POST /api/roaster/:roasterId":                nil,
+	"PUT /api/roaster/:roasterId":                 {summary: "Updates a roaster", body: models.Roaster{}, data: models.Roaster{}},
+	"DELETE /api/roaster/:roasterId":              {summary: "Deletes a roaster"},
+	"POST /api/roaster/:roasterId/photo":          {summary: "Uploads the roaster's profile photo", body: form{"profile"}},
+	"DELETE /api/roaster/:roasterId/photo":        {summary: "Removes the roaster's profile photo"},
+	"POST /api/roaster/:roasterId/photo/upload":   {summary: "Returns a URL to upload the roaster's photo straight to S3", body: models.UploadRequest{}, data: models.PresignedUpload{}},
+	"POST /api/roaster/:roasterId/photo/complete": {summary: "Makes the photo uploaded to S3 the roaster's profile photo", body: models.UploadComplete{}},
+	"GET /api/roaster/:roasterId/user":            {summary: "Returns the user who owns the roaster", data: models.User{}},
+	"POST /api/roaster/:roasterId/phone/code":     {summary: "Texts a verification code to the roaster's phone"},
+	"POST /api/roaster/:roasterId/phone/verify":   {summary: "Marks the roaster's phone as verified", body: models.VerificationRequest{}},
+
+	"GET /api/roaster/:roasterId/profile":             {summary: "Returns the roaster's storefront profile", data: models.RoasterProfile{}},
+	"PUT /api/roaster/:roasterId/profile":             {summary: "Updates the roaster's storefront profile", body: models.RoasterProfile{}, data: models.RoasterProfile{}},
+	"GET /api/roaster/:roasterId/gallery":             {summary: "Returns the roaster's gallery in order", data: []*models.GalleryImage{}},
+	"POST /api/roaster/:roasterId/gallery":            {summary: "Adds an image to the end of the roaster's gallery", body: form{"image", "caption"}, data: models.GalleryImage{}},
+	"PUT /api/roaster/:roasterId/gallery":             {summary: "Reorders the roaster's gallery", body: handlers.GalleryOrder{}, data: []*models.GalleryImage{}},
+	"PUT /api/roaster/:roasterId/gallery/:imageId":    {summary: "Changes an image's caption", body: models.GalleryImage{}, data: models.GalleryImage{}},
+	"DELETE /api/roaster/:roasterId/gallery/:imageId": {summary: "Removes an image from the roaster's gallery"},
+
+	"GET /api/roaster/:roasterId/documents":  {summary: "Returns the roaster's verification documents", data: []*models.RoasterDocument{}},
+	"POST /api/roaster/:roasterId/documents": {summary: "Uploads a verification document of the given kind", body: form{"document", "kind"}, data: models.RoasterDocument{}},
+	"GET /api/roaster/:roasterId/status":     {summary: "Returns the roaster's status changes", data: []*models.StatusChange{}},
+	"POST /api/roaster/:roasterId/submit":    {summary: "Submits a pending roaster for review", body: models.StatusRequest{}, data: models.Roaster{}},
+	"POST /api/roaster/:roasterId/approve":   {summary: "Activates a roaster under review, admins only", body: models.StatusRequest{}, data: models.Roaster{}},
+	"POST /api/roaster/:roasterId/reject":    {summary: "Sends a roaster under review back to pending, admins only", body: models.StatusRequest{}, data: models.Roaster{}},
+	"POST /api/roaster/:roasterId/suspend":   {summary: "Suspends an active roaster, admins only", body: models.StatusRequest{}, data: models.Roaster{}},
+	"POST /api/roaster/:roasterId/reinstate": {summary: "Reactivates a suspended roaster, admins only", body: models.StatusRequest{}, data: models.Roaster{}},
+	"POST /api/roaster/:roasterId/close":     {summary: "Closes the roaster for good", body: models.StatusRequest{}, data: models.Roaster{}},
+
+	"GET /api/roaster/:roasterId/transfer":    {summary: "Returns the roaster's pending ownership transfer", data: models.Transfer{}},
+	"POST /api/roaster/:roasterId/transfer":   {summary: "Offers the roaster to the user with the given email", body: models.TransferRequest{}, data: models.Transfer{}},
+	"DELETE /api/roaster/:roasterId/transfer": {summary: "Cancels the roaster's pending ownership transfer", data: models.Transfer{}},
+
+	"GET /api/roaster/:roasterId/webhooks":                                           {summary: "Returns the roaster's webhooks", data: []*models.Webhook{}},
+	"POST /api/roaster/:roasterId/webhooks":                                          {summary: "Adds a webhook for the roaster", body: models.WebhookRequest{}, data: models.Webhook{}},
+	"GET /api/roaster/:roasterId/webhooks/:webhookId":                                {summary: "Returns one of the roaster's webhooks", data: models.Webhook{}},
+	"PUT /api/roaster/:roasterId/webhooks/:webhookId":                                {summary: "Updates one of the roaster's webhooks", body: models.WebhookRequest{}, data: models.Webhook{}},
+	"DELETE /api/roaster/:roasterId/webhooks/:webhookId":                             {summary: "Removes one of the roaster's webhooks"},
+	"GET /api/roaster/:roasterId/webhooks/:webhookId/deliveries":                     {summary: "Returns a page of the webhook's deliveries, newest first", query: pagingQuery, data: []*models.WebhookDelivery{}},
+	"POST /api/roaster/:roasterId/webhooks/:webhookId/deliveries/:deliveryId/replay": {summary: "Sends a delivery again", data: models.WebhookDelivery{}},
+
+	"GET /api/transfer/:token":          {summary: "Returns the transfer offered with the token", data: models.Transfer{}},
+	"POST /api/transfer/:token/accept":  {summary: "Accepts the transfer, making the caller the roaster's owner", data: models.Transfer{}},
+	"POST /api/transfer/:token/decline": {summary: "Declines the transfer", data: models.Transfer{}},
+
+	"POST /api/import":                  {summary: "Starts importing the users or roasters in the body, admins only", query: []string{"kind", "format", "dryRun"}, body: raw{"text/csv", "application/x-ndjson"}, data: models.Import{}},
+	"GET /api/import/:importId":         {summary: "Returns an import's progress, admins only", data: models.Import{}},
+	"POST /api/import/:importId/resume": {summary: "Resumes an interrupted import, admins only", data: models.Import{}},
+
+	"GET /api/export/user":    {summary: "Streams every user as CSV or JSON lines, admins only", query: exportQuery, data: exportMedia},
+	"GET /api/export/roaster": {summary: "Streams every roaster as CSV or JSON lines, admins only", query: append([]string{"status"}, exportQuery...), data: exportMedia},
+
+	"GET /api/public/roaster/:slug":        {summary: "Returns an active roaster's storefront, redirecting old slugs to the current one", data: models.PublicRoaster{}, public: true},
+	"GET /api/public/roaster/:slug/avatar": {summary: "Returns the roaster's generated avatar", query: avatarQuery, data: avatarMedia, public: true},
+	"GET /api/public/user/:userId/avatar":  {summary: "Returns the user's generated avatar", query: avatarQuery, data: avatarMedia, public: true},
+
+	"POST /api/reset":        {summary: "Emails a password reset token to the user with the given email", query: []string{"email"}, data: m.Receipt{}, public: true},
+	"GET /api/reset/:token":  {summary: "Returns a password reset token", data: models.Token{}, public: true},
+	"POST /api/reset/:token": {summary: "Sets the password of the token's user", body: models.ResetRequest{}, public: true},
+}
+
+/*queryTypes are the schemas of the query parameters that aren't strings*/
+var queryTypes = map[string]map[string]interface{}{
+	"offset":        {"type": "integer", "minimum": 0},
+	"limit":         {"type": "integer", "minimum": 1},
+	"size":          {"type": "integer"},
+	"lat":           {"type": "number"},
+	"lng":           {"type": "number"},
+	"radiusKm":      {"type": "number"},
+	"dryRun":        {"type": "boolean"},
+	"createdAfter":  {"type": "string", "format": "date-time"},
+	"createdBefore": {"type": "string", "format": "date-time"},
+	"updatedSince":  {"type": "string", "format": "date-time"},
+}
+
+/*openAPI serves the OpenAPI document for every route*/
+func (tc *TownCenter) openAPI(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, tc.OpenAPI())
+}
+
+/*routes returns every route served, both those registered with gin and those dispatched to*/
+func (tc *TownCenter) routes() gin.RoutesInfo {
+	routes := tc.router.Routes()
+	return append(routes, dispatched...)
+}
+
+// OpenAPI generates the OpenAPI document from the routes the router serves,
+// with their schemas generated from the types in operations. Routes
+// without an entry are left out.
+func (tc *TownCenter) OpenAPI() map[string]interface{} {
+	s := make(schemas)
+	paths := make(map[string]map[string]interface{})
+	for _, route := range tc.routes() {
+		op := operations[route.Method+" "+route.Path]
+		if op == nil {
+			continue
+		}
+
+		path, params := openAPIPath(route.Path)
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
+		}
+		paths[path][strings.ToLower(route.Method)] = s.operation(op, params)
+	}
+
+	return map[string]interface{}{
+		"openapi": OpenAPIVersion,
+		"info": map[string]interface{}{
+			"title":       "TownCenter",
+			"description": "The user service for Expresso. Responses are wrapped in an envelope with success, msg and data.",
+			"version":     "1.0.0",
+		},
+		"paths":    paths,
+		"security": []map[string][]string{{"token": {}}},
+		"components": map[string]interface{}{
+			"schemas": s,
+			"securitySchemes": map[string]interface{}{
+				"token": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-Auth"},
+			},
+			"responses": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"description": "The request failed, data lists the invalid fields of a 400",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": envelopeSchema(false, s.of(reflect.TypeOf(models.ValidationErrors{})))},
+					},
+				},
+			},
+		},
+	}
+}
+
+/*openAPIPath turns gin's :param path segments into OpenAPI's {param}, returning the path parameters*/
+func openAPIPath(path string) (string, []interface{}) {
+	params := make([]interface{}, 0)
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+/*envelopeSchema is the schema of a response wrapped in TownCenter's envelope with data*/
+func envelopeSchema(success bool, data map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{
+		"success": map[string]interface{}{"type": "boolean", "enum": []bool{success}},
+	}
+	if !success {
+		properties["msg"] = map[string]interface{}{"type": "string"}
+	}
+	if data != nil {
+		properties["data"] = data
+	}
+
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// schemas are the schemas of the named types operations use, keyed by the
+// type's name. Each is added the first time it's referenced.
+type schemas map[string]interface{}
+
+func (s schemas) operation(op *operation, params []interface{}) map[string]interface{} {
+	for _, name := range op.query {
+		schema, ok := queryTypes[name]
+		if !ok {
+			schema = map[string]interface{}{"type": "string"}
+		}
+		params = append(params, map[string]interface{}{"name": name, "in": "query", "schema": schema})
+	}
+
+	o := map[string]interface{}{
+		"summary": op.summary,
+		"responses": map[string]interface{}{
+			"200":     s.response(op.data),
+			"default": map[string]interface{}{"$ref": "#/components/responses/Error"},
+		},
+	}
+	if len(params) > 0 {
+		o["parameters"] = params
+	}
+	if op.body != nil {
+		o["requestBody"] = s.body(op.body)
+	}
+	if op.public {
+		o["security"] = []interface{}{}
+	}
+
+	return o
+}
+
+func (s schemas) response(data interface{}) map[string]interface{} {
+	if media, ok := data.(raw); ok {
+		return map[string]interface{}{"description": "OK", "content": binary(media)}
+	}
+
+	var schema map[string]interface{}
+	if data != nil {
+		schema = s.of(reflect.TypeOf(data))
+	}
+
+	return map[string]interface{}{
+		"description": "OK",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": envelopeSchema(true, schema)},
+		},
+	}
+}
+
+func (s schemas) body(body interface{}) map[string]interface{} {
+	switch body := body.(type) {
+	case raw:
+		return map[string]interface{}{"required": true, "content": binary(body)}
+	case form:
+		properties := map[string]interface{}{
+			body[0]: map[string]interface{}{"type": "string", "format": "binary"},
+		}
+		for _, field := range body[1:] {
+			properties[field] = map[string]interface{}{"type": "string"}
+		}
+
+		schema := map[string]interface{}{"type": "object", "properties": properties, "required": []string{body[0]}}
+		return map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"multipart/form-data": map[string]interface{}{"schema": schema}},
+		}
+	}
+
+	return map[string]interface{}{
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": s.of(reflect.TypeOf(body))},
+		},
+	}
+}
+
+func binary(media raw) map[string]interface{} {
+	content := make(map[string]interface{})
+	for _, t := range media {
+		content[t] = map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}
+	}
+
+	return content
+}
+
+var (
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	timeType = reflect.TypeOf(time.Time{})
+	dateType = reflect.TypeOf(models.Date{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+/*of returns the schema of t as encoding/json writes it, named structs are referenced from components*/
+func (s schemas) of(t reflect.Type) map[string]interface{} {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	switch t {
+	case uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case dateType:
+		return map[string]interface{}{"type": "string", "format": "date"}
+	case rawType:
+		return map[string]interface{}{}
+	}
+
+	var schema map[string]interface{}
+	switch t.Kind() {
+	case reflect.Bool:
+		schema = map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema = map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		schema = map[string]interface{}{"type": "number"}
+	case reflect.String:
+		schema = map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		schema = map[string]interface{}{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		schema = map[string]interface{}{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if _, ok := s[t.Name()]; !ok {
+			//Claim the name first so types that refer to themselves end
+			s[t.Name()] = nil
+			s[t.Name()] = s.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		schema = map[string]interface{}{}
+	}
+
+	if nullable {
+		schema["nullable"] = true
+	}
+
+	return schema
+}
+
+/*object returns the schema of struct t, with the fields of embedded structs inlined as encoding/json does*/
+func (s schemas) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	s.properties(t, properties)
+
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func (s schemas) properties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+
+		embedded := field.Type
+		if embedded.Kind() == reflect.Ptr {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && name == "" && embedded.Kind() == reflect.Struct {
+			s.properties(embedded, properties)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.of(field.Type)
+	}
+}
@@ -2,23 +2,38 @@ package router
 
 import (
 	"fmt"
+	"net/http"
+	"os"
 
 	"gopkg.in/alexcesaro/statsd.v2"
 	"gopkg.in/gin-gonic/gin.v1"
 
-	"github.com/ghmeier/bloodlines/config"
 	"github.com/ghmeier/bloodlines/gateways"
 	h "github.com/ghmeier/bloodlines/handlers"
 	c "github.com/ghmeier/coinage/gateways"
+	"github.com/jakelong95/TownCenter/config"
+	tg "github.com/jakelong95/TownCenter/gateways"
 	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
 )
 
 /* TownCenter is the main server object which routes the requests */
 type TownCenter struct {
-	router  *gin.Engine
-	user    handlers.UserI
-	roaster handlers.RoasterI
-	reset   handlers.ResetI
+	router     *gin.Engine
+	user       handlers.UserI
+	roaster    handlers.RoasterI
+	reset      handlers.ResetI
+	phone      handlers.PhoneI
+	address    handlers.AddressI
+	public     handlers.PublicI
+	storefront handlers.StorefrontI
+	onboarding handlers.OnboardingI
+	transfer   handlers.TransferI
+	imports    handlers.ImportI
+	exports    handlers.ExportI
+	webhooks   handlers.WebhookI
+	idempotent handlers.IdempotencyI
 }
 
 /* Creates a ready-to-run TownCenter struct from the given config */
@@ -38,7 +53,14 @@ func New(config *config.Root) (*TownCenter, error) {
 		fmt.Println(err.Error())
 	}
 
-	s3 := gateways.NewS3(config.S3)
+	err = loadCentroids(os.Getenv(models.CentroidsFile))
+	if err != nil {
+		fmt.Println("ERROR: could not load postal code centroids.")
+		fmt.Println(err.Error())
+		return nil, err
+	}
+
+	s3 := tg.NewS3(config.S3)
 
 	bloodlines := gateways.NewBloodlines(config.Bloodlines)
 	coinage := c.NewCoinage(config.Coinage)
@@ -52,19 +74,44 @@ func New(config *config.Root) (*TownCenter, error) {
 	}
 
 	tc := &TownCenter{
-		user:    handlers.NewUser(ctx),
-		roaster: handlers.NewRoaster(ctx),
-		reset:   handlers.NewReset(ctx),
+		user:       handlers.NewUser(ctx),
+		roaster:    handlers.NewRoaster(ctx),
+		reset:      handlers.NewReset(ctx),
+		phone:      handlers.NewPhone(ctx),
+		address:    handlers.NewAddress(ctx),
+		public:     handlers.NewPublic(ctx),
+		storefront: handlers.NewStorefront(ctx),
+		onboarding: handlers.NewOnboarding(ctx),
+		transfer:   handlers.NewTransfer(ctx),
+		imports:    handlers.NewImport(ctx),
+		exports:    handlers.NewExport(ctx),
+		webhooks:   handlers.NewWebhook(ctx),
+		idempotent: handlers.NewIdempotency(ctx),
 	}
 
 	InitRouter(tc)
 
+	//Pick back up any imports that were interrupted by a restart
+	importer := helpers.NewImporter(sql, s3, coinage, bloodlines)
+	go importer.Watch(helpers.ImportStale)
+
+	//Publish the events saved alongside changes, they wait in the outbox until RabbitMQ is configured
+	if config.Rabbit.Host != "" {
+		relay := helpers.NewRelay(sql, tg.NewAMQP(config.Rabbit))
+		go relay.Watch(helpers.OutboxInterval)
+	}
+
+	//Send the webhook deliveries queued alongside changes
+	dispatcher := helpers.NewDispatcher(sql)
+	go dispatcher.Watch(helpers.WebhookInterval)
+
 	return tc, nil
 }
 
 func InitRouter(tc *TownCenter) {
 	tc.router = gin.Default()
 	tc.router.Use(h.GetCors())
+	tc.router.GET("/api/openapi.json", tc.openAPI)
 
 	authenticate := tc.router.Group("/api/auth")
 	{
@@ -75,27 +122,104 @@ func InitRouter(tc *TownCenter) {
 	user := tc.router.Group("/api/user")
 	{
 		user.Use(tc.user.Time())
-		user.POST("", tc.user.New)
+		user.POST("", tc.idempotent.Idempotent(), tc.user.New)
 		user.Use(tc.user.GetJWT())
 		user.GET("", tc.user.ViewByToken)
 		//user.GET("/", tc.user.ViewAll)
 		user.PUT("/:userId", tc.user.Update)
 		user.DELETE("/:userId", tc.user.Delete)
-		user.GET("/:userId", tc.user.View)
+		user.GET("/:userId", tc.userView)
+		user.POST("/:userId", tc.userPost)
 		user.POST("/:userId/photo", tc.user.Upload)
+		user.DELETE("/:userId/photo", tc.user.DeletePhoto)
+		user.POST("/:userId/photo/upload", tc.user.PresignPhoto)
+		user.POST("/:userId/photo/complete", tc.user.CompletePhoto)
+		user.POST("/:userId/phone/code", tc.phone.RequestUser)
+		user.POST("/:userId/phone/verify", tc.phone.VerifyUser)
+		user.GET("/:userId/addresses", tc.address.ViewAll)
+		user.POST("/:userId/addresses", tc.address.New)
+		user.GET("/:userId/addresses/:addressId", tc.address.View)
+		user.PUT("/:userId/addresses/:addressId", tc.address.Update)
+		user.DELETE("/:userId/addresses/:addressId", tc.address.Delete)
 	}
 
 	roaster := tc.router.Group("/api/roaster")
 	{
 		roaster.Use(tc.roaster.GetJWT())
 		roaster.Use(tc.roaster.Time())
-		roaster.POST("", tc.roaster.New)
+		roaster.POST("", tc.idempotent.Idempotent(), tc.roaster.New)
 		roaster.GET("", tc.roaster.ViewAll)
 		roaster.PUT("/:roasterId", tc.roaster.Update)
 		roaster.DELETE("/:roasterId", tc.roaster.Delete)
-		roaster.GET("/:roasterId", tc.roaster.View)
+		roaster.GET("/:roasterId", tc.roasterView)
+		roaster.POST("/:roasterId", tc.roasterPost)
 		roaster.POST("/:roasterId/photo", tc.roaster.Upload)
+		roaster.DELETE("/:roasterId/photo", tc.roaster.DeletePhoto)
+		roaster.POST("/:roasterId/photo/upload", tc.roaster.PresignPhoto)
+		roaster.POST("/:roasterId/photo/complete", tc.roaster.CompletePhoto)
 		roaster.GET("/:roasterId/user", tc.user.ViewByRoaster)
+		roaster.POST("/:roasterId/phone/code", tc.phone.RequestRoaster)
+		roaster.POST("/:roasterId/phone/verify", tc.phone.VerifyRoaster)
+		roaster.GET("/:roasterId/profile", tc.storefront.ViewProfile)
+		roaster.PUT("/:roasterId/profile", tc.storefront.UpdateProfile)
+		roaster.GET("/:roasterId/gallery", tc.storefront.ViewGallery)
+		roaster.POST("/:roasterId/gallery", tc.storefront.AddImage)
+		roaster.PUT("/:roasterId/gallery", tc.storefront.OrderGallery)
+		roaster.PUT("/:roasterId/gallery/:imageId", tc.storefront.UpdateImage)
+		roaster.DELETE("/:roasterId/gallery/:imageId", tc.storefront.DeleteImage)
+		roaster.GET("/:roasterId/documents", tc.onboarding.ViewDocuments)
+		roaster.POST("/:roasterId/documents", tc.onboarding.UploadDocument)
+		roaster.GET("/:roasterId/status", tc.onboarding.History)
+		roaster.POST("/:roasterId/submit", tc.onboarding.Submit)
+		roaster.POST("/:roasterId/approve", tc.onboarding.Approve)
+		roaster.POST("/:roasterId/reject", tc.onboarding.Reject)
+		roaster.POST("/:roasterId/suspend", tc.onboarding.Suspend)
+		roaster.POST("/:roasterId/reinstate", tc.onboarding.Reinstate)
+		roaster.POST("/:roasterId/close", tc.onboarding.Close)
+		roaster.GET("/:roasterId/transfer", tc.transfer.View)
+		roaster.POST("/:roasterId/transfer", tc.transfer.Initiate)
+		roaster.DELETE("/:roasterId/transfer", tc.transfer.Cancel)
+		roaster.GET("/:roasterId/webhooks", tc.webhooks.ViewAll)
+		roaster.POST("/:roasterId/webhooks", tc.webhooks.New)
+		roaster.GET("/:roasterId/webhooks/:webhookId", tc.webhooks.View)
+		roaster.PUT("/:roasterId/webhooks/:webhookId", tc.webhooks.Update)
+		roaster.DELETE("/:roasterId/webhooks/:webhookId", tc.webhooks.Delete)
+		roaster.GET("/:roasterId/webhooks/:webhookId/deliveries", tc.webhooks.Deliveries)
+		roaster.POST("/:roasterId/webhooks/:webhookId/deliveries/:deliveryId/replay", tc.webhooks.Replay)
+	}
+
+	transfer := tc.router.Group("/api/transfer")
+	{
+		transfer.Use(tc.transfer.GetJWT())
+		transfer.Use(tc.transfer.Time())
+		transfer.GET("/:token", tc.transfer.ViewByToken)
+		transfer.POST("/:token/accept", tc.transfer.Accept)
+		transfer.POST("/:token/decline", tc.transfer.Decline)
+	}
+
+	imports := tc.router.Group("/api/import")
+	{
+		imports.Use(tc.imports.GetJWT())
+		imports.Use(tc.imports.Time())
+		imports.POST("", tc.imports.New)
+		imports.GET("/:importId", tc.imports.View)
+		imports.POST("/:importId/resume", tc.imports.Resume)
+	}
+
+	exports := tc.router.Group("/api/export")
+	{
+		exports.Use(tc.exports.GetJWT())
+		exports.Use(tc.exports.Time())
+		exports.GET("/user", tc.exports.Users)
+		exports.GET("/roaster", tc.exports.Roasters)
+	}
+
+	public := tc.router.Group("/api/public")
+	{
+		public.Use(tc.public.Time())
+		public.GET("/roaster/:slug", tc.public.ViewRoaster)
+		public.GET("/roaster/:slug/avatar", tc.public.RoasterAvatar)
+		public.GET("/user/:userId/avatar", tc.public.UserAvatar)
 	}
 
 	reset := tc.router.Group("/api/reset")
@@ -108,6 +232,88 @@ func InitRouter(tc *TownCenter) {
 	}
 }
 
+// dispatched are the static routes gin can't register next to a wildcard,
+// which the functions below dispatch to instead. Add a route here when
+// adding a case to them so it's documented.
+var dispatched = gin.RoutesInfo{
+	{Method: "GET", Path: "/api/roaster/nearby"},
+	{Method: "GET", Path: "/api/roaster/search"},
+	{Method: "GET", Path: "/api/user/search"},
+	{Method: "GET", Path: "/api/user/email"},
+	{Method: "POST", Path: "/api/roaster/batch"},
+	{Method: "POST", Path: "/api/user/batch"},
+}
+
+// roasterView serves GET /api/roaster/:roasterId. gin can't register static
+// routes like /api/roaster/nearby next to a wildcard, so they're dispatched here.
+func (tc *TownCenter) roasterView(ctx *gin.Context) {
+	switch ctx.Param("roasterId") {
+	case "nearby":
+		tc.roaster.Nearby(ctx)
+	case "search":
+		tc.roaster.Search(ctx)
+	default:
+		tc.roaster.View(ctx)
+	}
+}
+
+// userView serves GET /api/user/:userId, dispatching static routes the same
+// way as roasterView.
+func (tc *TownCenter) userView(ctx *gin.Context) {
+	switch ctx.Param("userId") {
+	case "search":
+		tc.user.Search(ctx)
+	case "email":
+		tc.user.ViewByEmail(ctx)
+	default:
+		tc.user.View(ctx)
+	}
+}
+
+// roasterPost serves POST /api/roaster/:roasterId, where the only route is
+// the static /api/roaster/batch.
+func (tc *TownCenter) roasterPost(ctx *gin.Context) {
+	switch ctx.Param("roasterId") {
+	case "batch":
+		tc.roaster.Batch(ctx)
+	default:
+		ctx.AbortWithStatus(http.StatusNotFound)
+	}
+}
+
+// userPost serves POST /api/user/:userId, where the only route is the static
+// /api/user/batch.
+func (tc *TownCenter) userPost(ctx *gin.Context) {
+	switch ctx.Param("userId") {
+	case "batch":
+		tc.user.Batch(ctx)
+	default:
+		ctx.AbortWithStatus(http.StatusNotFound)
+	}
+}
+
+/*loadCentroids adds the GeoNames postal codes in the file at path to the geocoding dataset, if there is one*/
+func loadCentroids(path string) error {
+	if path == "" {
+		fmt.Println("WARNING: no " + models.CentroidsFile + " file, only the bundled sample of postal codes can be geocoded")
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	count, err := models.LoadCentroids(f)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Loaded %d postal code centroids\n", count)
+	return nil
+}
+
 /* Starts the TownCenter server */
 func (tc *TownCenter) Start(port string) {
 	tc.router.Run(port)
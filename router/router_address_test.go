@@ -0,0 +1,218 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+func TestAddressViewAllSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.NewUUID()
+	tc, addressMock, _ := mockAddress()
+	addressMock.On("GetByUser", id.String()).Return(make([]*models.Address, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/user/"+id.String()+"/addresses", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestAddressNewFirstIsDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: uuid.NewUUID()}
+	tc, addressMock, userMock := mockAddress()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	userMock.On("SetAddress", user.ID.String(), mock.AnythingOfType("*models.Address")).Return(nil)
+	addressMock.On("GetByUser", user.ID.String()).Return(make([]*models.Address, 0), nil)
+	addressMock.On("Insert", mock.AnythingOfType("*models.Address")).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	body := []byte(`{"label":"Home","addressLine1":"1 Main St","addressCity":"Ames","addressZip":"50010","addressCountry":"US"}`)
+	request, _ := http.NewRequest("POST", "/api/user/"+user.ID.String()+"/addresses", bytes.NewReader(body))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	address := addressMock.Calls[1].Arguments.Get(0).(*models.Address)
+	assert.Equal(user.ID, address.UserID)
+	assert.True(address.DefaultShipping)
+	assert.True(address.DefaultBilling)
+	userMock.AssertCalled(t, "SetAddress", user.ID.String(), address)
+}
+
+func TestAddressNewNotDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: uuid.NewUUID()}
+	tc, addressMock, userMock := mockAddress()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	addressMock.On("GetByUser", user.ID.String()).Return([]*models.Address{getAddress(user.ID)}, nil)
+	addressMock.On("Insert", mock.AnythingOfType("*models.Address")).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	body := []byte(`{"label":"Work","addressLine1":"1 Main St","addressCity":"Ames","addressCountry":"US"}`)
+	request, _ := http.NewRequest("POST", "/api/user/"+user.ID.String()+"/addresses", bytes.NewReader(body))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	userMock.AssertNotCalled(t, "SetAddress", mock.Anything, mock.Anything)
+}
+
+func TestAddressNewInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.NewUUID()
+	tc, _, _ := mockAddress()
+
+	recorder := httptest.NewRecorder()
+	body := []byte(`{"addressLine1":"1 Main St","addressZip":"abc","addressCountry":"US"}`)
+	request, _ := http.NewRequest("POST", "/api/user/"+id.String()+"/addresses", bytes.NewReader(body))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	assert.Contains(recorder.Body.String(), models.REQUIRED)
+	assert.Contains(recorder.Body.String(), models.INVALID_POSTAL_CODE)
+}
+
+func TestAddressNewNoUser(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.NewUUID()
+	tc, _, userMock := mockAddress()
+	userMock.On("GetByID", id.String()).Return(nil, nil)
+
+	recorder := httptest.NewRecorder()
+	body := []byte(`{"addressLine1":"1 Main St","addressCity":"Ames","addressCountry":"US"}`)
+	request, _ := http.NewRequest("POST", "/api/user/"+id.String()+"/addresses", bytes.NewReader(body))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
+func TestAddressViewOtherUser(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	address := getAddress(uuid.NewUUID())
+	tc, addressMock, _ := mockAddress()
+	addressMock.On("GetByID", address.ID.String()).Return(address, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/user/"+uuid.New()+"/addresses/"+address.ID.String(), nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
+func TestAddressUpdateSyncsDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	address := getAddress(uuid.NewUUID())
+	address.DefaultShipping = true
+	tc, addressMock, userMock := mockAddress()
+	addressMock.On("GetByID", address.ID.String()).Return(address, nil)
+	addressMock.On("Update", mock.AnythingOfType("*models.Address"), address.ID.String()).Return(nil)
+	userMock.On("SetAddress", address.UserID.String(), mock.AnythingOfType("*models.Address")).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	body := []byte(`{"addressLine1":"2 Main St"}`)
+	request, _ := http.NewRequest("PUT", "/api/user/"+address.UserID.String()+"/addresses/"+address.ID.String(), bytes.NewReader(body))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	synced := userMock.Calls[0].Arguments.Get(1).(*models.Address)
+	assert.Equal("2 Main St", synced.AddressLine1)
+	assert.Equal(address.AddressCity, synced.AddressCity)
+}
+
+func TestAddressUpdateFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	address := getAddress(uuid.NewUUID())
+	tc, addressMock, _ := mockAddress()
+	addressMock.On("GetByID", address.ID.String()).Return(address, nil)
+	addressMock.On("Update", mock.AnythingOfType("*models.Address"), address.ID.String()).Return(fmt.Errorf("This is an error"))
+
+	recorder := httptest.NewRecorder()
+	body := []byte(`{"label":"Work"}`)
+	request, _ := http.NewRequest("PUT", "/api/user/"+address.UserID.String()+"/addresses/"+address.ID.String(), bytes.NewReader(body))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
+func TestAddressDeletePromotesDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.NewUUID()
+	address := getAddress(userID)
+	address.DefaultShipping = true
+	address.DefaultBilling = true
+	next := getAddress(userID)
+	tc, addressMock, userMock := mockAddress()
+	addressMock.On("GetByID", address.ID.String()).Return(address, nil)
+	addressMock.On("Delete", address.ID.String()).Return(nil)
+	addressMock.On("GetByUser", userID.String()).Return([]*models.Address{next}, nil)
+	addressMock.On("Update", next, next.ID.String()).Return(nil)
+	userMock.On("SetAddress", userID.String(), next).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/user/"+userID.String()+"/addresses/"+address.ID.String(), nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.True(next.DefaultShipping)
+	assert.True(next.DefaultBilling)
+	userMock.AssertExpectations(t)
+}
+
+func TestAddressDeleteNotDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	address := getAddress(uuid.NewUUID())
+	tc, addressMock, _ := mockAddress()
+	addressMock.On("GetByID", address.ID.String()).Return(address, nil)
+	addressMock.On("Delete", address.ID.String()).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/user/"+address.UserID.String()+"/addresses/"+address.ID.String(), nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	addressMock.AssertNotCalled(t, "GetByUser", mock.Anything)
+}
+
+func getAddress(userID uuid.UUID) *models.Address {
+	return models.NewAddress(userID, "Home", "1 Main St", "", "Ames", "IA", "50010", "US")
+}
@@ -0,0 +1,712 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	mockg "github.com/ghmeier/bloodlines/_mocks/gateways"
+	"github.com/ghmeier/bloodlines/config"
+	h "github.com/ghmeier/bloodlines/handlers"
+	m "github.com/ghmeier/bloodlines/models"
+	mocks "github.com/jakelong95/TownCenter/_mocks/helpers"
+	"github.com/jakelong95/TownCenter/gateways"
+	"github.com/jakelong95/TownCenter/gateways/towncentertest"
+	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+// TestTownCenterContract runs every TownCenterI call through the client
+// against the router, so the client's paths, payloads and error handling
+// can't drift from what the router serves.
+func TestTownCenterContract(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	os.Setenv(handlers.AdminUsers, contractAdmin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	servers := make([]*httptest.Server, 0)
+	defer func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	}()
+
+	towncentertest.Contract(t, func() *towncentertest.Target {
+		s := newStore()
+		server := httptest.NewServer(checkResponses(t, mockStore(s).router))
+		servers = append(servers, server)
+
+		return &towncentertest.Target{Client: getStoreClient(server), AddRoaster: s.addRoaster, ResetToken: s.resetToken}
+	})
+}
+
+/*contractAdmin is the user every contract request is made as, so the admin routes can be called*/
+var contractAdmin = uuid.New()
+
+/*envelope is the JSON every TownCenter response is wrapped in*/
+type envelope struct {
+	Success *bool           `json:"success"`
+	Msg     string          `json:"msg"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// shapes are the models returned by the routes the client reads records
+// from, the fields of each record returned must match the model's.
+var shapes = []struct {
+	method string
+	path   *regexp.Regexp
+	model  interface{}
+}{
+	{"GET", regexp.MustCompile(`^/api/user/(list|search|email|[0-9a-f-]{36})$`), models.User{}},
+	{"POST", regexp.MustCompile(`^/api/(user|auth/login)$`), models.User{}},
+	{"GET", regexp.MustCompile(`^/api/roaster(/search|/[0-9a-f-]{36})?$`), models.Roaster{}},
+	{"POST", regexp.MustCompile(`^/api/roaster$`), models.Roaster{}},
+	{"GET", regexp.MustCompile(`^/api/roaster/[0-9a-f-]{36}/user$`), models.User{}},
+}
+
+// checkResponses serves router as contractAdmin, the way the JWT middleware
+// would for an admin's token. It fails t for any response that isn't in
+// TownCenter's envelope, which is what a path the router doesn't serve gets,
+// for any server error, and for records that aren't shaped like their model.
+func checkResponses(t *testing.T, router http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("X-UserId", contractAdmin)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, r)
+
+		route := r.Method + " " + r.URL.Path
+		var body envelope
+		err := json.Unmarshal(recorder.Body.Bytes(), &body)
+		switch {
+		case err != nil || body.Success == nil:
+			t.Errorf("%s: %d response isn't a TownCenter response: %s", route, recorder.Code, recorder.Body.String())
+		case recorder.Code >= http.StatusInternalServerError:
+			t.Errorf("%s: server error %d: %s", route, recorder.Code, body.Msg)
+		case *body.Success != (recorder.Code < http.StatusBadRequest):
+			t.Errorf("%s: success is %t for a %d", route, *body.Success, recorder.Code)
+		case *body.Success:
+			checkShape(t, r, body.Data)
+		}
+
+		for name, values := range recorder.Header() {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(recorder.Code)
+		w.Write(recorder.Body.Bytes())
+	})
+}
+
+/*checkShape fails t if the records in data don't have exactly the fields of the model the route returns*/
+func checkShape(t *testing.T, r *http.Request, data json.RawMessage) {
+	for _, shape := range shapes {
+		if shape.method != r.Method || !shape.path.MatchString(r.URL.Path) {
+			continue
+		}
+
+		records := make([]map[string]interface{}, 0)
+		if json.Unmarshal(data, &records) != nil {
+			var record map[string]interface{}
+			json.Unmarshal(data, &record)
+			records = append(records, record)
+		}
+
+		model, _ := json.Marshal(shape.model)
+		var fields map[string]interface{}
+		json.Unmarshal(model, &fields)
+
+		for _, record := range records {
+			if !reflect.DeepEqual(keys(fields), keys(record)) {
+				t.Errorf("%s %s: returned fields %v, expected %v", r.Method, r.URL.Path, keys(record), keys(fields))
+			}
+		}
+		return
+	}
+}
+
+func keys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+/*getStoreClient returns a client for server that doesn't retry, so server errors fail the contract straight away*/
+func getStoreClient(server *httptest.Server) gateways.TownCenterI {
+	u, _ := url.Parse(server.URL)
+	host, port := u.Host, ""
+	for i := len(host) - 1; i >= 0; i-- {
+		if host[i] == ':' {
+			host, port = u.Host[:i], u.Host[i+1:]
+			break
+		}
+	}
+
+	return gateways.NewTownCenter(config.TownCenter{Host: host, Port: port}, gateways.TownCenterOptions{Retries: -1, BreakerThreshold: -1}, nil)
+}
+
+// mockStore routes the user, roaster, reset and idempotency handlers to
+// helper mocks backed by s, leaving the rest of the router as
+// getMockTownCenter makes it.
+func mockStore(s *store) *TownCenter {
+	t := getMockTownCenter()
+
+	userHelper := new(mocks.UserI)
+	userHelper.On("GetByID", mock.AnythingOfType("string")).Return(s.user, nil)
+	userHelper.On("GetByIDs", mock.AnythingOfType("[]string")).Return(s.usersByID, nil)
+	userHelper.On("GetByEmail", mock.AnythingOfType("string")).Return(s.userByEmail, nil)
+	userHelper.On("GetByRoaster", mock.AnythingOfType("string")).Return(s.userByRoaster, nil)
+	userHelper.On("Insert", mock.AnythingOfType("*models.User")).Return(s.insertUser)
+	userHelper.On("Update", mock.AnythingOfType("*models.User"), mock.AnythingOfType("string")).Return(s.updateUser)
+	userHelper.On("Delete", mock.AnythingOfType("string")).Return(s.deleteUser)
+	userHelper.On("GetAll", mock.AnythingOfType("int"), mock.AnythingOfType("int"), mock.AnythingOfType("*models.ListFilter")).Return(s.allUsers, nil)
+	userHelper.On("Search", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(s.searchUsers, nil)
+	userHelper.On("Profile", mock.AnythingOfType("string"), mock.AnythingOfType("*helpers.Photo")).Return(s.userProfile)
+	userHelper.On("RemoveProfile", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(s.removeUserProfile)
+
+	roasterHelper := new(mocks.RoasterI)
+	roasterHelper.On("GetByID", mock.AnythingOfType("string")).Return(s.roaster, nil)
+	roasterHelper.On("GetByIDs", mock.AnythingOfType("[]string")).Return(s.roastersByID, nil)
+	roasterHelper.On("GetAll", mock.AnythingOfType("int"), mock.AnythingOfType("int"), mock.AnythingOfType("*models.ListFilter")).Return(s.allRoasters, nil)
+	roasterHelper.On("Insert", mock.AnythingOfType("*models.Roaster")).Return(s.insertRoaster)
+	roasterHelper.On("Update", mock.AnythingOfType("*models.Roaster"), mock.AnythingOfType("string")).Return(s.updateRoaster)
+	roasterHelper.On("Delete", mock.AnythingOfType("string")).Return(s.deleteRoaster)
+	roasterHelper.On("SlugTaken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(s.slugTaken, nil)
+	roasterHelper.On("CreateAccount", mock.AnythingOfType("uuid.UUID")).Return(nil)
+	roasterHelper.On("Search", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(s.searchRoasters, nil)
+	roasterHelper.On("Profile", mock.AnythingOfType("string"), mock.AnythingOfType("*helpers.Photo")).Return(s.roasterProfile)
+	roasterHelper.On("RemoveProfile", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(s.removeRoasterProfile)
+
+	resetHelper := new(mocks.ResetI)
+	resetHelper.On("Insert", mock.AnythingOfType("*models.Token")).Return(s.insertToken)
+	resetHelper.On("Get", mock.AnythingOfType("string")).Return(s.token, nil)
+	resetHelper.On("SetStatus", mock.AnythingOfType("*models.Token"), mock.AnythingOfType("models.TokenStatus")).Return(s.setTokenStatus, nil)
+
+	idempotencyHelper := new(mocks.IdempotencyI)
+	idempotencyHelper.On("Claim", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(models.NewIdempotencyRecord, true, nil)
+	idempotencyHelper.On("Save", mock.AnythingOfType("*models.IdempotencyRecord")).Return(nil)
+	idempotencyHelper.On("Release", mock.AnythingOfType("*models.IdempotencyRecord")).Return(nil)
+
+	bloodlines := new(mockg.Bloodlines)
+	bloodlines.On("NewPreference", mock.AnythingOfType("uuid.UUID")).Return(&m.Preference{}, nil)
+	bloodlines.On("ActivateTrigger", "password_reset", mock.AnythingOfType("*models.Receipt")).Return(&m.Receipt{}, nil)
+
+	t.user = &handlers.User{
+		Helper:      userHelper,
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Bloodlines:  bloodlines,
+	}
+	t.roaster = &handlers.Roaster{
+		Helper:      roasterHelper,
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		UserHelper:  userHelper,
+	}
+	t.reset = &handlers.Reset{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		User:        userHelper,
+		Reset:       resetHelper,
+		Bloodlines:  bloodlines,
+		Expiration:  time.Hour,
+	}
+	t.idempotent = &handlers.Idempotency{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Helper:      idempotencyHelper,
+	}
+	InitRouter(t)
+
+	return t
+}
+
+// store keeps users, roasters and reset tokens in memory, reading and
+// writing them the way the queries in helpers do, so the handlers behave as
+// they do over MySQL. Photos are kept in a LocalS3.
+type store struct {
+	mutex    sync.Mutex
+	users    map[string]*models.User
+	roasters map[string]*models.Roaster
+	slugs    map[string]string
+	tokens   map[string]*models.Token
+	resets   map[string]string
+	s3       *gateways.LocalS3
+}
+
+func newStore() *store {
+	return &store{
+		users:    make(map[string]*models.User),
+		roasters: make(map[string]*models.Roaster),
+		slugs:    make(map[string]string),
+		tokens:   make(map[string]*models.Token),
+		resets:   make(map[string]string),
+		s3:       gateways.NewLocalS3("bucket"),
+	}
+}
+
+func (s *store) user(id string) *models.User {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return copyUser(s.users[id])
+}
+
+func (s *store) usersByID(ids []string) []*models.User {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	users := make([]*models.User, 0)
+	for _, id := range ids {
+		if user, ok := s.users[id]; ok {
+			users = append(users, copyUser(user))
+		}
+	}
+
+	return users
+}
+
+func (s *store) userByEmail(email string) *models.User {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, id := range s.userIDs() {
+		if s.users[id].Email == email {
+			return copyUser(s.users[id])
+		}
+	}
+
+	return nil
+}
+
+func (s *store) userByRoaster(roasterID string) *models.User {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, id := range s.userIDs() {
+		if s.users[id].RoasterId.String() == roasterID {
+			return copyUser(s.users[id])
+		}
+	}
+
+	return nil
+}
+
+func (s *store) insertUser(user *models.User) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	user.PassHash = storeHash(user.PassHash)
+	user.CreatedAt = storeNow()
+	user.UpdatedAt = user.CreatedAt
+	s.users[user.ID.String()] = copyUser(user)
+
+	return nil
+}
+
+func (s *store) updateUser(user *models.User, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	user.UpdatedAt = storeNow()
+	existing, ok := s.users[id]
+	if !ok {
+		return nil
+	}
+
+	updated := *user
+	updated.ID = existing.ID
+	updated.PhoneVerified = existing.PhoneVerified && existing.Phone == user.Phone
+	updated.CreatedAt = existing.CreatedAt
+	updated.PassHash = existing.PassHash
+	if user.PassHash != "" {
+		user.PassHash = storeHash(user.PassHash)
+		updated.PassHash = user.PassHash
+	}
+	updated.SetPhotos()
+	s.users[id] = &updated
+
+	return nil
+}
+
+func (s *store) deleteUser(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.users, id)
+	return nil
+}
+
+func (s *store) allUsers(offset int, limit int, filter *models.ListFilter) []*models.User {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	users := make([]*models.User, 0)
+	for _, id := range storePage(s.userIDs(), offset, limit) {
+		users = append(users, copyUser(s.users[id]))
+	}
+
+	return users
+}
+
+func (s *store) searchUsers(query string, offset int, limit int) []*models.User {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	terms := make([]*models.IndexedTerm, 0)
+	for _, user := range s.users {
+		terms = append(terms, indexed(user.ID, user.SearchTerms())...)
+	}
+
+	users := make([]*models.User, 0)
+	for _, id := range storePage(storeSearch(query, terms), offset, limit) {
+		users = append(users, copyUser(s.users[id]))
+	}
+
+	return users
+}
+
+func (s *store) userProfile(id string, photo *helpers.Photo) error {
+	url, err := photo.Upload(s.s3, "profile", id)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if user, ok := s.users[id]; ok {
+		user.ProfileURL = url
+		user.SetPhotos()
+	}
+
+	return nil
+}
+
+func (s *store) removeUserProfile(id string, url string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if user, ok := s.users[id]; ok {
+		user.ProfileURL = ""
+		user.SetPhotos()
+	}
+
+	return s.s3.Delete(url)
+}
+
+func (s *store) insertToken(token *models.Token) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := *token
+	s.tokens[token.Value] = &c
+	s.resets[token.Email] = token.Value
+
+	return nil
+}
+
+func (s *store) token(value string) *models.Token {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	token, ok := s.tokens[value]
+	if !ok {
+		return nil
+	}
+
+	c := *token
+	return &c
+}
+
+func (s *store) setTokenStatus(token *models.Token, status models.TokenStatus) *models.Token {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	token.Status = status
+	if stored, ok := s.tokens[token.Value]; ok {
+		stored.Status = status
+	}
+
+	return token
+}
+
+/*resetToken returns the last token made for email, which the reset handler would have emailed*/
+func (s *store) resetToken(email string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.resets[email]
+}
+
+/*addRoaster stores roaster as it is, as a test would insert it into the table*/
+func (s *store) addRoaster(roaster *models.Roaster) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	roaster.ID = uuid.NewUUID()
+	roaster.Slug = s.uniqueSlug(roaster)
+	roaster.SetPhotos()
+	s.roasters[roaster.ID.String()] = copyRoaster(roaster)
+}
+
+func (s *store) roaster(id string) *models.Roaster {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return copyRoaster(s.roasters[id])
+}
+
+func (s *store) roastersByID(ids []string) []*models.Roaster {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	roasters := make([]*models.Roaster, 0)
+	for _, id := range ids {
+		if roaster, ok := s.roasters[id]; ok {
+			roasters = append(roasters, copyRoaster(roaster))
+		}
+	}
+
+	return roasters
+}
+
+func (s *store) allRoasters(offset int, limit int, filter *models.ListFilter) []*models.Roaster {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ids := make([]string, 0)
+	for id, roaster := range s.roasters {
+		if filter.Status == "" || roaster.Status == filter.Status {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	roasters := make([]*models.Roaster, 0)
+	for _, id := range storePage(ids, offset, limit) {
+		roasters = append(roasters, copyRoaster(s.roasters[id]))
+	}
+
+	return roasters
+}
+
+func (s *store) insertRoaster(roaster *models.Roaster) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	roaster.CreatedAt = storeNow()
+	roaster.UpdatedAt = roaster.CreatedAt
+	roaster.Geocode()
+	if roaster.Slug == "" {
+		roaster.Slug = s.uniqueSlug(roaster)
+	}
+	s.roasters[roaster.ID.String()] = copyRoaster(roaster)
+
+	return nil
+}
+
+func (s *store) updateRoaster(roaster *models.Roaster, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	roaster.UpdatedAt = storeNow()
+	roaster.Geocode()
+	if roaster.Slug == "" {
+		roaster.Slug = s.uniqueSlug(roaster)
+	}
+
+	existing, ok := s.roasters[id]
+	if !ok {
+		return nil
+	}
+	if existing.Slug != roaster.Slug {
+		s.slugs[existing.Slug] = id
+	}
+	delete(s.slugs, roaster.Slug)
+
+	updated := *roaster
+	updated.ID = existing.ID
+	updated.PhoneVerified = existing.PhoneVerified && existing.Phone == roaster.Phone
+	updated.Status = existing.Status
+	updated.CreatedAt = existing.CreatedAt
+	updated.SetPhotos()
+	s.roasters[id] = &updated
+
+	return nil
+}
+
+func (s *store) deleteRoaster(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.roasters, id)
+	return nil
+}
+
+func (s *store) searchRoasters(query string, offset int, limit int) []*models.Roaster {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	terms := make([]*models.IndexedTerm, 0)
+	for _, roaster := range s.roasters {
+		if roaster.Status == models.STATUS_ACTIVE {
+			terms = append(terms, indexed(roaster.ID, roaster.SearchTerms())...)
+		}
+	}
+
+	roasters := make([]*models.Roaster, 0)
+	for _, id := range storePage(storeSearch(query, terms), offset, limit) {
+		roasters = append(roasters, copyRoaster(s.roasters[id]))
+	}
+
+	return roasters
+}
+
+func (s *store) roasterProfile(id string, photo *helpers.Photo) error {
+	url, err := photo.Upload(s.s3, "profile", id)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if roaster, ok := s.roasters[id]; ok {
+		roaster.ProfileUrl = url
+		roaster.SetPhotos()
+	}
+
+	return nil
+}
+
+func (s *store) removeRoasterProfile(id string, url string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if roaster, ok := s.roasters[id]; ok {
+		roaster.ProfileUrl = ""
+		roaster.SetPhotos()
+	}
+
+	return s.s3.Delete(url)
+}
+
+func (s *store) slugTaken(slug string, id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.taken(slug, id)
+}
+
+func (s *store) taken(slug string, id string) bool {
+	for _, roaster := range s.roasters {
+		if roaster.Slug == slug && roaster.ID.String() != id {
+			return true
+		}
+	}
+
+	owner, ok := s.slugs[slug]
+	return ok && owner != id
+}
+
+func (s *store) uniqueSlug(roaster *models.Roaster) string {
+	base := models.Slugify(roaster.Name)
+	for n := 1; ; n++ {
+		slug := models.SlugCandidate(base, n)
+		if !s.taken(slug, roaster.ID.String()) {
+			return slug
+		}
+	}
+}
+
+func (s *store) userIDs() []string {
+	ids := make([]string, 0, len(s.users))
+	for id := range s.users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+/*indexed returns the terms of the record with id as the search index stores them*/
+func indexed(id uuid.UUID, terms []*models.SearchTerm) []*models.IndexedTerm {
+	list := make([]*models.IndexedTerm, len(terms))
+	for i, term := range terms {
+		list[i] = &models.IndexedTerm{ID: id, SearchTerm: *term}
+	}
+
+	return list
+}
+
+/*storeSearch ranks terms against query, with the same first letter candidates as helpers.Search*/
+func storeSearch(query string, terms []*models.IndexedTerm) []string {
+	tokens := models.Tokenize(query)
+
+	first := make(map[byte]bool)
+	for _, token := range tokens {
+		first[token[0]] = true
+	}
+
+	candidates := make([]*models.IndexedTerm, 0)
+	for _, term := range terms {
+		if first[term.Term[0]] {
+			candidates = append(candidates, term)
+		}
+	}
+
+	return models.Rank(tokens, candidates)
+}
+
+func storePage(ids []string, offset int, limit int) []string {
+	if offset >= len(ids) {
+		return make([]string, 0)
+	}
+	if offset+limit < len(ids) {
+		ids = ids[:offset+limit]
+	}
+
+	return ids[offset:]
+}
+
+func copyUser(user *models.User) *models.User {
+	if user == nil {
+		return nil
+	}
+
+	c := *user
+	return &c
+}
+
+func copyRoaster(roaster *models.Roaster) *models.Roaster {
+	if roaster == nil {
+		return nil
+	}
+
+	c := *roaster
+	return &c
+}
+
+func storeHash(password string) string {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	return string(hashed)
+}
+
+/*storeNow is the current time at the second precision mysql stores*/
+func storeNow() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
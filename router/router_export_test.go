@@ -0,0 +1,195 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+func TestExportUsersSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	user := models.NewUser("", "Jake", "Long", "jake@expresso.store", "", "", "", "", "", "", "")
+	tc, exportMock := mockExport()
+	exportMock.On("Users", mock.AnythingOfType("*models.ListFilter"), mock.Anything).Return(func(filter *models.ListFilter, each func(*models.User) error) error {
+		return each(user)
+	})
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/export/user?fields=email,firstName", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal("text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.True(strings.HasPrefix(recorder.Header().Get("Content-Disposition"), "attachment; filename=users-"))
+	assert.Equal("email,firstName\njake@expresso.store,Jake\n", recorder.Body.String())
+}
+
+func TestExportUsersJSONL(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	user := models.NewUser("hash", "Jake", "Long", "jake@expresso.store", "", "", "", "", "", "", "")
+	tc, exportMock := mockExport()
+	exportMock.On("Users", mock.AnythingOfType("*models.ListFilter"), mock.Anything).Return(func(filter *models.ListFilter, each func(*models.User) error) error {
+		return each(user)
+	})
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/export/user?format=jsonl&createdAfter=2017-01-13T00:00:00Z", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal("application/x-ndjson", recorder.Header().Get("Content-Type"))
+	assert.True(strings.Contains(recorder.Body.String(), `"email":"jake@expresso.store"`))
+	assert.False(strings.Contains(recorder.Body.String(), "passHash"))
+	filter := exportMock.Calls[0].Arguments.Get(0).(*models.ListFilter)
+	assert.Equal(2017, filter.CreatedAfter.Year())
+}
+
+func TestExportUsersNotAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, exportMock := mockExport()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/export/user", nil)
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+	exportMock.AssertNotCalled(t, "Users", mock.Anything, mock.Anything)
+}
+
+func TestExportUsersBadFields(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, exportMock := mockExport()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/export/user?fields=email,passHash", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	exportMock.AssertNotCalled(t, "Users", mock.Anything, mock.Anything)
+}
+
+func TestExportUsersBadFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, _ := mockExport()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/export/user?format=xml", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestExportUsersFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, exportMock := mockExport()
+	exportMock.On("Users", mock.AnythingOfType("*models.ListFilter"), mock.Anything).Return(fmt.Errorf("some error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/export/user", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+	assert.Equal("", recorder.Header().Get("Content-Disposition"))
+	assert.True(strings.Contains(recorder.Header().Get("Content-Type"), "application/json"))
+}
+
+func TestExportRoastersStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	roaster := models.NewRoaster("Kaldi's", "hello@kaldis.com", "", "", "", "Ames", "IA", "", "US", "")
+	tc, exportMock := mockExport()
+	exportMock.On("Roasters", mock.AnythingOfType("*models.ListFilter"), mock.Anything).Return(func(filter *models.ListFilter, each func(*models.Roaster) error) error {
+		return each(roaster)
+	})
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/export/roaster?status=pending&fields=name,status", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal("name,status\nKaldi's,pending\n", recorder.Body.String())
+	filter := exportMock.Calls[0].Arguments.Get(0).(*models.ListFilter)
+	assert.Equal(models.STATUS_PENDING, filter.Status)
+}
+
+func TestExportRoastersBadStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, exportMock := mockExport()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/export/roaster?status=open", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	exportMock.AssertNotCalled(t, "Roasters", mock.Anything, mock.Anything)
+}
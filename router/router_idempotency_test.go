@@ -0,0 +1,176 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+const userBody = `{"firstName":"Jake","email":"jake@expresso.store","passHash":"secret"}`
+
+func TestIdempotentSavesResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, userMock := mockUser()
+	idempotencyMock := mockIdempotency(tc)
+	record := models.NewIdempotencyRecord("key-1", "POST /api/user ", requestHash(userBody))
+	userMock.On("GetByEmail", "jake@expresso.store").Return(nil, nil)
+	userMock.On("Insert", mock.AnythingOfType("*models.User")).Return(nil)
+	idempotencyMock.On("Claim", "key-1", "POST /api/user ", requestHash(userBody)).Return(record, true, nil)
+	idempotencyMock.On("Save", record).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user", strings.NewReader(userBody))
+	request.Header.Set(handlers.IdempotencyKey, "key-1")
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal(200, record.ResponseCode)
+	assert.Equal(recorder.Body.String(), record.ResponseBody)
+	assert.NotEqual("", recorder.Header().Get("X-Auth"))
+	assert.Equal("", record.Headers["X-Auth"])
+	user := userMock.Calls[1].Arguments.Get(0).(*models.User)
+	assert.Equal(user.ID.String(), record.TokenUserID)
+	idempotencyMock.AssertExpectations(t)
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, userMock := mockUser()
+	idempotencyMock := mockIdempotency(tc)
+	record := models.NewIdempotencyRecord("key-1", "POST /api/user ", requestHash(userBody))
+	record.ResponseCode = 200
+	record.ResponseBody = `{"success":true,"data":{"email":"jake@expresso.store"}}`
+	record.Headers["X-Auth"] = "stored"
+	record.TokenUserID = uuid.New()
+	idempotencyMock.On("Claim", "key-1", "POST /api/user ", requestHash(userBody)).Return(record, false, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user", strings.NewReader(userBody))
+	request.Header.Set(handlers.IdempotencyKey, "key-1")
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal(record.ResponseBody, recorder.Body.String())
+	token := recorder.Header().Get("X-Auth")
+	assert.NotEqual("stored", token)
+	parsed, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("JWT_TOKEN")), nil
+	})
+	assert.NoError(err)
+	issuedTo := ""
+	for _, value := range parsed.Claims.(jwt.MapClaims) {
+		if value == record.TokenUserID {
+			issuedTo = record.TokenUserID
+		}
+	}
+	assert.Equal(record.TokenUserID, issuedTo)
+	assert.Equal("true", recorder.Header().Get(handlers.IdempotencyReplayed))
+	userMock.AssertNotCalled(t, "Insert", mock.Anything)
+}
+
+func TestIdempotentDifferentRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, userMock := mockUser()
+	idempotencyMock := mockIdempotency(tc)
+	record := models.NewIdempotencyRecord("key-1", "POST /api/user ", requestHash(`{"email":"other@expresso.store"}`))
+	record.ResponseCode = 200
+	idempotencyMock.On("Claim", "key-1", "POST /api/user ", requestHash(userBody)).Return(record, false, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user", strings.NewReader(userBody))
+	request.Header.Set(handlers.IdempotencyKey, "key-1")
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(422, recorder.Code)
+	userMock.AssertNotCalled(t, "Insert", mock.Anything)
+}
+
+func TestIdempotentInProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, userMock := mockUser()
+	idempotencyMock := mockIdempotency(tc)
+	record := models.NewIdempotencyRecord("key-1", "POST /api/user ", requestHash(userBody))
+	idempotencyMock.On("Claim", "key-1", "POST /api/user ", requestHash(userBody)).Return(record, false, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user", strings.NewReader(userBody))
+	request.Header.Set(handlers.IdempotencyKey, "key-1")
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(409, recorder.Code)
+	userMock.AssertNotCalled(t, "Insert", mock.Anything)
+}
+
+func TestIdempotentServerErrorReleases(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	body := `{"roaster":{"name":"Kaldi's"},"userId":"` + "86c3d82d-da86-11e6-9d4c-0242ac120004" + `"}`
+	tc, roasterMock := mockRoaster()
+	idempotencyMock := mockIdempotency(tc)
+	record := models.NewIdempotencyRecord("key-2", "POST /api/roaster user-1", requestHash(body))
+	roasterMock.On("Insert", mock.AnythingOfType("*models.Roaster")).Return(fmt.Errorf("This is an error"))
+	idempotencyMock.On("Claim", "key-2", "POST /api/roaster user-1", requestHash(body)).Return(record, true, nil)
+	idempotencyMock.On("Release", record).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster", bytes.NewReader([]byte(body)))
+	request.Header.Set(handlers.IdempotencyKey, "key-2")
+	request.Header.Set("X-UserId", "user-1")
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+	idempotencyMock.AssertCalled(t, "Release", record)
+	idempotencyMock.AssertNotCalled(t, "Save", mock.Anything)
+}
+
+func TestIdempotentKeyTooLong(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, userMock := mockUser()
+	idempotencyMock := mockIdempotency(tc)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user", strings.NewReader(userBody))
+	request.Header.Set(handlers.IdempotencyKey, strings.Repeat("k", handlers.MaxIdempotencyKey+1))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	idempotencyMock.AssertNotCalled(t, "Claim", mock.Anything, mock.Anything, mock.Anything)
+	userMock.AssertNotCalled(t, "Insert", mock.Anything)
+}
+
+func requestHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,187 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+func TestImportNewSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	body := "email,firstName\njake@expresso.store,Jake\ngreg@expresso.store,Greg\n"
+	tc, importMock, importerMock := mockImport()
+	importMock.On("Insert", mock.AnythingOfType("*models.Import"), []byte(body)).Return(nil)
+	importerMock.On("Run", mock.AnythingOfType("string")).Return(nil, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/import?kind=user&dryRun=true", bytes.NewReader([]byte(body)))
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	job := importMock.Calls[0].Arguments.Get(0).(*models.Import)
+	assert.Equal(models.IMPORT_USER, job.Kind)
+	assert.Equal(models.IMPORT_CSV, job.Format)
+	assert.True(job.DryRun)
+	assert.Equal(2, job.Total)
+}
+
+func TestImportNewNotAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, importMock, _ := mockImport()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/import?kind=user", bytes.NewReader([]byte("email\njake@expresso.store\n")))
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+	importMock.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+}
+
+func TestImportNewBadKind(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, _, _ := mockImport()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/import?kind=order", bytes.NewReader([]byte("email\njake@expresso.store\n")))
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestImportNewBadCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, _, _ := mockImport()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/import?kind=user", bytes.NewReader([]byte("email,firstName\njake@expresso.store\n")))
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	assert.Contains(recorder.Body.String(), "invalid csv")
+}
+
+func TestImportView(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	job := &models.Import{ID: uuid.NewUUID(), Status: models.IMPORT_RUNNING, Total: 10, Processed: 4}
+	tc, importMock, _ := mockImport()
+	importMock.On("GetByID", job.ID.String()).Return(job, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/import/"+job.ID.String(), nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), `"processed":4`)
+}
+
+func TestImportViewNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, importMock, _ := mockImport()
+	importMock.On("GetByID", "missing").Return(nil, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/import/missing", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
+func TestImportResume(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	job := &models.Import{ID: uuid.NewUUID(), Status: models.IMPORT_FAILED, Total: 10, Processed: 4}
+	tc, importMock, importerMock := mockImport()
+	importMock.On("GetByID", job.ID.String()).Return(job, nil)
+	importMock.On("Save", job, "").Return(nil)
+	importerMock.On("Run", job.ID.String()).Return(job, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/import/"+job.ID.String()+"/resume", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), `"status":"pending"`)
+}
+
+func TestImportResumeNotFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	job := &models.Import{ID: uuid.NewUUID(), Status: models.IMPORT_RUNNING}
+	tc, importMock, _ := mockImport()
+	importMock.On("GetByID", job.ID.String()).Return(job, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/import/"+job.ID.String()+"/resume", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	importMock.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,427 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	bmodels "github.com/ghmeier/bloodlines/models"
+	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+func TestOnboardingUploadDocumentSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_PENDING)
+	tc, _, documentMock, roasterMock, userMock, _ := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	documentMock.On("Insert", mock.AnythingOfType("*models.RoasterDocument"), mock.Anything).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request := getDocumentRequest("/api/roaster/"+roaster.ID.String()+"/documents", models.DOCUMENT_BUSINESS_LICENSE)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	document := documentMock.Calls[0].Arguments.Get(0).(*models.RoasterDocument)
+	assert.Equal(models.DOCUMENT_BUSINESS_LICENSE, document.Kind)
+	assert.Equal("license.pdf", document.Filename)
+}
+
+func TestOnboardingUploadDocumentNotOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_PENDING)
+	tc, _, documentMock, roasterMock, userMock, _ := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+
+	recorder := httptest.NewRecorder()
+	request := getDocumentRequest("/api/roaster/"+roaster.ID.String()+"/documents", models.DOCUMENT_BUSINESS_LICENSE)
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+	documentMock.AssertNotCalled(t, "Insert", mock.AnythingOfType("*models.RoasterDocument"), mock.Anything)
+}
+
+func TestOnboardingViewDocumentsOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_PENDING)
+	document := models.NewRoasterDocument(roaster.ID, models.DOCUMENT_TAX_ID, "ein.pdf")
+	document.Key = "documents/" + document.ID.String() + "-ein.pdf"
+	document.Url = "https://bucket.s3.amazonaws.com/" + document.Key + "?X-Amz-Signature=signed"
+	tc, _, documentMock, roasterMock, userMock, _ := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	documentMock.On("GetByRoaster", roaster.ID.String()).Return([]*models.RoasterDocument{document}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+roaster.ID.String()+"/documents", nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), "X-Amz-Signature=signed")
+}
+
+func TestOnboardingViewDocumentsAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_UNDER_REVIEW)
+	tc, _, documentMock, roasterMock, userMock, _ := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	documentMock.On("GetByRoaster", roaster.ID.String()).Return(make([]*models.RoasterDocument, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+roaster.ID.String()+"/documents", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestOnboardingViewDocumentsNotOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_PENDING)
+	tc, _, documentMock, roasterMock, userMock, _ := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+roaster.ID.String()+"/documents", nil)
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+	documentMock.AssertNotCalled(t, "GetByRoaster", roaster.ID.String())
+}
+
+func TestOnboardingUploadDocumentInvalidKind(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _, _, _, _, _ := mockOnboarding()
+
+	recorder := httptest.NewRecorder()
+	request := getDocumentRequest("/api/roaster/"+uuid.New()+"/documents", "selfie")
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestOnboardingSubmitSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_PENDING)
+	tc, statusMock, documentMock, roasterMock, userMock, bloodlines := mockOnboarding()
+	documentMock.On("GetByRoaster", roaster.ID.String()).Return([]*models.RoasterDocument{models.NewRoasterDocument(roaster.ID, models.DOCUMENT_TAX_ID, "ein.pdf")}, nil)
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	statusMock.On("Set", mock.AnythingOfType("*models.StatusChange")).Return(nil)
+	bloodlines.On("ActivateTrigger", "roaster_under_review", mock.AnythingOfType("*models.Receipt")).Return(&bmodels.Receipt{}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/submit", nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	change := statusMock.Calls[0].Arguments.Get(0).(*models.StatusChange)
+	assert.Equal(models.STATUS_PENDING, change.From)
+	assert.Equal(models.STATUS_UNDER_REVIEW, change.To)
+	assert.Equal(owner.ID.String(), change.ActorID)
+	bloodlines.AssertExpectations(t)
+}
+
+func TestOnboardingSubmitNoDocuments(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	tc, _, documentMock, _, _, _ := mockOnboarding()
+	documentMock.On("GetByRoaster", id).Return(make([]*models.RoasterDocument, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+id+"/submit", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestOnboardingSubmitNotOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_PENDING)
+	tc, _, documentMock, roasterMock, userMock, _ := mockOnboarding()
+	documentMock.On("GetByRoaster", roaster.ID.String()).Return([]*models.RoasterDocument{models.NewRoasterDocument(roaster.ID, models.DOCUMENT_TAX_ID, "ein.pdf")}, nil)
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/submit", nil)
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+}
+
+func TestOnboardingApproveSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_UNDER_REVIEW)
+	tc, statusMock, _, roasterMock, userMock, bloodlines := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	statusMock.On("Set", mock.AnythingOfType("*models.StatusChange")).Return(nil)
+	bloodlines.On("ActivateTrigger", "roaster_active", mock.AnythingOfType("*models.Receipt")).Return(&bmodels.Receipt{}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/approve", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), `"status":"active"`)
+}
+
+func TestOnboardingApproveNotAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _, _, _, _, _ := mockOnboarding()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+uuid.New()+"/approve", nil)
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+}
+
+func TestOnboardingApproveWrongStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_PENDING)
+	tc, _, _, roasterMock, userMock, _ := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/approve", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestOnboardingRejectNeedsReason(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, _, _, _, _, _ := mockOnboarding()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+uuid.New()+"/reject", bytes.NewReader([]byte(`{"note":"Try again"}`)))
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestOnboardingRejectInvalidReason(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, _, _, _, _, _ := mockOnboarding()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+uuid.New()+"/reject", bytes.NewReader([]byte(`{"reason":"bad vibes"}`)))
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestOnboardingSuspendNotificationFails(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, statusMock, _, roasterMock, userMock, bloodlines := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	statusMock.On("Set", mock.AnythingOfType("*models.StatusChange")).Return(nil)
+	bloodlines.On("ActivateTrigger", "roaster_suspended", mock.AnythingOfType("*models.Receipt")).Return(nil, fmt.Errorf("some error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/suspend", bytes.NewReader([]byte(`{"reason":"policy_violation","note":"Selling tea"}`)))
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	change := statusMock.Calls[0].Arguments.Get(0).(*models.StatusChange)
+	assert.Equal(models.REASON_POLICY_VIOLATION, change.Reason)
+	assert.Equal("Selling tea", change.Note)
+}
+
+func TestOnboardingCloseByOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, statusMock, _, roasterMock, userMock, bloodlines := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	statusMock.On("Set", mock.AnythingOfType("*models.StatusChange")).Return(nil)
+	bloodlines.On("ActivateTrigger", "roaster_closed", mock.AnythingOfType("*models.Receipt")).Return(&bmodels.Receipt{}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/close", nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	change := statusMock.Calls[0].Arguments.Get(0).(*models.StatusChange)
+	assert.Equal(models.REASON_OWNER_REQUEST, change.Reason)
+}
+
+func TestOnboardingSetFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, statusMock, _, roasterMock, userMock, _ := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	statusMock.On("Set", mock.AnythingOfType("*models.StatusChange")).Return(fmt.Errorf("This is an error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/close", nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
+func TestOnboardingHistory(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, statusMock, _, roasterMock, userMock, _ := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	statusMock.On("GetHistory", roaster.ID.String()).Return(make([]*models.StatusChange, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+roaster.ID.String()+"/status", nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestOnboardingHistoryNotOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, statusMock, _, roasterMock, userMock, _ := mockOnboarding()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+roaster.ID.String()+"/status", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+	statusMock.AssertNotCalled(t, "GetHistory", roaster.ID.String())
+}
+
+func getOnboardingRoaster(status string) (*models.Roaster, *models.User) {
+	roaster := models.NewRoaster("Kaldi's", "", "", "", "", "", "", "", "", "")
+	roaster.Status = status
+	owner := &models.User{ID: uuid.NewUUID(), RoasterId: roaster.ID}
+
+	return roaster, owner
+}
+
+func getDocumentRequest(url string, kind string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("kind", kind)
+	part, _ := writer.CreateFormFile("document", "license.pdf")
+	part.Write([]byte("not really a pdf"))
+	writer.Close()
+
+	request, _ := http.NewRequest("POST", url, body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
@@ -0,0 +1,155 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc := getMockTownCenter()
+	InitRouter(tc)
+
+	served := make(map[string]bool)
+	for _, route := range tc.routes() {
+		key := route.Method + " " + route.Path
+		served[key] = true
+
+		_, ok := operations[key]
+		assert.True(ok, "%s has no entry in operations", key)
+	}
+
+	for key := range operations {
+		assert.True(served[key], "%s is in operations but isn't served", key)
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc := getMockTownCenter()
+	InitRouter(tc)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/openapi.json", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &spec)
+
+	assert.Equal(200, recorder.Code)
+	assert.NoError(err)
+	assert.Equal(OpenAPIVersion, spec.OpenAPI)
+	assert.Contains(spec.Paths["/api/user/{userId}"], "get")
+	assert.Contains(spec.Paths["/api/user/{userId}"], "put")
+	assert.Contains(spec.Paths["/api/user/search"], "get")
+	assert.Contains(spec.Paths["/api/roaster/batch"], "post")
+	assert.NotContains(spec.Paths["/api/user/{userId}"], "post")
+}
+
+func TestOpenAPIModelSchemas(t *testing.T) {
+	assert := assert.New(t)
+
+	tc := getMockTownCenter()
+	InitRouter(tc)
+
+	spec := tc.OpenAPI()
+	schemas := spec["components"].(map[string]interface{})["schemas"].(schemas)
+
+	for name, model := range map[string]interface{}{"User": models.User{}, "Roaster": models.Roaster{}, "Address": models.Address{}} {
+		schema := schemas[name].(map[string]interface{})
+		properties := schema["properties"].(map[string]interface{})
+
+		b, _ := json.Marshal(model)
+		var fields map[string]interface{}
+		json.Unmarshal(b, &fields)
+
+		assert.Equal(keys(fields), keys(properties), name)
+	}
+}
+
+func TestOpenAPIEmbedded(t *testing.T) {
+	assert := assert.New(t)
+
+	s := make(schemas)
+	ref := s.of(reflect.TypeOf([]*models.NearbyRoaster{}))
+
+	nearby := s["NearbyRoaster"].(map[string]interface{})["properties"].(map[string]interface{})
+	latitude := nearby["latitude"].(map[string]interface{})
+
+	assert.Equal("#/components/schemas/NearbyRoaster", ref["items"].(map[string]interface{})["$ref"])
+	assert.Contains(nearby, "distanceKm")
+	assert.Contains(nearby, "addressCountry")
+	assert.Equal(true, latitude["nullable"])
+	assert.Equal("uuid", nearby["id"].(map[string]interface{})["format"])
+}
+
+func TestOpenAPIBatchResponses(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	found, missing := uuid.NewUUID(), uuid.NewUUID()
+	body := `{"ids":["` + found.String() + `","` + missing.String() + `"]}`
+
+	tc, userMock := mockUser()
+	userMock.On("GetByIDs", []string{found.String(), missing.String()}).Return([]*models.User{{ID: found}}, nil)
+	assertDocumented(assert, tc, "POST", "/api/user/batch", body)
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByIDs", []string{found.String(), missing.String()}).Return([]*models.Roaster{{ID: found}}, nil)
+	assertDocumented(assert, tc, "POST", "/api/roaster/batch", body)
+}
+
+// assertDocumented serves the request and checks its data decodes into the
+// type operations documents for the route without losing anything, so a
+// slice documented for an object or a missing field fails.
+func assertDocumented(assert *assert.Assertions, tc *TownCenter, method, path, body string) {
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+	tc.router.ServeHTTP(recorder, request)
+
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+
+	documented := reflect.New(reflect.TypeOf(operations[method+" "+path].data))
+	err := json.Unmarshal(response.Data, documented.Interface())
+	decoded, _ := json.Marshal(documented.Interface())
+
+	assert.Equal(200, recorder.Code, path)
+	assert.NoError(err, path)
+	assert.JSONEq(string(response.Data), string(decoded), path)
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	assert := assert.New(t)
+
+	path, params := openAPIPath("/api/user/:userId/addresses/:addressId")
+
+	assert.Equal("/api/user/{userId}/addresses/{addressId}", path)
+	assert.Len(params, 2)
+
+	path, params = openAPIPath("/api/user")
+	assert.Equal("/api/user", path)
+	assert.Empty(params)
+}
@@ -0,0 +1,242 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	bmodels "github.com/ghmeier/bloodlines/models"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+func TestPhoneRequestUserSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "", "", "", "+15155550123", "", "", "", "", "", "")
+	tc, userMock, _, verificationMock, bloodlines := mockPhone()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	verificationMock.On("Get", user.ID.String()).Return(nil, nil)
+	verificationMock.On("Insert", mock.AnythingOfType("*models.Verification")).Return(nil)
+	bloodlines.On("ActivateTrigger", "phone_verification", mock.AnythingOfType("*models.Receipt")).Return(&bmodels.Receipt{Values: map[string]string{"code": "428193"}}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+user.ID.String()+"/phone/code", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	verification := verificationMock.Calls[1].Arguments.Get(0).(*models.Verification)
+	receipt := bloodlines.Calls[0].Arguments.Get(1).(*bmodels.Receipt)
+	assert.Equal(user.Phone, verification.Phone)
+	assert.Equal(verification.Code, receipt.Values["code"])
+	assert.Equal(user.ID, receipt.UserID)
+	assert.NotContains(recorder.Body.String(), "428193")
+}
+
+func TestPhoneRequestUserNoPhone(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "", "", "", "", "", "", "", "", "", "")
+	tc, userMock, _, _, _ := mockPhone()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+user.ID.String()+"/phone/code", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestPhoneRequestUserTriggerFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "", "", "", "+15155550123", "", "", "", "", "", "")
+	tc, userMock, _, verificationMock, bloodlines := mockPhone()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	verificationMock.On("Get", user.ID.String()).Return(nil, nil)
+	verificationMock.On("Insert", mock.AnythingOfType("*models.Verification")).Return(nil)
+	bloodlines.On("ActivateTrigger", "phone_verification", mock.AnythingOfType("*models.Receipt")).Return(nil, fmt.Errorf("some error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+user.ID.String()+"/phone/code", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
+func TestPhoneRequestUserCooldown(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "", "", "", "+15155550123", "", "", "", "", "", "")
+	tc, userMock, _, verificationMock, bloodlines := mockPhone()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	verificationMock.On("Get", user.ID.String()).Return(getHashedVerification(user.Phone, "123456", time.Now().Add(-time.Second*10)), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+user.ID.String()+"/phone/code", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	verificationMock.AssertNotCalled(t, "Insert", mock.AnythingOfType("*models.Verification"))
+	bloodlines.AssertNotCalled(t, "ActivateTrigger", "phone_verification", mock.AnythingOfType("*models.Receipt"))
+}
+
+func TestPhoneRequestUserAfterCooldown(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "", "", "", "+15155550123", "", "", "", "", "", "")
+	tc, userMock, _, verificationMock, bloodlines := mockPhone()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	verificationMock.On("Get", user.ID.String()).Return(getHashedVerification(user.Phone, "123456", time.Now().Add(-time.Minute*2)), nil)
+	verificationMock.On("Insert", mock.AnythingOfType("*models.Verification")).Return(nil)
+	bloodlines.On("ActivateTrigger", "phone_verification", mock.AnythingOfType("*models.Receipt")).Return(&bmodels.Receipt{}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+user.ID.String()+"/phone/code", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestPhoneRequestRoasterSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("", "", "+15155550123", "", "", "", "", "", "", "")
+	owner := models.NewUser("", "", "", "", "", "", "", "", "", "", "")
+	tc, userMock, roasterMock, verificationMock, bloodlines := mockPhone()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	verificationMock.On("Get", roaster.ID.String()).Return(nil, nil)
+	verificationMock.On("Insert", mock.AnythingOfType("*models.Verification")).Return(nil)
+	bloodlines.On("ActivateTrigger", "phone_verification", mock.AnythingOfType("*models.Receipt")).Return(&bmodels.Receipt{}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/phone/code", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	receipt := bloodlines.Calls[0].Arguments.Get(1).(*bmodels.Receipt)
+	assert.Equal(owner.ID, receipt.UserID)
+}
+
+func TestPhoneVerifyUserSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "", "", "", "+15155550123", "", "", "", "", "", "")
+	tc, userMock, _, verificationMock, _ := mockPhone()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	userMock.On("VerifyPhone", user.ID.String(), user.Phone).Return(nil)
+	verificationMock.On("Get", user.ID.String()).Return(getHashedVerification(user.Phone, "123456", time.Now()), nil)
+	verificationMock.On("Delete", user.ID.String()).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+user.ID.String()+"/phone/verify", bytes.NewReader([]byte(`{"code": "123456"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	userMock.AssertCalled(t, "VerifyPhone", user.ID.String(), user.Phone)
+}
+
+func TestPhoneVerifyUserWrongCode(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "", "", "", "+15155550123", "", "", "", "", "", "")
+	verification := getHashedVerification(user.Phone, "123456", time.Now())
+	tc, userMock, _, verificationMock, _ := mockPhone()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	verificationMock.On("Get", user.ID.String()).Return(verification, nil)
+	verificationMock.On("Attempt", verification).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+user.ID.String()+"/phone/verify", bytes.NewReader([]byte(`{"code": "654321"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	verificationMock.AssertCalled(t, "Attempt", verification)
+	userMock.AssertNotCalled(t, "VerifyPhone", user.ID.String(), user.Phone)
+}
+
+func TestPhoneVerifyUserExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "", "", "", "+15155550123", "", "", "", "", "", "")
+	tc, userMock, _, verificationMock, _ := mockPhone()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	verificationMock.On("Get", user.ID.String()).Return(getHashedVerification(user.Phone, "123456", time.Now().Add(-time.Hour)), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+user.ID.String()+"/phone/verify", bytes.NewReader([]byte(`{"code": "123456"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestPhoneVerifyUserPhoneChanged(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "", "", "", "+15155550123", "", "", "", "", "", "")
+	tc, userMock, _, verificationMock, _ := mockPhone()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	verificationMock.On("Get", user.ID.String()).Return(getHashedVerification("+15155550199", "123456", time.Now()), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+user.ID.String()+"/phone/verify", bytes.NewReader([]byte(`{"code": "123456"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestPhoneVerifyRoasterSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("", "", "+15155550123", "", "", "", "", "", "", "")
+	tc, _, roasterMock, verificationMock, _ := mockPhone()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	roasterMock.On("VerifyPhone", roaster.ID.String(), roaster.Phone).Return(nil)
+	verificationMock.On("Get", roaster.ID.String()).Return(getHashedVerification(roaster.Phone, "123456", time.Now()), nil)
+	verificationMock.On("Delete", roaster.ID.String()).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/phone/verify", bytes.NewReader([]byte(`{"code": "123456"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func getHashedVerification(phone, code string, createdAt time.Time) *models.Verification {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte(code), bcrypt.MinCost)
+	return &models.Verification{
+		Phone:     phone,
+		Code:      string(hashed),
+		CreatedAt: createdAt,
+	}
+}
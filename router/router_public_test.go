@@ -0,0 +1,251 @@
+package router
+
+import (
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+func TestPublicRoasterSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("Kaldi's", "owner@kaldis.com", "+15155550123", "1 Main St", "", "Ames", "IA", "50010", "US", "")
+	roaster.Status = models.STATUS_ACTIVE
+	roaster.Slug = "kaldis"
+	tc, roasterMock, profileMock, galleryMock := mockPublic()
+	roasterMock.On("GetBySlug", "kaldis").Return(roaster, nil)
+	profileMock.On("Get", roaster.ID.String()).Return(nil, nil)
+	galleryMock.On("GetByRoaster", roaster.ID.String()).Return(make([]*models.GalleryImage, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/roaster/kaldis", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), "Ames")
+	assert.NotContains(recorder.Body.String(), "owner@kaldis.com")
+	assert.NotContains(recorder.Body.String(), "+15155550123")
+	assert.NotContains(recorder.Body.String(), "1 Main St")
+	assert.Contains(recorder.Body.String(), `"gallery":[]`)
+	assert.Contains(recorder.Body.String(), `"openNow":false`)
+}
+
+func TestPublicRoasterStorefront(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("Kaldi's", "owner@kaldis.com", "+15155550123", "1 Main St", "", "Ames", "IA", "50010", "US", "")
+	roaster.Status = models.STATUS_ACTIVE
+	roaster.Slug = "kaldis"
+	profile := models.NewRoasterProfile(roaster.ID)
+	profile.Description = "Small batch roasting since 2009"
+	image := models.NewGalleryImage(roaster.ID, "Our roaster", 0)
+	tc, roasterMock, profileMock, galleryMock := mockPublic()
+	roasterMock.On("GetBySlug", "kaldis").Return(roaster, nil)
+	profileMock.On("Get", roaster.ID.String()).Return(profile, nil)
+	galleryMock.On("GetByRoaster", roaster.ID.String()).Return([]*models.GalleryImage{image}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/roaster/kaldis", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), "Small batch roasting since 2009")
+	assert.Contains(recorder.Body.String(), "Our roaster")
+}
+
+func TestPublicRoasterStorefrontFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("Kaldi's", "owner@kaldis.com", "+15155550123", "1 Main St", "", "Ames", "IA", "50010", "US", "")
+	roaster.Status = models.STATUS_ACTIVE
+	tc, roasterMock, profileMock, _ := mockPublic()
+	roasterMock.On("GetBySlug", "kaldis").Return(roaster, nil)
+	profileMock.On("Get", roaster.ID.String()).Return(nil, fmt.Errorf("This is an error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/roaster/kaldis", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
+func TestPublicRoasterRedirect(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, roasterMock, _, _ := mockPublic()
+	roasterMock.On("GetBySlug", "old-name").Return(nil, nil)
+	roasterMock.On("GetRedirect", "old-name").Return("new-name", nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/roaster/old-name", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(301, recorder.Code)
+	assert.Equal("/api/public/roaster/new-name", recorder.Header().Get("Location"))
+}
+
+func TestPublicRoasterNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, roasterMock, _, _ := mockPublic()
+	roasterMock.On("GetBySlug", "unknown").Return(nil, nil)
+	roasterMock.On("GetRedirect", "unknown").Return("", nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/roaster/unknown", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
+func TestPublicRoasterNotActive(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("Kaldi's", "owner@kaldis.com", "+15155550123", "1 Main St", "", "Ames", "IA", "50010", "US", "")
+	roaster.Slug = "kaldis"
+	tc, roasterMock, _, _ := mockPublic()
+	roasterMock.On("GetBySlug", "kaldis").Return(roaster, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/roaster/kaldis", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
+func TestPublicRoasterFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, roasterMock, _, _ := mockPublic()
+	roasterMock.On("GetBySlug", "kaldis").Return(nil, fmt.Errorf("This is an error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/roaster/kaldis", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
+func TestPublicUserAvatarSVG(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "Jake", "Long", "jake@expresso.store", "", "", "", "", "", "", "")
+	tc, _, userMock := mockAvatar()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/user/"+user.ID.String()+"/avatar", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal("image/svg+xml", recorder.Header().Get("Content-Type"))
+	assert.Contains(recorder.Body.String(), ">JL</text>")
+}
+
+func TestPublicUserAvatarPNG(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "Jake", "Long", "jake@expresso.store", "", "", "", "", "", "", "")
+	tc, _, userMock := mockAvatar()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/user/"+user.ID.String()+"/avatar?format=png&size=64", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal("image/png", recorder.Header().Get("Content-Type"))
+	img, err := png.Decode(recorder.Body)
+	assert.NoError(err)
+	assert.Equal(64, img.Bounds().Dx())
+}
+
+func TestPublicUserAvatarBadSize(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "Jake", "Long", "jake@expresso.store", "", "", "", "", "", "", "")
+	tc, _, userMock := mockAvatar()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/user/"+user.ID.String()+"/avatar?format=png&size=5000", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestPublicUserAvatarNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _, userMock := mockAvatar()
+	userMock.On("GetByID", "missing").Return(nil, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/user/missing/avatar", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
+func TestPublicRoasterAvatarBySlug(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("Kaldi's Coffee", "owner@kaldis.com", "", "", "", "", "", "", "", "")
+	tc, roasterMock, _ := mockAvatar()
+	roasterMock.On("GetBySlug", "kaldis").Return(roaster, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/roaster/kaldis/avatar", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), ">KC</text>")
+}
+
+func TestPublicRoasterAvatarByID(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("Kaldi's Coffee", "owner@kaldis.com", "", "", "", "", "", "", "", "")
+	tc, roasterMock, _ := mockAvatar()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/public/roaster/"+roaster.ID.String()+"/avatar", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal("public, max-age=86400", recorder.Header().Get("Cache-Control"))
+}
@@ -4,11 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/jpeg"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
+	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
 	"github.com/jakelong95/TownCenter/models"
 
 	"github.com/pborman/uuid"
@@ -33,6 +39,89 @@ func TestRoasterViewSuccess(t *testing.T) {
 	assert.Equal(200, recorder.Code)
 }
 
+func TestRoasterViewNotModified(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.NewUUID()
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", id.String()).Return(&models.Roaster{ID: id, Name: "Kaldi's"}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+id.String(), nil)
+	tc.router.ServeHTTP(recorder, request)
+	etag := recorder.Header().Get("ETag")
+
+	revalidate := httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/roaster/"+id.String(), nil)
+	request.Header.Set("If-None-Match", etag)
+	tc.router.ServeHTTP(revalidate, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.NotEqual("", etag)
+	assert.Equal(304, revalidate.Code)
+	assert.Equal("", revalidate.Body.String())
+}
+
+func TestRoasterViewChangedETag(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.NewUUID()
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", id.String()).Return(&models.Roaster{ID: id, Name: "Kaldi's"}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+id.String(), nil)
+	request.Header.Set("If-None-Match", `"stale"`)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.NotEqual(`"stale"`, recorder.Header().Get("ETag"))
+}
+
+func TestRoasterBatchSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	found, missing := uuid.NewUUID(), uuid.NewUUID()
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByIDs", []string{found.String(), missing.String()}).Return([]*models.Roaster{{ID: found, Name: "Kaldi's"}}, nil)
+
+	body := `{"ids":["` + found.String() + `","` + missing.String() + `"]}`
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/batch", bytes.NewReader([]byte(body)))
+	tc.router.ServeHTTP(recorder, request)
+
+	var response struct {
+		Data map[string]*models.RoasterResult `json:"data"`
+	}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal("Kaldi's", response.Data[found.String()].Roaster.Name)
+	assert.False(response.Data[missing.String()].Found)
+}
+
+func TestRoasterBatchFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.NewUUID()
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByIDs", []string{id.String()}).Return(nil, fmt.Errorf("This is an error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/batch", bytes.NewReader([]byte(`{"ids":["`+id.String()+`"]}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
 func TestRoasterViewFail(t *testing.T) {
 	assert := assert.New(t)
 
@@ -55,7 +144,7 @@ func TestRoasterViewAllSuccess(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tc, roasterMock := mockRoaster()
-	roasterMock.On("GetAll", 0, 20).Return(make([]*models.Roaster, 0), nil)
+	roasterMock.On("GetAll", 0, 20, &models.ListFilter{Status: models.STATUS_ACTIVE}).Return(make([]*models.Roaster, 0), nil)
 
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest("GET", "/api/roaster", nil)
@@ -70,7 +159,7 @@ func TestRoasterViewAllFail(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tc, roasterMock := mockRoaster()
-	roasterMock.On("GetAll", 0, 20).Return(make([]*models.Roaster, 0), fmt.Errorf("This is an error"))
+	roasterMock.On("GetAll", 0, 20, &models.ListFilter{Status: models.STATUS_ACTIVE}).Return(make([]*models.Roaster, 0), fmt.Errorf("This is an error"))
 
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest("GET", "/api/roaster", nil)
@@ -85,7 +174,7 @@ func TestRoasterViewAllParams(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tc, roasterMock := mockRoaster()
-	roasterMock.On("GetAll", 20, 40).Return(make([]*models.Roaster, 0), nil)
+	roasterMock.On("GetAll", 20, 40, &models.ListFilter{Status: models.STATUS_ACTIVE}).Return(make([]*models.Roaster, 0), nil)
 
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest("GET", "/api/roaster?offset=20&limit=40", nil)
@@ -94,6 +183,216 @@ func TestRoasterViewAllParams(t *testing.T) {
 	assert.Equal(200, recorder.Code)
 }
 
+func TestRoasterViewAllUpdatedSince(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	since := time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetAll", 0, 20, &models.ListFilter{UpdatedSince: since, Status: models.STATUS_ACTIVE}).Return(make([]*models.Roaster, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster?updatedSince="+since.Format(time.RFC3339), nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestRoasterViewAllStatusAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetAll", 0, 20, &models.ListFilter{Status: models.STATUS_UNDER_REVIEW}).Return(make([]*models.Roaster, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster?status=under_review", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestRoasterViewAllStatusAll(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetAll", 0, 20, &models.ListFilter{}).Return(make([]*models.Roaster, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster?status=all", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestRoasterViewAllStatusNotAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _ := mockRoaster()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster?status=suspended", nil)
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+}
+
+func TestRoasterViewAllInvalidStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, _ := mockRoaster()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster?status=sleeping", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestRoasterViewAllInvalidFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _ := mockRoaster()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster?createdAfter=yesterday", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestRoasterNearbySuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetNearby", 42.03, -93.62, 50.0, 0, 20).Return(make([]*models.NearbyRoaster, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/nearby?lat=42.03&lng=-93.62&radiusKm=50", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	roasterMock.AssertNotCalled(t, "GetByID", "nearby")
+}
+
+func TestRoasterNearbyDefaultRadius(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetNearby", 42.03, -93.62, handlers.DefaultRadius, 0, 20).Return(make([]*models.NearbyRoaster, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/nearby?lat=42.03&lng=-93.62", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestRoasterNearbyInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _ := mockRoaster()
+
+	for _, query := range []string{"lng=-93.62", "lat=91&lng=0", "lat=0&lng=abc", "lat=0&lng=0&radiusKm=0", "lat=0&lng=0&radiusKm=501"} {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/roaster/nearby?"+query, nil)
+		tc.router.ServeHTTP(recorder, request)
+
+		assert.Equal(400, recorder.Code, query)
+	}
+}
+
+func TestRoasterNearbyFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetNearby", 0.0, 0.0, handlers.DefaultRadius, 0, 20).Return(nil, fmt.Errorf("This is an error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/nearby?lat=0&lng=0", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
+func TestRoasterSearchSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("Search", "kaldi ames", 0, 20).Return(make([]*models.Roaster, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/search?q=kaldi+ames", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestRoasterSearchNoQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _ := mockRoaster()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/search", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestRoasterSearchFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("Search", "kaldi", 0, 20).Return(nil, fmt.Errorf("This is an error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/search?q=kaldi", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
 func TestRoasterNewSuccess(t *testing.T) {
 	assert := assert.New(t)
 
@@ -111,6 +410,22 @@ func TestRoasterNewSuccess(t *testing.T) {
 	assert.Equal(200, recorder.Code)
 }
 
+func TestRoasterNewSlugTaken(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("SlugTaken", "kaldis", mock.AnythingOfType("string")).Return(true, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster", bytes.NewReader([]byte(`{"roaster":{"name":"Kaldi's","slug":"kaldis"}}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	roasterMock.AssertNotCalled(t, "Insert", mock.Anything)
+}
+
 func TestRoasterNewFail(t *testing.T) {
 	assert := assert.New(t)
 
@@ -127,6 +442,23 @@ func TestRoasterNewFail(t *testing.T) {
 	assert.Equal(500, recorder.Code)
 }
 
+func TestRoasterNewValidationError(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _ := mockRoaster()
+
+	roaster := models.NewRoaster("", "", "not a phone", "", "", "", "", "", "", "")
+	body, _ := json.Marshal(&handlers.RoasterInfo{Roaster: *roaster, UserID: uuid.NewUUID()})
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster", bytes.NewReader(body))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	assert.Contains(recorder.Body.String(), models.INVALID_PHONE)
+}
+
 /*func TestRoasterNewInvalid(t *testing.T) {
 	assert := assert.New(t)
 
@@ -151,6 +483,7 @@ func TestRoasterUpdateSuccess(t *testing.T) {
 	roaster := models.NewRoaster("", "", "", "", "", "", "", "", "", "")
 
 	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
 	roasterMock.On("Update", roaster, roaster.ID.String()).Return(nil)
 
 	recorder := httptest.NewRecorder()
@@ -168,6 +501,7 @@ func TestRoasterUpdateFail(t *testing.T) {
 	roaster := models.NewRoaster("", "", "", "", "", "", "", "", "", "")
 
 	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
 	roasterMock.On("Update", roaster, roaster.ID.String()).Return(fmt.Errorf("This is an error"))
 
 	recorder := httptest.NewRecorder()
@@ -177,6 +511,113 @@ func TestRoasterUpdateFail(t *testing.T) {
 	assert.Equal(500, recorder.Code)
 }
 
+func TestRoasterUpdateKeepsSlug(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	existing := models.NewRoaster("Kaldi's", "", "", "", "", "", "", "", "", "")
+	existing.Slug = "kaldis"
+	roaster := *existing
+	roaster.Slug = ""
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", existing.ID.String()).Return(existing, nil)
+	roasterMock.On("Update", mock.AnythingOfType("*models.Roaster"), existing.ID.String()).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+existing.ID.String(), getRoasterString(&roaster))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	updated := roasterMock.Calls[1].Arguments.Get(0).(*models.Roaster)
+	assert.Equal("kaldis", updated.Slug)
+	roasterMock.AssertNotCalled(t, "SlugTaken", mock.Anything, mock.Anything)
+}
+
+func TestRoasterUpdateSlugTaken(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	existing := models.NewRoaster("Kaldi's", "", "", "", "", "", "", "", "", "")
+	existing.Slug = "kaldis"
+	roaster := *existing
+	roaster.Slug = "best-coffee"
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", existing.ID.String()).Return(existing, nil)
+	roasterMock.On("SlugTaken", "best-coffee", existing.ID.String()).Return(true, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+existing.ID.String(), getRoasterString(&roaster))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	roasterMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestRoasterUpdateInvalidSlug(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("", "", "", "", "", "", "", "", "", "")
+	roaster.Slug = "Not A Slug"
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(models.NewRoaster("", "", "", "", "", "", "", "", "", ""), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+roaster.ID.String(), getRoasterString(roaster))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	assert.Contains(recorder.Body.String(), models.INVALID_SLUG)
+}
+
+func TestRoasterUpdateValidationError(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("A roaster name that is far too long", "", "", "", "", "", "", "", "", "")
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(models.NewRoaster("", "", "", "", "", "", "", "", "", ""), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+roaster.ID.String(), getRoasterString(roaster))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	assert.Contains(recorder.Body.String(), models.TOO_LONG)
+}
+
+func TestRoasterUpdateLegacyCountry(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	existing := models.NewRoaster("Kaldi's", "", "", "", "", "", "", "", "USA", "")
+	existing.Slug = "kaldis"
+	roaster := *existing
+	roaster.Name = "Kaldi's Coffee"
+
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", existing.ID.String()).Return(existing, nil)
+	roasterMock.On("Update", mock.AnythingOfType("*models.Roaster"), existing.ID.String()).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+existing.ID.String(), getRoasterString(&roaster))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	updated := roasterMock.Calls[1].Arguments.Get(0).(*models.Roaster)
+	assert.Equal("Kaldi's Coffee", updated.Name)
+	assert.Equal("USA", updated.AddressCountry)
+}
+
 func TestRoasterUpdateInvalid(t *testing.T) {
 	assert := assert.New(t)
 
@@ -228,3 +669,127 @@ func getRoasterString(m *models.Roaster) io.Reader {
 	s, _ := json.Marshal(m)
 	return bytes.NewReader(s)
 }
+
+func TestRoasterUploadSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 80, 80)), nil)
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("Profile", id, mock.AnythingOfType("*helpers.Photo")).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request := getPhotoRequest("/api/roaster/"+id+"/photo", buf.Bytes())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	photo := roasterMock.Calls[0].Arguments.Get(1).(*helpers.Photo)
+	assert.Equal(helpers.PHOTO_JPEG, photo.Format)
+}
+
+func TestRoasterDeletePhotoSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("Kaldi's", "owner@kaldis.com", "", "", "", "", "", "", "", "")
+	roaster.ProfileUrl = "https://s3.com/profile/id-1-original.png"
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	roasterMock.On("RemoveProfile", roaster.ID.String(), roaster.ProfileUrl).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/roaster/"+roaster.ID.String()+"/photo", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	roasterMock.AssertCalled(t, "RemoveProfile", roaster.ID.String(), roaster.ProfileUrl)
+}
+
+func TestRoasterDeletePhotoNoPhoto(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("Kaldi's", "owner@kaldis.com", "", "", "", "", "", "", "", "")
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/roaster/"+roaster.ID.String()+"/photo", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+	roasterMock.AssertNotCalled(t, "RemoveProfile", roaster.ID.String(), "")
+}
+
+func TestRoasterDeletePhotoFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := models.NewRoaster("Kaldi's", "owner@kaldis.com", "", "", "", "", "", "", "", "")
+	roaster.ProfileUrl = "https://s3.com/profile/id-1-original.png"
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	roasterMock.On("RemoveProfile", roaster.ID.String(), roaster.ProfileUrl).Return(fmt.Errorf("some error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/roaster/"+roaster.ID.String()+"/photo", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
+func TestRoasterPresignPhotoSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("PresignProfile", id, "image/jpeg").
+		Return(&models.PresignedUpload{Key: "upload/profile/" + id + "-1.jpg", URL: "https://s3.com/upload", Method: "PUT"}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+id+"/photo/upload", bytes.NewReader([]byte(`{"contentType":"image/jpeg"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), `"method":"PUT"`)
+}
+
+func TestRoasterCompletePhotoSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("CompleteProfile", id, "upload/profile/"+id+"-1.jpg").Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+id+"/photo/complete", bytes.NewReader([]byte(`{"key":"upload/profile/`+id+`-1.jpg"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestRoasterCompletePhotoNotPhoto(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	tc, roasterMock := mockRoaster()
+	roasterMock.On("CompleteProfile", id, "upload/profile/"+id+"-1.jpg").Return(helpers.ErrPhotoType)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+id+"/photo/complete", bytes.NewReader([]byte(`{"key":"upload/profile/`+id+`-1.jpg"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
@@ -0,0 +1,442 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+func TestStorefrontViewProfileSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	profile := models.NewRoasterProfile(uuid.NewUUID())
+	profile.Description = "Small batch"
+	tc, profileMock, _, _, _ := mockStorefront()
+	profileMock.On("Get", profile.RoasterID.String()).Return(profile, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+profile.RoasterID.String()+"/profile", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), "Small batch")
+}
+
+func TestStorefrontViewProfileEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster := &models.Roaster{ID: uuid.NewUUID()}
+	tc, profileMock, _, roasterMock, _ := mockStorefront()
+	profileMock.On("Get", roaster.ID.String()).Return(nil, nil)
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+roaster.ID.String()+"/profile", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), `"tags":[]`)
+}
+
+func TestStorefrontViewProfileNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	tc, profileMock, _, roasterMock, _ := mockStorefront()
+	profileMock.On("Get", id).Return(nil, nil)
+	roasterMock.On("GetByID", id).Return(nil, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+id+"/profile", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
+func TestStorefrontUpdateProfileSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, profileMock, _, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	profileMock.On("Set", mock.AnythingOfType("*models.RoasterProfile")).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+roaster.ID.String()+"/profile", bytes.NewReader([]byte(`{"description":"Small batch","founded":"2009","website":"https://kaldis.com","social":{"Instagram":"https://instagram.com/kaldis"},"hours":[{"day":"mon","open":"07:00","close":"15:00"}],"timezone":"America/Chicago","tags":["Espresso"]}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	profile := profileMock.Calls[0].Arguments.Get(0).(*models.RoasterProfile)
+	assert.Equal(roaster.ID, profile.RoasterID)
+	assert.Equal("2009-01-01", profile.Founded.String())
+	assert.Equal("https://instagram.com/kaldis", profile.Social["instagram"])
+	assert.Equal([]string{"espresso"}, profile.Tags)
+}
+
+func TestStorefrontUpdateProfileAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, profileMock, _, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	profileMock.On("Set", mock.AnythingOfType("*models.RoasterProfile")).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+roaster.ID.String()+"/profile", bytes.NewReader([]byte(`{"description":"Small batch"}`)))
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestStorefrontUpdateProfileNotOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, profileMock, _, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+roaster.ID.String()+"/profile", bytes.NewReader([]byte(`{"description":"Small batch"}`)))
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+	profileMock.AssertNotCalled(t, "Set", mock.AnythingOfType("*models.RoasterProfile"))
+}
+
+func TestStorefrontUpdateProfileValidationError(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _, _, _, _ := mockStorefront()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+uuid.New()+"/profile", bytes.NewReader([]byte(`{"website":"kaldis","hours":[{"day":"mon","open":"07:00","close":"15:00"}]}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	assert.Contains(recorder.Body.String(), models.INVALID_URL)
+	assert.Contains(recorder.Body.String(), models.REQUIRED)
+}
+
+func TestStorefrontUpdateProfileInvalidDate(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _, _, _, _ := mockStorefront()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+uuid.New()+"/profile", bytes.NewReader([]byte(`{"founded":"a while ago"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestStorefrontUpdateProfileFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, profileMock, _, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	profileMock.On("Set", mock.AnythingOfType("*models.RoasterProfile")).Return(fmt.Errorf("This is an error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+roaster.ID.String()+"/profile", bytes.NewReader([]byte(`{"description":"Small batch"}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
+func TestStorefrontViewGallerySuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	tc, _, galleryMock, _, _ := mockStorefront()
+	galleryMock.On("GetByRoaster", id).Return(make([]*models.GalleryImage, 0), nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+id+"/gallery", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestStorefrontAddImageSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	last := models.NewGalleryImage(roaster.ID, "", 4)
+	tc, _, galleryMock, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	galleryMock.On("GetByRoaster", roaster.ID.String()).Return([]*models.GalleryImage{last}, nil)
+	galleryMock.On("Insert", mock.AnythingOfType("*models.GalleryImage"), "beans.jpg", mock.Anything).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request := getImageRequest("/api/roaster/"+roaster.ID.String()+"/gallery", "Fresh beans")
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	image := galleryMock.Calls[1].Arguments.Get(0).(*models.GalleryImage)
+	assert.Equal("Fresh beans", image.Caption)
+	assert.Equal(5, image.Position)
+}
+
+func TestStorefrontAddImageNotOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, _, galleryMock, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+
+	recorder := httptest.NewRecorder()
+	request := getImageRequest("/api/roaster/"+roaster.ID.String()+"/gallery", "Fresh beans")
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+	galleryMock.AssertNotCalled(t, "Insert", mock.AnythingOfType("*models.GalleryImage"), "beans.jpg", mock.Anything)
+}
+
+func TestStorefrontAddImageGalleryFull(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	images := make([]*models.GalleryImage, models.MaxGallerySize)
+	tc, _, galleryMock, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	galleryMock.On("GetByRoaster", roaster.ID.String()).Return(images, nil)
+
+	recorder := httptest.NewRecorder()
+	request := getImageRequest("/api/roaster/"+roaster.ID.String()+"/gallery", "")
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestStorefrontAddImageMissingFile(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _, _, _, _ := mockStorefront()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+uuid.New()+"/gallery", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestStorefrontUpdateImageSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	image := models.NewGalleryImage(roaster.ID, "Old caption", 0)
+	tc, _, galleryMock, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	galleryMock.On("GetByID", image.ID.String()).Return(image, nil)
+	galleryMock.On("Update", image, image.ID.String()).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+roaster.ID.String()+"/gallery/"+image.ID.String(), bytes.NewReader([]byte(`{"caption":"New caption"}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal("New caption", image.Caption)
+}
+
+func TestStorefrontUpdateImageOtherRoaster(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	image := models.NewGalleryImage(uuid.NewUUID(), "", 0)
+	tc, _, galleryMock, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	galleryMock.On("GetByID", image.ID.String()).Return(image, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+roaster.ID.String()+"/gallery/"+image.ID.String(), bytes.NewReader([]byte(`{"caption":"New caption"}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
+func TestStorefrontOrderGallerySuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	first := models.NewGalleryImage(roaster.ID, "", 0)
+	second := models.NewGalleryImage(roaster.ID, "", 1)
+	ids := []string{second.ID.String(), first.ID.String()}
+	tc, _, galleryMock, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	galleryMock.On("GetByRoaster", roaster.ID.String()).Return([]*models.GalleryImage{first, second}, nil)
+	galleryMock.On("Reorder", roaster.ID.String(), ids).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+roaster.ID.String()+"/gallery", bytes.NewReader([]byte(`{"ids":["`+ids[0]+`","`+ids[1]+`"]}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal(0, second.Position)
+	assert.Equal(1, first.Position)
+}
+
+func TestStorefrontOrderGalleryIncomplete(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	first := models.NewGalleryImage(roaster.ID, "", 0)
+	second := models.NewGalleryImage(roaster.ID, "", 1)
+	tc, _, galleryMock, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	galleryMock.On("GetByRoaster", roaster.ID.String()).Return([]*models.GalleryImage{first, second}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+roaster.ID.String()+"/gallery", bytes.NewReader([]byte(`{"ids":["`+first.ID.String()+`","`+first.ID.String()+`"]}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestStorefrontDeleteImageSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	image := models.NewGalleryImage(roaster.ID, "", 0)
+	tc, _, galleryMock, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	galleryMock.On("GetByID", image.ID.String()).Return(image, nil)
+	galleryMock.On("Delete", image.ID.String()).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/roaster/"+roaster.ID.String()+"/gallery/"+image.ID.String(), nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestStorefrontDeleteImageNotOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	image := models.NewGalleryImage(roaster.ID, "", 0)
+	tc, _, galleryMock, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/roaster/"+roaster.ID.String()+"/gallery/"+image.ID.String(), nil)
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+	galleryMock.AssertNotCalled(t, "Delete", image.ID.String())
+}
+
+func TestStorefrontDeleteImageFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	image := models.NewGalleryImage(roaster.ID, "", 0)
+	tc, _, galleryMock, roasterMock, userMock := mockStorefront()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	galleryMock.On("GetByID", image.ID.String()).Return(image, nil)
+	galleryMock.On("Delete", image.ID.String()).Return(fmt.Errorf("This is an error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/roaster/"+roaster.ID.String()+"/gallery/"+image.ID.String(), nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
+func getImageRequest(url string, caption string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("caption", caption)
+	part, _ := writer.CreateFormFile("image", "beans.jpg")
+	part.Write([]byte("not really a jpeg"))
+	writer.Close()
+
+	request, _ := http.NewRequest("POST", url, body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
@@ -2,12 +2,14 @@ package router
 
 import (
 	"testing"
+	"time"
 
 	mockg "github.com/ghmeier/bloodlines/_mocks/gateways"
-	"github.com/ghmeier/bloodlines/config"
+	bconfig "github.com/ghmeier/bloodlines/config"
 	h "github.com/ghmeier/bloodlines/handlers"
 	m "github.com/ghmeier/bloodlines/models"
 	mocks "github.com/jakelong95/TownCenter/_mocks/helpers"
+	"github.com/jakelong95/TownCenter/config"
 	"github.com/jakelong95/TownCenter/handlers"
 	"github.com/jakelong95/TownCenter/models"
 
@@ -19,7 +21,7 @@ import (
 func TestNewSuccess(t *testing.T) {
 	assert := assert.New(t)
 
-	r, err := New(&config.Root{SQL: config.MySQL{}})
+	r, err := New(&config.Root{Root: &bconfig.Root{SQL: bconfig.MySQL{}}})
 
 	assert.NoError(err)
 	assert.NotNil(r)
@@ -34,9 +36,19 @@ func getMockTownCenter() *TownCenter {
 	}
 
 	return &TownCenter{
-		user:    handlers.NewUser(ctx),
-		roaster: handlers.NewRoaster(ctx),
-		reset:   handlers.NewReset(ctx),
+		user:       handlers.NewUser(ctx),
+		roaster:    handlers.NewRoaster(ctx),
+		reset:      handlers.NewReset(ctx),
+		phone:      handlers.NewPhone(ctx),
+		address:    handlers.NewAddress(ctx),
+		public:     handlers.NewPublic(ctx),
+		storefront: handlers.NewStorefront(ctx),
+		onboarding: handlers.NewOnboarding(ctx),
+		transfer:   handlers.NewTransfer(ctx),
+		imports:    handlers.NewImport(ctx),
+		exports:    handlers.NewExport(ctx),
+		webhooks:   handlers.NewWebhook(ctx),
+		idempotent: handlers.NewIdempotency(ctx),
 	}
 }
 
@@ -88,3 +100,188 @@ func mockReset() (*TownCenter, *mocks.UserI, *mocks.ResetI) {
 
 	return t, userHelper, resetMock
 }
+
+func mockPhone() (*TownCenter, *mocks.UserI, *mocks.RoasterI, *mocks.VerificationI, *mockg.Bloodlines) {
+	t := getMockTownCenter()
+	userHelper := new(mocks.UserI)
+	roasterHelper := new(mocks.RoasterI)
+	verificationMock := new(mocks.VerificationI)
+	bloodlines := new(mockg.Bloodlines)
+
+	t.phone = &handlers.Phone{
+		BaseHandler:  &h.BaseHandler{Stats: nil},
+		User:         userHelper,
+		Roaster:      roasterHelper,
+		Verification: verificationMock,
+		Bloodlines:   bloodlines,
+		Expiration:   time.Minute,
+		Cooldown:     time.Minute,
+	}
+	InitRouter(t)
+
+	return t, userHelper, roasterHelper, verificationMock, bloodlines
+}
+
+func mockAddress() (*TownCenter, *mocks.AddressI, *mocks.UserI) {
+	t := getMockTownCenter()
+	addressMock := new(mocks.AddressI)
+	userHelper := new(mocks.UserI)
+
+	t.address = &handlers.Address{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Helper:      addressMock,
+		UserHelper:  userHelper,
+	}
+	InitRouter(t)
+
+	return t, addressMock, userHelper
+}
+
+func mockPublic() (*TownCenter, *mocks.RoasterI, *mocks.RoasterProfileI, *mocks.GalleryI) {
+	t := getMockTownCenter()
+	roasterMock := new(mocks.RoasterI)
+	profileMock := new(mocks.RoasterProfileI)
+	galleryMock := new(mocks.GalleryI)
+
+	t.public = &handlers.Public{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Roaster:     roasterMock,
+		Profile:     profileMock,
+		Gallery:     galleryMock,
+	}
+	InitRouter(t)
+
+	return t, roasterMock, profileMock, galleryMock
+}
+
+func mockAvatar() (*TownCenter, *mocks.RoasterI, *mocks.UserI) {
+	t := getMockTownCenter()
+	roasterMock := new(mocks.RoasterI)
+	userMock := new(mocks.UserI)
+
+	t.public = &handlers.Public{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Roaster:     roasterMock,
+		User:        userMock,
+	}
+	InitRouter(t)
+
+	return t, roasterMock, userMock
+}
+
+func mockStorefront() (*TownCenter, *mocks.RoasterProfileI, *mocks.GalleryI, *mocks.RoasterI, *mocks.UserI) {
+	t := getMockTownCenter()
+	profileMock := new(mocks.RoasterProfileI)
+	galleryMock := new(mocks.GalleryI)
+	roasterMock := new(mocks.RoasterI)
+	userMock := new(mocks.UserI)
+
+	t.storefront = &handlers.Storefront{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Profile:     profileMock,
+		Gallery:     galleryMock,
+		Roaster:     roasterMock,
+		User:        userMock,
+	}
+	InitRouter(t)
+
+	return t, profileMock, galleryMock, roasterMock, userMock
+}
+
+func mockOnboarding() (*TownCenter, *mocks.StatusI, *mocks.DocumentI, *mocks.RoasterI, *mocks.UserI, *mockg.Bloodlines) {
+	t := getMockTownCenter()
+	statusMock := new(mocks.StatusI)
+	documentMock := new(mocks.DocumentI)
+	roasterMock := new(mocks.RoasterI)
+	userMock := new(mocks.UserI)
+	bloodlines := new(mockg.Bloodlines)
+
+	t.onboarding = &handlers.Onboarding{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Helper:      statusMock,
+		Document:    documentMock,
+		Roaster:     roasterMock,
+		UserHelper:  userMock,
+		Bloodlines:  bloodlines,
+	}
+	InitRouter(t)
+
+	return t, statusMock, documentMock, roasterMock, userMock, bloodlines
+}
+
+func mockTransfer() (*TownCenter, *mocks.TransferI, *mocks.RoasterI, *mocks.UserI, *mockg.Bloodlines) {
+	t := getMockTownCenter()
+	transferMock := new(mocks.TransferI)
+	roasterMock := new(mocks.RoasterI)
+	userMock := new(mocks.UserI)
+	bloodlines := new(mockg.Bloodlines)
+
+	t.transfer = &handlers.Transfer{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Helper:      transferMock,
+		Roaster:     roasterMock,
+		UserHelper:  userMock,
+		Bloodlines:  bloodlines,
+		Expiration:  time.Hour,
+	}
+	InitRouter(t)
+
+	return t, transferMock, roasterMock, userMock, bloodlines
+}
+
+func mockImport() (*TownCenter, *mocks.ImportI, *mocks.ImporterI) {
+	t := getMockTownCenter()
+	importMock := new(mocks.ImportI)
+	importerMock := new(mocks.ImporterI)
+
+	t.imports = &handlers.Import{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Helper:      importMock,
+		Importer:    importerMock,
+	}
+	InitRouter(t)
+
+	return t, importMock, importerMock
+}
+
+func mockExport() (*TownCenter, *mocks.ExportI) {
+	t := getMockTownCenter()
+	exportMock := new(mocks.ExportI)
+
+	t.exports = &handlers.Export{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Helper:      exportMock,
+	}
+	InitRouter(t)
+
+	return t, exportMock
+}
+
+func mockWebhook() (*TownCenter, *mocks.WebhookI, *mocks.RoasterI, *mocks.UserI) {
+	t := getMockTownCenter()
+	webhookMock := new(mocks.WebhookI)
+	roasterMock := new(mocks.RoasterI)
+	userMock := new(mocks.UserI)
+
+	t.webhooks = &handlers.Webhook{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Helper:      webhookMock,
+		Roaster:     roasterMock,
+		UserHelper:  userMock,
+	}
+	InitRouter(t)
+
+	return t, webhookMock, roasterMock, userMock
+}
+
+func mockIdempotency(t *TownCenter) *mocks.IdempotencyI {
+	idempotencyMock := new(mocks.IdempotencyI)
+
+	t.idempotent = &handlers.Idempotency{
+		BaseHandler: &h.BaseHandler{Stats: nil},
+		Helper:      idempotencyMock,
+	}
+	InitRouter(t)
+
+	return idempotencyMock
+}
@@ -0,0 +1,331 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	bmodels "github.com/ghmeier/bloodlines/models"
+	"github.com/jakelong95/TownCenter/helpers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+func TestTransferInitiateSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	recipient := &models.User{ID: uuid.NewUUID(), Email: "new@owner.com"}
+	tc, transferMock, roasterMock, userMock, bloodlines := mockTransfer()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	userMock.On("GetByEmail", "new@owner.com").Return(recipient, nil)
+	transferMock.On("GetPending", roaster.ID.String()).Return(nil, nil)
+	transferMock.On("Insert", mock.AnythingOfType("*models.Transfer")).Return(nil)
+	bloodlines.On("ActivateTrigger", "roaster_transfer", mock.AnythingOfType("*models.Receipt")).Return(&bmodels.Receipt{}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/transfer", bytes.NewReader([]byte(`{"email":"new@owner.com"}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	transfer := transferMock.Calls[1].Arguments.Get(0).(*models.Transfer)
+	assert.Equal(owner.ID, transfer.FromUserID)
+	assert.Equal(recipient.ID, transfer.ToUserID)
+	receipt := bloodlines.Calls[0].Arguments.Get(1).(*bmodels.Receipt)
+	assert.Equal(recipient.ID, receipt.UserID)
+	assert.Equal("https://expresso.store/transfer/"+transfer.Token, receipt.Values["accept_link"])
+	assert.NotContains(recorder.Body.String(), transfer.Token)
+}
+
+func TestTransferInitiateNotOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, _, roasterMock, userMock, _ := mockTransfer()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/transfer", bytes.NewReader([]byte(`{"email":"new@owner.com"}`)))
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+}
+
+func TestTransferInitiateRecipientOwnsRoaster(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	recipient := &models.User{ID: uuid.NewUUID(), Email: "new@owner.com", RoasterId: uuid.NewUUID()}
+	tc, _, roasterMock, userMock, _ := mockTransfer()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	userMock.On("GetByEmail", "new@owner.com").Return(recipient, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/transfer", bytes.NewReader([]byte(`{"email":"new@owner.com"}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestTransferInitiateAlreadyPending(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	recipient := &models.User{ID: uuid.NewUUID(), Email: "new@owner.com"}
+	pending := models.NewTransfer(roaster.ID, owner.ID, uuid.NewUUID(), "other@owner.com", time.Hour)
+	tc, transferMock, roasterMock, userMock, _ := mockTransfer()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	userMock.On("GetByEmail", "new@owner.com").Return(recipient, nil)
+	transferMock.On("GetPending", roaster.ID.String()).Return(pending, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/transfer", bytes.NewReader([]byte(`{"email":"new@owner.com"}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	transferMock.AssertNotCalled(t, "Insert", mock.Anything)
+}
+
+func TestTransferInitiateNoUser(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, _, roasterMock, userMock, _ := mockTransfer()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	userMock.On("GetByEmail", "nobody@owner.com").Return(nil, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/transfer", bytes.NewReader([]byte(`{"email":"nobody@owner.com"}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
+func TestTransferViewExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	pending := models.NewTransfer(roaster.ID, owner.ID, uuid.NewUUID(), "new@owner.com", -time.Hour)
+	tc, transferMock, roasterMock, userMock, _ := mockTransfer()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	transferMock.On("GetPending", roaster.ID.String()).Return(pending, nil)
+	transferMock.On("SetStatus", pending, models.TRANSFER_EXPIRED).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+roaster.ID.String()+"/transfer", nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+	transferMock.AssertExpectations(t)
+}
+
+func TestTransferCancelSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	pending := models.NewTransfer(roaster.ID, owner.ID, uuid.NewUUID(), "new@owner.com", time.Hour)
+	tc, transferMock, roasterMock, userMock, _ := mockTransfer()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	transferMock.On("GetPending", roaster.ID.String()).Return(pending, nil)
+	transferMock.On("SetStatus", pending, models.TRANSFER_CANCELLED).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/roaster/"+roaster.ID.String()+"/transfer", nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	transferMock.AssertExpectations(t)
+}
+
+func TestTransferViewByTokenNotRecipient(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	transfer := models.NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", time.Hour)
+	tc, transferMock, _, _, _ := mockTransfer()
+	transferMock.On("GetByToken", transfer.Token).Return(transfer, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/transfer/"+transfer.Token, nil)
+	request.Header.Set("X-UserId", transfer.FromUserID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+}
+
+func TestTransferAcceptSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	recipient := &models.User{ID: uuid.NewUUID(), Email: "new@owner.com"}
+	transfer := models.NewTransfer(roaster.ID, owner.ID, recipient.ID, recipient.Email, time.Hour)
+	tc, transferMock, roasterMock, userMock, bloodlines := mockTransfer()
+	transferMock.On("GetByToken", transfer.Token).Return(transfer, nil)
+	userMock.On("GetByID", recipient.ID.String()).Return(recipient, nil)
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	transferMock.On("Accept", transfer).Return(nil)
+	roasterMock.On("CreateAccount", recipient.ID).Return(nil)
+	bloodlines.On("ActivateTrigger", "roaster_transfer_accepted", mock.AnythingOfType("*models.Receipt")).Return(&bmodels.Receipt{}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/transfer/"+transfer.Token+"/accept", nil)
+	request.Header.Set("X-UserId", recipient.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	transferMock.AssertExpectations(t)
+	roasterMock.AssertCalled(t, "CreateAccount", recipient.ID)
+	receipt := bloodlines.Calls[0].Arguments.Get(1).(*bmodels.Receipt)
+	assert.Equal(owner.ID, receipt.UserID)
+}
+
+func TestTransferAcceptAccountFails(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	recipient := &models.User{ID: uuid.NewUUID(), Email: "new@owner.com"}
+	transfer := models.NewTransfer(roaster.ID, owner.ID, recipient.ID, recipient.Email, time.Hour)
+	tc, transferMock, roasterMock, userMock, bloodlines := mockTransfer()
+	transferMock.On("GetByToken", transfer.Token).Return(transfer, nil)
+	userMock.On("GetByID", recipient.ID.String()).Return(recipient, nil)
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	transferMock.On("Accept", transfer).Return(nil)
+	roasterMock.On("CreateAccount", recipient.ID).Return(fmt.Errorf("This is an error"))
+	bloodlines.On("ActivateTrigger", "roaster_transfer_accepted", mock.AnythingOfType("*models.Receipt")).Return(&bmodels.Receipt{}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/transfer/"+transfer.Token+"/accept", nil)
+	request.Header.Set("X-UserId", recipient.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+	transferMock.AssertExpectations(t)
+}
+
+func TestTransferAcceptNoLongerPending(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	recipient := &models.User{ID: uuid.NewUUID(), Email: "new@owner.com"}
+	transfer := models.NewTransfer(roaster.ID, owner.ID, recipient.ID, recipient.Email, time.Hour)
+	tc, transferMock, roasterMock, userMock, bloodlines := mockTransfer()
+	transferMock.On("GetByToken", transfer.Token).Return(transfer, nil)
+	userMock.On("GetByID", recipient.ID.String()).Return(recipient, nil)
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	transferMock.On("Accept", transfer).Return(helpers.ErrTransferNotPending)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/transfer/"+transfer.Token+"/accept", nil)
+	request.Header.Set("X-UserId", recipient.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	roasterMock.AssertNotCalled(t, "CreateAccount", mock.Anything)
+	bloodlines.AssertNotCalled(t, "ActivateTrigger", mock.Anything, mock.Anything)
+}
+
+func TestTransferAcceptFails(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	recipient := &models.User{ID: uuid.NewUUID(), Email: "new@owner.com"}
+	transfer := models.NewTransfer(roaster.ID, owner.ID, recipient.ID, recipient.Email, time.Hour)
+	tc, transferMock, roasterMock, userMock, bloodlines := mockTransfer()
+	transferMock.On("GetByToken", transfer.Token).Return(transfer, nil)
+	userMock.On("GetByID", recipient.ID.String()).Return(recipient, nil)
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	transferMock.On("Accept", transfer).Return(fmt.Errorf("This is an error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/transfer/"+transfer.Token+"/accept", nil)
+	request.Header.Set("X-UserId", recipient.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+	roasterMock.AssertNotCalled(t, "CreateAccount", mock.Anything)
+	bloodlines.AssertNotCalled(t, "ActivateTrigger", mock.Anything, mock.Anything)
+}
+
+func TestTransferAcceptExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	transfer := models.NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", -time.Hour)
+	tc, transferMock, _, _, _ := mockTransfer()
+	transferMock.On("GetByToken", transfer.Token).Return(transfer, nil)
+	transferMock.On("SetStatus", transfer, models.TRANSFER_EXPIRED).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/transfer/"+transfer.Token+"/accept", nil)
+	request.Header.Set("X-UserId", transfer.ToUserID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	transferMock.AssertExpectations(t)
+}
+
+func TestTransferDeclineSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	transfer := models.NewTransfer(uuid.NewUUID(), uuid.NewUUID(), uuid.NewUUID(), "new@owner.com", time.Hour)
+	tc, transferMock, _, _, bloodlines := mockTransfer()
+	transferMock.On("GetByToken", transfer.Token).Return(transfer, nil)
+	transferMock.On("SetStatus", transfer, models.TRANSFER_DECLINED).Return(nil)
+	bloodlines.On("ActivateTrigger", "roaster_transfer_declined", mock.AnythingOfType("*models.Receipt")).Return(&bmodels.Receipt{}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/transfer/"+transfer.Token+"/decline", nil)
+	request.Header.Set("X-UserId", transfer.ToUserID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	transferMock.AssertExpectations(t)
+	bloodlines.AssertExpectations(t)
+}
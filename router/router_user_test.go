@@ -4,11 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/png"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
+	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/helpers"
 	"github.com/jakelong95/TownCenter/models"
 
 	"github.com/pborman/uuid"
@@ -33,6 +39,86 @@ func TestUserViewSuccess(t *testing.T) {
 	assert.Equal(200, recorder.Code)
 }
 
+func TestUserViewNotModified(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.NewUUID()
+	tc, userMock := mockUser()
+	userMock.On("GetByID", id.String()).Return(&models.User{ID: id, Email: "jake@expresso.store"}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/user/"+id.String(), nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	revalidate := httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/user/"+id.String(), nil)
+	request.Header.Set("If-None-Match", `"other", `+recorder.Header().Get("ETag"))
+	tc.router.ServeHTTP(revalidate, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Equal(304, revalidate.Code)
+	assert.Equal(recorder.Header().Get("ETag"), revalidate.Header().Get("ETag"))
+}
+
+func TestUserBatchSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	found, missing := uuid.NewUUID(), uuid.NewUUID()
+	tc, userMock := mockUser()
+	userMock.On("GetByIDs", []string{found.String(), missing.String()}).Return([]*models.User{{ID: found, PassHash: "hash"}}, nil)
+
+	body := `{"ids":["` + found.String() + `","` + missing.String() + `","` + found.String() + `"]}`
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/batch", bytes.NewReader([]byte(body)))
+	tc.router.ServeHTTP(recorder, request)
+
+	var response struct {
+		Data map[string]*models.UserResult `json:"data"`
+	}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+
+	assert.Equal(200, recorder.Code)
+	assert.NotContains(recorder.Body.String(), `"hash"`)
+	assert.Equal(2, len(response.Data))
+	assert.True(response.Data[found.String()].Found)
+	assert.Equal(found, response.Data[found.String()].User.ID)
+	assert.False(response.Data[missing.String()].Found)
+	assert.Nil(response.Data[missing.String()].User)
+}
+
+func TestUserBatchEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, userMock := mockUser()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/batch", bytes.NewReader([]byte(`{"ids":[]}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	userMock.AssertNotCalled(t, "GetByIDs", mock.Anything)
+}
+
+func TestUserPostUnknown(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _ := mockUser()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+uuid.New(), bytes.NewReader([]byte(`{}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
 func TestUserViewFail(t *testing.T) {
 	assert := assert.New(t)
 
@@ -49,13 +135,115 @@ func TestUserViewFail(t *testing.T) {
 	assert.Equal(500, recorder.Code)
 }
 
+func TestUserSearchSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, uuid.New()+","+admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, userMock := mockUser()
+	userMock.On("Search", "jake", 0, 20).Return([]*models.User{{PassHash: "hash"}}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/user/search?q=jake", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.NotContains(recorder.Body.String(), `"hash"`)
+}
+
+func TestUserSearchNotAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	os.Setenv(handlers.AdminUsers, uuid.New())
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, userMock := mockUser()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/user/search?q=jake", nil)
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+	userMock.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything)
+	userMock.AssertNotCalled(t, "GetByID", "search")
+}
+
+func TestUserViewByEmailSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, userMock := mockUser()
+	userMock.On("GetByEmail", "jake+1@expresso.store").Return(&models.User{Email: "jake+1@expresso.store", PassHash: "hash"}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/user/email?email=jake%2B1%40expresso.store", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.NotContains(recorder.Body.String(), `"hash"`)
+	userMock.AssertNotCalled(t, "GetByID", "email")
+}
+
+func TestUserViewByEmailNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, userMock := mockUser()
+	userMock.On("GetByEmail", "jake@expresso.store").Return(nil, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/user/email?email=jake@expresso.store", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
+func TestUserViewByEmailNotAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	os.Setenv(handlers.AdminUsers, uuid.New())
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	tc, userMock := mockUser()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/user/email?email=jake@expresso.store", nil)
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+	userMock.AssertNotCalled(t, "GetByEmail", mock.Anything)
+}
+
 // func TestUserViewAllSuccess(t *testing.T) {
 // 	assert := assert.New(t)
 
 // 	gin.SetMode(gin.TestMode)
 
 // 	tc, userMock := mockUser()
-// 	userMock.On("GetAll", 0, 20).Return(make([]*models.User, 0), nil)
+// 	userMock.On("GetAll", 0, 20, &models.ListFilter{}).Return(make([]*models.User, 0), nil)
 
 // 	recorder := httptest.NewRecorder()
 // 	request, _ := http.NewRequest("GET", "/api/user", nil)
@@ -70,7 +258,7 @@ func TestUserViewFail(t *testing.T) {
 // 	gin.SetMode(gin.TestMode)
 
 // 	tc, userMock := mockUser()
-// 	userMock.On("GetAll", 0, 20).Return(make([]*models.User, 0), fmt.Errorf("This is an error"))
+// 	userMock.On("GetAll", 0, 20, &models.ListFilter{}).Return(make([]*models.User, 0), fmt.Errorf("This is an error"))
 
 // 	recorder := httptest.NewRecorder()
 // 	request, _ := http.NewRequest("GET", "/api/user/list", nil)
@@ -85,7 +273,7 @@ func TestUserViewFail(t *testing.T) {
 // 	gin.SetMode(gin.TestMode)
 
 // 	tc, userMock := mockUser()
-// 	userMock.On("GetAll", 20, 40).Return(make([]*models.User, 0), nil)
+// 	userMock.On("GetAll", 20, 40, &models.ListFilter{}).Return(make([]*models.User, 0), nil)
 
 // 	recorder := httptest.NewRecorder()
 // 	request, _ := http.NewRequest("GET", "/api/user/list?offset=20&limit=40", nil)
@@ -162,6 +350,22 @@ func TestUserNewInvalid(t *testing.T) {
 	assert.Equal(400, recorder.Code)
 }
 
+func TestUserNewValidationError(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, _ := mockUser()
+
+	user := getUserString(models.NewUser("", "", "", "not-an-email", "", "", "", "", "", "", ""))
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user", user)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	assert.Contains(recorder.Body.String(), models.INVALID_EMAIL)
+}
+
 func TestUserUpdateSuccess(t *testing.T) {
 	assert := assert.New(t)
 
@@ -215,6 +419,87 @@ func TestUserUpdateInvalid(t *testing.T) {
 	assert.Equal(400, recorder.Code)
 }
 
+func TestUserUpdateValidationError(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+	user := models.NewUser("", "", "", "", "", "", "", "", "", "", "")
+
+	tc, userMock := mockUser()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+
+	update := models.NewUser("", "", "", "", "", "", "", "", "", "", "Nowhere")
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/user/"+user.ID.String(), getUserString(update))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	assert.Contains(recorder.Body.String(), models.INVALID_COUNTRY)
+}
+
+func TestUserUpdateLegacyCountry(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+	user := models.NewUser("", "First", "", "", "", "", "", "", "", "", "USA")
+
+	tc, userMock := mockUser()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	userMock.On("Update", mock.AnythingOfType("*models.User"), user.ID.String()).Return(nil)
+
+	update := models.NewUser("", "Other", "", "", "", "", "", "", "", "", "")
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/user/"+user.ID.String(), getUserString(update))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	updated := userMock.Calls[1].Arguments.Get(0).(*models.User)
+	assert.Equal("Other", updated.FirstName)
+	assert.Equal("USA", updated.AddressCountry)
+}
+
+func TestUserUpdateUpperCasesCountry(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+	user := models.NewUser("", "", "", "", "", "", "", "", "", "", "USA")
+
+	tc, userMock := mockUser()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	userMock.On("Update", mock.AnythingOfType("*models.User"), user.ID.String()).Return(nil)
+
+	update := models.NewUser("", "", "", "", "", "", "", "", "", "", " us ")
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/user/"+user.ID.String(), getUserString(update))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	updated := userMock.Calls[1].Arguments.Get(0).(*models.User)
+	assert.Equal("US", updated.AddressCountry)
+}
+
+func TestUserUpdateNormalizesPhone(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+	user := models.NewUser("", "", "", "", "+15155550123", "", "", "", "", "", "US")
+	user.PhoneVerified = true
+
+	tc, userMock := mockUser()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	userMock.On("Update", mock.AnythingOfType("*models.User"), user.ID.String()).Return(nil)
+
+	update := models.NewUser("", "", "", "", "(515) 555-0199", "", "", "", "", "", "")
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/user/"+user.ID.String(), getUserString(update))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	updated := userMock.Calls[1].Arguments.Get(0).(*models.User)
+	assert.Equal("+15155550199", updated.Phone)
+	assert.False(updated.PhoneVerified)
+}
+
 func TestUserUpdateNoUser(t *testing.T) {
 	assert := assert.New(t)
 
@@ -254,6 +539,7 @@ func TestUserUpdateMaintainRoasterID(t *testing.T) {
 	user := models.NewUser("", "", "", "", "", "", "", "", "", "", "")
 	existing := models.NewUser("", "", "", "", "", "", "", "", "", "", "")
 	existing.ID = user.ID
+	existing.SetPhotos()
 	existing.RoasterId = uuid.NewUUID()
 
 	tc, userMock := mockUser()
@@ -304,3 +590,202 @@ func getUserString(m *models.User) io.Reader {
 	s, _ := json.Marshal(m)
 	return bytes.NewReader(s)
 }
+
+func TestUserUploadSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 300, 200)))
+	tc, userMock := mockUser()
+	userMock.On("Profile", id, mock.AnythingOfType("*helpers.Photo")).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request := getPhotoRequest("/api/user/"+id+"/photo", buf.Bytes())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	photo := userMock.Calls[0].Arguments.Get(1).(*helpers.Photo)
+	assert.Equal(helpers.PHOTO_PNG, photo.Format)
+	assert.Equal(4, len(photo.Sizes))
+}
+
+func TestUserUploadNotImage(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, userMock := mockUser()
+
+	recorder := httptest.NewRecorder()
+	request := getPhotoRequest("/api/user/"+uuid.New()+"/photo", []byte("<html>surprise</html>"))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	userMock.AssertNotCalled(t, "Profile", mock.Anything, mock.Anything)
+}
+
+func getPhotoRequest(url string, photo []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("profile", "me.jpg")
+	part.Write(photo)
+	writer.Close()
+
+	request, _ := http.NewRequest("POST", url, body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+func TestUserDeletePhotoSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "Jake", "Long", "jake@expresso.store", "", "", "", "", "", "", "")
+	user.ProfileURL = "https://s3.com/profile/id-1-original.png"
+	tc, userMock := mockUser()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	userMock.On("RemoveProfile", user.ID.String(), user.ProfileURL).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/user/"+user.ID.String()+"/photo", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	userMock.AssertCalled(t, "RemoveProfile", user.ID.String(), user.ProfileURL)
+}
+
+func TestUserDeletePhotoNoPhoto(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "Jake", "Long", "jake@expresso.store", "", "", "", "", "", "", "")
+	tc, userMock := mockUser()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/user/"+user.ID.String()+"/photo", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+	userMock.AssertNotCalled(t, "RemoveProfile", user.ID.String(), "")
+}
+
+func TestUserDeletePhotoFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	user := models.NewUser("", "Jake", "Long", "jake@expresso.store", "", "", "", "", "", "", "")
+	user.ProfileURL = "https://s3.com/profile/id-1-original.png"
+	tc, userMock := mockUser()
+	userMock.On("GetByID", user.ID.String()).Return(user, nil)
+	userMock.On("RemoveProfile", user.ID.String(), user.ProfileURL).Return(fmt.Errorf("some error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/user/"+user.ID.String()+"/photo", nil)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
+func TestUserPresignPhotoSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	tc, userMock := mockUser()
+	userMock.On("PresignProfile", id, "image/png").
+		Return(&models.PresignedUpload{Key: "upload/profile/" + id + "-1.png", URL: "https://s3.com/upload", Method: "PUT"}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+id+"/photo/upload", bytes.NewReader([]byte(`{"contentType":"image/png"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), `"url":"https://s3.com/upload"`)
+}
+
+func TestUserPresignPhotoType(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	tc, userMock := mockUser()
+	userMock.On("PresignProfile", id, "image/gif").Return(nil, helpers.ErrPhotoType)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+id+"/photo/upload", bytes.NewReader([]byte(`{"contentType":"image/gif"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestUserCompletePhotoSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	tc, userMock := mockUser()
+	userMock.On("CompleteProfile", id, "upload/profile/"+id+"-1.png").Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+id+"/photo/complete", bytes.NewReader([]byte(`{"key":"upload/profile/`+id+`-1.png"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+}
+
+func TestUserCompletePhotoNoKey(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	tc, userMock := mockUser()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+uuid.New()+"/photo/complete", bytes.NewReader([]byte(`{}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	userMock.AssertNotCalled(t, "CompleteProfile", mock.Anything, mock.Anything)
+}
+
+func TestUserCompletePhotoMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	tc, userMock := mockUser()
+	userMock.On("CompleteProfile", id, "upload/profile/"+id+"-1.png").Return(helpers.ErrUploadMissing)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+id+"/photo/complete", bytes.NewReader([]byte(`{"key":"upload/profile/`+id+`-1.png"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+}
+
+func TestUserCompletePhotoFail(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	id := uuid.New()
+	tc, userMock := mockUser()
+	userMock.On("CompleteProfile", id, "upload/profile/"+id+"-1.png").Return(fmt.Errorf("some error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/user/"+id+"/photo/complete", bytes.NewReader([]byte(`{"key":"upload/profile/`+id+`-1.png"}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
@@ -0,0 +1,268 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jakelong95/TownCenter/handlers"
+	"github.com/jakelong95/TownCenter/models"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/gin-gonic/gin.v1"
+)
+
+func TestWebhookNewSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, webhookMock, roasterMock, userMock := mockWebhook()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	webhookMock.On("Insert", mock.AnythingOfType("*models.Webhook")).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/webhooks", bytes.NewReader([]byte(`{"url":"https://roaster.com/hooks","events":["user.created","roaster.updated"]}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	webhook := webhookMock.Calls[0].Arguments.Get(0).(*models.Webhook)
+	assert.Equal(roaster.ID, webhook.RoasterID)
+	assert.Equal([]string{models.USER_CREATED, models.ROASTER_UPDATED}, webhook.Events)
+	assert.Contains(recorder.Body.String(), `"secret":"`+webhook.Secret+`"`)
+}
+
+func TestWebhookNewInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, _ := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, webhookMock, _, _ := mockWebhook()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/webhooks", bytes.NewReader([]byte(`{"url":"https://roaster.com/hooks","events":["order.created"]}`)))
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(400, recorder.Code)
+	assert.Contains(recorder.Body.String(), models.INVALID_EVENT)
+	webhookMock.AssertNotCalled(t, "Insert", mock.Anything)
+}
+
+func TestWebhookNewNotOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	tc, webhookMock, roasterMock, userMock := mockWebhook()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/webhooks", bytes.NewReader([]byte(`{"url":"https://roaster.com/hooks","events":["user.created"]}`)))
+	request.Header.Set("X-UserId", uuid.New())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(403, recorder.Code)
+	webhookMock.AssertNotCalled(t, "Insert", mock.Anything)
+}
+
+func TestWebhookViewAllAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	os.Setenv(handlers.AdminUsers, admin)
+	defer os.Unsetenv(handlers.AdminUsers)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	webhook := getRouterWebhook(roaster)
+	tc, webhookMock, roasterMock, userMock := mockWebhook()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	webhookMock.On("GetByRoaster", roaster.ID.String()).Return([]*models.Webhook{webhook}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+roaster.ID.String()+"/webhooks", nil)
+	request.Header.Set("X-UserId", admin)
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), webhook.ID.String())
+	assert.NotContains(recorder.Body.String(), "secret")
+}
+
+func TestWebhookViewOtherRoaster(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	other, _ := getOnboardingRoaster(models.STATUS_ACTIVE)
+	webhook := getRouterWebhook(other)
+	tc, webhookMock, roasterMock, userMock := mockWebhook()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+roaster.ID.String()+"/webhooks/"+webhook.ID.String(), nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+}
+
+func TestWebhookUpdateSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	webhook := getRouterWebhook(roaster)
+	secret := webhook.Secret
+	tc, webhookMock, roasterMock, userMock := mockWebhook()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("Update", webhook).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("PUT", "/api/roaster/"+roaster.ID.String()+"/webhooks/"+webhook.ID.String(), bytes.NewReader([]byte(`{"url":"https://roaster.com/new","events":["roaster.deleted"],"active":false}`)))
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	updated := webhookMock.Calls[1].Arguments.Get(0).(*models.Webhook)
+	assert.Equal("https://roaster.com/new", updated.URL)
+	assert.False(updated.Active)
+	assert.NotContains(recorder.Body.String(), secret)
+}
+
+func TestWebhookDeleteSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	webhook := getRouterWebhook(roaster)
+	tc, webhookMock, roasterMock, userMock := mockWebhook()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("Delete", webhook.ID.String()).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("DELETE", "/api/roaster/"+roaster.ID.String()+"/webhooks/"+webhook.ID.String(), nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	webhookMock.AssertCalled(t, "Delete", webhook.ID.String())
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	webhook := getRouterWebhook(roaster)
+	delivery := &models.WebhookDelivery{ID: uuid.NewUUID(), WebhookID: webhook.ID, Status: models.DELIVERY_FAILED}
+	tc, webhookMock, roasterMock, userMock := mockWebhook()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("GetDeliveries", webhook.ID.String(), 0, 20).Return([]*models.WebhookDelivery{delivery}, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/roaster/"+roaster.ID.String()+"/webhooks/"+webhook.ID.String()+"/deliveries?offset=0&limit=20", nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	assert.Contains(recorder.Body.String(), delivery.ID.String())
+}
+
+func TestWebhookReplaySuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	webhook := getRouterWebhook(roaster)
+	delivery := &models.WebhookDelivery{ID: uuid.NewUUID(), WebhookID: webhook.ID, Status: models.DELIVERY_FAILED}
+	tc, webhookMock, roasterMock, userMock := mockWebhook()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("GetDelivery", delivery.ID.String()).Return(delivery, nil)
+	webhookMock.On("Replay", delivery).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/webhooks/"+webhook.ID.String()+"/deliveries/"+delivery.ID.String()+"/replay", nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(200, recorder.Code)
+	webhookMock.AssertCalled(t, "Replay", delivery)
+}
+
+func TestWebhookReplayOtherWebhook(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	webhook := getRouterWebhook(roaster)
+	delivery := &models.WebhookDelivery{ID: uuid.NewUUID(), WebhookID: uuid.NewUUID()}
+	tc, webhookMock, roasterMock, userMock := mockWebhook()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("GetDelivery", delivery.ID.String()).Return(delivery, nil)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/webhooks/"+webhook.ID.String()+"/deliveries/"+delivery.ID.String()+"/replay", nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(404, recorder.Code)
+	webhookMock.AssertNotCalled(t, "Replay", mock.Anything)
+}
+
+func TestWebhookReplayError(t *testing.T) {
+	assert := assert.New(t)
+
+	gin.SetMode(gin.TestMode)
+
+	roaster, owner := getOnboardingRoaster(models.STATUS_ACTIVE)
+	webhook := getRouterWebhook(roaster)
+	tc, webhookMock, roasterMock, userMock := mockWebhook()
+	roasterMock.On("GetByID", roaster.ID.String()).Return(roaster, nil)
+	userMock.On("GetByRoaster", roaster.ID.String()).Return(owner, nil)
+	webhookMock.On("GetByID", webhook.ID.String()).Return(webhook, nil)
+	webhookMock.On("GetDelivery", "missing").Return(nil, fmt.Errorf("some error"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/api/roaster/"+roaster.ID.String()+"/webhooks/"+webhook.ID.String()+"/deliveries/missing/replay", nil)
+	request.Header.Set("X-UserId", owner.ID.String())
+	tc.router.ServeHTTP(recorder, request)
+
+	assert.Equal(500, recorder.Code)
+}
+
+func getRouterWebhook(roaster *models.Roaster) *models.Webhook {
+	webhook, _ := models.NewWebhook(roaster.ID, &models.WebhookRequest{URL: "https://roaster.com/hooks", Events: []string{models.USER_CREATED}})
+	return webhook
+}
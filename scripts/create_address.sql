@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS address;
+CREATE TABLE address(
+	id VARCHAR(36) NOT NULL PRIMARY KEY,
+	userId VARCHAR(36) NOT NULL,
+	label VARCHAR(30),
+	addressLine1 VARCHAR(200) NOT NULL,
+	addressLine2 VARCHAR(200),
+	addressCity VARCHAR(30) NOT NULL,
+	addressState VARCHAR(30),
+	addressZip VARCHAR(10),
+	addressCountry VARCHAR(2) NOT NULL,
+	defaultShipping BOOLEAN NOT NULL DEFAULT FALSE,
+	defaultBilling BOOLEAN NOT NULL DEFAULT FALSE,
+	createdAt DATETIME NOT NULL,
+	updatedAt DATETIME NOT NULL,
+	INDEX (userId)
+);
\ No newline at end of file
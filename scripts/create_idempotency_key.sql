@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS idempotencyKey;
+CREATE TABLE idempotencyKey(
+	idemKey VARCHAR(255) NOT NULL,
+	scope VARCHAR(255) NOT NULL,
+	requestHash VARCHAR(64) NOT NULL,
+	claim VARCHAR(36) NOT NULL,
+	responseCode INT NOT NULL DEFAULT 0,
+	responseBody MEDIUMTEXT NOT NULL,
+	headers TEXT NOT NULL,
+	tokenUserId VARCHAR(36) NOT NULL DEFAULT '',
+	createdAt DATETIME NOT NULL,
+	expiresAt DATETIME NOT NULL,
+	PRIMARY KEY (idemKey, scope),
+	INDEX (expiresAt)
+);
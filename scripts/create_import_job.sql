@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS importJob;
+CREATE TABLE importJob(
+	id VARCHAR(36) NOT NULL PRIMARY KEY,
+	kind VARCHAR(20) NOT NULL,
+	format VARCHAR(10) NOT NULL,
+	dryRun BOOLEAN NOT NULL DEFAULT FALSE,
+	status VARCHAR(20) NOT NULL,
+	body MEDIUMTEXT NOT NULL,
+	total INT NOT NULL DEFAULT 0,
+	processed INT NOT NULL DEFAULT 0,
+	created INT NOT NULL DEFAULT 0,
+	updated INT NOT NULL DEFAULT 0,
+	failed INT NOT NULL DEFAULT 0,
+	errors MEDIUMTEXT NOT NULL,
+	message VARCHAR(255) NOT NULL DEFAULT '',
+	runner VARCHAR(36),
+	createdAt DATETIME NOT NULL,
+	updatedAt DATETIME NOT NULL,
+	INDEX (status)
+);
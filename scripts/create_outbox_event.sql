@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS outboxEvent;
+CREATE TABLE outboxEvent(
+	seq BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	id VARCHAR(36) NOT NULL UNIQUE,
+	type VARCHAR(40) NOT NULL,
+	aggregateId VARCHAR(36) NOT NULL,
+	roasterId VARCHAR(36),
+	data MEDIUMTEXT NOT NULL,
+	createdAt DATETIME NOT NULL,
+	publishedAt DATETIME,
+	runner VARCHAR(36),
+	claimedUntil DATETIME,
+	INDEX (publishedAt)
+);
@@ -2,12 +2,22 @@ DROP TABLE IF EXISTS roaster;
 CREATE TABLE roaster(
 	id VARCHAR(36) NOT NULL PRIMARY KEY,
 	name VARCHAR(30) NOT NULL,
+	slug VARCHAR(60) UNIQUE,
 	email VARCHAR(200) NOT NULL,
-	phone VARCHAR(12),
+	phone VARCHAR(16),
+	phoneVerified BOOLEAN NOT NULL DEFAULT FALSE,
 	addressLine1 VARCHAR(200) NOT NULL,
 	addressLine2 VARCHAR(200) NOT NULL,
 	addressCity VARCHAR(30) NOT NULL,
 	addressState VARCHAR(30) NOT NULL,
 	addressZip VARCHAR(10) NOT NULL,
-	addressCountry VARCHAR(20) NOT NULL
+	addressCountry VARCHAR(20) NOT NULL,
+	latitude DOUBLE,
+	longitude DOUBLE,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	createdAt DATETIME NOT NULL,
+	updatedAt DATETIME NOT NULL,
+	INDEX (updatedAt),
+	INDEX (latitude, longitude),
+	INDEX (status)
 );
\ No newline at end of file
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS roasterDocument;
+CREATE TABLE roasterDocument(
+	id VARCHAR(36) NOT NULL PRIMARY KEY,
+	roasterId VARCHAR(36) NOT NULL,
+	kind VARCHAR(20) NOT NULL,
+	filename VARCHAR(200) NOT NULL,
+	url VARCHAR(500) NOT NULL,
+	createdAt DATETIME NOT NULL,
+	INDEX (roasterId)
+);
\ No newline at end of file
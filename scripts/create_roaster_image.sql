@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS roasterImage;
+CREATE TABLE roasterImage(
+	id VARCHAR(36) NOT NULL PRIMARY KEY,
+	roasterId VARCHAR(36) NOT NULL,
+	url VARCHAR(500) NOT NULL,
+	caption VARCHAR(200) NOT NULL,
+	position INT NOT NULL,
+	createdAt DATETIME NOT NULL,
+	updatedAt DATETIME NOT NULL,
+	INDEX (roasterId, position)
+);
\ No newline at end of file
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS roasterProfile;
+CREATE TABLE roasterProfile(
+	roasterId VARCHAR(36) NOT NULL PRIMARY KEY,
+	description TEXT NOT NULL,
+	founded DATE,
+	website VARCHAR(200) NOT NULL,
+	social TEXT NOT NULL,
+	hours TEXT NOT NULL,
+	timezone VARCHAR(64) NOT NULL,
+	tags TEXT NOT NULL,
+	updatedAt DATETIME NOT NULL
+);
\ No newline at end of file
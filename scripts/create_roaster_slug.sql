@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS roasterSlug;
+CREATE TABLE roasterSlug(
+	slug VARCHAR(60) NOT NULL PRIMARY KEY,
+	roasterId VARCHAR(36) NOT NULL,
+	createdAt DATETIME NOT NULL,
+	INDEX (roasterId)
+);
\ No newline at end of file
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS roasterStatus;
+CREATE TABLE roasterStatus(
+	id VARCHAR(36) NOT NULL PRIMARY KEY,
+	roasterId VARCHAR(36) NOT NULL,
+	fromStatus VARCHAR(20) NOT NULL,
+	toStatus VARCHAR(20) NOT NULL,
+	reason VARCHAR(30) NOT NULL,
+	note VARCHAR(500) NOT NULL,
+	actorId VARCHAR(36) NOT NULL,
+	createdAt DATETIME NOT NULL,
+	INDEX (roasterId, createdAt)
+);
\ No newline at end of file
@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS roasterTransfer;
+CREATE TABLE roasterTransfer(
+	id VARCHAR(36) NOT NULL PRIMARY KEY,
+	roasterId VARCHAR(36) NOT NULL,
+	fromUserId VARCHAR(36) NOT NULL,
+	toUserId VARCHAR(36) NOT NULL,
+	toEmail VARCHAR(255) NOT NULL,
+	token VARCHAR(36) NOT NULL UNIQUE,
+	status VARCHAR(20) NOT NULL,
+	expiresAt DATETIME NOT NULL,
+	createdAt DATETIME NOT NULL,
+	updatedAt DATETIME NOT NULL,
+	INDEX (roasterId, status)
+);
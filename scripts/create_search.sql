@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS searchTerm;
+CREATE TABLE searchTerm(
+	kind VARCHAR(10) NOT NULL,
+	id VARCHAR(36) NOT NULL,
+	term VARCHAR(64) NOT NULL,
+	weight DOUBLE NOT NULL,
+	PRIMARY KEY (kind, id, term),
+	INDEX (kind, term)
+);
\ No newline at end of file
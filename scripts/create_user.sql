@@ -5,7 +5,8 @@ CREATE TABLE user(
 	firstName VARCHAR(20) NOT NULL,
 	lastName VARCHAR(20) NOT NULL,
 	email VARCHAR(200) NOT NULL,
-	phone VARCHAR(12),
+	phone VARCHAR(16),
+	phoneVerified BOOLEAN NOT NULL DEFAULT FALSE,
 	addressLine1 VARCHAR(200) NOT NULL,
 	addressLine2 VARCHAR(200) NOT NULL,
 	addressCity VARCHAR(30) NOT NULL,
@@ -13,5 +14,8 @@ CREATE TABLE user(
 	addressZip VARCHAR(10) NOT NULL,
 	addressCountry VARCHAR(20) NOT NULL,
 	roasterId VARCHAR(36),
-	isRoaster SMALLINT
+	isRoaster SMALLINT,
+	createdAt DATETIME NOT NULL,
+	updatedAt DATETIME NOT NULL,
+	INDEX (updatedAt)
 );
\ No newline at end of file
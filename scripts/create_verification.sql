@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS verification;
+CREATE TABLE verification(
+	id VARCHAR(36) NOT NULL PRIMARY KEY,
+	phone VARCHAR(16) NOT NULL,
+	code VARCHAR(60) NOT NULL,
+	createdAt DATETIME NOT NULL,
+	attempts INT NOT NULL DEFAULT 0
+);
\ No newline at end of file
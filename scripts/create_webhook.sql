@@ -0,0 +1,33 @@
+DROP TABLE IF EXISTS webhook;
+CREATE TABLE webhook(
+	id VARCHAR(36) NOT NULL PRIMARY KEY,
+	roasterId VARCHAR(36) NOT NULL,
+	url VARCHAR(2048) NOT NULL,
+	secret VARCHAR(255) NOT NULL,
+	events VARCHAR(255) NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	createdAt DATETIME NOT NULL,
+	updatedAt DATETIME NOT NULL,
+	INDEX (roasterId)
+);
+
+DROP TABLE IF EXISTS webhookDelivery;
+CREATE TABLE webhookDelivery(
+	id VARCHAR(36) NOT NULL PRIMARY KEY,
+	webhookId VARCHAR(36) NOT NULL,
+	eventId VARCHAR(36) NOT NULL,
+	type VARCHAR(40) NOT NULL,
+	payload MEDIUMTEXT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	responseCode INT NOT NULL DEFAULT 0,
+	error VARCHAR(255) NOT NULL DEFAULT '',
+	nextAttemptAt DATETIME NOT NULL,
+	runner VARCHAR(36),
+	createdAt DATETIME NOT NULL,
+	updatedAt DATETIME NOT NULL,
+	UNIQUE (webhookId, eventId),
+	INDEX (status, nextAttemptAt),
+	INDEX (runner),
+	INDEX (webhookId, createdAt)
+);
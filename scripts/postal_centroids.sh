@@ -0,0 +1,26 @@
+#!/bin/sh
+# Builds the postal code file POSTAL_CENTROIDS points at from the GeoNames
+# postal code export. Only the columns LoadCentroids reads are kept, so the
+# file is a fraction of the export's size.
+#
+#   scripts/postal_centroids.sh centroids.txt         every country
+#   scripts/postal_centroids.sh centroids.txt US CA   only the US and Canada
+set -e
+
+OUT=${1:?usage: postal_centroids.sh OUTPUT [COUNTRY...]}
+shift
+if [ $# -eq 0 ]; then
+	set -- allCountries
+fi
+
+TMP=$(mktemp -d)
+trap 'rm -rf "$TMP"' EXIT
+
+: > "$OUT"
+for COUNTRY in "$@"; do
+	curl -fsSL -o "$TMP/$COUNTRY.zip" "https://download.geonames.org/export/zip/$COUNTRY.zip"
+	unzip -p "$TMP/$COUNTRY.zip" "$COUNTRY.txt" |
+		awk -F '\t' -v OFS='\t' '{ print $1, $2, "", "", "", "", "", "", "", $10, $11 }' >> "$OUT"
+done
+
+echo "$(wc -l < "$OUT") postal codes written to $OUT"